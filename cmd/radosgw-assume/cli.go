@@ -6,11 +6,14 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/x/term"
+	"github.com/fitbeard/radosgw-assume/internal/auth"
 	"github.com/fitbeard/radosgw-assume/internal/config"
 	"github.com/fitbeard/radosgw-assume/internal/credentialcache"
 	"github.com/fitbeard/radosgw-assume/internal/credentials"
+	"github.com/fitbeard/radosgw-assume/internal/tokencache"
 	"github.com/fitbeard/radosgw-assume/internal/ui"
 
 	"gopkg.in/ini.v1"
@@ -19,6 +22,7 @@ import (
 const foregroundExportEnvironment = "RADOSGW_ASSUME_FOREGROUND_EXPORT"
 
 type cliRunner struct {
+	stdin  io.Reader
 	stdout io.Writer
 	stderr io.Writer
 
@@ -32,15 +36,24 @@ type cliRunner struct {
 	selectProfile         func([]string) (string, error)
 	getCredentials        func(context.Context, credentials.RequestOptions) (*config.AssumeRoleResult, error)
 	getProcessCredentials func(context.Context, credentials.ProcessRequestOptions) (*config.AssumeRoleResult, error)
+	getWebIdentityToken   func(context.Context, credentials.RequestOptions) (string, error)
 	inspectCache          func() (credentialcache.Summary, error)
 	clearCache            func() (credentialcache.ClearResult, error)
+	clearProfileCache     func(string) (credentialcache.ClearResult, error)
+	clearTokenStore       func() (int, error)
+	logout                func(context.Context, credentials.LogoutOptions) (credentials.LogoutResult, error)
+	openBrowser           func(string) error
 	openTerminal          func() (io.WriteCloser, error)
 	environ               func() []string
+	getenv                func(string) string
+	readFile              func(string) ([]byte, error)
+	now                   func() time.Time
 	execCommand           func([]string, []string) error
 }
 
 func newCLIRunner(stdout, stderr io.Writer) *cliRunner {
 	return &cliRunner{
+		stdin:                  os.Stdin,
 		stdout:                 stdout,
 		stderr:                 stderr,
 		deferInteractiveExport: shouldDeferInteractiveExport(stdout, processIsForeground()),
@@ -52,10 +65,18 @@ func newCLIRunner(stdout, stderr io.Writer) *cliRunner {
 		selectProfile:          ui.SelectProfileInteractively,
 		getCredentials:         credentials.GetCredentials,
 		getProcessCredentials:  credentials.GetProcessCredentials,
+		getWebIdentityToken:    credentials.GetWebIdentityToken,
 		inspectCache:           credentialcache.Inspect,
 		clearCache:             credentialcache.Clear,
+		clearProfileCache:      credentialcache.ClearProfile,
+		clearTokenStore:        clearTokenStore,
+		logout:                 credentials.Logout,
+		openBrowser:            auth.OpenBrowser,
 		openTerminal:           openControllingTerminal,
 		environ:                os.Environ,
+		getenv:                 os.Getenv,
+		readFile:               os.ReadFile,
+		now:                    time.Now,
 		execCommand:            replaceProcess,
 	}
 }
@@ -74,6 +95,12 @@ func (r *cliRunner) runContext(ctx context.Context, program string, args []strin
 	if exitCode, handled := r.runStandaloneAction(options); handled {
 		return exitCode
 	}
+	if options.action == actionTokenInspect {
+		return r.runTokenInspectAction(ctx, options)
+	}
+	if options.action == actionLogout {
+		return r.runLogoutAction(ctx, options)
+	}
 	if options.action == actionRun && r.stdoutIsTerminal && !options.showCredentials {
 		fprintTerminalExportRefusal(r.stderr, program, args)
 		return 1
@@ -95,6 +122,14 @@ func (r *cliRunner) runContext(ctx context.Context, program string, args []strin
 	return r.runCredentialAction(options, result)
 }
 
+func clearTokenStore() (int, error) {
+	store, err := tokencache.New()
+	if err != nil {
+		return 0, err
+	}
+	return store.Clear()
+}
+
 func openControllingTerminal() (io.WriteCloser, error) {
 	return os.OpenFile("/dev/tty", os.O_WRONLY, 0)
 }
@@ -153,10 +188,17 @@ func credentialEnvironment(environment []string, result *config.AssumeRoleResult
 	if result.ProfileName != "env" {
 		overrides = append(overrides, "AWS_PROFILE="+result.ProfileName)
 	}
+	// The OIDC token and client secret are only needed to obtain temporary
+	// credentials and must not be exposed to the executed command. An
+	// inherited role ARN would describe a different session.
+	removedNames := []string{"RADOSGW_OIDC_TOKEN", "RADOSGW_OIDC_CLIENT_SECRET"}
+	if result.AssumedRoleArn != "" {
+		overrides = append(overrides, "RADOSGW_ASSUMED_ROLE_ARN="+result.AssumedRoleArn)
+	} else {
+		removedNames = append(removedNames, "RADOSGW_ASSUMED_ROLE_ARN")
+	}
 
-	// The OIDC token is only needed to obtain temporary credentials and must not
-	// be exposed to the executed command.
-	return environmentWithOverrides(environment, overrides, "RADOSGW_OIDC_TOKEN")
+	return environmentWithOverrides(environment, overrides, removedNames...)
 }
 
 func shellEnvironment(environment []string, result *config.AssumeRoleResult) []string {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/fitbeard/radosgw-assume/internal/config"
 	"github.com/fitbeard/radosgw-assume/internal/credentialcache"
@@ -95,11 +96,29 @@ func (r *cliRunner) loadCLIProfile(options cliOptions) (*cliProfile, int) {
 			_, _ = fmt.Fprintf(r.stderr, "Error: %v\n", err)
 			return nil, 1
 		}
+		// Like the AWS CLI and SDKs, AWS_CA_BUNDLE and AWS_REGION take
+		// precedence over ca_bundle and region, including values inherited
+		// through source_profile.
+		if caBundle := r.getenv("AWS_CA_BUNDLE"); caBundle != "" {
+			profileConfig.CABundle = caBundle
+		}
+		if region := config.RegionFromEnv(r.getenv); region != "" {
+			profileConfig.Region = region
+		}
 	}
 
 	if options.sessionName != "" {
 		profileConfig.RoleSessionName = options.sessionName
 	}
+	// A policy given on the command line replaces the profile's policy of
+	// either kind rather than being combined with it.
+	if options.policy != "" || options.policyFile != "" {
+		profileConfig.SessionPolicy = options.policy
+		profileConfig.SessionPolicyFile = options.policyFile
+	}
+	if len(options.policyARNs) > 0 {
+		profileConfig.SessionPolicyARNs = strings.Join(options.policyARNs, ",")
+	}
 
 	return &cliProfile{name: profileName, profileConfig: profileConfig, awsConfig: awsConfig}, 0
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fitbeard/radosgw-assume/internal/config"
 	"github.com/fitbeard/radosgw-assume/internal/sts"
 	"github.com/fitbeard/radosgw-assume/pkg/duration"
 )
@@ -20,6 +21,8 @@ const (
 	actionCredentialProcess
 	actionCacheStatus
 	actionCacheClear
+	actionTokenInspect
+	actionLogout
 )
 
 type cliOptions struct {
@@ -30,8 +33,16 @@ type cliOptions struct {
 	showCredentials bool
 	sessionDuration time.Duration
 	sessionName     string
+	policyFile      string
+	policy          string
+	policyARNs      []string
 	noPrompt        bool
 	noCache         bool
+	tokenFile       string
+	tokenEnv        string
+	jsonOutput      bool
+	allProfiles     bool
+	endSession      bool
 	command         []string
 }
 
@@ -48,6 +59,12 @@ func parseCLIArguments(program string, args []string) (cliOptions, error) {
 			return parseShellArguments(program, args[1:])
 		case "credential-process":
 			return parseCredentialProcessArguments(program, args[1:])
+		case "token":
+			return parseTokenArguments(program, args[1:])
+		case "whoami":
+			return parseTokenInspectArguments(program, "whoami", args[1:])
+		case "logout":
+			return parseLogoutArguments(program, args[1:])
 		case "version":
 			if len(args) == 1 {
 				return newCLIOptions(actionVersion), nil
@@ -139,6 +156,63 @@ func parseCredentialProcessArguments(program string, args []string) (cliOptions,
 	return options, nil
 }
 
+func parseTokenArguments(program string, args []string) (cliOptions, error) {
+	if len(args) == 0 {
+		return cliOptions{}, fmt.Errorf("token requires 'inspect'\nUsage: %s token inspect [OPTIONS]", program)
+	}
+	switch args[0] {
+	case "inspect":
+		return parseTokenInspectArguments(program, "token inspect", args[1:])
+	case "-h", "--help":
+		return newCLIOptions(actionHelp), nil
+	default:
+		return cliOptions{}, fmt.Errorf("unknown token command '%s'\nUsage: %s token inspect [OPTIONS]", args[0], program)
+	}
+}
+
+func parseTokenInspectArguments(program, command string, args []string) (cliOptions, error) {
+	options, err := parseCommandOptions(program, args, actionTokenInspect, func(_ *cliOptions, args []string, index int) (bool, error) {
+		argument := args[index]
+		if argument == "--" {
+			return false, fmt.Errorf("unexpected argument '--'\nUse -h or --help for usage information")
+		}
+		return false, fmt.Errorf("unexpected %s argument '%s'\nUsage: %s %s [OPTIONS]", command, argument, program, command)
+	})
+	if err != nil || options.action == actionHelp {
+		return options, err
+	}
+	if err := validateCommandOptions(options); err != nil {
+		return cliOptions{}, err
+	}
+	if options.tokenFile != "" && options.tokenEnv != "" {
+		return cliOptions{}, fmt.Errorf("--token-file and --token-env cannot be used together")
+	}
+	if (options.tokenFile != "" || options.tokenEnv != "") && (options.profileName != "" || options.useEnv) {
+		return cliOptions{}, fmt.Errorf("--token-file and --token-env cannot be used with --profile or --env")
+	}
+	return options, nil
+}
+
+func parseLogoutArguments(program string, args []string) (cliOptions, error) {
+	options, err := parseCommandOptions(program, args, actionLogout, func(_ *cliOptions, args []string, index int) (bool, error) {
+		argument := args[index]
+		if argument == "--" {
+			return false, fmt.Errorf("unexpected argument '--'\nUse -h or --help for usage information")
+		}
+		return false, fmt.Errorf("unexpected logout argument '%s'\nUsage: %s logout [-p PROFILE | --all] [--end-session]", argument, program)
+	})
+	if err != nil || options.action == actionHelp {
+		return options, err
+	}
+	if err := validateCommandOptions(options); err != nil {
+		return cliOptions{}, err
+	}
+	if options.allProfiles && (options.profileName != "" || options.useEnv) {
+		return cliOptions{}, fmt.Errorf("--all cannot be used with --profile or --env")
+	}
+	return options, nil
+}
+
 func parseCommandOptions(program string, args []string, action cliAction, handleArgument positionalArgumentHandler) (cliOptions, error) {
 	options := newCLIOptions(action)
 	for index := 0; index < len(args); index++ {
@@ -183,6 +257,24 @@ func parseSharedOption(program string, args []string, index *int, options *cliOp
 		options.useEnv = true
 	case "--show-credentials":
 		options.showCredentials = true
+	case "--json":
+		options.jsonOutput = true
+	case "--all":
+		options.allProfiles = true
+	case "--end-session":
+		options.endSession = true
+	case "--token-file":
+		if *index+1 >= len(args) || args[*index+1] == "" || (args[*index+1] != "-" && strings.HasPrefix(args[*index+1], "-")) {
+			return false, true, fmt.Errorf("token file flag requires a value\nUsage: %s token inspect --token-file FILE (use - for stdin)", program)
+		}
+		(*index)++
+		options.tokenFile = args[*index]
+	case "--token-env":
+		if *index+1 >= len(args) || args[*index+1] == "" || strings.HasPrefix(args[*index+1], "-") {
+			return false, true, fmt.Errorf("token environment flag requires a variable name\nUsage: %s token inspect --token-env NAME", program)
+		}
+		(*index)++
+		options.tokenEnv = args[*index]
 	case "-p", "--profile":
 		if *index+1 >= len(args) || strings.HasPrefix(args[*index+1], "-") {
 			return false, true, fmt.Errorf("profile flag requires a value\nUsage: %s -p PROFILE", program)
@@ -219,6 +311,28 @@ func parseSharedOption(program string, args []string, index *int, options *cliOp
 			return false, true, fmt.Errorf("invalid session name '%s': %v", sessionName, validationErr)
 		}
 		options.sessionName = sessionName
+	case "--policy-file":
+		if *index+1 >= len(args) || args[*index+1] == "" || strings.HasPrefix(args[*index+1], "-") {
+			return false, true, fmt.Errorf("policy file flag requires a value\nUsage: %s --policy-file FILE [-p PROFILE]", program)
+		}
+		(*index)++
+		options.policyFile = args[*index]
+	case "--policy":
+		if *index+1 >= len(args) {
+			return false, true, fmt.Errorf("policy flag requires a JSON policy document\nUsage: %s --policy JSON [-p PROFILE]", program)
+		}
+		(*index)++
+		policy, parseErr := config.ParseSessionPolicy("--policy", args[*index])
+		if parseErr != nil {
+			return false, true, parseErr
+		}
+		options.policy = policy
+	case "--policy-arn":
+		if *index+1 >= len(args) || !strings.HasPrefix(args[*index+1], "arn:") {
+			return false, true, fmt.Errorf("policy ARN flag requires a managed policy ARN\nUsage: %s --policy-arn ARN [-p PROFILE]", program)
+		}
+		(*index)++
+		options.policyARNs = append(options.policyARNs, args[*index])
 	default:
 		return false, false, nil
 	}
@@ -249,6 +363,20 @@ func validateScopedOptions(options cliOptions) error {
 	if options.noCache && options.action != actionCredentialProcess {
 		return fmt.Errorf("--no-cache can only be used with the credential-process command")
 	}
+	if (options.jsonOutput || options.tokenFile != "" || options.tokenEnv != "") && options.action != actionTokenInspect {
+		return fmt.Errorf("--json, --token-file and --token-env can only be used with the token inspect command")
+	}
+	if (options.allProfiles || options.endSession) && options.action != actionLogout {
+		return fmt.Errorf("--all and --end-session can only be used with the logout command")
+	}
+	if options.policy != "" || options.policyFile != "" || len(options.policyARNs) > 0 {
+		if options.action == actionTokenInspect || options.action == actionLogout {
+			return fmt.Errorf("--policy, --policy-file and --policy-arn cannot be used with the token inspect or logout commands")
+		}
+		if options.policy != "" && options.policyFile != "" {
+			return fmt.Errorf("--policy and --policy-file cannot be used together")
+		}
+	}
 	return nil
 }
 
@@ -163,6 +163,63 @@ func TestParseCLIArguments(t *testing.T) {
 			args: []string{"cache", "status", "--help"},
 			want: cliOptions{action: actionHelp, sessionDuration: time.Hour},
 		},
+		{
+			name: "token inspect with profile",
+			args: []string{"token", "inspect", "--profile", "profile", "--json", "--verbose"},
+			want: cliOptions{action: actionTokenInspect, profileName: "profile", jsonOutput: true, verbose: true, sessionDuration: time.Hour},
+		},
+		{
+			name: "token inspect from stdin",
+			args: []string{"token", "inspect", "--token-file", "-"},
+			want: cliOptions{action: actionTokenInspect, tokenFile: "-", sessionDuration: time.Hour},
+		},
+		{
+			name: "whoami from environment variable",
+			args: []string{"whoami", "--token-env", "RADOSGW_OIDC_TOKEN"},
+			want: cliOptions{action: actionTokenInspect, tokenEnv: "RADOSGW_OIDC_TOKEN", sessionDuration: time.Hour},
+		},
+		{
+			name: "whoami with environment configuration",
+			args: []string{"whoami", "--env"},
+			want: cliOptions{action: actionTokenInspect, useEnv: true, sessionDuration: time.Hour},
+		},
+		{
+			name: "token help",
+			args: []string{"token", "--help"},
+			want: cliOptions{action: actionHelp, sessionDuration: time.Hour},
+		},
+		{
+			name: "token inspect help",
+			args: []string{"token", "inspect", "--help"},
+			want: cliOptions{action: actionHelp, sessionDuration: time.Hour},
+		},
+		{
+			name: "logout profile",
+			args: []string{"logout", "-p", "profile", "--end-session"},
+			want: cliOptions{action: actionLogout, profileName: "profile", endSession: true, sessionDuration: time.Hour},
+		},
+		{
+			name: "session policies",
+			args: []string{"exec", "-p", "profile", "--policy", `{ "Version": "2012-10-17", "Statement": [] }`, "--policy-arn", "arn:aws:iam::aws:policy/ReadOnly", "--policy-arn", "arn:aws:iam::123456789012:policy/CI", "--", "aws"},
+			want: cliOptions{
+				action:          actionExec,
+				profileName:     "profile",
+				sessionDuration: time.Hour,
+				policy:          `{"Version":"2012-10-17","Statement":[]}`,
+				policyARNs:      []string{"arn:aws:iam::aws:policy/ReadOnly", "arn:aws:iam::123456789012:policy/CI"},
+				command:         []string{"aws"},
+			},
+		},
+		{
+			name: "session policy file",
+			args: []string{"credential-process", "-p", "profile", "--policy-file", "ci-policy.json"},
+			want: cliOptions{action: actionCredentialProcess, profileName: "profile", policyFile: "ci-policy.json", sessionDuration: time.Hour},
+		},
+		{
+			name: "logout all profiles",
+			args: []string{"logout", "--all", "-v"},
+			want: cliOptions{action: actionLogout, allProfiles: true, verbose: true, sessionDuration: time.Hour},
+		},
 	}
 
 	for _, tt := range tests {
@@ -219,6 +276,26 @@ func TestParseCLIArgumentsErrors(t *testing.T) {
 		{name: "cache command unknown", args: []string{"cache", "prune"}, wantMessage: "unknown cache command 'prune'"},
 		{name: "cache status argument", args: []string{"cache", "status", "extra"}, wantMessage: "unexpected cache argument 'extra'"},
 		{name: "cache clear flag", args: []string{"cache", "clear", "--verbose"}, wantMessage: "unexpected cache argument '--verbose'"},
+		{name: "token command missing", args: []string{"token"}, wantMessage: "token requires 'inspect'"},
+		{name: "token command unknown", args: []string{"token", "print"}, wantMessage: "unknown token command 'print'"},
+		{name: "token inspect positional token", args: []string{"token", "inspect", "eyJ.token.value"}, wantMessage: "unexpected token inspect argument 'eyJ.token.value'"},
+		{name: "whoami positional argument", args: []string{"whoami", "me"}, wantMessage: "unexpected whoami argument 'me'\nUsage: custom-name whoami [OPTIONS]"},
+		{name: "token file value missing", args: []string{"whoami", "--token-file"}, wantMessage: "token file flag requires a value"},
+		{name: "token environment value missing", args: []string{"whoami", "--token-env", "--json"}, wantMessage: "token environment flag requires a variable name"},
+		{name: "token file and environment", args: []string{"whoami", "--token-file", "token", "--token-env", "TOKEN"}, wantMessage: "--token-file and --token-env cannot be used together"},
+		{name: "token file and profile", args: []string{"whoami", "--token-file", "token", "-p", "profile"}, wantMessage: "--token-file and --token-env cannot be used with --profile or --env"},
+		{name: "JSON option without token inspect", args: []string{"-p", "profile", "--json"}, wantMessage: "--json, --token-file and --token-env can only be used with the token inspect command"},
+		{name: "token file with exec", args: []string{"exec", "--token-file", "token", "--", "aws"}, wantMessage: "can only be used with the token inspect command"},
+		{name: "logout positional argument", args: []string{"logout", "profile"}, wantMessage: "unexpected logout argument 'profile'\nUsage: custom-name logout [-p PROFILE | --all] [--end-session]"},
+		{name: "logout all with profile", args: []string{"logout", "--all", "-p", "profile"}, wantMessage: "--all cannot be used with --profile or --env"},
+		{name: "policy file value missing", args: []string{"--policy-file"}, wantMessage: "policy file flag requires a value\nUsage: custom-name --policy-file FILE [-p PROFILE]"},
+		{name: "policy value missing", args: []string{"--policy"}, wantMessage: "policy flag requires a JSON policy document"},
+		{name: "policy invalid", args: []string{"--policy", `{"Version":`}, wantMessage: "invalid --policy: policy is not valid JSON"},
+		{name: "policy ARN invalid", args: []string{"--policy-arn", "ReadOnly"}, wantMessage: "policy ARN flag requires a managed policy ARN"},
+		{name: "policy and policy file", args: []string{"--policy", "{}", "--policy-file", "ci-policy.json"}, wantMessage: "--policy and --policy-file cannot be used together"},
+		{name: "policy with logout", args: []string{"logout", "--policy-file", "ci-policy.json"}, wantMessage: "cannot be used with the token inspect or logout commands"},
+		{name: "policy with token inspect", args: []string{"whoami", "--policy-arn", "arn:aws:iam::aws:policy/ReadOnly"}, wantMessage: "cannot be used with the token inspect or logout commands"},
+		{name: "all without logout", args: []string{"shell", "--all"}, wantMessage: "--all and --end-session can only be used with the logout command"},
 	}
 
 	for _, tt := range tests {
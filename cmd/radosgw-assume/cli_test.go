@@ -352,11 +352,24 @@ func TestCLIRunnerNamedProfile(t *testing.T) {
 	runner, stdout, stderr := newTestCLIRunner(t)
 	runner.stdoutIsTerminal = true
 	awsConfig := ini.Empty()
-	profileConfig := &config.ProfileConfig{RoleSessionName: "config-session"}
+	profileConfig := &config.ProfileConfig{RoleSessionName: "config-session", CABundle: "/etc/radosgw/config-ca.pem"}
 
 	runner.loadAWSConfig = func() (*ini.File, error) {
 		return awsConfig, nil
 	}
+	runner.getenv = func(name string) string {
+		switch name {
+		case "AWS_CA_BUNDLE":
+			return "/etc/radosgw/env-ca.pem"
+		case "AWS_REGION":
+			return ""
+		case "AWS_DEFAULT_REGION":
+			return "zonegroup-env"
+		default:
+			t.Errorf("getenv() name = %q", name)
+			return ""
+		}
+	}
 	runner.getProfile = func(profileName string, gotConfig *ini.File) (*config.ProfileConfig, error) {
 		if profileName != "version" {
 			t.Errorf("getProfile() name = %q, want version", profileName)
@@ -382,6 +395,12 @@ func TestCLIRunnerNamedProfile(t *testing.T) {
 		if options.ProfileConfig.RoleSessionName != "cli-session" {
 			t.Errorf("session name = %q, want CLI override", options.ProfileConfig.RoleSessionName)
 		}
+		if options.ProfileConfig.CABundle != "/etc/radosgw/env-ca.pem" {
+			t.Errorf("CA bundle = %q, want AWS_CA_BUNDLE override", options.ProfileConfig.CABundle)
+		}
+		if options.ProfileConfig.Region != "zonegroup-env" {
+			t.Errorf("region = %q, want AWS_DEFAULT_REGION override", options.ProfileConfig.Region)
+		}
 		return testAssumeRoleResult("version"), nil
 	}
 
@@ -397,6 +416,31 @@ func TestCLIRunnerNamedProfile(t *testing.T) {
 	}
 }
 
+func TestCLIRunnerSessionPolicyOverride(t *testing.T) {
+	runner, _, stderr := newTestCLIRunner(t)
+	runner.loadEnvConfig = func() (*config.ProfileConfig, error) {
+		return &config.ProfileConfig{
+			SessionPolicy:     `{"Version":"2012-10-17","Statement":[]}`,
+			SessionPolicyARNs: "arn:aws:iam::aws:policy/ReadOnly",
+		}, nil
+	}
+	runner.getCredentials = func(_ context.Context, options credentials.RequestOptions) (*config.AssumeRoleResult, error) {
+		profileConfig := options.ProfileConfig
+		if profileConfig.SessionPolicy != "" || profileConfig.SessionPolicyFile != "ci-policy.json" {
+			t.Errorf("session policy, file = %q %q, want only the CLI policy file", profileConfig.SessionPolicy, profileConfig.SessionPolicyFile)
+		}
+		if profileConfig.SessionPolicyARNs != "arn:aws:iam::123456789012:policy/CI,arn:aws:iam::123456789012:policy/Logs" {
+			t.Errorf("session policy ARNs = %q, want CLI override", profileConfig.SessionPolicyARNs)
+		}
+		return testAssumeRoleResult("env"), nil
+	}
+
+	exitCode := runner.run("radosgw-assume", []string{"--env", "--policy-file", "ci-policy.json", "--policy-arn", "arn:aws:iam::123456789012:policy/CI", "--policy-arn", "arn:aws:iam::123456789012:policy/Logs"})
+	if exitCode != 0 {
+		t.Fatalf("run() exit code = %d, want 0; stderr: %s", exitCode, stderr.String())
+	}
+}
+
 func TestCLIRunnerEnvironmentConfiguration(t *testing.T) {
 	runner, stdout, stderr := newTestCLIRunner(t)
 	profileConfig := &config.ProfileConfig{}
@@ -721,6 +765,7 @@ func TestCredentialEnvironmentKeepsRealEnvironmentProfile(t *testing.T) {
 		"AWS_PROFILE=existing-profile",
 		"AWS_ACCESS_KEY_ID=stale-access-key",
 		"RADOSGW_OIDC_TOKEN=source-token",
+		"RADOSGW_OIDC_CLIENT_SECRET=client-secret",
 	}, result)
 
 	assertCommandEnvironment(t, environment, map[string]string{
@@ -733,6 +778,18 @@ func TestCredentialEnvironmentKeepsRealEnvironmentProfile(t *testing.T) {
 		"AWS_SESSION_EXPIRATION":    result.Expiration,
 	})
 	assertEnvironmentMissing(t, environment, "RADOSGW_OIDC_TOKEN")
+	assertEnvironmentMissing(t, environment, "RADOSGW_OIDC_CLIENT_SECRET")
+}
+
+func TestCredentialEnvironmentExportsAssumedRoleARN(t *testing.T) {
+	result := testAssumeRoleResult("profile")
+	result.AssumedRoleArn = "arn:aws:sts::123456789012:assumed-role/TestRole/session"
+	environment := credentialEnvironment([]string{"RADOSGW_ASSUMED_ROLE_ARN=arn:stale"}, result)
+	assertCommandEnvironment(t, environment, map[string]string{"RADOSGW_ASSUMED_ROLE_ARN": result.AssumedRoleArn})
+
+	result.AssumedRoleArn = ""
+	environment = credentialEnvironment([]string{"RADOSGW_ASSUMED_ROLE_ARN=arn:stale"}, result)
+	assertEnvironmentMissing(t, environment, "RADOSGW_ASSUMED_ROLE_ARN")
 }
 
 func assertCommandEnvironment(t *testing.T, environment []string, want map[string]string) {
@@ -976,14 +1033,50 @@ func newTestCLIRunner(t *testing.T) (*cliRunner, *bytes.Buffer, *bytes.Buffer) {
 			t.Fatal("unexpected clearCache() call")
 			return credentialcache.ClearResult{}, nil
 		},
+		clearProfileCache: func(string) (credentialcache.ClearResult, error) {
+			t.Fatal("unexpected clearProfileCache() call")
+			return credentialcache.ClearResult{}, nil
+		},
+		clearTokenStore: func() (int, error) {
+			t.Fatal("unexpected clearTokenStore() call")
+			return 0, nil
+		},
+		logout: func(context.Context, credentials.LogoutOptions) (credentials.LogoutResult, error) {
+			t.Fatal("unexpected logout() call")
+			return credentials.LogoutResult{}, nil
+		},
+		openBrowser: func(string) error {
+			t.Fatal("unexpected openBrowser() call")
+			return nil
+		},
 		openTerminal: func() (io.WriteCloser, error) {
 			t.Fatal("unexpected openTerminal() call")
 			return nil, nil
 		},
+		getWebIdentityToken: func(context.Context, credentials.RequestOptions) (string, error) {
+			t.Fatal("unexpected getWebIdentityToken() call")
+			return "", nil
+		},
 		environ: func() []string {
 			t.Fatal("unexpected environ() call")
 			return nil
 		},
+		getenv: func(name string) string {
+			switch name {
+			case "AWS_CA_BUNDLE", "AWS_REGION", "AWS_DEFAULT_REGION":
+				return ""
+			default:
+				t.Fatalf("unexpected getenv(%q) call", name)
+				return ""
+			}
+		},
+		readFile: func(string) ([]byte, error) {
+			t.Fatal("unexpected readFile() call")
+			return nil, nil
+		},
+		now: func() time.Time {
+			return time.Unix(1700000000, 0)
+		},
 		execCommand: func([]string, []string) error {
 			t.Fatal("unexpected execCommand() call")
 			return nil
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/credentials"
+)
+
+// runLogoutAction revokes and removes the stored tokens and cached
+// credentials of one profile, or of every RadosGW profile with --all. A
+// failed profile does not stop the others; the exit code reports it.
+func (r *cliRunner) runLogoutAction(ctx context.Context, options cliOptions) int {
+	if !options.allProfiles {
+		profile, exitCode := r.loadCLIProfile(options)
+		if profile == nil {
+			return exitCode
+		}
+		return r.logoutProfile(ctx, options, profile, make(map[string]bool))
+	}
+
+	awsConfig, err := r.loadAWSConfig()
+	if err != nil {
+		_, _ = fmt.Fprintf(r.stderr, "Error loading AWS config: %v\n", err)
+		return 1
+	}
+	exitCode := 0
+	openedEndSessions := make(map[string]bool)
+	for _, profileName := range r.getProfiles(awsConfig) {
+		profileOptions := options
+		profileOptions.profileName = profileName
+		profile, profileExitCode := r.loadCLIProfile(profileOptions)
+		if profile == nil {
+			exitCode = max(exitCode, profileExitCode)
+			continue
+		}
+		if profileExitCode = r.logoutProfile(ctx, options, profile, openedEndSessions); profileExitCode == 130 {
+			return profileExitCode
+		}
+		exitCode = max(exitCode, profileExitCode)
+	}
+
+	// Tokens of profiles that were renamed or removed from the AWS config are
+	// no longer reachable through a profile, so they can only be discarded.
+	removed, err := r.clearTokenStore()
+	if err != nil {
+		_, _ = fmt.Fprintf(r.stderr, "Error clearing OIDC token storage: %v\n", err)
+		return 1
+	}
+	if removed > 0 {
+		_, _ = fmt.Fprintf(r.stdout, "Removed %d other stored OIDC tokens\n", removed)
+	}
+	cleared, err := r.clearCache()
+	if err != nil {
+		_, _ = fmt.Fprintf(r.stderr, "Error clearing credential cache: %v\n", err)
+		return 1
+	}
+	_, _ = fmt.Fprintf(r.stdout, "Cleared %d credential cache entries from %s\n", cleared.Removed, cleared.Directory)
+	return exitCode
+}
+
+// logoutProfile prints one line per removed token. openedEndSessions keeps
+// --all from opening the same provider session page more than once.
+func (r *cliRunner) logoutProfile(ctx context.Context, options cliOptions, profile *cliProfile, openedEndSessions map[string]bool) int {
+	_, _ = fmt.Fprintf(r.stdout, "Profile %s:\n", profile.name)
+	result, err := r.logout(ctx, credentials.LogoutOptions{
+		ProfileName:   profile.name,
+		ProfileConfig: profile.profileConfig,
+		AWSConfig:     profile.awsConfig,
+		EndSession:    options.endSession,
+		Verbose:       options.verbose,
+		Output:        r.stderr,
+	})
+	exitCode := 0
+	for _, token := range result.Tokens {
+		switch {
+		case token.Revoked:
+			_, _ = fmt.Fprintf(r.stdout, "  Revoked %s at %s (client %s)\n", token.Type, result.ProviderURL, result.ClientID)
+		case errors.Is(token.Err, auth.ErrRevocationUnsupported):
+			_, _ = fmt.Fprintf(r.stdout, "  Removed %s locally; %s does not support revocation\n", token.Type, result.ProviderURL)
+		case token.Err != nil:
+			_, _ = fmt.Fprintf(r.stdout, "  Removed %s locally; revocation failed: %v\n", token.Type, token.Err)
+			exitCode = 1
+		default:
+			_, _ = fmt.Fprintf(r.stdout, "  Removed cached %s (client %s)\n", token.Type, result.ClientID)
+		}
+	}
+	if err != nil {
+		if exitCode = r.reportCredentialError(err); exitCode == 130 {
+			return exitCode
+		}
+	} else if len(result.Tokens) == 0 {
+		_, _ = fmt.Fprintln(r.stdout, "  No stored OIDC tokens")
+	}
+
+	if !options.allProfiles {
+		cleared, err := r.clearProfileCache(profile.name)
+		if err != nil {
+			_, _ = fmt.Fprintf(r.stderr, "Error clearing credential cache: %v\n", err)
+			return 1
+		}
+		if cleared.Removed > 0 {
+			_, _ = fmt.Fprintf(r.stdout, "  Removed %d cached credential entries\n", cleared.Removed)
+		}
+	}
+
+	switch {
+	case options.endSession && err == nil && result.EndSessionURL == "" && result.ProviderURL != "":
+		_, _ = fmt.Fprintf(r.stdout, "  %s does not advertise an end_session_endpoint\n", result.ProviderURL)
+	case result.EndSessionURL != "" && !openedEndSessions[result.EndSessionURL]:
+		openedEndSessions[result.EndSessionURL] = true
+		if err := r.openBrowser(result.EndSessionURL); err != nil {
+			_, _ = fmt.Fprintf(r.stderr, "Could not open a browser (%v). Open this URL to end the provider session:\n%s\n", err, result.EndSessionURL)
+		} else {
+			_, _ = fmt.Fprintf(r.stdout, "  Opened the %s end session page\n", result.ProviderURL)
+		}
+	}
+	return exitCode
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/credentialcache"
+	"github.com/fitbeard/radosgw-assume/internal/credentials"
+
+	"gopkg.in/ini.v1"
+)
+
+func configureLogoutProfiles(runner *cliRunner, profiles ...string) {
+	awsConfig := ini.Empty()
+	runner.loadAWSConfig = func() (*ini.File, error) { return awsConfig, nil }
+	runner.getProfiles = func(*ini.File) []string { return profiles }
+	runner.getProfile = func(string, *ini.File) (*config.ProfileConfig, error) {
+		return &config.ProfileConfig{}, nil
+	}
+}
+
+func TestCLIRunnerLogoutProfile(t *testing.T) {
+	runner, stdout, stderr := newTestCLIRunner(t)
+	configureLogoutProfiles(runner, "dev")
+	runner.logout = func(_ context.Context, options credentials.LogoutOptions) (credentials.LogoutResult, error) {
+		if options.ProfileName != "dev" || !options.EndSession || !options.Verbose || options.Output != stderr {
+			t.Errorf("logout() options = %+v", options)
+		}
+		return credentials.LogoutResult{
+			ProviderURL: "https://oidc.example.com",
+			ClientID:    "radosgw",
+			Tokens: []credentials.LoggedOutToken{
+				{Type: "refresh_token", Revoked: true},
+				{Type: "id_token"},
+			},
+			EndSessionURL: "https://oidc.example.com/logout?client_id=radosgw",
+		}, nil
+	}
+	runner.clearProfileCache = func(profileName string) (credentialcache.ClearResult, error) {
+		if profileName != "dev" {
+			t.Errorf("clearProfileCache() profile = %q, want dev", profileName)
+		}
+		return credentialcache.ClearResult{Removed: 2}, nil
+	}
+	var opened []string
+	runner.openBrowser = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+
+	if exitCode := runner.run("radosgw-assume", []string{"logout", "-p", "dev", "--end-session", "-v"}); exitCode != 0 {
+		t.Fatalf("run() exit code = %d, want 0; stderr: %s", exitCode, stderr.String())
+	}
+	want := "Profile dev:\n" +
+		"  Revoked refresh_token at https://oidc.example.com (client radosgw)\n" +
+		"  Removed cached id_token (client radosgw)\n" +
+		"  Removed 2 cached credential entries\n" +
+		"  Opened the https://oidc.example.com end session page\n"
+	if stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+	if len(opened) != 1 || opened[0] != "https://oidc.example.com/logout?client_id=radosgw" {
+		t.Errorf("opened URLs = %v", opened)
+	}
+}
+
+func TestCLIRunnerLogoutReportsRevocationFailures(t *testing.T) {
+	runner, stdout, stderr := newTestCLIRunner(t)
+	configureLogoutProfiles(runner, "dev")
+	runner.logout = func(context.Context, credentials.LogoutOptions) (credentials.LogoutResult, error) {
+		return credentials.LogoutResult{
+			ProviderURL: "https://oidc.example.com",
+			ClientID:    "radosgw",
+			Tokens: []credentials.LoggedOutToken{
+				{Type: "refresh_token", Err: errors.New("token revocation failed with status 503")},
+				{Type: "access_token", Err: fmt.Errorf("provider: %w", auth.ErrRevocationUnsupported)},
+			},
+		}, nil
+	}
+	runner.clearProfileCache = func(string) (credentialcache.ClearResult, error) {
+		return credentialcache.ClearResult{}, nil
+	}
+
+	if exitCode := runner.run("radosgw-assume", []string{"logout", "-p", "dev"}); exitCode != 1 {
+		t.Fatalf("run() exit code = %d, want 1; stderr: %s", exitCode, stderr.String())
+	}
+	for _, want := range []string{
+		"  Removed refresh_token locally; revocation failed: token revocation failed with status 503\n",
+		"  Removed access_token locally; https://oidc.example.com does not support revocation\n",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout = %q, want %q", stdout.String(), want)
+		}
+	}
+}
+
+func TestCLIRunnerLogoutAllProfiles(t *testing.T) {
+	runner, stdout, stderr := newTestCLIRunner(t)
+	configureLogoutProfiles(runner, "dev", "prod", "ci")
+	runner.logout = func(_ context.Context, options credentials.LogoutOptions) (credentials.LogoutResult, error) {
+		switch options.ProfileName {
+		case "dev", "prod":
+			return credentials.LogoutResult{
+				ProviderURL:   "https://oidc.example.com",
+				ClientID:      "radosgw",
+				Tokens:        []credentials.LoggedOutToken{{Type: "refresh_token", Revoked: true}},
+				EndSessionURL: "https://oidc.example.com/logout?client_id=radosgw",
+			}, nil
+		default:
+			return credentials.LogoutResult{}, errors.New("profile 'ci': missing required 'role_arn'")
+		}
+	}
+	runner.clearTokenStore = func() (int, error) { return 1, nil }
+	runner.clearCache = func() (credentialcache.ClearResult, error) {
+		return credentialcache.ClearResult{Directory: "/cache/credentials-v1", Removed: 4}, nil
+	}
+	var opened []string
+	runner.openBrowser = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+
+	if exitCode := runner.run("radosgw-assume", []string{"logout", "--all", "--end-session"}); exitCode != 1 {
+		t.Fatalf("run() exit code = %d, want 1 for the failed profile; stderr: %s", exitCode, stderr.String())
+	}
+	for _, want := range []string{
+		"Profile dev:\n  Revoked refresh_token at https://oidc.example.com (client radosgw)\n  Opened the https://oidc.example.com end session page\n",
+		"Profile prod:\n  Revoked refresh_token at https://oidc.example.com (client radosgw)\nProfile ci:\n",
+		"Removed 1 other stored OIDC tokens\n",
+		"Cleared 4 credential cache entries from /cache/credentials-v1\n",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout = %q, want %q", stdout.String(), want)
+		}
+	}
+	if !strings.Contains(stderr.String(), "Error: profile 'ci': missing required 'role_arn'") {
+		t.Errorf("stderr = %q, want the failed profile", stderr.String())
+	}
+	if len(opened) != 1 {
+		t.Errorf("opened end session pages = %v, want one per provider session", opened)
+	}
+}
+
+func TestCLIRunnerLogoutWithoutStoredTokens(t *testing.T) {
+	runner, stdout, stderr := newTestCLIRunner(t)
+	configureLogoutProfiles(runner, "ci")
+	runner.logout = func(context.Context, credentials.LogoutOptions) (credentials.LogoutResult, error) {
+		return credentials.LogoutResult{}, nil
+	}
+	runner.clearProfileCache = func(string) (credentialcache.ClearResult, error) {
+		return credentialcache.ClearResult{}, nil
+	}
+
+	if exitCode := runner.run("radosgw-assume", []string{"logout", "-p", "ci", "--end-session"}); exitCode != 0 {
+		t.Fatalf("run() exit code = %d, want 0; stderr: %s", exitCode, stderr.String())
+	}
+	if want := "Profile ci:\n  No stored OIDC tokens\n"; stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/credentials"
+	"github.com/fitbeard/radosgw-assume/internal/ui"
+)
+
+func (r *cliRunner) runTokenInspectAction(ctx context.Context, options cliOptions) int {
+	token, exitCode := r.inspectedToken(ctx, options)
+	if token == "" {
+		return exitCode
+	}
+
+	decoded, err := auth.DecodeToken(token)
+	if err != nil {
+		_, _ = fmt.Fprintf(r.stderr, "Error: cannot inspect token: %v\n", err)
+		return 1
+	}
+	if options.jsonOutput {
+		if err := ui.FprintTokenInspectionJSON(r.stdout, decoded, r.now()); err != nil {
+			_, _ = fmt.Fprintf(r.stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	ui.FprintTokenInspection(r.stdout, decoded, r.now())
+	return 0
+}
+
+// inspectedToken reads the token from the requested source, or runs the
+// profile's authentication flow. An empty token means the returned exit code
+// ends the command.
+func (r *cliRunner) inspectedToken(ctx context.Context, options cliOptions) (string, int) {
+	var token string
+	switch {
+	case options.tokenFile == "-":
+		content, err := io.ReadAll(r.stdin)
+		if err != nil {
+			_, _ = fmt.Fprintf(r.stderr, "Error reading token from stdin: %v\n", err)
+			return "", 1
+		}
+		token = strings.TrimSpace(string(content))
+	case options.tokenFile != "":
+		content, err := r.readFile(options.tokenFile)
+		if err != nil {
+			_, _ = fmt.Fprintf(r.stderr, "Error reading token file: %v\n", err)
+			return "", 1
+		}
+		token = strings.TrimSpace(string(content))
+	case options.tokenEnv != "":
+		token = strings.TrimSpace(r.getenv(options.tokenEnv))
+		if token == "" {
+			_, _ = fmt.Fprintf(r.stderr, "Error: environment variable %s is not set\n", options.tokenEnv)
+			return "", 1
+		}
+	default:
+		profile, exitCode := r.loadCLIProfile(options)
+		if profile == nil {
+			return "", exitCode
+		}
+		var err error
+		token, err = r.getWebIdentityToken(ctx, credentials.RequestOptions{
+			ProfileName:   profile.name,
+			ProfileConfig: profile.profileConfig,
+			AWSConfig:     profile.awsConfig,
+			Verbose:       options.verbose,
+		})
+		if err != nil {
+			return "", r.reportCredentialError(err)
+		}
+	}
+
+	if token == "" {
+		_, _ = fmt.Fprintln(r.stderr, "Error: token is empty")
+		return "", 1
+	}
+	return token, 0
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/credentials"
+
+	"gopkg.in/ini.v1"
+)
+
+func testInspectedToken() string {
+	segment := func(value string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(value))
+	}
+	return segment(`{"alg":"RS256","kid":"key-1"}`) + "." + segment(`{"iss":"https://oidc.example.com","sub":"user","aud":"radosgw","groups":["admins"],"exp":1700000270}`) + ".c2lnbmF0dXJl"
+}
+
+func TestCLIRunnerTokenInspectProfile(t *testing.T) {
+	runner, stdout, stderr := newTestCLIRunner(t)
+	awsConfig := ini.Empty()
+	profileConfig := &config.ProfileConfig{}
+	runner.loadAWSConfig = func() (*ini.File, error) {
+		return awsConfig, nil
+	}
+	runner.getProfile = func(profileName string, _ *ini.File) (*config.ProfileConfig, error) {
+		if profileName != "profile" {
+			t.Errorf("getProfile() name = %q, want profile", profileName)
+		}
+		return profileConfig, nil
+	}
+	runner.getWebIdentityToken = func(_ context.Context, options credentials.RequestOptions) (string, error) {
+		if options.ProfileName != "profile" || options.ProfileConfig != profileConfig || options.AWSConfig != awsConfig || !options.Verbose {
+			t.Errorf("getWebIdentityToken() options = %+v", options)
+		}
+		return testInspectedToken(), nil
+	}
+
+	exitCode := runner.run("radosgw-assume", []string{"whoami", "-p", "profile", "-v"})
+	if exitCode != 0 {
+		t.Fatalf("run() exit code = %d, want 0; stderr: %s", exitCode, stderr.String())
+	}
+	for _, want := range []string{"  kid: key-1\n", "  sub: user\n", "  aud: radosgw\n", "  groups: admins\n", "  exp: 2023-11-14T22:17:50Z (expires in 4m 30s)\n"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("stdout = %q, want %q", stdout.String(), want)
+		}
+	}
+	if strings.Contains(stdout.String()+stderr.String(), testInspectedToken()) || strings.Contains(stdout.String(), "c2lnbmF0dXJl") {
+		t.Error("raw token was printed")
+	}
+}
+
+func TestCLIRunnerTokenInspectSources(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		set  func(*cliRunner)
+	}{
+		{
+			name: "stdin",
+			args: []string{"token", "inspect", "--token-file", "-", "--json"},
+			set: func(runner *cliRunner) {
+				runner.stdin = strings.NewReader(testInspectedToken() + "\n")
+			},
+		},
+		{
+			name: "file",
+			args: []string{"token", "inspect", "--token-file", "/run/token", "--json"},
+			set: func(runner *cliRunner) {
+				runner.readFile = func(path string) ([]byte, error) {
+					if path != "/run/token" {
+						t.Errorf("readFile() path = %q, want /run/token", path)
+					}
+					return []byte(testInspectedToken()), nil
+				}
+			},
+		},
+		{
+			name: "environment variable",
+			args: []string{"whoami", "--token-env", "CI_TOKEN", "--json"},
+			set: func(runner *cliRunner) {
+				runner.getenv = func(name string) string {
+					if name != "CI_TOKEN" {
+						t.Errorf("getenv() name = %q, want CI_TOKEN", name)
+					}
+					return testInspectedToken()
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runner, stdout, stderr := newTestCLIRunner(t)
+			test.set(runner)
+
+			exitCode := runner.run("radosgw-assume", test.args)
+			if exitCode != 0 {
+				t.Fatalf("run() exit code = %d, want 0; stderr: %s", exitCode, stderr.String())
+			}
+			for _, want := range []string{`"sub": "user"`, `"expires_at": "2023-11-14T22:17:50Z"`, `"expired": false`} {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("stdout = %s, want %s", stdout.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestCLIRunnerTokenInspectFailures(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		set          func(*cliRunner)
+		wantExitCode int
+		wantStderr   string
+	}{
+		{
+			name: "opaque token",
+			args: []string{"whoami", "--token-env", "TOKEN"},
+			set: func(runner *cliRunner) {
+				runner.getenv = func(string) string { return "opaque-access-token" }
+			},
+			wantExitCode: 1,
+			wantStderr:   "Error: cannot inspect token: token is not a JWT",
+		},
+		{
+			name: "unset environment variable",
+			args: []string{"whoami", "--token-env", "TOKEN"},
+			set: func(runner *cliRunner) {
+				runner.getenv = func(string) string { return "" }
+			},
+			wantExitCode: 1,
+			wantStderr:   "Error: environment variable TOKEN is not set",
+		},
+		{
+			name: "empty file",
+			args: []string{"whoami", "--token-file", "token"},
+			set: func(runner *cliRunner) {
+				runner.readFile = func(string) ([]byte, error) { return []byte("\n"), nil }
+			},
+			wantExitCode: 1,
+			wantStderr:   "Error: token is empty",
+		},
+		{
+			name: "unreadable file",
+			args: []string{"whoami", "--token-file", "token"},
+			set: func(runner *cliRunner) {
+				runner.readFile = func(string) ([]byte, error) { return nil, errors.New("permission denied") }
+			},
+			wantExitCode: 1,
+			wantStderr:   "Error reading token file: permission denied",
+		},
+		{
+			name: "authentication failure",
+			args: []string{"whoami", "--env"},
+			set: func(runner *cliRunner) {
+				runner.loadEnvConfig = func() (*config.ProfileConfig, error) { return &config.ProfileConfig{}, nil }
+				runner.getWebIdentityToken = func(context.Context, credentials.RequestOptions) (string, error) {
+					return "", errors.New("device authorization failed")
+				}
+			},
+			wantExitCode: 1,
+			wantStderr:   "Error: device authorization failed",
+		},
+		{
+			name: "cancelled authentication",
+			args: []string{"whoami", "--env"},
+			set: func(runner *cliRunner) {
+				runner.loadEnvConfig = func() (*config.ProfileConfig, error) { return &config.ProfileConfig{}, nil }
+				runner.getWebIdentityToken = func(context.Context, credentials.RequestOptions) (string, error) {
+					return "", context.Canceled
+				}
+			},
+			wantExitCode: 130,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runner, stdout, stderr := newTestCLIRunner(t)
+			test.set(runner)
+
+			exitCode := runner.run("radosgw-assume", test.args)
+			if exitCode != test.wantExitCode {
+				t.Fatalf("run() exit code = %d, want %d; stderr: %s", exitCode, test.wantExitCode, stderr.String())
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("stdout = %q, want empty", stdout.String())
+			}
+			if !strings.Contains(stderr.String(), test.wantStderr) {
+				t.Errorf("stderr = %q, want %q", stderr.String(), test.wantStderr)
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
 )
 
 // Note: These tests focus on struct validation and type checking
@@ -150,9 +151,9 @@ func TestAuthenticateDeviceFlow(t *testing.T) {
 			currentTime := time.Unix(0, 0)
 			dependencies := newDeviceFlowDependencies()
 			dependencies.stderr = io.Discard
-			dependencies.newHTTPClient = func(sslVerify bool) *http.Client {
-				if !sslVerify {
-					t.Error("sslVerify = false, want true")
+			dependencies.newHTTPClient = func(clientOptions httpclient.Options) *http.Client {
+				if !clientOptions.VerifyTLS {
+					t.Error("VerifyTLS = false, want true")
 				}
 				return server.Client()
 			}
@@ -172,8 +173,8 @@ func TestAuthenticateDeviceFlow(t *testing.T) {
 			if err != nil {
 				t.Fatalf("AuthenticateDeviceFlow() error = %v", err)
 			}
-			if token != "test-access-token" {
-				t.Errorf("token = %q, want test-access-token", token)
+			if token.AccessToken != "test-access-token" {
+				t.Errorf("token = %q, want test-access-token", token.AccessToken)
 			}
 			if got := tokenRequests.Load(); got != 2 {
 				t.Errorf("token requests = %d, want 2", got)
@@ -316,7 +317,7 @@ func TestAuthenticationFlowsHonorPreCanceledContext(t *testing.T) {
 
 	tests := []struct {
 		name         string
-		authenticate func(context.Context, OIDCOptions) (string, error)
+		authenticate func(context.Context, OIDCOptions) (TokenResponse, error)
 	}{
 		{name: "device", authenticate: AuthenticateDeviceFlow},
 		{name: "browser", authenticate: AuthenticateBrowserFlow},
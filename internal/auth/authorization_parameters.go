@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
+// AuthorizationParameters are optional parameters added to the browser
+// authorization request and the device authorization request.
+type AuthorizationParameters struct {
+	Prompt    string
+	LoginHint string
+	ACRValues string
+	Audience  string
+	Resources []string
+}
+
+// RequiresInteraction reports whether the prompt asks the provider to show
+// the user a login, consent or account selection page, which a cached or
+// refreshed token would skip.
+func (parameters AuthorizationParameters) RequiresInteraction() bool {
+	for prompt := range strings.FieldsSeq(parameters.Prompt) {
+		if prompt != config.PromptNone {
+			return true
+		}
+	}
+	return false
+}
+
+func (parameters AuthorizationParameters) apply(values url.Values) {
+	setIfNotEmpty(values, "prompt", parameters.Prompt)
+	setIfNotEmpty(values, "login_hint", parameters.LoginHint)
+	setIfNotEmpty(values, "acr_values", parameters.ACRValues)
+	parameters.applyTokenTarget(values)
+}
+
+// applyTokenTarget adds the audience and resource indicators, which token
+// requests such as the refresh grant repeat (RFC 8707 section 2.2) so the
+// issued token is for the same target as at login.
+func (parameters AuthorizationParameters) applyTokenTarget(values url.Values) {
+	setIfNotEmpty(values, "audience", parameters.Audience)
+	if len(parameters.Resources) > 0 {
+		values["resource"] = append([]string(nil), parameters.Resources...)
+	}
+}
+
+func setIfNotEmpty(values url.Values, key, value string) {
+	if value != "" {
+		values.Set(key, value)
+	}
+}
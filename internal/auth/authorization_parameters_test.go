@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestAuthorizationParametersApply(t *testing.T) {
+	values := url.Values{"client_id": {"test-client"}, "audience": {"from-endpoint"}}
+	AuthorizationParameters{
+		Prompt:    "login consent",
+		LoginHint: "user@example.com",
+		ACRValues: "mfa",
+		Audience:  "radosgw",
+		Resources: []string{"https://storage.example.com", "https://sts.example.com"},
+	}.apply(values)
+
+	want := url.Values{
+		"client_id":  {"test-client"},
+		"prompt":     {"login consent"},
+		"login_hint": {"user@example.com"},
+		"acr_values": {"mfa"},
+		"audience":   {"radosgw"},
+		"resource":   {"https://storage.example.com", "https://sts.example.com"},
+	}
+	if values.Encode() != want.Encode() {
+		t.Errorf("apply() = %s, want %s", values.Encode(), want.Encode())
+	}
+
+	empty := url.Values{"audience": {"from-endpoint"}}
+	AuthorizationParameters{}.apply(empty)
+	if empty.Encode() != "audience=from-endpoint" {
+		t.Errorf("apply() with no parameters = %s, want unchanged values", empty.Encode())
+	}
+}
+
+func TestAuthorizationParametersRequiresInteraction(t *testing.T) {
+	for _, test := range []struct {
+		prompt string
+		want   bool
+	}{
+		{prompt: ""},
+		{prompt: "none"},
+		{prompt: "login", want: true},
+		{prompt: "consent select_account", want: true},
+	} {
+		if got := (AuthorizationParameters{Prompt: test.prompt}).RequiresInteraction(); got != test.want {
+			t.Errorf("RequiresInteraction(%q) = %v, want %v", test.prompt, got, test.want)
+		}
+	}
+}
+
+func TestAuthorizationParametersReachBothFlows(t *testing.T) {
+	options := testOIDCOptions()
+	options.Authorization = AuthorizationParameters{
+		Prompt:    "login",
+		LoginHint: "user@example.com",
+		ACRValues: "mfa",
+		Audience:  "radosgw",
+		Resources: []string{"https://storage.example.com"},
+	}
+	assertParameters := func(t *testing.T, values url.Values) {
+		t.Helper()
+		for key, want := range map[string]string{
+			"prompt":     "login",
+			"login_hint": "user@example.com",
+			"acr_values": "mfa",
+			"audience":   "radosgw",
+		} {
+			if got := values.Get(key); got != want {
+				t.Errorf("%s = %q, want %q", key, got, want)
+			}
+		}
+		if got := values["resource"]; !slices.Equal(got, []string{"https://storage.example.com"}) {
+			t.Errorf("resource = %q, want https://storage.example.com", got)
+		}
+	}
+
+	t.Run("browser", func(t *testing.T) {
+		dependencies := newTestBrowserFlowDependencies(io.Discard)
+		dependencies.openBrowser = func(authURL string) error {
+			parsed, err := url.Parse(authURL)
+			if err != nil {
+				return err
+			}
+			assertParameters(t, parsed.Query())
+			return nil
+		}
+
+		if _, err := authenticateBrowserFlow(t.Context(), options, dependencies); err != nil {
+			t.Fatalf("authenticateBrowserFlow() error = %v", err)
+		}
+	})
+
+	t.Run("device", func(t *testing.T) {
+		var authorizationForm url.Values
+		client := newDeviceFlowHTTPClient(
+			testDeviceHTTPResponse{status: http.StatusOK, body: validDeviceResponse},
+			testDeviceHTTPResponse{status: http.StatusOK, body: `{"access_token":"test-access-token"}`},
+		)
+		transport := client.Transport
+		client.Transport = roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			if authorizationForm == nil {
+				body, err := io.ReadAll(request.Body)
+				if err != nil {
+					t.Fatalf("read device authorization request: %v", err)
+				}
+				authorizationForm, err = url.ParseQuery(string(body))
+				if err != nil {
+					t.Fatalf("parse device authorization request: %v", err)
+				}
+				request.Body = io.NopCloser(strings.NewReader(string(body)))
+			}
+			return transport.RoundTrip(request)
+		})
+		dependencies, _, _ := newTestDeviceFlowDependencies(io.Discard, client)
+
+		if _, err := authenticateDeviceFlow(t.Context(), options, dependencies); err != nil {
+			t.Fatalf("authenticateDeviceFlow() error = %v", err)
+		}
+		assertParameters(t, authorizationForm)
+	})
+}
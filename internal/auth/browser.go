@@ -8,6 +8,9 @@ import (
 	"net/url"
 	"os"
 	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
 )
 
 type browserFlowTimer interface {
@@ -22,19 +25,22 @@ type browserFlowProgress interface {
 
 type browserFlowDependencies struct {
 	stderr io.Writer
+	stdin  io.Reader
 
 	generateRandomString func(int) (string, error)
 	generatePKCE         func(string) (string, string, string, error)
-	startCallbackServer  func(chan<- browserCallbackResult) (*browserCallbackServer, error)
+	startCallbackServer  func(browserCallbackConfig, chan<- browserCallbackResult) (*browserCallbackServer, error)
 	openBrowser          func(string) error
-	newHTTPClient        func(bool) *http.Client
+	newHTTPClient        func(httpclient.Options) *http.Client
 	discoverEndpoints    func(context.Context, *http.Client, string) (oidcEndpoints, error)
 	newTimer             func(time.Duration) browserFlowTimer
 	newProgress          func() browserFlowProgress
+	verifyIDToken        func(context.Context, *http.Client, oidcEndpoints, OIDCOptions, string, string) error
 }
 
 type browserFlowSetup struct {
 	state              string
+	nonce              string
 	codeVerifier       string
 	codeChallenge      string
 	resolvedPKCEMethod string
@@ -57,74 +63,62 @@ func (timer *realBrowserFlowTimer) Stop() {
 func newBrowserFlowDependencies() browserFlowDependencies {
 	return browserFlowDependencies{
 		stderr:               os.Stderr,
+		stdin:                os.Stdin,
 		generateRandomString: GenerateRandomString,
 		generatePKCE:         GeneratePKCE,
-		startCallbackServer: func(results chan<- browserCallbackResult) (*browserCallbackServer, error) {
-			return startBrowserCallbackServer(
-				callbackListenHost,
-				[]int{CallbackPort, CallbackFallbackPort},
-				results,
-			)
-		},
-		openBrowser:       openBrowser,
-		newHTTPClient:     NewHTTPClient,
-		discoverEndpoints: discoverOIDCEndpoints,
+		startCallbackServer:  startBrowserCallbackServer,
+		openBrowser:          openBrowser,
+		newHTTPClient:        NewHTTPClient,
+		discoverEndpoints:    discoverOIDCEndpoints,
 		newTimer: func(timeout time.Duration) browserFlowTimer {
 			return &realBrowserFlowTimer{timer: time.NewTimer(timeout)}
 		},
 		newProgress: func() browserFlowProgress { return NewProgressIndicator() },
+		verifyIDToken: func(ctx context.Context, client *http.Client, endpoints oidcEndpoints, options OIDCOptions, idToken, nonce string) error {
+			return verifyIDToken(ctx, client, endpoints, options, idToken, nonce, time.Now())
+		},
 	}
 }
 
 // AuthenticateBrowserFlow performs OIDC authorization code flow with PKCE.
-func AuthenticateBrowserFlow(ctx context.Context, options OIDCOptions) (string, error) {
+func AuthenticateBrowserFlow(ctx context.Context, options OIDCOptions) (TokenResponse, error) {
 	return AuthenticateBrowserFlowWithOutput(ctx, options, os.Stderr)
 }
 
 // AuthenticateBrowserFlowWithOutput performs OIDC authorization code flow
 // authentication and writes user interaction to output.
-func AuthenticateBrowserFlowWithOutput(ctx context.Context, options OIDCOptions, output io.Writer) (string, error) {
+func AuthenticateBrowserFlowWithOutput(ctx context.Context, options OIDCOptions, output io.Writer) (TokenResponse, error) {
 	dependencies := newBrowserFlowDependencies()
 	dependencies.stderr = output
 	dependencies.newProgress = func() browserFlowProgress { return newProgressIndicatorWithOutput(output) }
 	return authenticateBrowserFlow(ctx, options, dependencies)
 }
 
-func authenticateBrowserFlow(ctx context.Context, options OIDCOptions, dependencies browserFlowDependencies) (string, error) {
-	setup, err := prepareBrowserFlow(ctx, options, dependencies)
+func authenticateBrowserFlow(ctx context.Context, options OIDCOptions, dependencies browserFlowDependencies) (TokenResponse, error) {
+	callback, err := resolveBrowserCallbackConfig(options)
 	if err != nil {
-		return "", err
+		return TokenResponse{}, err
 	}
-
-	callbackResults := make(chan browserCallbackResult, 1)
-	callbackServer, err := dependencies.startCallbackServer(callbackResults)
+	setup, err := prepareBrowserFlow(ctx, options, dependencies)
 	if err != nil {
-		return "", fmt.Errorf("both callback ports (%d and %d) are in use, please free one of them: %w", CallbackPort, CallbackFallbackPort, err)
+		return TokenResponse{}, err
 	}
-	defer func() { _ = callbackServer.close() }()
 
-	if callbackServer.port == CallbackFallbackPort && options.Verbose {
-		_, _ = fmt.Fprintf(dependencies.stderr, "# Port %d is busy, using fallback port %d...\n", CallbackPort, CallbackFallbackPort)
+	var redirectURI string
+	var callbackResult browserCallbackResult
+	if options.BrowserMode == config.BrowserModeManual {
+		redirectURI = callback.redirectURI(callback.ports[0])
+		callbackResult, err = receiveManualBrowserResponse(ctx, options, setup, redirectURI, dependencies)
+	} else {
+		redirectURI, callbackResult, err = receiveBrowserCallback(ctx, options, callback, setup, dependencies)
 	}
-
-	redirectURI := fmt.Sprintf("http://localhost:%d/callback", callbackServer.port)
-	authURL := setup.authorizationURL(options, redirectURI)
-
-	presentBrowserAuthorization(authURL, dependencies)
-	callbackResult, err := waitForBrowserCallback(ctx, callbackResults, callbackServer, dependencies)
 	if err != nil {
-		return "", err
-	}
-
-	shutdownContext, cancelShutdown := context.WithTimeout(context.Background(), CallbackShutdownTimeout)
-	defer cancelShutdown()
-	if err := callbackServer.shutdown(shutdownContext); err != nil {
-		return "", fmt.Errorf("failed to stop callback server: %w", err)
+		return TokenResponse{}, err
 	}
 
 	authorizationCode, err := browserAuthorizationCode(callbackResult, setup.state, options.ProviderURL)
 	if err != nil {
-		return "", err
+		return TokenResponse{}, err
 	}
 
 	if options.Verbose {
@@ -132,16 +126,64 @@ func authenticateBrowserFlow(ctx context.Context, options OIDCOptions, dependenc
 		_, _ = fmt.Fprintln(dependencies.stderr, "# Exchanging authorization code for tokens...")
 	}
 
-	accessToken, err := setup.exchangeAuthorizationCode(ctx, options, authorizationCode, redirectURI)
+	tokens, err := setup.exchangeAuthorizationCode(ctx, options, authorizationCode, redirectURI)
 	if err != nil {
-		return "", err
+		return TokenResponse{}, err
+	}
+
+	if setup.nonce != "" {
+		if err := dependencies.verifyIDToken(ctx, setup.client, setup.endpoints, options, tokens.IDToken, setup.nonce); err != nil {
+			return TokenResponse{}, fmt.Errorf("ID token rejected: %w", err)
+		}
 	}
 
 	if options.Verbose {
+		if setup.nonce != "" {
+			_, _ = fmt.Fprintln(dependencies.stderr, "# ✓ ID token signature, nonce and claims verified")
+		}
 		_, _ = fmt.Fprintln(dependencies.stderr, "# ✓ Successfully obtained access token")
 	}
 
-	return accessToken, nil
+	return tokens, nil
+}
+
+// receiveBrowserCallback opens the browser and waits for the provider to
+// redirect it to the local callback server. It returns the redirect URI that
+// was sent with the authorization request.
+func receiveBrowserCallback(ctx context.Context, options OIDCOptions, callback browserCallbackConfig, setup browserFlowSetup, dependencies browserFlowDependencies) (string, browserCallbackResult, error) {
+	callbackResults := make(chan browserCallbackResult, 1)
+	callbackServer, err := dependencies.startCallbackServer(callback, callbackResults)
+	if err != nil {
+		if len(callback.ports) == 1 {
+			return "", browserCallbackResult{}, fmt.Errorf("callback port %s is in use, please free it or configure radosgw_oidc_callback_ports: %w", callback.portList(), err)
+		}
+		return "", browserCallbackResult{}, fmt.Errorf("all callback ports (%s) are in use, please free one of them or configure radosgw_oidc_callback_ports: %w", callback.portList(), err)
+	}
+	defer func() { _ = callbackServer.close() }()
+
+	if firstPort := callback.ports[0]; firstPort != 0 && callbackServer.port != firstPort && options.Verbose {
+		_, _ = fmt.Fprintf(dependencies.stderr, "# Port %d is busy, using fallback port %d...\n", firstPort, callbackServer.port)
+	}
+
+	redirectURI := callback.redirectURI(callbackServer.port)
+	authURL, err := setup.authorizationRequestURL(ctx, options, redirectURI, dependencies)
+	if err != nil {
+		return "", browserCallbackResult{}, err
+	}
+
+	presentBrowserAuthorization(authURL, dependencies)
+	callbackResult, err := waitForBrowserCallback(ctx, callbackResults, callbackServer, dependencies)
+	if err != nil {
+		return "", browserCallbackResult{}, err
+	}
+
+	shutdownContext, cancelShutdown := context.WithTimeout(context.Background(), CallbackShutdownTimeout)
+	defer cancelShutdown()
+	if err := callbackServer.shutdown(shutdownContext); err != nil {
+		return "", browserCallbackResult{}, fmt.Errorf("failed to stop callback server: %w", err)
+	}
+
+	return redirectURI, callbackResult, nil
 }
 
 func prepareBrowserFlow(ctx context.Context, options OIDCOptions, dependencies browserFlowDependencies) (browserFlowSetup, error) {
@@ -153,22 +195,32 @@ func prepareBrowserFlow(ctx context.Context, options OIDCOptions, dependencies b
 	if err != nil {
 		return browserFlowSetup{}, fmt.Errorf("failed to generate state: %w", err)
 	}
+	// The nonce binds the ID token to this authorization request. It is only
+	// sent when an ID token is requested.
+	var nonce string
+	if requestsOpenIDScope(options.Scope) {
+		nonce, err = dependencies.generateRandomString(32)
+		if err != nil {
+			return browserFlowSetup{}, fmt.Errorf("failed to generate nonce: %w", err)
+		}
+	}
 	codeVerifier, codeChallenge, resolvedPKCEMethod, err := dependencies.generatePKCE(string(options.PKCEMethod))
 	if err != nil {
 		return browserFlowSetup{}, err
 	}
 
-	client := dependencies.newHTTPClient(options.SSLVerify)
-	endpoints, err := dependencies.discoverEndpoints(ctx, client, options.ProviderURL)
+	client := dependencies.newHTTPClient(options.httpClientOptions())
+	endpoints, err := discoverEndpointsWithRetry(ctx, client, options, dependencies.discoverEndpoints)
 	if err != nil {
 		return browserFlowSetup{}, err
 	}
-	if err := endpoints.validateBrowserFlow(); err != nil {
+	if err := endpoints.validateBrowserFlow(options.RequirePAR); err != nil {
 		return browserFlowSetup{}, err
 	}
 
 	return browserFlowSetup{
 		state:              state,
+		nonce:              nonce,
 		codeVerifier:       codeVerifier,
 		codeChallenge:      codeChallenge,
 		resolvedPKCEMethod: resolvedPKCEMethod,
@@ -225,30 +277,65 @@ func browserAuthorizationCode(result browserCallbackResult, expectedState, provi
 	return result.code, nil
 }
 
-func (setup browserFlowSetup) exchangeAuthorizationCode(ctx context.Context, options OIDCOptions, authorizationCode, redirectURI string) (string, error) {
+func (setup browserFlowSetup) exchangeAuthorizationCode(ctx context.Context, options OIDCOptions, authorizationCode, redirectURI string) (TokenResponse, error) {
 	tokenData := url.Values{}
 	tokenData.Set("grant_type", "authorization_code")
-	tokenData.Set("client_id", options.ClientID)
 	tokenData.Set("code", authorizationCode)
 	tokenData.Set("redirect_uri", redirectURI)
 	tokenData.Set("code_verifier", setup.codeVerifier)
 
-	return exchangeBrowserAuthorizationCode(ctx, setup.client, setup.endpoints.token, tokenData, options.ProviderURL)
+	return exchangeBrowserAuthorizationCode(ctx, setup.client, setup.endpoints.token, tokenData, options)
 }
 
-func (setup browserFlowSetup) authorizationURL(options OIDCOptions, redirectURI string) string {
+// authorizationRequestURL returns the URL opened in the browser. When the
+// provider supports pushed authorization requests, the parameters are posted
+// to it first and the URL only carries client_id and the returned request_uri.
+func (setup browserFlowSetup) authorizationRequestURL(ctx context.Context, options OIDCOptions, redirectURI string, dependencies browserFlowDependencies) (string, error) {
+	parameters := setup.authorizationParameters(options, redirectURI)
+	if setup.endpoints.pushedAuthorization == "" {
+		return setup.authorizationURL(parameters), nil
+	}
+
+	pushed, err := pushAuthorizationRequest(ctx, setup.client, setup.endpoints.pushedAuthorization, setup.endpoints.token, parameters, options)
+	if err != nil {
+		return "", err
+	}
+	if options.Verbose {
+		_, _ = fmt.Fprintf(dependencies.stderr, "# ✓ Pushed authorization request (request_uri expires in %ds)\n", pushed.ExpiresIn)
+	}
+	return setup.authorizationURL(url.Values{
+		"client_id":   {options.ClientID},
+		"request_uri": {pushed.RequestURI},
+	}), nil
+}
+
+func (setup browserFlowSetup) authorizationParameters(options OIDCOptions, redirectURI string) url.Values {
+	parameters := url.Values{}
+	parameters.Set("client_id", options.ClientID)
+	parameters.Set("redirect_uri", redirectURI)
+	parameters.Set("response_type", "code")
+	parameters.Set("scope", options.Scope)
+	parameters.Set("state", setup.state)
+	if setup.nonce != "" {
+		parameters.Set("nonce", setup.nonce)
+	}
+	parameters.Set("code_challenge", setup.codeChallenge)
+	parameters.Set("code_challenge_method", setup.resolvedPKCEMethod)
+	options.Authorization.apply(parameters)
+	return parameters
+}
+
+// authorizationURL adds parameters to the authorization endpoint, keeping any
+// query parameters the provider included in the discovered endpoint.
+func (setup browserFlowSetup) authorizationURL(parameters url.Values) string {
 	authURL, err := url.Parse(setup.endpoints.authorization)
 	if err != nil {
 		return setup.endpoints.authorization
 	}
 	authParams := authURL.Query()
-	authParams.Set("client_id", options.ClientID)
-	authParams.Set("redirect_uri", redirectURI)
-	authParams.Set("response_type", "code")
-	authParams.Set("scope", options.Scope)
-	authParams.Set("state", setup.state)
-	authParams.Set("code_challenge", setup.codeChallenge)
-	authParams.Set("code_challenge_method", setup.resolvedPKCEMethod)
+	for key, values := range parameters {
+		authParams[key] = values
+	}
 	authURL.RawQuery = authParams.Encode()
 	return authURL.String()
 }
@@ -268,24 +355,25 @@ func printBrowserAuthenticationWait(stderr io.Writer) {
 	_, _ = fmt.Fprintln(stderr, "# Waiting for authentication...")
 }
 
-func exchangeBrowserAuthorizationCode(ctx context.Context, client *http.Client, tokenEndpoint string, tokenData url.Values, providerURL string) (string, error) {
-	response, err := postOIDCForm(ctx, client, tokenEndpoint, tokenData)
+func exchangeBrowserAuthorizationCode(ctx context.Context, client *http.Client, tokenEndpoint string, tokenData url.Values, options OIDCOptions) (TokenResponse, error) {
+	providerURL := options.ProviderURL
+	response, err := postTokenRequest(ctx, client, tokenEndpoint, tokenEndpoint, tokenData, options)
 	if err != nil {
-		return "", fmt.Errorf("token exchange failed: %w", err)
+		return TokenResponse{}, fmt.Errorf("token exchange failed: %w", err)
 	}
 	body, err := readOIDCResponseAndClose(response)
 	if err != nil {
-		return "", fmt.Errorf("failed to read token response: %w", err)
+		return TokenResponse{}, fmt.Errorf("failed to read token response: %w", err)
 	}
 
 	tokenResponse, err := decodeOIDCTokenResponse("token exchange", response.StatusCode, body, providerURL)
 	if err != nil {
-		return "", err
+		return TokenResponse{}, err
 	}
 
 	if response.StatusCode != http.StatusOK {
-		return "", oidcHTTPStatusError("token exchange", response.StatusCode, body, providerURL)
+		return TokenResponse{}, oidcHTTPStatusError("token exchange", response.StatusCode, body, providerURL)
 	}
 
-	return accessTokenFromOIDCResponse(tokenResponse, providerURL)
+	return tokensFromOIDCResponse(tokenResponse, providerURL)
 }
@@ -8,9 +8,20 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
 )
 
-const callbackListenHost = "127.0.0.1"
+// browserCallbackConfig describes where the local callback server listens and
+// the redirect URI registered with the provider.
+type browserCallbackConfig struct {
+	host          config.CallbackHost
+	listenNetwork string
+	listenHost    string
+	ports         []int
+	path          string
+}
 
 type browserCallbackResult struct {
 	code             string
@@ -26,14 +37,75 @@ type browserCallbackServer struct {
 	close    func() error
 }
 
-func startBrowserCallbackServer(host string, ports []int, results chan<- browserCallbackResult) (*browserCallbackServer, error) {
-	listener, port, err := listenOnCallbackPorts(host, ports...)
+// resolveBrowserCallbackConfig applies the callback defaults and validates the
+// configured values before any provider request is made. The localhost name is
+// served on the IPv4 loopback address so the redirect never depends on how the
+// browser resolves it.
+func resolveBrowserCallbackConfig(options OIDCOptions) (browserCallbackConfig, error) {
+	callback := browserCallbackConfig{
+		host:  options.CallbackHost,
+		ports: options.CallbackPorts,
+		path:  options.CallbackPath,
+	}
+	if callback.host == "" {
+		callback.host = config.CallbackHostLocalhost
+	}
+	if len(callback.ports) == 0 {
+		callback.ports = []int{CallbackPort, CallbackFallbackPort}
+	}
+	if callback.path == "" {
+		callback.path = DefaultCallbackPath
+	}
+
+	if err := options.BrowserMode.Validate(); err != nil {
+		return browserCallbackConfig{}, err
+	}
+	if err := callback.host.Validate(); err != nil {
+		return browserCallbackConfig{}, err
+	}
+	for _, port := range callback.ports {
+		if port < 0 || port > 65535 {
+			return browserCallbackConfig{}, fmt.Errorf("invalid callback port %d", port)
+		}
+	}
+	if err := config.ValidateCallbackPath(callback.path); err != nil {
+		return browserCallbackConfig{}, err
+	}
+	if options.BrowserMode == config.BrowserModeManual && callback.ports[0] == 0 {
+		return browserCallbackConfig{}, fmt.Errorf("radosgw_oidc_browser_mode = manual needs a fixed redirect URI; the first radosgw_oidc_callback_ports entry must not be 0")
+	}
+
+	switch callback.host {
+	case config.CallbackHostIPv6:
+		callback.listenNetwork = "tcp6"
+		callback.listenHost = "::1"
+	default:
+		callback.listenNetwork = "tcp4"
+		callback.listenHost = "127.0.0.1"
+	}
+	return callback, nil
+}
+
+func (callback browserCallbackConfig) redirectURI(port int) string {
+	return fmt.Sprintf("http://%s:%d%s", callback.host, port, callback.path)
+}
+
+func (callback browserCallbackConfig) portList() string {
+	ports := make([]string, 0, len(callback.ports))
+	for _, port := range callback.ports {
+		ports = append(ports, strconv.Itoa(port))
+	}
+	return strings.Join(ports, ", ")
+}
+
+func startBrowserCallbackServer(callback browserCallbackConfig, results chan<- browserCallbackResult) (*browserCallbackServer, error) {
+	listener, port, err := listenOnCallbackPorts(callback.listenNetwork, callback.listenHost, callback.ports...)
 	if err != nil {
 		return nil, err
 	}
 
 	server := &http.Server{
-		Handler:           newBrowserCallbackHandler(results),
+		Handler:           newBrowserCallbackHandler(callback.path, results),
 		ReadHeaderTimeout: CallbackReadHeaderTimeout,
 	}
 	serverErrors := make(chan error, 1)
@@ -51,14 +123,14 @@ func startBrowserCallbackServer(host string, ports []int, results chan<- browser
 	}, nil
 }
 
-func listenOnCallbackPorts(host string, ports ...int) (net.Listener, int, error) {
+func listenOnCallbackPorts(network, host string, ports ...int) (net.Listener, int, error) {
 	if len(ports) == 0 {
 		return nil, 0, fmt.Errorf("no callback ports configured")
 	}
 
 	var listenErrors []error
 	for _, port := range ports {
-		listener, err := net.Listen("tcp4", net.JoinHostPort(host, strconv.Itoa(port)))
+		listener, err := net.Listen(network, net.JoinHostPort(host, strconv.Itoa(port)))
 		if err != nil {
 			listenErrors = append(listenErrors, fmt.Errorf("port %d: %w", port, err))
 			continue
@@ -71,9 +143,14 @@ func listenOnCallbackPorts(host string, ports ...int) (net.Listener, int, error)
 	return nil, 0, errors.Join(listenErrors...)
 }
 
-func newBrowserCallbackHandler(results chan<- browserCallbackResult) http.Handler {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+func newBrowserCallbackHandler(path string, results chan<- browserCallbackResult) http.Handler {
+	// Match the path exactly rather than registering it as a ServeMux pattern,
+	// which would give characters such as braces a special meaning.
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
 		query := r.URL.Query()
 
 		if errorCode := query.Get("error"); errorCode != "" {
@@ -156,8 +233,6 @@ func newBrowserCallbackHandler(results chan<- browserCallbackResult) http.Handle
 			`)
 		deliverBrowserCallbackResult(results, browserCallbackResult{code: code, state: state})
 	})
-
-	return mux
 }
 
 func deliverBrowserCallbackResult(results chan<- browserCallbackResult, result browserCallbackResult) {
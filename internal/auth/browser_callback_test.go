@@ -5,14 +5,84 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
 )
 
+func TestResolveBrowserCallbackConfig(t *testing.T) {
+	tests := []struct {
+		name            string
+		options         OIDCOptions
+		wantNetwork     string
+		wantListenHost  string
+		wantPorts       []int
+		wantRedirectURI string
+		wantContain     string
+	}{
+		{
+			name:            "defaults",
+			wantNetwork:     "tcp4",
+			wantListenHost:  "127.0.0.1",
+			wantPorts:       []int{CallbackPort, CallbackFallbackPort},
+			wantRedirectURI: "http://localhost:8080/callback",
+		},
+		{
+			name: "IPv4 loopback",
+			options: OIDCOptions{
+				CallbackHost:  config.CallbackHostIPv4,
+				CallbackPorts: []int{8400, 0},
+				CallbackPath:  "/oauth2/callback",
+			},
+			wantNetwork:     "tcp4",
+			wantListenHost:  "127.0.0.1",
+			wantPorts:       []int{8400, 0},
+			wantRedirectURI: "http://127.0.0.1:8080/oauth2/callback",
+		},
+		{
+			name:            "IPv6 loopback",
+			options:         OIDCOptions{CallbackHost: config.CallbackHostIPv6},
+			wantNetwork:     "tcp6",
+			wantListenHost:  "::1",
+			wantPorts:       []int{CallbackPort, CallbackFallbackPort},
+			wantRedirectURI: "http://[::1]:8080/callback",
+		},
+		{name: "invalid host", options: OIDCOptions{CallbackHost: "0.0.0.0"}, wantContain: "invalid radosgw_oidc_callback_host"},
+		{name: "invalid port", options: OIDCOptions{CallbackPorts: []int{70000}}, wantContain: "invalid callback port 70000"},
+		{name: "invalid path", options: OIDCOptions{CallbackPath: "callback"}, wantContain: "invalid radosgw_oidc_callback_path"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			callback, err := resolveBrowserCallbackConfig(test.options)
+			if test.wantContain != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+					t.Fatalf("resolveBrowserCallbackConfig() error = %v, want containing %q", err, test.wantContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveBrowserCallbackConfig() error = %v", err)
+			}
+			if callback.listenNetwork != test.wantNetwork || callback.listenHost != test.wantListenHost {
+				t.Errorf("listen = %s %s, want %s %s", callback.listenNetwork, callback.listenHost, test.wantNetwork, test.wantListenHost)
+			}
+			if !slices.Equal(callback.ports, test.wantPorts) {
+				t.Errorf("ports = %v, want %v", callback.ports, test.wantPorts)
+			}
+			if got := callback.redirectURI(8080); got != test.wantRedirectURI {
+				t.Errorf("redirectURI() = %q, want %q", got, test.wantRedirectURI)
+			}
+		})
+	}
+}
+
 func TestListenOnCallbackPorts(t *testing.T) {
 	t.Run("keeps selected port reserved", func(t *testing.T) {
-		listener, port, err := listenOnCallbackPorts("127.0.0.1", 0)
+		listener, port, err := listenOnCallbackPorts("tcp4", "127.0.0.1", 0)
 		if err != nil {
 			t.Fatalf("listenOnCallbackPorts() error = %v", err)
 		}
@@ -33,7 +103,7 @@ func TestListenOnCallbackPorts(t *testing.T) {
 		t.Cleanup(func() { _ = occupied.Close() })
 		occupiedPort := occupied.Addr().(*net.TCPAddr).Port
 
-		listener, port, err := listenOnCallbackPorts("127.0.0.1", occupiedPort, 0)
+		listener, port, err := listenOnCallbackPorts("tcp4", "127.0.0.1", occupiedPort, 0)
 		if err != nil {
 			t.Fatalf("listenOnCallbackPorts() error = %v", err)
 		}
@@ -58,6 +128,7 @@ func TestListenOnCallbackPorts(t *testing.T) {
 		t.Cleanup(func() { _ = second.Close() })
 
 		_, _, err = listenOnCallbackPorts(
+			"tcp4",
 			"127.0.0.1",
 			first.Addr().(*net.TCPAddr).Port,
 			second.Addr().(*net.TCPAddr).Port,
@@ -68,7 +139,7 @@ func TestListenOnCallbackPorts(t *testing.T) {
 	})
 
 	t.Run("rejects an empty port list", func(t *testing.T) {
-		_, _, err := listenOnCallbackPorts("127.0.0.1")
+		_, _, err := listenOnCallbackPorts("tcp4", "127.0.0.1")
 		if err == nil {
 			t.Fatal("listenOnCallbackPorts() expected an error")
 		}
@@ -78,7 +149,7 @@ func TestListenOnCallbackPorts(t *testing.T) {
 func TestBrowserCallbackHandler(t *testing.T) {
 	t.Run("delivers authorization code", func(t *testing.T) {
 		results := make(chan browserCallbackResult, 1)
-		handler := newBrowserCallbackHandler(results)
+		handler := newBrowserCallbackHandler("/callback", results)
 		response := httptest.NewRecorder()
 		request := httptest.NewRequest(http.MethodGet, "/callback?code=test-code&state=test-state", nil)
 
@@ -95,7 +166,7 @@ func TestBrowserCallbackHandler(t *testing.T) {
 
 	t.Run("delivers provider error and escapes response", func(t *testing.T) {
 		results := make(chan browserCallbackResult, 1)
-		handler := newBrowserCallbackHandler(results)
+		handler := newBrowserCallbackHandler("/callback", results)
 		response := httptest.NewRecorder()
 		request := httptest.NewRequest(http.MethodGet, "/callback?error=access_denied&error_description=%3Cscript%3Ealert(1)%3C/script%3E", nil)
 
@@ -115,7 +186,7 @@ func TestBrowserCallbackHandler(t *testing.T) {
 
 	t.Run("rejects incomplete callback without completing flow", func(t *testing.T) {
 		results := make(chan browserCallbackResult, 1)
-		handler := newBrowserCallbackHandler(results)
+		handler := newBrowserCallbackHandler("/callback", results)
 		response := httptest.NewRecorder()
 		request := httptest.NewRequest(http.MethodGet, "/callback?code=test-code", nil)
 
@@ -129,9 +200,29 @@ func TestBrowserCallbackHandler(t *testing.T) {
 		}
 	})
 
+	t.Run("serves only the configured path", func(t *testing.T) {
+		results := make(chan browserCallbackResult, 1)
+		handler := newBrowserCallbackHandler("/oauth2/{callback}", results)
+
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/callback?code=test-code&state=test-state", nil))
+		if response.Code != http.StatusNotFound {
+			t.Errorf("default path status = %d, want %d", response.Code, http.StatusNotFound)
+		}
+
+		response = httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/oauth2/%7Bcallback%7D?code=test-code&state=test-state", nil))
+		if response.Code != http.StatusOK {
+			t.Errorf("configured path status = %d, want %d", response.Code, http.StatusOK)
+		}
+		if result := <-results; result.code != "test-code" {
+			t.Errorf("result = %+v, want code", result)
+		}
+	})
+
 	t.Run("duplicate callback does not block", func(t *testing.T) {
 		results := make(chan browserCallbackResult, 1)
-		handler := newBrowserCallbackHandler(results)
+		handler := newBrowserCallbackHandler("/callback", results)
 		handler.ServeHTTP(
 			httptest.NewRecorder(),
 			httptest.NewRequest(http.MethodGet, "/callback?code=first&state=test-state", nil),
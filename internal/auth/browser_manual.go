@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/fitbeard/radosgw-assume/pkg/duration"
+)
+
+type manualBrowserInput struct {
+	line string
+	err  error
+}
+
+// receiveManualBrowserResponse prints the authorization URL and reads the
+// provider's redirect back from standard input. No local server is started,
+// so the browser may run on a different machine than radosgw-assume.
+func receiveManualBrowserResponse(ctx context.Context, options OIDCOptions, setup browserFlowSetup, redirectURI string, dependencies browserFlowDependencies) (browserCallbackResult, error) {
+	authURL, err := setup.authorizationRequestURL(ctx, options, redirectURI, dependencies)
+	if err != nil {
+		return browserCallbackResult{}, err
+	}
+	printManualBrowserInstructions(dependencies.stderr, authURL, redirectURI)
+
+	// The read cannot be interrupted, so it runs in the background and is
+	// abandoned if the wait ends first.
+	inputs := make(chan manualBrowserInput, 1)
+	go func() {
+		line, err := bufio.NewReader(dependencies.stdin).ReadString('\n')
+		inputs <- manualBrowserInput{line: line, err: err}
+	}()
+
+	timeout := dependencies.newTimer(ManualAuthTimeout)
+	defer timeout.Stop()
+
+	select {
+	case input := <-inputs:
+		if input.err != nil && !errors.Is(input.err, io.EOF) {
+			return browserCallbackResult{}, fmt.Errorf("failed to read authorization response: %w", input.err)
+		}
+		return parseManualBrowserResponse(input.line, setup.state, dependencies.stderr)
+	case <-timeout.Done():
+		return browserCallbackResult{}, fmt.Errorf("authentication timed out after %s", duration.Format(ManualAuthTimeout))
+	case <-ctx.Done():
+		return browserCallbackResult{}, ctx.Err()
+	}
+}
+
+// parseManualBrowserResponse accepts the full redirected URL, its query string
+// or the bare authorization code. A bare code carries no state to compare, so
+// it is paired with the expected state and a warning on stderr says that the
+// check protecting against a code obtained by someone else was skipped;
+// pasting the full URL keeps the state check meaningful.
+func parseManualBrowserResponse(input, expectedState string, stderr io.Writer) (browserCallbackResult, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return browserCallbackResult{}, fmt.Errorf("no authorization response entered")
+	}
+
+	rawQuery := strings.TrimPrefix(input, "?")
+	if strings.Contains(input, "://") || strings.HasPrefix(input, "/") {
+		redirected, err := url.Parse(input)
+		if err != nil {
+			return browserCallbackResult{}, fmt.Errorf("failed to parse redirected URL: %w", err)
+		}
+		rawQuery = redirected.RawQuery
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil || (!query.Has("code") && !query.Has("error")) {
+		if strings.ContainsAny(input, "/?&") {
+			return browserCallbackResult{}, fmt.Errorf("redirected URL has no code or error parameter")
+		}
+		_, _ = fmt.Fprintln(stderr, "# ⚠️  Warning: a bare code carries no state parameter, so the check that the code")
+		_, _ = fmt.Fprintln(stderr, "#    comes from this login request was skipped. Paste the full redirected URL instead")
+		_, _ = fmt.Fprintln(stderr, "#    unless you copied the code from your own browser yourself.")
+		return browserCallbackResult{code: input, state: expectedState}, nil
+	}
+
+	return browserCallbackResult{
+		code:             query.Get("code"),
+		state:            query.Get("state"),
+		errorCode:        query.Get("error"),
+		errorDescription: query.Get("error_description"),
+	}, nil
+}
+
+func printManualBrowserInstructions(stderr io.Writer, authURL, redirectURI string) {
+	_, _ = fmt.Fprintln(stderr, "#")
+	_, _ = fmt.Fprintln(stderr, "# 🔐 BROWSER AUTHENTICATION REQUIRED")
+	_, _ = fmt.Fprintln(stderr, "#")
+	_, _ = fmt.Fprintln(stderr, "# 1. Open this URL in a browser on any machine:")
+	_, _ = fmt.Fprintf(stderr, "#    %s\n", authURL)
+	_, _ = fmt.Fprintf(stderr, "# 2. After signing in, the browser is sent to %s,\n", redirectURI)
+	_, _ = fmt.Fprintln(stderr, "#    which may fail to load. Copy the full URL from the address bar.")
+	_, _ = fmt.Fprintln(stderr, "# 3. Paste the URL below. Pasting just its code parameter skips the state check.")
+	_, _ = fmt.Fprintln(stderr, "#")
+	_, _ = fmt.Fprintf(stderr, "# ⏰ You have %s to complete authentication\n", duration.Format(ManualAuthTimeout))
+	_, _ = fmt.Fprintln(stderr, "#")
+	_, _ = fmt.Fprint(stderr, "Redirected URL or code: ")
+}
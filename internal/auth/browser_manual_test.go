@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
+
+func TestParseManualBrowserResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        browserCallbackResult
+		wantWarning bool
+		wantContain string
+	}{
+		{
+			name:  "full redirected URL",
+			input: "http://localhost:8080/callback?code=test-code&state=pasted-state\n",
+			want:  browserCallbackResult{code: "test-code", state: "pasted-state"},
+		},
+		{
+			name:  "path and query",
+			input: "/callback?state=pasted-state&code=test-code",
+			want:  browserCallbackResult{code: "test-code", state: "pasted-state"},
+		},
+		{
+			name:  "query string",
+			input: "?code=test-code&state=pasted-state",
+			want:  browserCallbackResult{code: "test-code", state: "pasted-state"},
+		},
+		{
+			name:  "provider error",
+			input: "http://localhost:8080/callback?error=access_denied&error_description=cancelled&state=pasted-state",
+			want:  browserCallbackResult{state: "pasted-state", errorCode: "access_denied", errorDescription: "cancelled"},
+		},
+		{
+			name:        "bare code",
+			input:       "  a1b2c3.d4e5-f6  \n",
+			want:        browserCallbackResult{code: "a1b2c3.d4e5-f6", state: testBrowserState},
+			wantWarning: true,
+		},
+		{name: "URL without code", input: "http://localhost:8080/callback?session=abc", wantContain: "no code or error parameter"},
+		{name: "empty", input: "\n", wantContain: "no authorization response entered"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			result, err := parseManualBrowserResponse(test.input, testBrowserState, stderr)
+			if test.wantContain != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+					t.Fatalf("parseManualBrowserResponse() error = %v, want containing %q", err, test.wantContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseManualBrowserResponse() error = %v", err)
+			}
+			if result != test.want {
+				t.Errorf("parseManualBrowserResponse() = %+v, want %+v", result, test.want)
+			}
+			if warned := strings.Contains(stderr.String(), "state parameter, so the check"); warned != test.wantWarning {
+				t.Errorf("state check warning printed = %v, want %v; stderr: %q", warned, test.wantWarning, stderr.String())
+			}
+		})
+	}
+}
+
+func TestAuthenticateBrowserFlowManual(t *testing.T) {
+	var stderr bytes.Buffer
+	dependencies := newTestBrowserFlowDependencies(&stderr)
+	dependencies.stdin = strings.NewReader("http://127.0.0.1:8400/oauth2/callback?code=" + testBrowserCode + "&state=" + testBrowserState + "\n")
+	dependencies.startCallbackServer = func(browserCallbackConfig, chan<- browserCallbackResult) (*browserCallbackServer, error) {
+		t.Fatal("startCallbackServer called in manual mode")
+		return nil, nil
+	}
+	dependencies.openBrowser = func(string) error {
+		t.Fatal("openBrowser called in manual mode")
+		return nil
+	}
+	dependencies.newHTTPClient = func(httpclient.Options) *http.Client {
+		return &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			if err := request.ParseForm(); err != nil {
+				t.Fatalf("ParseForm() error = %v", err)
+			}
+			wantForm := map[string]string{
+				"code":          testBrowserCode,
+				"code_verifier": testBrowserCodeVerifier,
+				"redirect_uri":  "http://127.0.0.1:8400/oauth2/callback",
+			}
+			for key, want := range wantForm {
+				if got := request.Form.Get(key); got != want {
+					t.Errorf("token request %s = %q, want %q", key, got, want)
+				}
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(strings.NewReader(`{"access_token":"test-access-token"}`)),
+			}, nil
+		})}
+	}
+	options := testOIDCOptions()
+	options.BrowserMode = config.BrowserModeManual
+	options.CallbackHost = config.CallbackHostIPv4
+	options.CallbackPorts = []int{8400, 8401}
+	options.CallbackPath = "/oauth2/callback"
+
+	token, err := authenticateBrowserFlow(t.Context(), options, dependencies)
+
+	if err != nil {
+		t.Fatalf("authenticateBrowserFlow() error = %v", err)
+	}
+	if token.AccessToken != "test-access-token" {
+		t.Errorf("token = %q, want test-access-token", token.AccessToken)
+	}
+	authURL := manualAuthorizationURL(t, stderr.String())
+	if got := authURL.Query().Get("redirect_uri"); got != "http://127.0.0.1:8400/oauth2/callback" {
+		t.Errorf("authorization redirect_uri = %q, want first callback port", got)
+	}
+	for _, want := range []string{
+		"# ⏰ You have 5m to complete authentication",
+		"Redirected URL or code: ",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr does not contain %q:\n%s", want, stderr.String())
+		}
+	}
+}
+
+func TestAuthenticateBrowserFlowManualErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		configure   func(*browserFlowDependencies, *OIDCOptions)
+		wantContain string
+	}{
+		{
+			name: "state mismatch",
+			configure: func(dependencies *browserFlowDependencies, _ *OIDCOptions) {
+				dependencies.stdin = strings.NewReader("http://localhost:8080/callback?code=test-code&state=forged\n")
+			},
+			wantContain: "security error: state parameter mismatch",
+		},
+		{
+			name: "provider error",
+			configure: func(dependencies *browserFlowDependencies, _ *OIDCOptions) {
+				dependencies.stdin = strings.NewReader("http://localhost:8080/callback?error=access_denied&state=test-state\n")
+			},
+			wantContain: "access denied",
+		},
+		{
+			name: "read failure",
+			configure: func(dependencies *browserFlowDependencies, _ *OIDCOptions) {
+				dependencies.stdin = errorReader{err: errors.New("terminal closed")}
+			},
+			wantContain: "failed to read authorization response: terminal closed",
+		},
+		{
+			name: "timeout",
+			configure: func(dependencies *browserFlowDependencies, _ *OIDCOptions) {
+				reader, _ := io.Pipe()
+				dependencies.stdin = reader
+				dependencies.newTimer = func(timeout time.Duration) browserFlowTimer {
+					if timeout != ManualAuthTimeout {
+						t.Errorf("timeout = %v, want %v", timeout, ManualAuthTimeout)
+					}
+					timer := &testBrowserFlowTimer{done: make(chan time.Time, 1)}
+					timer.done <- time.Now()
+					return timer
+				}
+			},
+			wantContain: "authentication timed out after 5m",
+		},
+		{
+			name: "any free port",
+			configure: func(dependencies *browserFlowDependencies, options *OIDCOptions) {
+				options.CallbackPorts = []int{0}
+				dependencies.discoverEndpoints = nil
+			},
+			wantContain: "first radosgw_oidc_callback_ports entry must not be 0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dependencies := newTestBrowserFlowDependencies(io.Discard)
+			options := testOIDCOptions()
+			options.BrowserMode = config.BrowserModeManual
+			test.configure(&dependencies, &options)
+
+			_, err := authenticateBrowserFlow(t.Context(), options, dependencies)
+
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("authenticateBrowserFlow() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
+
+type errorReader struct {
+	err error
+}
+
+func (reader errorReader) Read([]byte) (int, error) {
+	return 0, reader.err
+}
+
+func manualAuthorizationURL(t *testing.T, output string) *url.URL {
+	t.Helper()
+
+	for line := range strings.SplitSeq(output, "\n") {
+		if rawURL, found := strings.CutPrefix(strings.TrimSpace(line), "#    https://"); found {
+			authURL, err := url.Parse("https://" + rawURL)
+			if err != nil {
+				t.Fatalf("parse authorization URL: %v", err)
+			}
+			return authURL
+		}
+	}
+	t.Fatalf("authorization URL not printed:\n%s", output)
+	return nil
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// pushedAuthorizationResponse is the RFC 9126 section 2.2 response.
+type pushedAuthorizationResponse struct {
+	RequestURI string `json:"request_uri"`
+	ExpiresIn  int    `json:"expires_in"`
+}
+
+// pushAuthorizationRequest posts the authorization parameters to the pushed
+// authorization request endpoint, authenticating the client as the token
+// endpoint does. The returned request_uri replaces the parameters in the
+// browser URL, so they never pass through the browser.
+func pushAuthorizationRequest(ctx context.Context, client *http.Client, endpoint, tokenEndpoint string, parameters url.Values, options OIDCOptions) (pushedAuthorizationResponse, error) {
+	response, err := postTokenRequest(ctx, client, endpoint, tokenEndpoint, parameters, options)
+	if err != nil {
+		return pushedAuthorizationResponse{}, fmt.Errorf("pushed authorization request failed: %w", err)
+	}
+	body, err := readOIDCResponseAndClose(response)
+	if err != nil {
+		return pushedAuthorizationResponse{}, fmt.Errorf("failed to read pushed authorization response: %w", err)
+	}
+	// RFC 9126 requires 201 Created, but some providers answer 200 OK.
+	if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusOK {
+		return pushedAuthorizationResponse{}, oidcHTTPStatusError("pushed authorization request", response.StatusCode, body, options.ProviderURL)
+	}
+
+	var pushed pushedAuthorizationResponse
+	if err := json.Unmarshal(body, &pushed); err != nil {
+		return pushedAuthorizationResponse{}, fmt.Errorf("failed to parse pushed authorization response: %w", err)
+	}
+	if pushed.RequestURI == "" {
+		return pushedAuthorizationResponse{}, fmt.Errorf("pushed authorization response has no request_uri")
+	}
+	return pushed, nil
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
+
+const (
+	testPAREndpoint   = "https://oidc.example.com/par"
+	testTokenEndpoint = "https://oidc.example.com/token"
+	testRequestURI    = "urn:ietf:params:oauth:request_uri:test-request"
+)
+
+func TestAuthenticateBrowserFlowPushedAuthorization(t *testing.T) {
+	tests := []struct {
+		name string
+		mode config.BrowserMode
+	}{
+		{name: "callback", mode: config.BrowserModeCallback},
+		{name: "manual", mode: config.BrowserModeManual},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			dependencies := newTestBrowserFlowDependencies(&stderr)
+			dependencies.stdin = strings.NewReader("http://localhost:8080/callback?code=" + testBrowserCode + "&state=" + testBrowserState + "\n")
+			dependencies.discoverEndpoints = func(context.Context, *http.Client, string) (oidcEndpoints, error) {
+				return oidcEndpoints{
+					authorization:       "https://oidc.example.com/authorize?tenant=storage",
+					token:               "https://oidc.example.com/token",
+					pushedAuthorization: testPAREndpoint,
+				}, nil
+			}
+			var pushed url.Values
+			dependencies.newHTTPClient = func(httpclient.Options) *http.Client {
+				return &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+					if err := request.ParseForm(); err != nil {
+						t.Fatalf("ParseForm() error = %v", err)
+					}
+					status, body := http.StatusOK, `{"access_token":"test-access-token"}`
+					if request.URL.String() == testPAREndpoint {
+						pushed = request.PostForm
+						status, body = http.StatusCreated, `{"request_uri":"`+testRequestURI+`","expires_in":60}`
+					}
+					return &http.Response{StatusCode: status, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(body))}, nil
+				})}
+			}
+			var authURL string
+			dependencies.openBrowser = func(value string) error {
+				authURL = value
+				return nil
+			}
+			options := testOIDCOptions()
+			options.BrowserMode = test.mode
+			options.Authorization = AuthorizationParameters{LoginHint: "user@example.com"}
+			options.Verbose = true
+
+			if _, err := authenticateBrowserFlow(t.Context(), options, dependencies); err != nil {
+				t.Fatalf("authenticateBrowserFlow() error = %v", err)
+			}
+
+			for key, want := range map[string]string{
+				"client_id":      "test-client",
+				"response_type":  "code",
+				"state":          testBrowserState,
+				"code_challenge": testBrowserCodeChallenge,
+				"login_hint":     "user@example.com",
+			} {
+				if got := pushed.Get(key); got != want {
+					t.Errorf("pushed %s = %q, want %q", key, got, want)
+				}
+			}
+			if test.mode == config.BrowserModeManual {
+				authURL = manualAuthorizationURL(t, stderr.String()).String()
+			}
+			parsedAuthURL, err := url.Parse(authURL)
+			if err != nil {
+				t.Fatalf("parse authorization URL: %v", err)
+			}
+			query := parsedAuthURL.Query()
+			if keys, want := slices.Sorted(maps.Keys(query)), []string{"client_id", "request_uri", "tenant"}; !slices.Equal(keys, want) {
+				t.Errorf("authorization URL parameters = %v, want %v", keys, want)
+			}
+			if got := query.Get("request_uri"); got != testRequestURI {
+				t.Errorf("request_uri = %q, want %q", got, testRequestURI)
+			}
+			if !strings.Contains(stderr.String(), "# ✓ Pushed authorization request (request_uri expires in 60s)") {
+				t.Errorf("stderr does not report pushed request:\n%s", stderr.String())
+			}
+		})
+	}
+}
+
+func TestPushAuthorizationRequestErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		transport   error
+		wantContain string
+	}{
+		{name: "transport", transport: errors.New("connection failed"), wantContain: "pushed authorization request failed"},
+		{
+			name:        "provider error",
+			status:      http.StatusBadRequest,
+			body:        `{"error":"invalid_request","error_description":"redirect_uri not registered"}`,
+			wantContain: "pushed authorization request failed with status 400: invalid request",
+		},
+		{name: "malformed response", status: http.StatusCreated, body: "{", wantContain: "failed to parse pushed authorization response"},
+		{name: "missing request_uri", status: http.StatusCreated, body: `{"expires_in":60}`, wantContain: "pushed authorization response has no request_uri"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newBrowserTokenClient(test.status, test.body, test.transport)
+
+			_, err := pushAuthorizationRequest(t.Context(), client, testPAREndpoint, testTokenEndpoint, url.Values{}, testOIDCOptions())
+
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("pushAuthorizationRequest() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
+
+func TestPushAuthorizationRequestClientAuthentication(t *testing.T) {
+	client := &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		if err := request.ParseForm(); err != nil {
+			t.Fatalf("ParseForm() error = %v", err)
+		}
+		if got := request.PostForm.Get("client_secret"); got != "test-secret" {
+			t.Errorf("client_secret = %q, want test-secret", got)
+		}
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{"request_uri":"` + testRequestURI + `","expires_in":90}`)),
+		}, nil
+	})}
+	options := testOIDCOptions()
+	options.ClientAuthMethod = config.ClientAuthSecretPost
+	options.ClientSecret = "test-secret"
+
+	pushed, err := pushAuthorizationRequest(t.Context(), client, testPAREndpoint, testTokenEndpoint, url.Values{"scope": {"openid"}}, options)
+
+	if err != nil {
+		t.Fatalf("pushAuthorizationRequest() error = %v", err)
+	}
+	if pushed != (pushedAuthorizationResponse{RequestURI: testRequestURI, ExpiresIn: 90}) {
+		t.Errorf("pushAuthorizationRequest() = %+v", pushed)
+	}
+}
@@ -3,6 +3,9 @@ package auth
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -12,6 +15,9 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
 )
 
 const (
@@ -48,6 +54,10 @@ func (progress *testBrowserFlowProgress) StopQuiet() {
 }
 
 func TestAuthenticateBrowserFlow(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
 	tokenServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		switch request.URL.Path {
 		case "/.well-known/openid-configuration":
@@ -55,8 +65,13 @@ func TestAuthenticateBrowserFlow(t *testing.T) {
 			_, _ = fmt.Fprintf(writer, `{
 				"issuer":%q,
 				"authorization_endpoint":%q,
-				"token_endpoint":%q
-			}`, serverURL(request), serverURL(request)+"/oauth2/default/v1/authorize?audience=storage", serverURL(request)+"/oauth2/default/v1/token")
+				"token_endpoint":%q,
+				"jwks_uri":%q
+			}`, serverURL(request), serverURL(request)+"/oauth2/default/v1/authorize?audience=storage", serverURL(request)+"/oauth2/default/v1/token", serverURL(request)+"/oauth2/default/v1/keys")
+			return
+		case "/oauth2/default/v1/keys":
+			writer.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(writer).Encode(jsonWebKeySet{Keys: []jsonWebKey{testRSAJSONWebKey("browser-key", &key.PublicKey)}})
 			return
 		case "/oauth2/default/v1/token":
 		default:
@@ -83,15 +98,22 @@ func TestAuthenticateBrowserFlow(t *testing.T) {
 			t.Errorf("token request redirect_uri = %q, want loopback callback", redirectURI)
 		}
 
+		idToken := signTestJWT(t, key, "RS256", "browser-key", map[string]any{
+			"iss":   serverURL(request),
+			"aud":   "test-client",
+			"exp":   time.Now().Add(5 * time.Minute).Unix(),
+			"nonce": testBrowserState,
+		})
 		writer.Header().Set("Content-Type", "application/json")
-		_, _ = io.WriteString(writer, `{"access_token":"test-access-token"}`)
+		_, _ = fmt.Fprintf(writer, `{"access_token":"test-access-token","id_token":%q}`, idToken)
 	}))
 	t.Cleanup(tokenServer.Close)
 
 	var stderr bytes.Buffer
 	dependencies := newTestBrowserFlowDependencies(&stderr)
-	dependencies.startCallbackServer = func(results chan<- browserCallbackResult) (*browserCallbackServer, error) {
-		return startBrowserCallbackServer(callbackListenHost, []int{0}, results)
+	dependencies.startCallbackServer = func(callback browserCallbackConfig, results chan<- browserCallbackResult) (*browserCallbackServer, error) {
+		callback.ports = []int{0}
+		return startBrowserCallbackServer(callback, results)
 	}
 	dependencies.openBrowser = func(authURL string) error {
 		parsedAuthURL, err := url.Parse(authURL)
@@ -109,6 +131,7 @@ func TestAuthenticateBrowserFlow(t *testing.T) {
 			"response_type":         "code",
 			"scope":                 "openid profile",
 			"state":                 testBrowserState,
+			"nonce":                 testBrowserState,
 			"code_challenge":        testBrowserCodeChallenge,
 			"code_challenge_method": PKCEMethodS256,
 		}
@@ -137,13 +160,14 @@ func TestAuthenticateBrowserFlow(t *testing.T) {
 		}
 		return nil
 	}
-	dependencies.newHTTPClient = func(sslVerify bool) *http.Client {
-		if !sslVerify {
-			t.Error("sslVerify = false, want true")
+	dependencies.newHTTPClient = func(clientOptions httpclient.Options) *http.Client {
+		if !clientOptions.VerifyTLS {
+			t.Error("VerifyTLS = false, want true")
 		}
 		return tokenServer.Client()
 	}
 	dependencies.discoverEndpoints = discoverOIDCEndpoints
+	dependencies.verifyIDToken = newBrowserFlowDependencies().verifyIDToken
 	options := testOIDCOptions()
 	options.ProviderURL = tokenServer.URL + "/"
 	options.Scope = "openid profile"
@@ -154,13 +178,14 @@ func TestAuthenticateBrowserFlow(t *testing.T) {
 	if err != nil {
 		t.Fatalf("authenticateBrowserFlow() error = %v", err)
 	}
-	if token != "test-access-token" {
-		t.Errorf("token = %q, want test-access-token", token)
+	if token.AccessToken != "test-access-token" {
+		t.Errorf("token = %q, want test-access-token", token.AccessToken)
 	}
 
 	for _, want := range []string{
 		"# ✓ Browser opened successfully",
 		"# ✓ Authentication successful!",
+		"# ✓ ID token signature, nonce and claims verified",
 		"# ✓ Successfully obtained access token",
 	} {
 		if !strings.Contains(stderr.String(), want) {
@@ -172,7 +197,7 @@ func TestAuthenticateBrowserFlow(t *testing.T) {
 func TestAuthenticateBrowserFlowBrowserFallback(t *testing.T) {
 	var stderr bytes.Buffer
 	dependencies := newTestBrowserFlowDependencies(&stderr)
-	dependencies.startCallbackServer = func(results chan<- browserCallbackResult) (*browserCallbackServer, error) {
+	dependencies.startCallbackServer = func(_ browserCallbackConfig, results chan<- browserCallbackResult) (*browserCallbackServer, error) {
 		results <- browserCallbackResult{code: testBrowserCode, state: testBrowserState}
 		return newTestBrowserCallbackServer(CallbackFallbackPort), nil
 	}
@@ -186,8 +211,8 @@ func TestAuthenticateBrowserFlowBrowserFallback(t *testing.T) {
 	if err != nil {
 		t.Fatalf("authenticateBrowserFlow() error = %v", err)
 	}
-	if token != "test-access-token" {
-		t.Errorf("token = %q, want test-access-token", token)
+	if token.AccessToken != "test-access-token" {
+		t.Errorf("token = %q, want test-access-token", token.AccessToken)
 	}
 
 	for _, want := range []string{
@@ -216,6 +241,20 @@ func TestAuthenticateBrowserFlowErrors(t *testing.T) {
 			},
 			wantContain: "failed to generate state: random source failed",
 		},
+		{
+			name: "nonce generation",
+			configure: func(dependencies *browserFlowDependencies) {
+				calls := 0
+				dependencies.generateRandomString = func(int) (string, error) {
+					calls++
+					if calls > 1 {
+						return "", errors.New("random source failed")
+					}
+					return testBrowserState, nil
+				}
+			},
+			wantContain: "failed to generate nonce: random source failed",
+		},
 		{
 			name: "PKCE generation",
 			configure: func(dependencies *browserFlowDependencies) {
@@ -246,16 +285,16 @@ func TestAuthenticateBrowserFlowErrors(t *testing.T) {
 		{
 			name: "callback server startup",
 			configure: func(dependencies *browserFlowDependencies) {
-				dependencies.startCallbackServer = func(chan<- browserCallbackResult) (*browserCallbackServer, error) {
+				dependencies.startCallbackServer = func(browserCallbackConfig, chan<- browserCallbackResult) (*browserCallbackServer, error) {
 					return nil, errors.New("listen failed")
 				}
 			},
-			wantContain: "both callback ports",
+			wantContain: "all callback ports (8080, 18088) are in use",
 		},
 		{
 			name: "callback server runtime",
 			configure: func(dependencies *browserFlowDependencies) {
-				dependencies.startCallbackServer = func(chan<- browserCallbackResult) (*browserCallbackServer, error) {
+				dependencies.startCallbackServer = func(browserCallbackConfig, chan<- browserCallbackResult) (*browserCallbackServer, error) {
 					server := newTestBrowserCallbackServer(CallbackPort)
 					serverErrors := make(chan error, 1)
 					serverErrors <- errors.New("serve failed")
@@ -268,7 +307,7 @@ func TestAuthenticateBrowserFlowErrors(t *testing.T) {
 		{
 			name: "timeout",
 			configure: func(dependencies *browserFlowDependencies) {
-				dependencies.startCallbackServer = func(chan<- browserCallbackResult) (*browserCallbackServer, error) {
+				dependencies.startCallbackServer = func(browserCallbackConfig, chan<- browserCallbackResult) (*browserCallbackServer, error) {
 					return newTestBrowserCallbackServer(CallbackPort), nil
 				}
 				dependencies.newTimer = func(time.Duration) browserFlowTimer {
@@ -282,7 +321,7 @@ func TestAuthenticateBrowserFlowErrors(t *testing.T) {
 		{
 			name: "callback server shutdown",
 			configure: func(dependencies *browserFlowDependencies) {
-				dependencies.startCallbackServer = func(results chan<- browserCallbackResult) (*browserCallbackServer, error) {
+				dependencies.startCallbackServer = func(_ browserCallbackConfig, results chan<- browserCallbackResult) (*browserCallbackServer, error) {
 					results <- browserCallbackResult{code: testBrowserCode, state: testBrowserState}
 					server := newTestBrowserCallbackServer(CallbackPort)
 					server.shutdown = func(context.Context) error { return errors.New("shutdown failed") }
@@ -294,7 +333,7 @@ func TestAuthenticateBrowserFlowErrors(t *testing.T) {
 		{
 			name: "provider callback error",
 			configure: func(dependencies *browserFlowDependencies) {
-				dependencies.startCallbackServer = func(results chan<- browserCallbackResult) (*browserCallbackServer, error) {
+				dependencies.startCallbackServer = func(_ browserCallbackConfig, results chan<- browserCallbackResult) (*browserCallbackServer, error) {
 					results <- browserCallbackResult{errorCode: "access_denied", errorDescription: "cancelled"}
 					return newTestBrowserCallbackServer(CallbackPort), nil
 				}
@@ -304,7 +343,7 @@ func TestAuthenticateBrowserFlowErrors(t *testing.T) {
 		{
 			name: "missing authorization code",
 			configure: func(dependencies *browserFlowDependencies) {
-				dependencies.startCallbackServer = func(results chan<- browserCallbackResult) (*browserCallbackServer, error) {
+				dependencies.startCallbackServer = func(_ browserCallbackConfig, results chan<- browserCallbackResult) (*browserCallbackServer, error) {
 					results <- browserCallbackResult{state: testBrowserState}
 					return newTestBrowserCallbackServer(CallbackPort), nil
 				}
@@ -314,7 +353,7 @@ func TestAuthenticateBrowserFlowErrors(t *testing.T) {
 		{
 			name: "state mismatch",
 			configure: func(dependencies *browserFlowDependencies) {
-				dependencies.startCallbackServer = func(results chan<- browserCallbackResult) (*browserCallbackServer, error) {
+				dependencies.startCallbackServer = func(_ browserCallbackConfig, results chan<- browserCallbackResult) (*browserCallbackServer, error) {
 					results <- browserCallbackResult{code: testBrowserCode, state: "unexpected-state"}
 					return newTestBrowserCallbackServer(CallbackPort), nil
 				}
@@ -324,12 +363,21 @@ func TestAuthenticateBrowserFlowErrors(t *testing.T) {
 		{
 			name: "token exchange transport",
 			configure: func(dependencies *browserFlowDependencies) {
-				dependencies.newHTTPClient = func(bool) *http.Client {
+				dependencies.newHTTPClient = func(httpclient.Options) *http.Client {
 					return newBrowserTokenClient(0, "", errors.New("connection failed"))
 				}
 			},
 			wantContain: "token exchange failed",
 		},
+		{
+			name: "ID token validation",
+			configure: func(dependencies *browserFlowDependencies) {
+				dependencies.verifyIDToken = func(context.Context, *http.Client, oidcEndpoints, OIDCOptions, string, string) error {
+					return errors.New("nonce does not match the authorization request")
+				}
+			},
+			wantContain: "ID token rejected: nonce does not match the authorization request",
+		},
 	}
 
 	for _, test := range tests {
@@ -346,6 +394,147 @@ func TestAuthenticateBrowserFlowErrors(t *testing.T) {
 	}
 }
 
+func TestAuthenticateBrowserFlowNonce(t *testing.T) {
+	tests := []struct {
+		name       string
+		scope      string
+		wantNonce  string
+		wantVerify bool
+	}{
+		{name: "openid scope", scope: "profile openid", wantNonce: "test-nonce", wantVerify: true},
+		{name: "OAuth 2.0 only", scope: "storage", wantNonce: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dependencies := newTestBrowserFlowDependencies(io.Discard)
+			randomValues := []string{testBrowserState, "test-nonce"}
+			dependencies.generateRandomString = func(int) (string, error) {
+				value := randomValues[0]
+				randomValues = randomValues[1:]
+				return value, nil
+			}
+			var authURL string
+			dependencies.openBrowser = func(value string) error {
+				authURL = value
+				return nil
+			}
+			dependencies.newHTTPClient = func(httpclient.Options) *http.Client {
+				return newBrowserTokenClient(http.StatusOK, `{"access_token":"test-access-token","id_token":"test-id-token"}`, nil)
+			}
+			verified := false
+			dependencies.verifyIDToken = func(_ context.Context, _ *http.Client, _ oidcEndpoints, _ OIDCOptions, idToken, nonce string) error {
+				verified = true
+				if idToken != "test-id-token" || nonce != "test-nonce" {
+					t.Errorf("verifyIDToken(%q, %q), want test-id-token and test-nonce", idToken, nonce)
+				}
+				return nil
+			}
+			options := testOIDCOptions()
+			options.Scope = test.scope
+
+			if _, err := authenticateBrowserFlow(t.Context(), options, dependencies); err != nil {
+				t.Fatalf("authenticateBrowserFlow() error = %v", err)
+			}
+
+			parsedAuthURL, err := url.Parse(authURL)
+			if err != nil {
+				t.Fatalf("parse authorization URL: %v", err)
+			}
+			if got := parsedAuthURL.Query().Get("nonce"); got != test.wantNonce {
+				t.Errorf("authorization nonce = %q, want %q", got, test.wantNonce)
+			}
+			if verified != test.wantVerify {
+				t.Errorf("ID token verified = %v, want %v", verified, test.wantVerify)
+			}
+		})
+	}
+}
+
+func TestAuthenticateBrowserFlowCustomCallback(t *testing.T) {
+	var wantRedirectURI string
+	var stderr bytes.Buffer
+	dependencies := newTestBrowserFlowDependencies(&stderr)
+	dependencies.startCallbackServer = func(callback browserCallbackConfig, results chan<- browserCallbackResult) (*browserCallbackServer, error) {
+		if callback.listenNetwork != "tcp4" || callback.listenHost != "127.0.0.1" {
+			t.Errorf("listen = %s %s, want tcp4 127.0.0.1", callback.listenNetwork, callback.listenHost)
+		}
+		results <- browserCallbackResult{code: testBrowserCode, state: testBrowserState}
+		return newTestBrowserCallbackServer(8401), nil
+	}
+	dependencies.openBrowser = func(authURL string) error {
+		parsedAuthURL, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		wantRedirectURI = parsedAuthURL.Query().Get("redirect_uri")
+		return nil
+	}
+	dependencies.newHTTPClient = func(httpclient.Options) *http.Client {
+		return &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			if err := request.ParseForm(); err != nil {
+				t.Fatalf("ParseForm() error = %v", err)
+			}
+			if got := request.Form.Get("redirect_uri"); got != wantRedirectURI {
+				t.Errorf("token request redirect_uri = %q, want %q", got, wantRedirectURI)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(strings.NewReader(`{"access_token":"test-access-token"}`)),
+			}, nil
+		})}
+	}
+	options := testOIDCOptions()
+	options.CallbackHost = config.CallbackHostIPv4
+	options.CallbackPorts = []int{8400, 8401}
+	options.CallbackPath = "/oauth2/callback"
+	options.Verbose = true
+
+	if _, err := authenticateBrowserFlow(t.Context(), options, dependencies); err != nil {
+		t.Fatalf("authenticateBrowserFlow() error = %v", err)
+	}
+	if wantRedirectURI != "http://127.0.0.1:8401/oauth2/callback" {
+		t.Errorf("authorization redirect_uri = %q, want http://127.0.0.1:8401/oauth2/callback", wantRedirectURI)
+	}
+	if !strings.Contains(stderr.String(), "# Port 8400 is busy, using fallback port 8401...") {
+		t.Errorf("stderr does not report fallback port:\n%s", stderr.String())
+	}
+}
+
+func TestAuthenticateBrowserFlowCallbackConfigErrors(t *testing.T) {
+	t.Run("single port in use", func(t *testing.T) {
+		dependencies := newTestBrowserFlowDependencies(io.Discard)
+		dependencies.startCallbackServer = func(browserCallbackConfig, chan<- browserCallbackResult) (*browserCallbackServer, error) {
+			return nil, errors.New("address already in use")
+		}
+		options := testOIDCOptions()
+		options.CallbackPorts = []int{8400}
+
+		_, err := authenticateBrowserFlow(t.Context(), options, dependencies)
+
+		if err == nil || !strings.Contains(err.Error(), "callback port 8400 is in use") {
+			t.Errorf("authenticateBrowserFlow() error = %v, want single port in use", err)
+		}
+	})
+
+	t.Run("invalid path fails before discovery", func(t *testing.T) {
+		dependencies := newTestBrowserFlowDependencies(io.Discard)
+		dependencies.discoverEndpoints = func(context.Context, *http.Client, string) (oidcEndpoints, error) {
+			t.Fatal("discoverEndpoints called with invalid callback configuration")
+			return oidcEndpoints{}, nil
+		}
+		options := testOIDCOptions()
+		options.CallbackPath = "/callback?source=cli"
+
+		_, err := authenticateBrowserFlow(t.Context(), options, dependencies)
+
+		if err == nil || !strings.Contains(err.Error(), "invalid radosgw_oidc_callback_path") {
+			t.Errorf("authenticateBrowserFlow() error = %v, want invalid path", err)
+		}
+	})
+}
+
 func TestAuthenticateBrowserFlowStopsWaitResources(t *testing.T) {
 	for _, test := range []struct {
 		name             string
@@ -361,7 +550,7 @@ func TestAuthenticateBrowserFlowStopsWaitResources(t *testing.T) {
 		{
 			name: "server error",
 			configure: func(dependencies *browserFlowDependencies) {
-				dependencies.startCallbackServer = func(chan<- browserCallbackResult) (*browserCallbackServer, error) {
+				dependencies.startCallbackServer = func(browserCallbackConfig, chan<- browserCallbackResult) (*browserCallbackServer, error) {
 					server := newTestBrowserCallbackServer(CallbackPort)
 					serverErrors := make(chan error, 1)
 					serverErrors <- errors.New("serve failed")
@@ -399,7 +588,7 @@ func TestAuthenticateBrowserFlowCancelsCallbackWait(t *testing.T) {
 	ctx, cancel := context.WithCancel(t.Context())
 	progress := &testBrowserFlowProgress{}
 	dependencies := newTestBrowserFlowDependencies(io.Discard)
-	dependencies.startCallbackServer = func(chan<- browserCallbackResult) (*browserCallbackServer, error) {
+	dependencies.startCallbackServer = func(browserCallbackConfig, chan<- browserCallbackResult) (*browserCallbackServer, error) {
 		return newTestBrowserCallbackServer(CallbackPort), nil
 	}
 	dependencies.openBrowser = func(string) error {
@@ -482,10 +671,10 @@ func TestExchangeBrowserAuthorizationCode(t *testing.T) {
 				newBrowserTokenClient(test.status, test.body, test.transport),
 				"https://oidc.example.com/token",
 				tokenData,
-				"https://oidc.example.com")
+				testOIDCOptions())
 
-			if token != test.wantToken {
-				t.Errorf("token = %q, want %q", token, test.wantToken)
+			if token.AccessToken != test.wantToken {
+				t.Errorf("token = %q, want %q", token.AccessToken, test.wantToken)
 			}
 			if test.wantContain == "" && err != nil {
 				t.Errorf("exchangeBrowserAuthorizationCode() error = %v", err)
@@ -511,7 +700,7 @@ func TestExchangeBrowserAuthorizationCodeClosesResponseBody(t *testing.T) {
 		client,
 		"https://oidc.example.com/token",
 		url.Values{},
-		"https://oidc.example.com")
+		testOIDCOptions())
 
 	if err != nil {
 		t.Fatalf("exchangeBrowserAuthorizationCode() error = %v", err)
@@ -530,12 +719,12 @@ func newTestBrowserFlowDependencies(stderr io.Writer) browserFlowDependencies {
 		generatePKCE: func(string) (string, string, string, error) {
 			return testBrowserCodeVerifier, testBrowserCodeChallenge, PKCEMethodS256, nil
 		},
-		startCallbackServer: func(results chan<- browserCallbackResult) (*browserCallbackServer, error) {
+		startCallbackServer: func(_ browserCallbackConfig, results chan<- browserCallbackResult) (*browserCallbackServer, error) {
 			results <- browserCallbackResult{code: testBrowserCode, state: testBrowserState}
 			return newTestBrowserCallbackServer(CallbackPort), nil
 		},
 		openBrowser: func(string) error { return nil },
-		newHTTPClient: func(bool) *http.Client {
+		newHTTPClient: func(httpclient.Options) *http.Client {
 			return newBrowserTokenClient(http.StatusOK, `{"access_token":"test-access-token"}`, nil)
 		},
 		discoverEndpoints: func(context.Context, *http.Client, string) (oidcEndpoints, error) {
@@ -548,6 +737,9 @@ func newTestBrowserFlowDependencies(stderr io.Writer) browserFlowDependencies {
 			return &testBrowserFlowTimer{done: make(chan time.Time)}
 		},
 		newProgress: func() browserFlowProgress { return &testBrowserFlowProgress{} },
+		verifyIDToken: func(context.Context, *http.Client, oidcEndpoints, OIDCOptions, string, string) error {
+			return nil
+		},
 	}
 }
 
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"time"
+)
+
+// clientAssertionType identifies an RFC 7523 JWT bearer client assertion.
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// minimumRSAKeyBits rejects RSA keys too small for RS256 assertions.
+const minimumRSAKeyBits = 2048
+
+type clientAssertionHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+}
+
+type clientAssertionClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	Audience  string `json:"aud"`
+	JWTID     string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	NotBefore int64  `json:"nbf"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// ParseClientPrivateKey parses an unencrypted RSA or EC private key in PKCS #1,
+// SEC 1 or PKCS #8 PEM encoding for private_key_jwt client authentication.
+func ParseClientPrivateKey(pemData []byte) (crypto.Signer, error) {
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM private key found")
+		}
+
+		var key any
+		var err error
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "ENCRYPTED PRIVATE KEY":
+			return nil, fmt.Errorf("encrypted private keys are not supported; decrypt the key file first")
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", block.Type, err)
+		}
+		return clientSigningKey(key)
+	}
+}
+
+func clientSigningKey(key any) (crypto.Signer, error) {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < minimumRSAKeyBits {
+			return nil, fmt.Errorf("RSA private key is %d bits; at least %d bits are required", key.N.BitLen(), minimumRSAKeyBits)
+		}
+		return key, nil
+	case *ecdsa.PrivateKey:
+		if _, _, err := ecdsaAlgorithm(key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T (supported: RSA, EC P-256, P-384, P-521)", key)
+	}
+}
+
+func ecdsaAlgorithm(key *ecdsa.PrivateKey) (string, crypto.Hash, error) {
+	switch key.Curve {
+	case elliptic.P256():
+		return "ES256", crypto.SHA256, nil
+	case elliptic.P384():
+		return "ES384", crypto.SHA384, nil
+	case elliptic.P521():
+		return "ES512", crypto.SHA512, nil
+	default:
+		return "", 0, fmt.Errorf("unsupported EC curve %s (supported: P-256, P-384, P-521)", key.Curve.Params().Name)
+	}
+}
+
+// newClientAssertion signs an RFC 7523 client assertion for one token
+// request. The audience is the token endpoint that receives the assertion.
+func newClientAssertion(key crypto.Signer, clientID, audience string, now time.Time, randomReader io.Reader) (string, error) {
+	if key == nil {
+		return "", fmt.Errorf("private_key_jwt client authentication requires a client private key")
+	}
+	algorithm, hash, err := signingAlgorithm(key)
+	if err != nil {
+		return "", err
+	}
+	jwtID := make([]byte, 32)
+	if _, err := io.ReadFull(randomReader, jwtID); err != nil {
+		return "", fmt.Errorf("failed to generate client assertion ID: %w", err)
+	}
+
+	header, err := json.Marshal(clientAssertionHeader{Algorithm: algorithm, Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(clientAssertionClaims{
+		Issuer:    clientID,
+		Subject:   clientID,
+		Audience:  audience,
+		JWTID:     base64.RawURLEncoding.EncodeToString(jwtID),
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Unix(),
+		ExpiresAt: now.Add(ClientAssertionLifetime).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := hash.New()
+	digest.Write([]byte(signingInput))
+	signature, err := signDigest(key, hash, digest.Sum(nil), randomReader)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign client assertion: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func signingAlgorithm(key crypto.Signer) (string, crypto.Hash, error) {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return "RS256", crypto.SHA256, nil
+	case *ecdsa.PrivateKey:
+		return ecdsaAlgorithm(key)
+	default:
+		return "", 0, fmt.Errorf("unsupported client assertion key type %T", key)
+	}
+}
+
+func signDigest(key crypto.Signer, hash crypto.Hash, digest []byte, randomReader io.Reader) ([]byte, error) {
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return key.Sign(randomReader, digest, hash)
+	}
+
+	// JWS uses the fixed-width R || S encoding rather than ASN.1 (RFC 7518
+	// section 3.4).
+	r, s, err := ecdsa.Sign(randomReader, ecKey, digest)
+	if err != nil {
+		return nil, err
+	}
+	size := (ecKey.Curve.Params().BitSize + 7) / 8
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	s.FillBytes(signature[size:])
+	return signature, nil
+}
@@ -0,0 +1,330 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
+func TestParseClientPrivateKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		pem         []byte
+		wantType    string
+		wantContain string
+	}{
+		{name: "PKCS #1 RSA", pem: pemBlock("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)), wantType: "*rsa.PrivateKey"},
+		{name: "SEC 1 EC", pem: pemBlock("EC PRIVATE KEY", ecDER), wantType: "*ecdsa.PrivateKey"},
+		{name: "PKCS #8", pem: pemBlock("PRIVATE KEY", pkcs8DER), wantType: "*rsa.PrivateKey"},
+		{
+			name:     "skips certificate before key",
+			pem:      append(pemBlock("CERTIFICATE", []byte("not parsed")), pemBlock("EC PRIVATE KEY", ecDER)...),
+			wantType: "*ecdsa.PrivateKey",
+		},
+		{name: "small RSA key", pem: pemBlock("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(smallKey)), wantContain: "at least 2048 bits"},
+		{name: "encrypted key", pem: pemBlock("ENCRYPTED PRIVATE KEY", []byte("ciphertext")), wantContain: "encrypted private keys are not supported"},
+		{name: "malformed key", pem: pemBlock("EC PRIVATE KEY", []byte("garbage")), wantContain: "parse EC PRIVATE KEY"},
+		{name: "no key", pem: []byte("not a PEM file"), wantContain: "no PEM private key found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key, err := ParseClientPrivateKey(test.pem)
+			if test.wantContain != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+					t.Fatalf("ParseClientPrivateKey() error = %v, want containing %q", err, test.wantContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseClientPrivateKey() error = %v", err)
+			}
+			if got := typeName(key); got != test.wantType {
+				t.Errorf("key type = %s, want %s", got, test.wantType)
+			}
+		})
+	}
+}
+
+func TestNewClientAssertion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	for _, test := range []struct {
+		name          string
+		key           crypto.Signer
+		wantAlgorithm string
+	}{
+		{name: "RSA", key: rsaKey, wantAlgorithm: "RS256"},
+		{name: "EC", key: ecKey, wantAlgorithm: "ES256"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assertion, err := newClientAssertion(test.key, "radosgw", "https://oidc.example.com/token", now, rand.Reader)
+			if err != nil {
+				t.Fatalf("newClientAssertion() error = %v", err)
+			}
+			header, claims := verifyClientAssertion(t, assertion, test.key.Public())
+			if header.Algorithm != test.wantAlgorithm || header.Type != "JWT" {
+				t.Errorf("header = %+v, want alg %s and typ JWT", header, test.wantAlgorithm)
+			}
+			want := clientAssertionClaims{
+				Issuer:    "radosgw",
+				Subject:   "radosgw",
+				Audience:  "https://oidc.example.com/token",
+				JWTID:     claims.JWTID,
+				IssuedAt:  now.Unix(),
+				NotBefore: now.Unix(),
+				ExpiresAt: now.Add(ClientAssertionLifetime).Unix(),
+			}
+			if claims != want {
+				t.Errorf("claims = %+v, want %+v", claims, want)
+			}
+			if len(claims.JWTID) != 43 {
+				t.Errorf("jti = %q, want 32 random bytes", claims.JWTID)
+			}
+		})
+	}
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := newClientAssertion(nil, "radosgw", "https://oidc.example.com/token", now, rand.Reader)
+		if err == nil || !strings.Contains(err.Error(), "requires a client private key") {
+			t.Errorf("newClientAssertion() error = %v, want missing key", err)
+		}
+	})
+}
+
+func TestPostTokenRequestPrivateKeyJWT(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	var jwtIDs []string
+	client := &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		if _, _, hasBasic := request.BasicAuth(); hasBasic {
+			t.Error("Basic authentication present with private_key_jwt")
+		}
+		if err := request.ParseForm(); err != nil {
+			t.Fatalf("ParseForm() error = %v", err)
+		}
+		if got := request.Form.Get("client_id"); got != "test-client" {
+			t.Errorf("client_id = %q, want test-client", got)
+		}
+		if got := request.Form.Get("client_assertion_type"); got != clientAssertionType {
+			t.Errorf("client_assertion_type = %q, want %q", got, clientAssertionType)
+		}
+		if request.Form.Has("client_secret") {
+			t.Error("client_secret sent with private_key_jwt")
+		}
+		_, claims := verifyClientAssertion(t, request.Form.Get("client_assertion"), key.Public())
+		if claims.Audience != request.URL.String() {
+			t.Errorf("aud = %q, want token endpoint %q", claims.Audience, request.URL)
+		}
+		jwtIDs = append(jwtIDs, claims.JWTID)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{"access_token":"access-token","token_type":"Bearer"}`)),
+		}, nil
+	})}
+	options := testOIDCOptions()
+	options.ClientAuthMethod = config.ClientAuthPrivateKeyJWT
+	options.ClientPrivateKey = key
+
+	if _, err := refreshTokens(t.Context(), options, "refresh-token", testTokenEndpointDependencies(client)); err != nil {
+		t.Fatalf("refreshTokens() error = %v", err)
+	}
+	if _, err := authenticateClientCredentials(t.Context(), options, testTokenEndpointDependencies(client)); err != nil {
+		t.Fatalf("authenticateClientCredentials() error = %v", err)
+	}
+	if len(jwtIDs) != 2 || jwtIDs[0] == jwtIDs[1] {
+		t.Errorf("jti values = %q, want a fresh value per request", jwtIDs)
+	}
+}
+
+func TestClientAssertionAudienceIsTokenEndpoint(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	options := testOIDCOptions()
+	options.ClientAuthMethod = config.ClientAuthPrivateKeyJWT
+	options.ClientPrivateKey = key
+
+	for _, test := range []struct {
+		name     string
+		endpoint string
+		body     string
+		request  func(*http.Client) error
+	}{
+		{
+			name:     "device authorization",
+			endpoint: "https://oidc.example.com/device",
+			body:     validDeviceResponse,
+			request: func(client *http.Client) error {
+				_, err := requestDeviceAuthorization(t.Context(), client, "https://oidc.example.com/device", testTokenEndpoint, url.Values{"scope": {"openid"}}, options)
+				return err
+			},
+		},
+		{
+			name:     "pushed authorization",
+			endpoint: testPAREndpoint,
+			body:     `{"request_uri":"` + testRequestURI + `","expires_in":90}`,
+			request: func(client *http.Client) error {
+				_, err := pushAuthorizationRequest(t.Context(), client, testPAREndpoint, testTokenEndpoint, url.Values{"scope": {"openid"}}, options)
+				return err
+			},
+		},
+		{
+			name:     "token revocation",
+			endpoint: "https://oidc.example.com/revoke",
+			request: func(client *http.Client) error {
+				dependencies := testRevocationDependencies(client, oidcEndpoints{token: testTokenEndpoint, revocation: "https://oidc.example.com/revoke"})
+				return revokeToken(t.Context(), options, "stored-refresh-token", "refresh_token", dependencies)
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			client := &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+				if request.URL.String() != test.endpoint {
+					t.Errorf("request URL = %q, want %q", request.URL, test.endpoint)
+				}
+				if err := request.ParseForm(); err != nil {
+					t.Fatalf("ParseForm() error = %v", err)
+				}
+				if got := request.Form.Get("client_assertion_type"); got != clientAssertionType {
+					t.Errorf("client_assertion_type = %q, want %q", got, clientAssertionType)
+				}
+				_, claims := verifyClientAssertion(t, request.Form.Get("client_assertion"), key.Public())
+				if claims.Audience != testTokenEndpoint {
+					t.Errorf("aud = %q, want token endpoint %q", claims.Audience, testTokenEndpoint)
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     make(http.Header),
+					Body:       io.NopCloser(strings.NewReader(test.body)),
+				}, nil
+			})}
+
+			if err := test.request(client); err != nil {
+				t.Fatalf("request error = %v", err)
+			}
+		})
+	}
+}
+
+func verifyClientAssertion(t *testing.T, assertion string, publicKey crypto.PublicKey) (clientAssertionHeader, clientAssertionClaims) {
+	t.Helper()
+
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		t.Fatalf("assertion has %d parts, want 3", len(parts))
+	}
+	var header clientAssertionHeader
+	decodeAssertionPart(t, parts[0], &header)
+	var claims clientAssertionClaims
+	decodeAssertionPart(t, parts[1], &claims)
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatalf("decode signature: %v", err)
+	}
+
+	signingInput := []byte(parts[0] + "." + parts[1])
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(signingInput)
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("RS256 signature does not verify: %v", err)
+		}
+	case *ecdsa.PublicKey:
+		var digest []byte
+		switch header.Algorithm {
+		case "ES256":
+			sum := sha256.Sum256(signingInput)
+			digest = sum[:]
+		case "ES384":
+			sum := sha512.Sum384(signingInput)
+			digest = sum[:]
+		}
+		size := len(signature) / 2
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			t.Errorf("%s signature does not verify", header.Algorithm)
+		}
+	default:
+		t.Fatalf("unexpected public key type %T", publicKey)
+	}
+	return header, claims
+}
+
+func decodeAssertionPart(t *testing.T, part string, target any) {
+	t.Helper()
+
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		t.Fatalf("decode assertion part: %v", err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		t.Fatalf("unmarshal assertion part: %v", err)
+	}
+}
+
+func pemBlock(blockType string, data []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data})
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case *rsa.PrivateKey:
+		return "*rsa.PrivateKey"
+	case *ecdsa.PrivateKey:
+		return "*ecdsa.PrivateKey"
+	default:
+		return "unknown"
+	}
+}
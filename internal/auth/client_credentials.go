@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
+// AuthenticateClientCredentials obtains tokens for a confidential client with
+// the OAuth 2.0 client credentials grant (RFC 6749 section 4.4).
+func AuthenticateClientCredentials(ctx context.Context, options OIDCOptions) (TokenResponse, error) {
+	return authenticateClientCredentials(ctx, options, newTokenEndpointDependencies())
+}
+
+func authenticateClientCredentials(ctx context.Context, options OIDCOptions, dependencies tokenEndpointDependencies) (TokenResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return TokenResponse{}, err
+	}
+	if options.ClientSecret == "" && options.ClientAuthMethod != config.ClientAuthPrivateKeyJWT {
+		return TokenResponse{}, fmt.Errorf("client secret or private_key_jwt client authentication is required for the client credentials grant")
+	}
+
+	client := dependencies.newHTTPClient(options.httpClientOptions())
+	endpoints, err := discoverEndpointsWithRetry(ctx, client, options, dependencies.discoverEndpoints)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	if endpoints.token == "" {
+		return TokenResponse{}, fmt.Errorf("OIDC discovery response is missing token_endpoint required by client credentials authentication")
+	}
+
+	tokenData := url.Values{}
+	tokenData.Set("grant_type", "client_credentials")
+	if options.Scope != "" {
+		tokenData.Set("scope", options.Scope)
+	}
+
+	response, err := postTokenRequest(ctx, client, endpoints.token, endpoints.token, tokenData, options)
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("client credentials token request failed: %w", err)
+	}
+	body, err := readOIDCResponseAndClose(response)
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("failed to read client credentials token response: %w", err)
+	}
+
+	tokenResponse, err := decodeOIDCTokenResponse("client credentials token request", response.StatusCode, body, options.ProviderURL)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	if response.StatusCode != http.StatusOK && tokenResponse.Error == "" {
+		return TokenResponse{}, oidcHTTPStatusError("client credentials token request", response.StatusCode, body, options.ProviderURL)
+	}
+
+	return tokensFromOIDCResponse(tokenResponse, options.ProviderURL)
+}
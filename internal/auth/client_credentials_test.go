@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
+func TestAuthenticateClientCredentials(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		method     config.ClientAuthMethod
+		wantBasic  bool
+		wantSecret string
+	}{
+		{name: "client_secret_basic", method: config.ClientAuthSecretBasic, wantBasic: true},
+		{name: "client_secret_post", method: config.ClientAuthSecretPost, wantSecret: "s3cr:et/+"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			client := &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+				if request.URL.String() != "https://oidc.example.com/token" {
+					t.Errorf("token endpoint = %q", request.URL)
+				}
+				username, password, hasBasic := request.BasicAuth()
+				if hasBasic != test.wantBasic {
+					t.Errorf("Basic authentication present = %v, want %v", hasBasic, test.wantBasic)
+				}
+				if test.wantBasic && (username != "service%3Aclient" || password != "s3cr%3Aet%2F%2B") {
+					t.Errorf("Basic credentials = %q:%q, want form-encoded client ID and secret", username, password)
+				}
+				if err := request.ParseForm(); err != nil {
+					t.Fatalf("ParseForm() error = %v", err)
+				}
+				if got := request.Form.Get("grant_type"); got != "client_credentials" {
+					t.Errorf("grant_type = %q, want client_credentials", got)
+				}
+				if got := request.Form.Get("scope"); got != "openid" {
+					t.Errorf("scope = %q, want openid", got)
+				}
+				if got := request.Form.Get("client_secret"); got != test.wantSecret {
+					t.Errorf("client_secret = %q, want %q", got, test.wantSecret)
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     make(http.Header),
+					Body:       io.NopCloser(strings.NewReader(`{"access_token":"service-access-token","token_type":"Bearer"}`)),
+				}, nil
+			})}
+			options := testOIDCOptions()
+			options.ClientID = "service:client"
+			options.ClientSecret = "s3cr:et/+"
+			options.ClientAuthMethod = test.method
+
+			tokens, err := authenticateClientCredentials(t.Context(), options, testTokenEndpointDependencies(client))
+			if err != nil {
+				t.Fatalf("authenticateClientCredentials() error = %v", err)
+			}
+			if tokens.AccessToken != "service-access-token" {
+				t.Errorf("access token = %q, want service-access-token", tokens.AccessToken)
+			}
+		})
+	}
+}
+
+func TestAuthenticateClientCredentialsErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		transport   error
+		wantContain string
+	}{
+		{
+			name:        "invalid client",
+			status:      http.StatusUnauthorized,
+			body:        `{"error":"invalid_client","error_description":"Invalid client credentials"}`,
+			wantContain: "client ID is not recognized",
+		},
+		{
+			name:        "unauthorized client",
+			status:      http.StatusBadRequest,
+			body:        `{"error":"unauthorized_client"}`,
+			wantContain: "not authorized for the requested authentication flow",
+		},
+		{
+			name:        "server error",
+			status:      http.StatusBadGateway,
+			body:        `upstream unavailable`,
+			wantContain: "client credentials token request failed with status 502: upstream unavailable",
+		},
+		{
+			name:        "missing access token",
+			status:      http.StatusOK,
+			body:        `{"token_type":"Bearer"}`,
+			wantContain: "no access token received",
+		},
+		{
+			name:        "transport",
+			transport:   errors.New("connection refused"),
+			wantContain: "client credentials token request failed: ",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+				if test.transport != nil {
+					return nil, test.transport
+				}
+				return &http.Response{
+					StatusCode: test.status,
+					Header:     make(http.Header),
+					Body:       io.NopCloser(strings.NewReader(test.body)),
+				}, nil
+			})}
+			options := testOIDCOptions()
+			options.ClientSecret = "secret"
+
+			_, err := authenticateClientCredentials(t.Context(), options, testTokenEndpointDependencies(client))
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("authenticateClientCredentials() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
+
+func TestAuthenticateClientCredentialsRequiresSecret(t *testing.T) {
+	dependencies := testTokenEndpointDependencies(nil)
+	dependencies.discoverEndpoints = nil
+
+	_, err := authenticateClientCredentials(t.Context(), testOIDCOptions(), dependencies)
+	if err == nil || !strings.Contains(err.Error(), "client secret or private_key_jwt") {
+		t.Errorf("authenticateClientCredentials() error = %v, want missing client authentication", err)
+	}
+}
@@ -6,6 +6,10 @@ const (
 	// AuthTimeout is the maximum time to wait for the browser callback.
 	// Device authentication uses the provider-issued device code lifetime.
 	AuthTimeout = 60 * time.Second
+	// ManualAuthTimeout is the maximum time to wait for the redirected URL or
+	// authorization code to be pasted in manual browser mode. Providers
+	// usually expire authorization codes within a few minutes.
+	ManualAuthTimeout = 5 * time.Minute
 	// OIDCRequestTimeout bounds each request to the identity provider.
 	OIDCRequestTimeout = 30 * time.Second
 	// DefaultPollingInterval is the default interval for device flow polling.
@@ -14,6 +18,9 @@ const (
 	CallbackReadHeaderTimeout = 5 * time.Second
 	// CallbackShutdownTimeout limits graceful shutdown of the local callback server.
 	CallbackShutdownTimeout = 5 * time.Second
+	// ClientAssertionLifetime bounds how long a private_key_jwt client
+	// assertion is accepted by the token endpoint.
+	ClientAssertionLifetime = 60 * time.Second
 )
 
 const (
@@ -21,4 +28,7 @@ const (
 	CallbackPort = 8080
 	// CallbackFallbackPort is used if the primary port is busy.
 	CallbackFallbackPort = 18088
+	// DefaultCallbackPath is the redirect URI path used when a profile does
+	// not configure one.
+	DefaultCallbackPath = "/callback"
 )
@@ -7,13 +7,13 @@ import (
 )
 
 // AuthenticateDeviceFlow performs OIDC device flow authentication with PKCE.
-func AuthenticateDeviceFlow(ctx context.Context, options OIDCOptions) (string, error) {
+func AuthenticateDeviceFlow(ctx context.Context, options OIDCOptions) (TokenResponse, error) {
 	return AuthenticateDeviceFlowWithOutput(ctx, options, os.Stderr)
 }
 
 // AuthenticateDeviceFlowWithOutput performs OIDC device flow authentication
 // and writes user interaction to output.
-func AuthenticateDeviceFlowWithOutput(ctx context.Context, options OIDCOptions, output io.Writer) (string, error) {
+func AuthenticateDeviceFlowWithOutput(ctx context.Context, options OIDCOptions, output io.Writer) (TokenResponse, error) {
 	dependencies := newDeviceFlowDependencies()
 	dependencies.stderr = output
 	dependencies.newProgress = func() deviceFlowProgress { return newProgressIndicatorWithOutput(output) }
@@ -8,8 +8,11 @@ import (
 	"net/url"
 )
 
-func requestDeviceAuthorization(ctx context.Context, client *http.Client, endpoint string, data url.Values, providerURL string) (DeviceAuthResponse, error) {
-	response, err := postOIDCForm(ctx, client, endpoint, data)
+// requestDeviceAuthorization starts the device flow. Confidential clients
+// authenticate at the device authorization endpoint as they do at the token
+// endpoint (RFC 8628 section 3.1).
+func requestDeviceAuthorization(ctx context.Context, client *http.Client, endpoint, tokenEndpoint string, data url.Values, options OIDCOptions) (DeviceAuthResponse, error) {
+	response, err := postTokenRequest(ctx, client, endpoint, tokenEndpoint, data, options)
 	if err != nil {
 		return DeviceAuthResponse{}, fmt.Errorf("device authorization request failed: %w", err)
 	}
@@ -19,7 +22,7 @@ func requestDeviceAuthorization(ctx context.Context, client *http.Client, endpoi
 	}
 
 	if response.StatusCode != http.StatusOK {
-		return DeviceAuthResponse{}, oidcHTTPStatusError("device authorization", response.StatusCode, body, providerURL)
+		return DeviceAuthResponse{}, oidcHTTPStatusError("device authorization", response.StatusCode, body, options.ProviderURL)
 	}
 
 	var deviceResponse DeviceAuthResponse
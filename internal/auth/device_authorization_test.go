@@ -21,8 +21,9 @@ func TestRequestDeviceAuthorizationClosesResponseBody(t *testing.T) {
 		t.Context(),
 		client,
 		"https://oidc.example.com/protocol/openid-connect/auth/device",
+		"https://oidc.example.com/protocol/openid-connect/token",
 		url.Values{"client_id": {"test-client"}},
-		"https://oidc.example.com",
+		testOIDCOptions(),
 	)
 	if err != nil {
 		t.Fatalf("requestDeviceAuthorization() error = %v", err)
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
 )
 
 type deviceFlowProgress interface {
@@ -17,7 +19,7 @@ type deviceFlowDependencies struct {
 	stderr io.Writer
 
 	generatePKCE      func(string) (string, string, string, error)
-	newHTTPClient     func(bool) *http.Client
+	newHTTPClient     func(httpclient.Options) *http.Client
 	discoverEndpoints func(context.Context, *http.Client, string) (oidcEndpoints, error)
 	now               func() time.Time
 	sleep             func(context.Context, time.Duration) error
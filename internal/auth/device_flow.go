@@ -7,21 +7,21 @@ import (
 	"time"
 )
 
-func authenticateDeviceFlow(ctx context.Context, options OIDCOptions, dependencies deviceFlowDependencies) (string, error) {
+func authenticateDeviceFlow(ctx context.Context, options OIDCOptions, dependencies deviceFlowDependencies) (TokenResponse, error) {
 	if err := ctx.Err(); err != nil {
-		return "", err
+		return TokenResponse{}, err
 	}
 	codeVerifier, codeChallenge, resolvedPKCEMethod, err := dependencies.generatePKCE(string(options.PKCEMethod))
 	if err != nil {
-		return "", err
+		return TokenResponse{}, err
 	}
-	client := dependencies.newHTTPClient(options.SSLVerify)
-	endpoints, err := dependencies.discoverEndpoints(ctx, client, options.ProviderURL)
+	client := dependencies.newHTTPClient(options.httpClientOptions())
+	endpoints, err := discoverEndpointsWithRetry(ctx, client, options, dependencies.discoverEndpoints)
 	if err != nil {
-		return "", err
+		return TokenResponse{}, err
 	}
 	if err := endpoints.validateDeviceFlow(); err != nil {
-		return "", err
+		return TokenResponse{}, err
 	}
 
 	if options.Verbose {
@@ -33,10 +33,11 @@ func authenticateDeviceFlow(ctx context.Context, options OIDCOptions, dependenci
 	authorizationData.Set("scope", options.Scope)
 	authorizationData.Set("code_challenge", codeChallenge)
 	authorizationData.Set("code_challenge_method", resolvedPKCEMethod)
+	options.Authorization.apply(authorizationData)
 
-	deviceResponse, err := requestDeviceAuthorization(ctx, client, endpoints.deviceAuthorization, authorizationData, options.ProviderURL)
+	deviceResponse, err := requestDeviceAuthorization(ctx, client, endpoints.deviceAuthorization, endpoints.token, authorizationData, options)
 	if err != nil {
-		return "", err
+		return TokenResponse{}, err
 	}
 	deviceLifetime := time.Duration(deviceResponse.ExpiresIn) * time.Second
 
@@ -44,7 +45,6 @@ func authenticateDeviceFlow(ctx context.Context, options OIDCOptions, dependenci
 
 	tokenData := url.Values{}
 	tokenData.Set("grant_type", "urn:ietf:params:oauth:grant-type:device_code")
-	tokenData.Set("client_id", options.ClientID)
 	tokenData.Set("device_code", deviceResponse.DeviceCode)
 	tokenData.Set("code_verifier", codeVerifier)
 
@@ -58,6 +58,7 @@ func authenticateDeviceFlow(ctx context.Context, options OIDCOptions, dependenci
 		client:      client,
 		endpoint:    endpoints.token,
 		data:        tokenData,
+		options:     options,
 		providerURL: options.ProviderURL,
 		interval:    pollInterval,
 		lifetime:    deviceLifetime,
@@ -28,8 +28,8 @@ func TestAuthenticateDeviceFlowPolling(t *testing.T) {
 	if err != nil {
 		t.Fatalf("authenticateDeviceFlow() error = %v", err)
 	}
-	if token != "test-access-token" {
-		t.Errorf("token = %q, want test-access-token", token)
+	if token.AccessToken != "test-access-token" {
+		t.Errorf("token = %q, want test-access-token", token.AccessToken)
 	}
 
 	wantSleeps := []time.Duration{2 * time.Second, 2 * time.Second, 7 * time.Second}
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
 	"github.com/fitbeard/radosgw-assume/pkg/duration"
 )
 
@@ -14,14 +15,29 @@ type deviceTokenPoll struct {
 	client      *http.Client
 	endpoint    string
 	data        url.Values
+	options     OIDCOptions
 	providerURL string
 	interval    time.Duration
 	lifetime    time.Duration
 	expiresAt   time.Time
 }
 
-func pollDeviceToken(ctx context.Context, poll deviceTokenPoll, verboseMode bool, dependencies deviceFlowDependencies) (string, error) {
+func pollDeviceToken(ctx context.Context, poll deviceTokenPoll, verboseMode bool, dependencies deviceFlowDependencies) (TokenResponse, error) {
 	progress := dependencies.newProgress()
+	// transientFailures counts consecutive network or server errors. They are
+	// retried at the next poll, up to poll.options.MaxAttempts in a row.
+	transientFailures := 0
+	retryTransient := func(err error) bool {
+		transientFailures++
+		maxAttempts := max(poll.options.MaxAttempts, 1)
+		if transientFailures >= maxAttempts || ctx.Err() != nil || !isTransientOIDCError(err) {
+			return false
+		}
+		if poll.options.OnRetry != nil {
+			poll.options.OnRetry(httpclient.RetryAttempt{Operation: "Device token polling", Attempt: transientFailures, MaxAttempts: maxAttempts, Delay: poll.interval, Err: err})
+		}
+		return true
+	}
 
 	for {
 		remaining := poll.expiresAt.Sub(dependencies.now())
@@ -31,47 +47,60 @@ func pollDeviceToken(ctx context.Context, poll deviceTokenPoll, verboseMode bool
 		wait := min(poll.interval, remaining)
 		if err := dependencies.sleep(ctx, wait); err != nil {
 			progress.StopQuiet()
-			return "", err
+			return TokenResponse{}, err
 		}
 		if !dependencies.now().Before(poll.expiresAt) {
 			break
 		}
 
-		response, err := postOIDCForm(ctx, poll.client, poll.endpoint, poll.data)
+		response, err := postTokenRequest(ctx, poll.client, poll.endpoint, poll.endpoint, poll.data, poll.options)
 		if err != nil {
+			err = fmt.Errorf("token request failed: %w", err)
+			if retryTransient(err) {
+				continue
+			}
 			progress.StopQuiet()
-			return "", fmt.Errorf("token request failed: %w", err)
+			return TokenResponse{}, err
 		}
 		body, err := readOIDCResponseAndClose(response)
 		if err != nil {
+			err = fmt.Errorf("failed to read token response: %w", err)
+			if retryTransient(err) {
+				continue
+			}
 			progress.StopQuiet()
-			return "", fmt.Errorf("failed to read token response: %w", err)
+			return TokenResponse{}, err
 		}
 
 		tokenResponse, err := decodeOIDCTokenResponse("token request", response.StatusCode, body, poll.providerURL)
 		if err != nil {
+			if retryTransient(err) {
+				continue
+			}
 			progress.StopQuiet()
-			return "", err
+			return TokenResponse{}, err
 		}
 
 		switch response.StatusCode {
 		case http.StatusOK:
-			accessToken, err := accessTokenFromOIDCResponse(tokenResponse, poll.providerURL)
+			tokens, err := tokensFromOIDCResponse(tokenResponse, poll.providerURL)
 			if err != nil {
 				progress.StopQuiet()
-				return "", err
+				return TokenResponse{}, err
 			}
 
 			progress.Stop()
 			if verboseMode {
 				_, _ = fmt.Fprintln(dependencies.stderr, "# ✓ Authentication successful!")
 			}
-			return accessToken, nil
+			return tokens, nil
 		case http.StatusBadRequest:
 			switch tokenResponse.Error {
 			case "authorization_pending":
+				transientFailures = 0
 				continue
 			case "slow_down":
+				transientFailures = 0
 				slowDown := DefaultPollingInterval * time.Second
 				if poll.lifetime-poll.interval < slowDown {
 					poll.interval = poll.lifetime
@@ -81,14 +110,18 @@ func pollDeviceToken(ctx context.Context, poll deviceTokenPoll, verboseMode bool
 				continue
 			default:
 				progress.StopQuiet()
-				return "", oidcHTTPStatusError("token request", response.StatusCode, body, poll.providerURL)
+				return TokenResponse{}, oidcHTTPStatusError("token request", response.StatusCode, body, poll.providerURL)
 			}
 		default:
+			err := oidcHTTPStatusError("token request", response.StatusCode, body, poll.providerURL)
+			if retryTransient(err) {
+				continue
+			}
 			progress.StopQuiet()
-			return "", oidcHTTPStatusError("token request", response.StatusCode, body, poll.providerURL)
+			return TokenResponse{}, err
 		}
 	}
 
 	progress.StopQuiet()
-	return "", fmt.Errorf("device authorization expired after %s; start authentication again", duration.Format(poll.lifetime))
+	return TokenResponse{}, fmt.Errorf("device authorization expired after %s; start authentication again", duration.Format(poll.lifetime))
 }
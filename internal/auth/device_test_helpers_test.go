@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
 )
 
 const (
@@ -65,7 +67,7 @@ func newTestDeviceFlowDependencies(stderr io.Writer, client *http.Client) (devic
 			}
 			return testDeviceCodeVerifier, challenge, method, nil
 		},
-		newHTTPClient: func(bool) *http.Client { return client },
+		newHTTPClient: func(httpclient.Options) *http.Client { return client },
 		discoverEndpoints: func(context.Context, *http.Client, string) (oidcEndpoints, error) {
 			return oidcEndpoints{
 				deviceAuthorization: "https://oidc.example.com/device",
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
+
+// githubActionsIssuer is the issuer of tokens requested from the GitHub Actions
+// runtime.
+const githubActionsIssuer = "https://token.actions.githubusercontent.com"
+
+// GitHubActionsOptions contains the runtime values GitHub exposes to jobs
+// granted the id-token: write permission.
+type GitHubActionsOptions struct {
+	RequestURL   string
+	RequestToken string
+	Audience     string
+}
+
+type githubActionsTokenResponse struct {
+	Value string `json:"value"`
+}
+
+// FetchGitHubActionsToken requests an OIDC ID token for the current workflow
+// job from the GitHub Actions runtime.
+func FetchGitHubActionsToken(ctx context.Context, options GitHubActionsOptions) (string, error) {
+	return fetchGitHubActionsToken(ctx, options, NewHTTPClient(httpclient.Options{VerifyTLS: true}))
+}
+
+func fetchGitHubActionsToken(ctx context.Context, options GitHubActionsOptions, client *http.Client) (string, error) {
+	if options.RequestURL == "" || options.RequestToken == "" {
+		return "", fmt.Errorf("ACTIONS_ID_TOKEN_REQUEST_URL and ACTIONS_ID_TOKEN_REQUEST_TOKEN are not set. Run inside a GitHub Actions job with 'permissions: id-token: write'")
+	}
+
+	requestURL, err := url.Parse(options.RequestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid ACTIONS_ID_TOKEN_REQUEST_URL: %w", err)
+	}
+	if requestURL.Scheme != "https" && requestURL.Scheme != "http" {
+		return "", fmt.Errorf("invalid ACTIONS_ID_TOKEN_REQUEST_URL: unsupported scheme %q", requestURL.Scheme)
+	}
+	if options.Audience != "" {
+		query := requestURL.Query()
+		query.Set("audience", options.Audience)
+		requestURL.RawQuery = query.Encode()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GitHub Actions token request: %w", err)
+	}
+	request.Header.Set("Authorization", "bearer "+options.RequestToken)
+	request.Header.Set("Accept", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("GitHub Actions token request failed: %w", err)
+	}
+	body, err := readOIDCResponseAndClose(response)
+	if err != nil {
+		return "", fmt.Errorf("failed to read GitHub Actions token response: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", oidcHTTPStatusError("GitHub Actions token request", response.StatusCode, body, githubActionsIssuer)
+	}
+
+	var tokenResponse githubActionsTokenResponse
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", fmt.Errorf("failed to parse GitHub Actions token response: %w", err)
+	}
+	if tokenResponse.Value == "" {
+		return "", fmt.Errorf("GitHub Actions token response did not include a token")
+	}
+	if strings.Count(tokenResponse.Value, ".") != 2 {
+		return "", fmt.Errorf("GitHub Actions token response did not contain a JWT")
+	}
+
+	return tokenResponse.Value, nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testGitHubActionsJWT = "eyJhbGciOiJSUzI1NiJ9.eyJpc3MiOiJodHRwczovL3Rva2VuLmFjdGlvbnMuZ2l0aHVidXNlcmNvbnRlbnQuY29tIn0.signature"
+
+func TestFetchGitHubActionsToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("method = %s, want GET", r.Method)
+		}
+		if got := r.Header.Get("Authorization"); got != "bearer request-token" {
+			t.Errorf("Authorization = %q, want bearer request-token", got)
+		}
+		if got := r.URL.Query().Get("api-version"); got != "2.0" {
+			t.Errorf("api-version = %q, want existing query to be preserved", got)
+		}
+		if got := r.URL.Query().Get("audience"); got != "sts.storage.example.com" {
+			t.Errorf("audience = %q, want sts.storage.example.com", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"count":1,"value":"` + testGitHubActionsJWT + `"}`))
+	}))
+	defer server.Close()
+
+	token, err := fetchGitHubActionsToken(t.Context(), GitHubActionsOptions{
+		RequestURL:   server.URL + "/token?api-version=2.0",
+		RequestToken: "request-token",
+		Audience:     "sts.storage.example.com",
+	}, server.Client())
+	if err != nil {
+		t.Fatalf("fetchGitHubActionsToken() error = %v", err)
+	}
+	if token != testGitHubActionsJWT {
+		t.Errorf("fetchGitHubActionsToken() = %q, want %q", token, testGitHubActionsJWT)
+	}
+}
+
+func TestFetchGitHubActionsTokenOmitsUnsetAudience(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("audience") {
+			t.Errorf("query = %q, want no audience parameter", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(`{"value":"` + testGitHubActionsJWT + `"}`))
+	}))
+	defer server.Close()
+
+	if _, err := fetchGitHubActionsToken(t.Context(), GitHubActionsOptions{
+		RequestURL:   server.URL,
+		RequestToken: "request-token",
+	}, server.Client()); err != nil {
+		t.Fatalf("fetchGitHubActionsToken() error = %v", err)
+	}
+}
+
+func TestFetchGitHubActionsTokenErrors(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		status      int
+		body        string
+		wantContain string
+	}{
+		{name: "status", status: http.StatusForbidden, body: `{"message":"Resource not accessible by integration"}`, wantContain: "failed with status 403"},
+		{name: "malformed JSON", status: http.StatusOK, body: `not-json`, wantContain: "failed to parse GitHub Actions token response"},
+		{name: "missing value", status: http.StatusOK, body: `{"count":0}`, wantContain: "did not include a token"},
+		{name: "not a JWT", status: http.StatusOK, body: `{"value":"opaque-token"}`, wantContain: "did not contain a JWT"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			_, err := fetchGitHubActionsToken(t.Context(), GitHubActionsOptions{
+				RequestURL:   server.URL,
+				RequestToken: "request-token",
+			}, server.Client())
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("fetchGitHubActionsToken() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
+
+func TestFetchGitHubActionsTokenRequiresRuntimeEnvironment(t *testing.T) {
+	for _, options := range []GitHubActionsOptions{
+		{RequestToken: "request-token"},
+		{RequestURL: "https://pipelines.actions.githubusercontent.com/token"},
+	} {
+		_, err := fetchGitHubActionsToken(t.Context(), options, http.DefaultClient)
+		if err == nil || !strings.Contains(err.Error(), "id-token: write") {
+			t.Errorf("fetchGitHubActionsToken(%+v) error = %v, want permission hint", options, err)
+		}
+	}
+}
+
+func TestFetchGitHubActionsTokenRejectsInvalidURL(t *testing.T) {
+	_, err := fetchGitHubActionsToken(t.Context(), GitHubActionsOptions{
+		RequestURL:   "file:///etc/passwd",
+		RequestToken: "request-token",
+	}, http.DefaultClient)
+	if err == nil || !strings.Contains(err.Error(), "unsupported scheme") {
+		t.Errorf("fetchGitHubActionsToken() error = %v, want unsupported scheme", err)
+	}
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// requestsOpenIDScope reports whether scope asks for an OpenID Connect ID
+// token.
+func requestsOpenIDScope(scope string) bool {
+	return slices.Contains(strings.Fields(scope), "openid")
+}
+
+// verifyIDToken validates the ID token returned by the authorization code
+// exchange as described in OpenID Connect Core 1.0 section 3.1.3.7. The
+// signature must verify against the provider's JWKS and the token must carry
+// the nonce sent with the authorization request, so an ID token issued for a
+// different login is rejected.
+func verifyIDToken(ctx context.Context, client *http.Client, endpoints oidcEndpoints, options OIDCOptions, idToken, nonce string, now time.Time) error {
+	if idToken == "" {
+		return fmt.Errorf("token response has no id_token although the openid scope was requested")
+	}
+	if endpoints.jwks == "" {
+		return fmt.Errorf("OIDC discovery response is missing jwks_uri required by ID token validation")
+	}
+	claims, err := verifyJWT(ctx, client, endpoints.jwks, options.ProviderURL, idToken)
+	if err != nil {
+		return err
+	}
+	if err := validateJWTClaims(claims, options, TokenVerificationOptions{Audiences: []string{options.ClientID}}, now); err != nil {
+		return err
+	}
+	// An authorized party alone satisfies validateJWTClaims, but an ID token
+	// must always list the client in its audience.
+	if !slices.Contains(claims.Audience, options.ClientID) {
+		return fmt.Errorf("audience [%s] does not include radosgw_oidc_client_id %q", strings.Join(claims.Audience, ", "), options.ClientID)
+	}
+	if claims.Nonce != nonce {
+		return fmt.Errorf("nonce does not match the authorization request")
+	}
+	return nil
+}
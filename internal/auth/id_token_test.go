@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyIDToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	withClaims := func(changes map[string]any) map[string]any {
+		claims := map[string]any{
+			"iss":   "https://oidc.example.com",
+			"aud":   "test-client",
+			"exp":   now.Add(5 * time.Minute).Unix(),
+			"nonce": "test-nonce",
+		}
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+				continue
+			}
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		missingJWKS bool
+		wantContain string
+	}{
+		{name: "valid", token: signTestJWT(t, key, "RS256", "rsa-key", withClaims(nil))},
+		{
+			name:  "audience and authorized party",
+			token: signTestJWT(t, key, "RS256", "rsa-key", withClaims(map[string]any{"aud": []string{"test-client", "account"}, "azp": "test-client"})),
+		},
+		{name: "missing token", wantContain: "token response has no id_token"},
+		{
+			name:        "missing jwks_uri",
+			token:       signTestJWT(t, key, "RS256", "rsa-key", withClaims(nil)),
+			missingJWKS: true,
+			wantContain: "OIDC discovery response is missing jwks_uri required by ID token validation",
+		},
+		{
+			name:        "swapped token",
+			token:       signTestJWT(t, otherKey, "RS256", "rsa-key", withClaims(nil)),
+			wantContain: "token signature does not verify",
+		},
+		{
+			name:        "nonce mismatch",
+			token:       signTestJWT(t, key, "RS256", "rsa-key", withClaims(map[string]any{"nonce": "replayed-nonce"})),
+			wantContain: "nonce does not match the authorization request",
+		},
+		{
+			name:        "missing nonce",
+			token:       signTestJWT(t, key, "RS256", "rsa-key", withClaims(map[string]any{"nonce": nil})),
+			wantContain: "nonce does not match the authorization request",
+		},
+		{
+			name:        "issuer mismatch",
+			token:       signTestJWT(t, key, "RS256", "rsa-key", withClaims(map[string]any{"iss": "https://other.example.com"})),
+			wantContain: `token issuer "https://other.example.com" does not match`,
+		},
+		{
+			name:        "expired",
+			token:       signTestJWT(t, key, "RS256", "rsa-key", withClaims(map[string]any{"exp": now.Add(-time.Minute).Unix()})),
+			wantContain: "token expired 1m ago",
+		},
+		{
+			name:        "audience mismatch",
+			token:       signTestJWT(t, key, "RS256", "rsa-key", withClaims(map[string]any{"aud": "other-client"})),
+			wantContain: "audience other-client not in [test-client]",
+		},
+		{
+			name:        "authorized party without audience",
+			token:       signTestJWT(t, key, "RS256", "rsa-key", withClaims(map[string]any{"aud": "account", "azp": "test-client"})),
+			wantContain: `audience [account] does not include radosgw_oidc_client_id "test-client"`,
+		},
+	}
+
+	client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		body, err := json.Marshal(jsonWebKeySet{Keys: []jsonWebKey{testRSAJSONWebKey("rsa-key", &key.PublicKey)}})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(string(body)))}, nil
+	})}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			endpoints := oidcEndpoints{token: "https://oidc.example.com/token", jwks: testJWKSURI}
+			if test.missingJWKS {
+				endpoints.jwks = ""
+			}
+
+			err := verifyIDToken(t.Context(), client, endpoints, testOIDCOptions(), test.token, "test-nonce", now)
+
+			if test.wantContain == "" {
+				if err != nil {
+					t.Fatalf("verifyIDToken() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Fatalf("verifyIDToken() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
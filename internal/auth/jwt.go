@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Issuer          string       `json:"iss"`
+	Audience        jwtAudience  `json:"aud"`
+	ExpiresAt       *json.Number `json:"exp"`
+	NotBefore       *json.Number `json:"nbf"`
+	AuthorizedParty string       `json:"azp"`
+	Nonce           string       `json:"nonce"`
+}
+
+// jwtAudience accepts both encodings of the aud claim allowed by RFC 7519.
+type jwtAudience []string
+
+func (audience *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*audience = jwtAudience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("aud claim must be a string or an array of strings")
+	}
+	*audience = multiple
+	return nil
+}
+
+func splitJWT(token string) ([]string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token is not a JWT")
+	}
+	return parts, nil
+}
+
+func decodeJWTSegment(segment, name string, target any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("decode JWT %s: %w", name, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(target); err != nil {
+		return fmt.Errorf("parse JWT %s: %w", name, err)
+	}
+	return nil
+}
+
+func numericDate(value *json.Number, claim string) (time.Time, error) {
+	seconds, err := value.Float64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse JWT %s claim: %w", claim, err)
+	}
+	return time.Unix(int64(seconds), 0), nil
+}
+
+// IsJWT reports whether token has the three-segment JWS compact form. Opaque
+// access tokens do not, and cannot be inspected locally.
+func IsJWT(token string) bool {
+	_, err := splitJWT(token)
+	return err == nil
+}
+
+// TokenExpiry returns the exp claim of a JWT without verifying its signature.
+// It only decides how long an already accepted token may be reused locally.
+func TokenExpiry(token string) (time.Time, error) {
+	parts, err := splitJWT(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var claims jwtClaims
+	if err := decodeJWTSegment(parts[1], "payload", &claims); err != nil {
+		return time.Time{}, err
+	}
+	if claims.ExpiresAt == nil {
+		return time.Time{}, fmt.Errorf("JWT has no exp claim")
+	}
+	return numericDate(claims.ExpiresAt, "exp")
+}
+
+// DecodedToken holds the header and claims of a JWT. Numeric values are kept
+// as json.Number so they are displayed exactly as issued.
+type DecodedToken struct {
+	Header map[string]any
+	Claims map[string]any
+}
+
+// DecodeToken decodes a JWT for display without verifying its signature. The
+// signature segment is discarded so callers cannot print the raw token.
+func DecodeToken(token string) (DecodedToken, error) {
+	parts, err := splitJWT(token)
+	if err != nil {
+		return DecodedToken{}, err
+	}
+	var decoded DecodedToken
+	if err := decodeJWTSegment(parts[0], "header", &decoded.Header); err != nil {
+		return DecodedToken{}, err
+	}
+	if err := decodeJWTSegment(parts[1], "payload", &decoded.Claims); err != nil {
+		return DecodedToken{}, err
+	}
+	return decoded, nil
+}
+
+// Expiry returns the exp claim, if present and numeric.
+func (token DecodedToken) Expiry() (time.Time, bool) {
+	value, ok := token.Claims["exp"].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	expiresAt, err := numericDate(&value, "exp")
+	return expiresAt, err == nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenExpiry(t *testing.T) {
+	jwt := func(payload string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+	}
+
+	for _, test := range []struct {
+		name        string
+		token       string
+		want        time.Time
+		wantContain string
+	}{
+		{name: "integer", token: jwt(`{"exp":1893456000}`), want: time.Unix(1893456000, 0)},
+		{name: "fractional", token: jwt(`{"exp":1893456000.75}`), want: time.Unix(1893456000, 0)},
+		{name: "opaque token", token: "opaque-access-token", wantContain: "not a JWT"},
+		{name: "invalid encoding", token: "e30.!!!.signature", wantContain: "decode JWT payload"},
+		{name: "invalid JSON", token: jwt(`{`), wantContain: "parse JWT payload"},
+		{name: "missing exp", token: jwt(`{"sub":"user"}`), wantContain: "no exp claim"},
+		{name: "string exp", token: jwt(`{"exp":"tomorrow"}`), wantContain: "parse JWT payload"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := TokenExpiry(test.token)
+			if test.wantContain != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+					t.Fatalf("TokenExpiry() error = %v, want containing %q", err, test.wantContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TokenExpiry() error = %v", err)
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("TokenExpiry() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsJWT(t *testing.T) {
+	for _, test := range []struct {
+		token string
+		want  bool
+	}{
+		{token: "header.payload.signature", want: true},
+		{token: "opaque-access-token"},
+		{token: "header.payload"},
+		{token: "a.b.c.d.e"},
+	} {
+		if got := IsJWT(test.token); got != test.want {
+			t.Errorf("IsJWT(%q) = %v, want %v", test.token, got, test.want)
+		}
+	}
+}
+
+func TestDecodeToken(t *testing.T) {
+	segment := func(value string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(value))
+	}
+	token := segment(`{"alg":"RS256","kid":"key-1"}`) + "." + segment(`{"sub":"user","groups":["a","b"],"exp":1893456000}`) + ".signature"
+
+	decoded, err := DecodeToken(token)
+	if err != nil {
+		t.Fatalf("DecodeToken() error = %v", err)
+	}
+	if decoded.Header["alg"] != "RS256" || decoded.Header["kid"] != "key-1" {
+		t.Errorf("header = %v", decoded.Header)
+	}
+	if decoded.Claims["sub"] != "user" || decoded.Claims["exp"] != json.Number("1893456000") {
+		t.Errorf("claims = %v", decoded.Claims)
+	}
+	if expiresAt, ok := decoded.Expiry(); !ok || !expiresAt.Equal(time.Unix(1893456000, 0)) {
+		t.Errorf("Expiry() = %v, %v", expiresAt, ok)
+	}
+
+	for _, test := range []struct {
+		name        string
+		token       string
+		wantContain string
+	}{
+		{name: "opaque token", token: "opaque-access-token", wantContain: "not a JWT"},
+		{name: "invalid header", token: "!!!." + segment(`{}`) + ".signature", wantContain: "decode JWT header"},
+		{name: "array payload", token: segment(`{}`) + "." + segment(`[]`) + ".signature", wantContain: "parse JWT payload"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := DecodeToken(test.token); err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Fatalf("DecodeToken() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
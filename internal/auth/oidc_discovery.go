@@ -13,13 +13,25 @@ type oidcEndpoints struct {
 	authorization       string
 	deviceAuthorization string
 	token               string
+	jwks                string
+	pushedAuthorization string
+	revocation          string
+	endSession          string
+	// requirePushedAuthorization is set when the provider only accepts
+	// authorization requests pushed to pushedAuthorization.
+	requirePushedAuthorization bool
 }
 
 type oidcProviderMetadata struct {
-	Issuer                      string `json:"issuer"`
-	AuthorizationEndpoint       string `json:"authorization_endpoint"`
-	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
-	TokenEndpoint               string `json:"token_endpoint"`
+	Issuer                             string `json:"issuer"`
+	AuthorizationEndpoint              string `json:"authorization_endpoint"`
+	DeviceAuthorizationEndpoint        string `json:"device_authorization_endpoint"`
+	TokenEndpoint                      string `json:"token_endpoint"`
+	JWKSURI                            string `json:"jwks_uri"`
+	PushedAuthorizationRequestEndpoint string `json:"pushed_authorization_request_endpoint"`
+	RequirePushedAuthorizationRequests bool   `json:"require_pushed_authorization_requests"`
+	RevocationEndpoint                 string `json:"revocation_endpoint"`
+	EndSessionEndpoint                 string `json:"end_session_endpoint"`
 }
 
 func discoverOIDCEndpoints(ctx context.Context, client *http.Client, providerURL string) (oidcEndpoints, error) {
@@ -57,9 +69,14 @@ func discoverOIDCEndpoints(ctx context.Context, client *http.Client, providerURL
 		)
 	}
 	endpoints := oidcEndpoints{
-		authorization:       metadata.AuthorizationEndpoint,
-		deviceAuthorization: metadata.DeviceAuthorizationEndpoint,
-		token:               metadata.TokenEndpoint,
+		authorization:              metadata.AuthorizationEndpoint,
+		deviceAuthorization:        metadata.DeviceAuthorizationEndpoint,
+		token:                      metadata.TokenEndpoint,
+		jwks:                       metadata.JWKSURI,
+		pushedAuthorization:        metadata.PushedAuthorizationRequestEndpoint,
+		requirePushedAuthorization: metadata.RequirePushedAuthorizationRequests,
+		revocation:                 metadata.RevocationEndpoint,
+		endSession:                 metadata.EndSessionEndpoint,
 	}
 	for _, endpoint := range []struct {
 		name string
@@ -68,6 +85,10 @@ func discoverOIDCEndpoints(ctx context.Context, client *http.Client, providerURL
 		{name: "authorization_endpoint", url: endpoints.authorization},
 		{name: "device_authorization_endpoint", url: endpoints.deviceAuthorization},
 		{name: "token_endpoint", url: endpoints.token},
+		{name: "jwks_uri", url: endpoints.jwks},
+		{name: "pushed_authorization_request_endpoint", url: endpoints.pushedAuthorization},
+		{name: "revocation_endpoint", url: endpoints.revocation},
+		{name: "end_session_endpoint", url: endpoints.endSession},
 	} {
 		if endpoint.url == "" {
 			continue
@@ -126,13 +147,23 @@ func validateOIDCEndpoint(name, endpointURL, issuerScheme string) error {
 	return nil
 }
 
-func (endpoints oidcEndpoints) validateBrowserFlow() error {
+// validateBrowserFlow checks the endpoints used by the authorization code
+// flow. requirePAR reports whether radosgw_oidc_require_par is enabled.
+func (endpoints oidcEndpoints) validateBrowserFlow(requirePAR bool) error {
 	if endpoints.authorization == "" {
 		return fmt.Errorf("OIDC discovery response is missing authorization_endpoint required by browser authentication")
 	}
 	if endpoints.token == "" {
 		return fmt.Errorf("OIDC discovery response is missing token_endpoint required by browser authentication")
 	}
+	if endpoints.pushedAuthorization == "" {
+		if requirePAR {
+			return fmt.Errorf("OIDC discovery response is missing pushed_authorization_request_endpoint required by radosgw_oidc_require_par")
+		}
+		if endpoints.requirePushedAuthorization {
+			return fmt.Errorf("OIDC discovery response sets require_pushed_authorization_requests but is missing pushed_authorization_request_endpoint")
+		}
+	}
 
 	return nil
 }
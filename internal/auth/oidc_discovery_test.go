@@ -22,7 +22,12 @@ func TestDiscoverOIDCEndpoints(t *testing.T) {
 				"issuer":"https://oidc.example.com/oauth2/default",
 				"authorization_endpoint":"https://oidc.example.com/oauth2/default/v1/authorize?audience=storage",
 				"device_authorization_endpoint":"https://oidc.example.com/oauth2/default/v1/device/authorize",
-				"token_endpoint":"https://oidc.example.com/oauth2/default/v1/token"
+				"token_endpoint":"https://oidc.example.com/oauth2/default/v1/token",
+				"jwks_uri":"https://oidc.example.com/oauth2/default/v1/keys",
+				"pushed_authorization_request_endpoint":"https://oidc.example.com/oauth2/default/v1/par",
+				"require_pushed_authorization_requests":true,
+				"revocation_endpoint":"https://oidc.example.com/oauth2/default/v1/revoke",
+				"end_session_endpoint":"https://oidc.example.com/oauth2/default/v1/logout"
 			}`)),
 		}, nil
 	})}
@@ -40,6 +45,18 @@ func TestDiscoverOIDCEndpoints(t *testing.T) {
 	if endpoints.token != issuer+"/v1/token" {
 		t.Errorf("token endpoint = %q", endpoints.token)
 	}
+	if endpoints.jwks != issuer+"/v1/keys" {
+		t.Errorf("JWKS URI = %q", endpoints.jwks)
+	}
+	if endpoints.pushedAuthorization != issuer+"/v1/par" || !endpoints.requirePushedAuthorization {
+		t.Errorf("pushed authorization endpoint = %q, required = %v", endpoints.pushedAuthorization, endpoints.requirePushedAuthorization)
+	}
+	if endpoints.revocation != issuer+"/v1/revoke" {
+		t.Errorf("revocation endpoint = %q", endpoints.revocation)
+	}
+	if endpoints.endSession != issuer+"/v1/logout" {
+		t.Errorf("end session endpoint = %q", endpoints.endSession)
+	}
 }
 
 func TestDiscoverOIDCEndpointsHonorsCancellation(t *testing.T) {
@@ -125,6 +142,20 @@ func TestDiscoverOIDCEndpointsErrors(t *testing.T) {
 			body:        `{"issuer":"https://oidc.example.com/oauth2/default","token_endpoint":"http://oidc.example.com/token"}`,
 			wantContain: "HTTPS issuer endpoints must use https",
 		},
+		{
+			name:        "pushed authorization endpoint scheme downgrade",
+			providerURL: issuer,
+			status:      http.StatusOK,
+			body:        `{"issuer":"https://oidc.example.com/oauth2/default","pushed_authorization_request_endpoint":"http://oidc.example.com/par"}`,
+			wantContain: "invalid pushed_authorization_request_endpoint",
+		},
+		{
+			name:        "revocation endpoint scheme downgrade",
+			providerURL: issuer,
+			status:      http.StatusOK,
+			body:        `{"issuer":"https://oidc.example.com/oauth2/default","revocation_endpoint":"http://oidc.example.com/revoke"}`,
+			wantContain: "invalid revocation_endpoint",
+		},
 		{
 			name:        "endpoint user information",
 			providerURL: issuer,
@@ -171,16 +202,35 @@ func TestOIDCEndpointRequirements(t *testing.T) {
 	}{
 		{
 			name:        "browser authorization endpoint",
-			validate:    oidcEndpoints.validateBrowserFlow,
+			validate:    func(endpoints oidcEndpoints) error { return endpoints.validateBrowserFlow(false) },
 			endpoints:   oidcEndpoints{token: "https://oidc.example.com/token"},
 			wantContain: "authorization_endpoint",
 		},
 		{
 			name:        "browser token endpoint",
-			validate:    oidcEndpoints.validateBrowserFlow,
+			validate:    func(endpoints oidcEndpoints) error { return endpoints.validateBrowserFlow(false) },
 			endpoints:   oidcEndpoints{authorization: "https://oidc.example.com/authorize"},
 			wantContain: "token_endpoint",
 		},
+		{
+			name:     "browser PAR required by profile",
+			validate: func(endpoints oidcEndpoints) error { return endpoints.validateBrowserFlow(true) },
+			endpoints: oidcEndpoints{
+				authorization: "https://oidc.example.com/authorize",
+				token:         "https://oidc.example.com/token",
+			},
+			wantContain: "missing pushed_authorization_request_endpoint required by radosgw_oidc_require_par",
+		},
+		{
+			name:     "browser PAR required by provider",
+			validate: func(endpoints oidcEndpoints) error { return endpoints.validateBrowserFlow(false) },
+			endpoints: oidcEndpoints{
+				authorization:              "https://oidc.example.com/authorize",
+				token:                      "https://oidc.example.com/token",
+				requirePushedAuthorization: true,
+			},
+			wantContain: "sets require_pushed_authorization_requests but is missing pushed_authorization_request_endpoint",
+		},
 		{
 			name:        "device authorization endpoint",
 			validate:    oidcEndpoints.validateDeviceFlow,
@@ -26,18 +26,26 @@ type oidcErrorResponse struct {
 	ErrorDesc string `json:"error_description"`
 }
 
-// NewHTTPClient creates a bounded HTTP client with optional SSL verification.
-func NewHTTPClient(sslVerify bool) *http.Client {
-	return httpclient.New(sslVerify, OIDCRequestTimeout)
+// NewHTTPClient creates a bounded HTTP client with the supplied TLS options.
+func NewHTTPClient(options httpclient.Options) *http.Client {
+	return httpclient.New(options, OIDCRequestTimeout)
 }
 
 func postOIDCForm(ctx context.Context, client *http.Client, endpoint string, data url.Values) (*http.Response, error) {
+	request, err := newOIDCFormRequest(ctx, endpoint, data)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(request)
+}
+
+func newOIDCFormRequest(ctx context.Context, endpoint string, data url.Values) (*http.Request, error) {
 	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
 	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	return client.Do(request)
+	return request, nil
 }
 
 func decodeOIDCTokenResponse(operation string, statusCode int, body []byte, providerURL string) (TokenResponse, error) {
@@ -52,15 +60,15 @@ func decodeOIDCTokenResponse(operation string, statusCode int, body []byte, prov
 	return tokenResponse, nil
 }
 
-func accessTokenFromOIDCResponse(tokenResponse TokenResponse, providerURL string) (string, error) {
+func tokensFromOIDCResponse(tokenResponse TokenResponse, providerURL string) (TokenResponse, error) {
 	if tokenResponse.Error != "" {
-		return "", FormatOIDCError(tokenResponse.Error, tokenResponse.ErrorDesc, providerURL)
+		return TokenResponse{}, FormatOIDCError(tokenResponse.Error, tokenResponse.ErrorDesc, providerURL)
 	}
 	if tokenResponse.AccessToken == "" {
-		return "", fmt.Errorf("no access token received")
+		return TokenResponse{}, fmt.Errorf("no access token received")
 	}
 
-	return tokenResponse.AccessToken, nil
+	return tokenResponse, nil
 }
 
 func readOIDCResponseAndClose(response *http.Response) ([]byte, error) {
@@ -80,7 +88,26 @@ func readOIDCResponseAndClose(response *http.Response) ([]byte, error) {
 	return body, nil
 }
 
+// oidcStatusError keeps the HTTP status of a failed OIDC request so transient
+// provider failures can be retried.
+type oidcStatusError struct {
+	statusCode int
+	err        error
+}
+
+func (err *oidcStatusError) Error() string {
+	return err.err.Error()
+}
+
+func (err *oidcStatusError) Unwrap() error {
+	return err.err
+}
+
 func oidcHTTPStatusError(operation string, statusCode int, body []byte, providerURL string) error {
+	return &oidcStatusError{statusCode: statusCode, err: formatOIDCHTTPStatusError(operation, statusCode, body, providerURL)}
+}
+
+func formatOIDCHTTPStatusError(operation string, statusCode int, body []byte, providerURL string) error {
 	var errorResponse oidcErrorResponse
 	if err := json.Unmarshal(body, &errorResponse); err == nil && errorResponse.Error != "" {
 		return fmt.Errorf(
@@ -4,10 +4,12 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
 )
 
 func TestNewHTTPClient(t *testing.T) {
-	client := NewHTTPClient(false)
+	client := NewHTTPClient(httpclient.Options{})
 	if client.Timeout != OIDCRequestTimeout {
 		t.Errorf("NewHTTPClient timeout = %v, want %v", client.Timeout, OIDCRequestTimeout)
 	}
@@ -1,9 +1,23 @@
 package auth
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
 
 type roundTripFunc func(*http.Request) (*http.Response, error)
 
 func (fn roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
 	return fn(request)
 }
+
+func testTokenEndpointDependencies(client *http.Client) tokenEndpointDependencies {
+	return tokenEndpointDependencies{
+		newHTTPClient: func(httpclient.Options) *http.Client { return client },
+		discoverEndpoints: func(context.Context, *http.Client, string) (oidcEndpoints, error) {
+			return oidcEndpoints{token: "https://oidc.example.com/token"}, nil
+		},
+	}
+}
@@ -1,15 +1,53 @@
 package auth
 
-import "github.com/fitbeard/radosgw-assume/internal/config"
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
 
 // OIDCOptions contains the shared configuration for an OIDC authentication
 // flow. Context cancellation and user interaction output remain explicit at
 // call sites because they describe execution rather than authentication data.
 type OIDCOptions struct {
-	ProviderURL string
-	ClientID    string
-	Scope       string
-	PKCEMethod  config.PKCEMethod
-	SSLVerify   bool
-	Verbose     bool
+	ProviderURL       string
+	ClientID          string
+	ClientSecret      string
+	ClientAuthMethod  config.ClientAuthMethod
+	ClientPrivateKey  crypto.Signer
+	Scope             string
+	PKCEMethod        config.PKCEMethod
+	Authorization     AuthorizationParameters
+	BrowserMode       config.BrowserMode
+	RequirePAR        bool
+	CallbackHost      config.CallbackHost
+	CallbackPorts     []int
+	CallbackPath      string
+	SSLVerify         bool
+	ClientCertificate *tls.Certificate
+	RootCAs           *x509.CertPool
+	PinnedSHA256      [][sha256.Size]byte
+	ProxyURL          *url.URL
+	NoProxy           string
+	OnProxy           func(target, proxy *url.URL)
+	MaxAttempts       int
+	OnRetry           func(httpclient.RetryAttempt)
+	Verbose           bool
+}
+
+func (options OIDCOptions) httpClientOptions() httpclient.Options {
+	return httpclient.Options{
+		VerifyTLS:         options.SSLVerify,
+		ClientCertificate: options.ClientCertificate,
+		RootCAs:           options.RootCAs,
+		PinnedSHA256:      options.PinnedSHA256,
+		ProxyURL:          options.ProxyURL,
+		NoProxy:           options.NoProxy,
+		OnProxy:           options.OnProxy,
+	}
 }
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrRefreshTokenRejected indicates that the identity provider refused a
+// stored refresh token. Callers should discard the token and authenticate
+// interactively.
+var ErrRefreshTokenRejected = errors.New("refresh token rejected")
+
+// RefreshTokens exchanges a refresh token for fresh tokens at the provider's
+// discovered token endpoint without user interaction.
+func RefreshTokens(ctx context.Context, options OIDCOptions, refreshToken string) (TokenResponse, error) {
+	return refreshTokens(ctx, options, refreshToken, newTokenEndpointDependencies())
+}
+
+func refreshTokens(ctx context.Context, options OIDCOptions, refreshToken string, dependencies tokenEndpointDependencies) (TokenResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return TokenResponse{}, err
+	}
+	if refreshToken == "" {
+		return TokenResponse{}, fmt.Errorf("%w: refresh token is empty", ErrRefreshTokenRejected)
+	}
+
+	client := dependencies.newHTTPClient(options.httpClientOptions())
+	endpoints, err := discoverEndpointsWithRetry(ctx, client, options, dependencies.discoverEndpoints)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	if endpoints.token == "" {
+		return TokenResponse{}, fmt.Errorf("OIDC discovery response is missing token_endpoint required by token refresh")
+	}
+
+	tokenData := url.Values{}
+	tokenData.Set("grant_type", "refresh_token")
+	tokenData.Set("refresh_token", refreshToken)
+	if options.Scope != "" {
+		tokenData.Set("scope", options.Scope)
+	}
+	options.Authorization.applyTokenTarget(tokenData)
+
+	response, err := postTokenRequest(ctx, client, endpoints.token, endpoints.token, tokenData, options)
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("token refresh failed: %w", err)
+	}
+	body, err := readOIDCResponseAndClose(response)
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("failed to read token refresh response: %w", err)
+	}
+
+	tokenResponse, err := decodeOIDCTokenResponse("token refresh", response.StatusCode, body, options.ProviderURL)
+	if err != nil {
+		if response.StatusCode == http.StatusBadRequest || response.StatusCode == http.StatusUnauthorized {
+			return TokenResponse{}, fmt.Errorf("%w: %w", ErrRefreshTokenRejected, err)
+		}
+		return TokenResponse{}, err
+	}
+	switch {
+	case response.StatusCode == http.StatusBadRequest || response.StatusCode == http.StatusUnauthorized:
+		return TokenResponse{}, fmt.Errorf("%w: %w", ErrRefreshTokenRejected, oidcHTTPStatusError("token refresh", response.StatusCode, body, options.ProviderURL))
+	case response.StatusCode != http.StatusOK:
+		return TokenResponse{}, oidcHTTPStatusError("token refresh", response.StatusCode, body, options.ProviderURL)
+	case tokenResponse.Error != "":
+		return TokenResponse{}, fmt.Errorf("%w: %w", ErrRefreshTokenRejected, FormatOIDCError(tokenResponse.Error, tokenResponse.ErrorDesc, options.ProviderURL))
+	}
+
+	return tokensFromOIDCResponse(tokenResponse, options.ProviderURL)
+}
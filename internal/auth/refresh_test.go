@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRefreshTokens(t *testing.T) {
+	client := &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		if request.URL.String() != "https://oidc.example.com/token" {
+			t.Errorf("refresh endpoint = %q", request.URL)
+		}
+		if err := request.ParseForm(); err != nil {
+			t.Fatalf("ParseForm() error = %v", err)
+		}
+		wantForm := map[string]string{
+			"grant_type":    "refresh_token",
+			"client_id":     "test-client",
+			"refresh_token": "stored-refresh-token",
+			"scope":         "openid",
+			"audience":      "storage",
+		}
+		for key, want := range wantForm {
+			if got := request.Form.Get(key); got != want {
+				t.Errorf("refresh request %s = %q, want %q", key, got, want)
+			}
+		}
+		if got := request.Form["resource"]; !slices.Equal(got, []string{"https://s3.example.com", "https://iam.example.com"}) {
+			t.Errorf("refresh request resource = %q, want both resource indicators", got)
+		}
+		for _, key := range []string{"login_hint", "acr_values", "prompt"} {
+			if request.Form.Has(key) {
+				t.Errorf("refresh request sent authorization parameter %s", key)
+			}
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{"access_token":"fresh-access-token","refresh_token":"rotated-refresh-token"}`)),
+		}, nil
+	})}
+
+	options := testOIDCOptions()
+	options.Authorization = AuthorizationParameters{
+		LoginHint: "alice",
+		ACRValues: "mfa",
+		Audience:  "storage",
+		Resources: []string{"https://s3.example.com", "https://iam.example.com"},
+	}
+	tokens, err := refreshTokens(t.Context(), options, "stored-refresh-token", testTokenEndpointDependencies(client))
+	if err != nil {
+		t.Fatalf("refreshTokens() error = %v", err)
+	}
+	if tokens.AccessToken != "fresh-access-token" || tokens.RefreshToken != "rotated-refresh-token" {
+		t.Errorf("refreshTokens() = %+v, want refreshed tokens", tokens)
+	}
+}
+
+func TestRefreshTokensErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		transport    error
+		wantRejected bool
+		wantContain  string
+	}{
+		{
+			name:         "invalid grant",
+			status:       http.StatusBadRequest,
+			body:         `{"error":"invalid_grant","error_description":"Token is not active"}`,
+			wantRejected: true,
+			wantContain:  "invalid grant",
+		},
+		{
+			name:         "unauthorized plain response",
+			status:       http.StatusUnauthorized,
+			body:         "unauthorized",
+			wantRejected: true,
+			wantContain:  "token refresh failed with status 401",
+		},
+		{
+			name:        "server error",
+			status:      http.StatusBadGateway,
+			body:        "upstream unavailable",
+			wantContain: "token refresh failed with status 502: upstream unavailable",
+		},
+		{
+			name:        "transport error",
+			transport:   errors.New("connection failed"),
+			wantContain: "token refresh failed: ",
+		},
+		{
+			name:        "missing access token",
+			status:      http.StatusOK,
+			body:        `{}`,
+			wantContain: "no access token received",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newBrowserTokenClient(test.status, test.body, test.transport)
+			_, err := refreshTokens(t.Context(), testOIDCOptions(), "stored-refresh-token", testTokenEndpointDependencies(client))
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Fatalf("refreshTokens() error = %v, want containing %q", err, test.wantContain)
+			}
+			if got := errors.Is(err, ErrRefreshTokenRejected); got != test.wantRejected {
+				t.Errorf("errors.Is(ErrRefreshTokenRejected) = %v, want %v", got, test.wantRejected)
+			}
+		})
+	}
+}
+
+func TestRefreshTokensRejectsEmptyToken(t *testing.T) {
+	dependencies := testTokenEndpointDependencies(nil)
+	dependencies.discoverEndpoints = func(context.Context, *http.Client, string) (oidcEndpoints, error) {
+		t.Fatal("discovery must not run for an empty refresh token")
+		return oidcEndpoints{}, nil
+	}
+
+	_, err := refreshTokens(t.Context(), testOIDCOptions(), "", dependencies)
+	if !errors.Is(err, ErrRefreshTokenRejected) {
+		t.Errorf("refreshTokens() error = %v, want ErrRefreshTokenRejected", err)
+	}
+}
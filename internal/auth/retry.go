@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
+
+func (options OIDCOptions) retryPolicy() httpclient.RetryPolicy {
+	return httpclient.RetryPolicy{MaxAttempts: options.MaxAttempts, OnRetry: options.OnRetry}
+}
+
+// isTransientOIDCError reports whether a failed OIDC request may succeed when
+// repeated: network failures, rate limiting and provider server errors.
+func isTransientOIDCError(err error) bool {
+	var statusError *oidcStatusError
+	if errors.As(err, &statusError) {
+		return httpclient.IsTransientStatus(statusError.statusCode)
+	}
+	return httpclient.IsTransientNetworkError(err)
+}
+
+// discoverEndpointsWithRetry retries OIDC discovery, a read-only request, on
+// transient failures within options.MaxAttempts.
+func discoverEndpointsWithRetry(ctx context.Context, client *http.Client, options OIDCOptions, discover func(context.Context, *http.Client, string) (oidcEndpoints, error)) (oidcEndpoints, error) {
+	var endpoints oidcEndpoints
+	err := retryTransientOIDC(ctx, options, "OIDC discovery", func() error {
+		var err error
+		endpoints, err = discover(ctx, client, options.ProviderURL)
+		return err
+	})
+	return endpoints, err
+}
+
+// retryTransientOIDC repeats an idempotent OIDC request on transient failures
+// within options.MaxAttempts.
+func retryTransientOIDC(ctx context.Context, options OIDCOptions, operation string, attempt func() error) error {
+	return httpclient.Retry(ctx, options.retryPolicy(), operation, attempt, isTransientOIDCError)
+}
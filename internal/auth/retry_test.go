@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
+
+func TestIsTransientOIDCError(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "bad gateway", err: oidcHTTPStatusError("OIDC discovery", http.StatusBadGateway, nil, "https://oidc.example.com"), want: true},
+		{name: "rate limited", err: fmt.Errorf("token: %w", oidcHTTPStatusError("token request", http.StatusTooManyRequests, nil, "https://oidc.example.com")), want: true},
+		{name: "not found", err: oidcHTTPStatusError("OIDC discovery", http.StatusNotFound, nil, "https://oidc.example.com")},
+		{name: "invalid grant", err: oidcHTTPStatusError("token request", http.StatusBadRequest, []byte(`{"error":"invalid_grant"}`), "https://oidc.example.com")},
+		{name: "connection refused", err: fmt.Errorf("OIDC discovery request failed: %w", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}), want: true},
+		{name: "issuer mismatch", err: errors.New("OIDC discovery issuer mismatch")},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isTransientOIDCError(test.err); got != test.want {
+				t.Errorf("isTransientOIDCError(%v) = %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverEndpointsWithRetry(t *testing.T) {
+	unavailable := oidcHTTPStatusError("OIDC discovery", http.StatusServiceUnavailable, nil, "https://oidc.example.com")
+	notFound := oidcHTTPStatusError("OIDC discovery", http.StatusNotFound, nil, "https://oidc.example.com")
+
+	for _, test := range []struct {
+		name         string
+		maxAttempts  int
+		results      []error
+		wantAttempts int
+		wantErr      error
+	}{
+		{name: "recovers", maxAttempts: 3, results: []error{unavailable, nil}, wantAttempts: 2},
+		{name: "retries disabled", maxAttempts: 1, results: []error{unavailable}, wantAttempts: 1, wantErr: unavailable},
+		{name: "exhausted", maxAttempts: 2, results: []error{unavailable, unavailable}, wantAttempts: 2, wantErr: unavailable},
+		{name: "permanent failure", maxAttempts: 3, results: []error{notFound}, wantAttempts: 1, wantErr: notFound},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var retries []httpclient.RetryAttempt
+			options := testOIDCOptions()
+			options.MaxAttempts = test.maxAttempts
+			options.OnRetry = func(attempt httpclient.RetryAttempt) { retries = append(retries, attempt) }
+			attempts := 0
+			discover := func(_ context.Context, _ *http.Client, providerURL string) (oidcEndpoints, error) {
+				attempts++
+				if providerURL != options.ProviderURL {
+					t.Errorf("provider URL = %q, want %q", providerURL, options.ProviderURL)
+				}
+				if err := test.results[attempts-1]; err != nil {
+					return oidcEndpoints{}, err
+				}
+				return oidcEndpoints{token: "https://oidc.example.com/token"}, nil
+			}
+
+			endpoints, err := discoverEndpointsWithRetry(t.Context(), nil, options, discover)
+
+			if !errors.Is(err, test.wantErr) || (test.wantErr == nil && err != nil) {
+				t.Fatalf("discoverEndpointsWithRetry() error = %v, want %v", err, test.wantErr)
+			}
+			if err == nil && endpoints.token == "" {
+				t.Error("discoverEndpointsWithRetry() returned no endpoints")
+			}
+			if attempts != test.wantAttempts || len(retries) != test.wantAttempts-1 {
+				t.Errorf("attempts, retries = %d %d, want %d %d", attempts, len(retries), test.wantAttempts, test.wantAttempts-1)
+			}
+			for _, retry := range retries {
+				if retry.Operation != "OIDC discovery" || retry.MaxAttempts != test.maxAttempts {
+					t.Errorf("retry = %+v", retry)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthenticateDeviceFlowRetriesTransientPollingFailures(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		maxAttempts int
+		wantRetries int
+		wantErr     string
+	}{
+		{name: "recovers", maxAttempts: 3, wantRetries: 2},
+		{name: "exhausted", maxAttempts: 2, wantRetries: 1, wantErr: "token request failed with status 502: Bad Gateway"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			client := newDeviceFlowHTTPClient(
+				testDeviceHTTPResponse{status: http.StatusOK, body: validDeviceResponse},
+				testDeviceHTTPResponse{err: syscall.ECONNRESET},
+				testDeviceHTTPResponse{status: http.StatusBadGateway},
+				testDeviceHTTPResponse{status: http.StatusOK, body: `{"access_token":"test-access-token"}`},
+			)
+			dependencies, clock, _ := newTestDeviceFlowDependencies(io.Discard, client)
+			var retries []httpclient.RetryAttempt
+			options := testOIDCOptions()
+			options.MaxAttempts = test.maxAttempts
+			options.OnRetry = func(attempt httpclient.RetryAttempt) { retries = append(retries, attempt) }
+
+			token, err := authenticateDeviceFlow(t.Context(), options, dependencies)
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("authenticateDeviceFlow() error = %v, want containing %q", err, test.wantErr)
+				}
+			} else if err != nil || token.AccessToken != "test-access-token" {
+				t.Fatalf("authenticateDeviceFlow() = %q, %v, want test-access-token", token.AccessToken, err)
+			}
+			if len(retries) != test.wantRetries {
+				t.Fatalf("retries = %+v, want %d", retries, test.wantRetries)
+			}
+			for index, retry := range retries {
+				if retry.Operation != "Device token polling" || retry.Attempt != index+1 || retry.Delay != clock.sleeps[0] {
+					t.Errorf("retry %d = %+v", index, retry)
+				}
+			}
+		})
+	}
+}
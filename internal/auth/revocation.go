@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+var (
+	// ErrRevocationUnsupported indicates that the provider does not advertise
+	// a revocation_endpoint. Callers can still discard their local copy.
+	ErrRevocationUnsupported = errors.New("OIDC discovery response has no revocation_endpoint")
+	// ErrEndSessionUnsupported indicates that the provider does not advertise
+	// an end_session_endpoint.
+	ErrEndSessionUnsupported = errors.New("OIDC discovery response has no end_session_endpoint")
+)
+
+// RevokeToken invalidates a token at the provider's discovered
+// revocation_endpoint (RFC 7009). tokenTypeHint is "refresh_token" or
+// "access_token" and may be empty. Providers answer 200 for tokens they no
+// longer know, so revoking an expired token succeeds.
+func RevokeToken(ctx context.Context, options OIDCOptions, token, tokenTypeHint string) error {
+	return revokeToken(ctx, options, token, tokenTypeHint, newTokenEndpointDependencies())
+}
+
+func revokeToken(ctx context.Context, options OIDCOptions, token, tokenTypeHint string, dependencies tokenEndpointDependencies) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	client := dependencies.newHTTPClient(options.httpClientOptions())
+	endpoints, err := discoverEndpointsWithRetry(ctx, client, options, dependencies.discoverEndpoints)
+	if err != nil {
+		return err
+	}
+	if endpoints.revocation == "" {
+		return ErrRevocationUnsupported
+	}
+
+	data := url.Values{}
+	data.Set("token", token)
+	if tokenTypeHint != "" {
+		data.Set("token_type_hint", tokenTypeHint)
+	}
+
+	// Revoking a token twice has the same effect as revoking it once, so
+	// transient failures are safe to repeat.
+	return retryTransientOIDC(ctx, options, "Token revocation", func() error {
+		response, err := postTokenRequest(ctx, client, endpoints.revocation, endpoints.token, data, options)
+		if err != nil {
+			return fmt.Errorf("token revocation request failed: %w", err)
+		}
+		body, err := readOIDCResponseAndClose(response)
+		if err != nil {
+			return fmt.Errorf("failed to read token revocation response: %w", err)
+		}
+		if response.StatusCode != http.StatusOK {
+			return oidcHTTPStatusError("token revocation", response.StatusCode, body, options.ProviderURL)
+		}
+		return nil
+	})
+}
+
+// EndSessionURL returns the provider's end_session_endpoint for
+// RP-initiated logout (OpenID Connect RP-Initiated Logout 1.0). The URL
+// carries client_id and, when idTokenHint is set, id_token_hint so the
+// provider can identify the session without prompting.
+func EndSessionURL(ctx context.Context, options OIDCOptions, idTokenHint string) (string, error) {
+	return endSessionURL(ctx, options, idTokenHint, newTokenEndpointDependencies())
+}
+
+func endSessionURL(ctx context.Context, options OIDCOptions, idTokenHint string, dependencies tokenEndpointDependencies) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	client := dependencies.newHTTPClient(options.httpClientOptions())
+	endpoints, err := discoverEndpointsWithRetry(ctx, client, options, dependencies.discoverEndpoints)
+	if err != nil {
+		return "", err
+	}
+	if endpoints.endSession == "" {
+		return "", ErrEndSessionUnsupported
+	}
+
+	endSession, err := url.Parse(endpoints.endSession)
+	if err != nil {
+		return "", fmt.Errorf("invalid end_session_endpoint %q: %w", endpoints.endSession, err)
+	}
+	query := endSession.Query()
+	query.Set("client_id", options.ClientID)
+	if idTokenHint != "" {
+		query.Set("id_token_hint", idTokenHint)
+	}
+	endSession.RawQuery = query.Encode()
+	return endSession.String(), nil
+}
+
+// OpenBrowser opens url in the user's default browser without waiting for it.
+func OpenBrowser(url string) error {
+	return openBrowser(url)
+}
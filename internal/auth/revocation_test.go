@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
+
+func testRevocationDependencies(client *http.Client, endpoints oidcEndpoints) tokenEndpointDependencies {
+	return tokenEndpointDependencies{
+		newHTTPClient: func(httpclient.Options) *http.Client { return client },
+		discoverEndpoints: func(context.Context, *http.Client, string) (oidcEndpoints, error) {
+			return endpoints, nil
+		},
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		hint        string
+		status      int
+		body        string
+		wantContain string
+	}{
+		{name: "refresh token", hint: "refresh_token", status: http.StatusOK},
+		{name: "without hint", status: http.StatusOK},
+		{
+			name:        "unsupported token type",
+			hint:        "access_token",
+			status:      http.StatusBadRequest,
+			body:        `{"error":"unsupported_token_type"}`,
+			wantContain: "token revocation failed with status 400: authentication error [unsupported_token_type]",
+		},
+		{name: "server error", hint: "refresh_token", status: http.StatusServiceUnavailable, wantContain: "token revocation failed with status 503"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			client := &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+				if request.URL.String() != "https://oidc.example.com/revoke" {
+					t.Errorf("revocation endpoint = %q", request.URL)
+				}
+				if err := request.ParseForm(); err != nil {
+					t.Fatalf("ParseForm() error = %v", err)
+				}
+				want := url.Values{"token": {"stored-refresh-token"}, "client_id": {"test-client"}}
+				if test.hint != "" {
+					want.Set("token_type_hint", test.hint)
+				}
+				if got := request.PostForm.Encode(); got != want.Encode() {
+					t.Errorf("revocation form = %q, want %q", got, want.Encode())
+				}
+				return &http.Response{
+					StatusCode: test.status,
+					Header:     make(http.Header),
+					Body:       io.NopCloser(strings.NewReader(test.body)),
+				}, nil
+			})}
+			dependencies := testRevocationDependencies(client, oidcEndpoints{revocation: "https://oidc.example.com/revoke"})
+
+			err := revokeToken(t.Context(), testOIDCOptions(), "stored-refresh-token", test.hint, dependencies)
+			if test.wantContain == "" {
+				if err != nil {
+					t.Fatalf("revokeToken() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("revokeToken() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
+
+func TestRevokeTokenWithoutRevocationEndpoint(t *testing.T) {
+	client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		t.Fatal("revokeToken() sent a request without a revocation endpoint")
+		return nil, nil
+	})}
+
+	err := revokeToken(t.Context(), testOIDCOptions(), "token", "refresh_token", testRevocationDependencies(client, oidcEndpoints{}))
+	if !errors.Is(err, ErrRevocationUnsupported) {
+		t.Errorf("revokeToken() error = %v, want %v", err, ErrRevocationUnsupported)
+	}
+}
+
+func TestEndSessionURL(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		endpoints oidcEndpoints
+		hint      string
+		want      string
+		wantErr   error
+	}{
+		{
+			name:      "with ID token hint",
+			endpoints: oidcEndpoints{endSession: "https://oidc.example.com/logout"},
+			hint:      "id.token.value",
+			want:      "https://oidc.example.com/logout?client_id=test-client&id_token_hint=id.token.value",
+		},
+		{
+			name:      "keeps endpoint query",
+			endpoints: oidcEndpoints{endSession: "https://oidc.example.com/logout?tenant=storage"},
+			want:      "https://oidc.example.com/logout?client_id=test-client&tenant=storage",
+		},
+		{name: "unsupported", wantErr: ErrEndSessionUnsupported},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := endSessionURL(t.Context(), testOIDCOptions(), test.hint, testRevocationDependencies(nil, test.endpoints))
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("endSessionURL() error = %v, want %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("endSessionURL() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
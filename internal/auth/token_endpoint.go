@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
+
+// tokenEndpointDependencies serves grants that talk only to the discovered
+// token endpoint, without user interaction.
+type tokenEndpointDependencies struct {
+	newHTTPClient     func(httpclient.Options) *http.Client
+	discoverEndpoints func(context.Context, *http.Client, string) (oidcEndpoints, error)
+}
+
+func newTokenEndpointDependencies() tokenEndpointDependencies {
+	return tokenEndpointDependencies{
+		newHTTPClient:     NewHTTPClient,
+		discoverEndpoints: discoverOIDCEndpoints,
+	}
+}
+
+// postTokenRequest posts a request to endpoint, the token endpoint or another
+// endpoint that authenticates clients the same way, and authenticates the
+// client with the configured method. Public clients only identify themselves
+// with client_id. Every call signs a new private_key_jwt assertion because
+// providers reject reused jti values; its audience is tokenEndpoint, the
+// discovered token_endpoint, whichever endpoint receives it (RFC 7523
+// section 3). Providers that advertise no token_endpoint get endpoint instead.
+func postTokenRequest(ctx context.Context, client *http.Client, endpoint, tokenEndpoint string, data url.Values, options OIDCOptions) (*http.Response, error) {
+	form := url.Values{}
+	for key, values := range data {
+		form[key] = append([]string(nil), values...)
+	}
+	form.Set("client_id", options.ClientID)
+
+	switch {
+	case options.ClientAuthMethod == config.ClientAuthPrivateKeyJWT:
+		audience := tokenEndpoint
+		if audience == "" {
+			audience = endpoint
+		}
+		assertion, err := newClientAssertion(options.ClientPrivateKey, options.ClientID, audience, time.Now(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		form.Set("client_assertion_type", clientAssertionType)
+		form.Set("client_assertion", assertion)
+	case options.ClientSecret == "":
+	case options.ClientAuthMethod == config.ClientAuthSecretPost:
+		form.Set("client_secret", options.ClientSecret)
+	default:
+		form.Del("client_id")
+		request, err := newOIDCFormRequest(ctx, endpoint, form)
+		if err != nil {
+			return nil, err
+		}
+		// RFC 6749 section 2.3.1 requires form-encoding both values before they
+		// are combined into the Basic credentials.
+		request.SetBasicAuth(url.QueryEscape(options.ClientID), url.QueryEscape(options.ClientSecret))
+		return client.Do(request)
+	}
+
+	return postOIDCForm(ctx, client, endpoint, form)
+}
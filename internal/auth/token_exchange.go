@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
+// tokenExchangeGrantType is the RFC 8693 token exchange grant.
+const tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+
+// TokenExchangeOptions describes the subject token and the token requested in
+// an RFC 8693 token exchange.
+type TokenExchangeOptions struct {
+	SubjectToken       string
+	SubjectTokenType   config.ExchangeTokenType
+	RequestedTokenType config.ExchangeTokenType
+	Audience           string
+}
+
+// ExchangeToken exchanges a subject token issued elsewhere for a token from
+// the configured provider. The issued token is returned in AccessToken
+// regardless of its type, as RFC 8693 section 2.2.1 specifies.
+func ExchangeToken(ctx context.Context, options OIDCOptions, exchange TokenExchangeOptions) (TokenResponse, error) {
+	return exchangeToken(ctx, options, exchange, newTokenEndpointDependencies())
+}
+
+func exchangeToken(ctx context.Context, options OIDCOptions, exchange TokenExchangeOptions, dependencies tokenEndpointDependencies) (TokenResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return TokenResponse{}, err
+	}
+	if exchange.SubjectToken == "" {
+		return TokenResponse{}, fmt.Errorf("subject token is empty")
+	}
+
+	client := dependencies.newHTTPClient(options.httpClientOptions())
+	endpoints, err := discoverEndpointsWithRetry(ctx, client, options, dependencies.discoverEndpoints)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	if endpoints.token == "" {
+		return TokenResponse{}, fmt.Errorf("OIDC discovery response is missing token_endpoint required by token exchange")
+	}
+
+	tokenData := url.Values{}
+	tokenData.Set("grant_type", tokenExchangeGrantType)
+	tokenData.Set("subject_token", exchange.SubjectToken)
+	tokenData.Set("subject_token_type", string(exchange.SubjectTokenType))
+	if exchange.RequestedTokenType != "" {
+		tokenData.Set("requested_token_type", string(exchange.RequestedTokenType))
+	}
+	if exchange.Audience != "" {
+		tokenData.Set("audience", exchange.Audience)
+	}
+	if options.Scope != "" {
+		tokenData.Set("scope", options.Scope)
+	}
+
+	response, err := postTokenRequest(ctx, client, endpoints.token, endpoints.token, tokenData, options)
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("token exchange request failed: %w", err)
+	}
+	body, err := readOIDCResponseAndClose(response)
+	if err != nil {
+		return TokenResponse{}, fmt.Errorf("failed to read token exchange response: %w", err)
+	}
+
+	tokenResponse, err := decodeOIDCTokenResponse("token exchange request", response.StatusCode, body, options.ProviderURL)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	if response.StatusCode != http.StatusOK && tokenResponse.Error == "" {
+		return TokenResponse{}, oidcHTTPStatusError("token exchange request", response.StatusCode, body, options.ProviderURL)
+	}
+
+	tokens, err := tokensFromOIDCResponse(tokenResponse, options.ProviderURL)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	if exchange.RequestedTokenType != "" && tokens.IssuedTokenType != "" && tokens.IssuedTokenType != string(exchange.RequestedTokenType) {
+		return TokenResponse{}, fmt.Errorf("token exchange issued %s, requested %s", tokens.IssuedTokenType, exchange.RequestedTokenType)
+	}
+	return tokens, nil
+}
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
+func TestExchangeToken(t *testing.T) {
+	client := &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		if err := request.ParseForm(); err != nil {
+			t.Fatalf("ParseForm() error = %v", err)
+		}
+		want := map[string]string{
+			"grant_type":           "urn:ietf:params:oauth:grant-type:token-exchange",
+			"client_id":            "test-client",
+			"subject_token":        "github.jwt.value",
+			"subject_token_type":   "urn:ietf:params:oauth:token-type:jwt",
+			"requested_token_type": "urn:ietf:params:oauth:token-type:access_token",
+			"audience":             "radosgw",
+			"scope":                "openid",
+		}
+		for name, value := range want {
+			if got := request.Form.Get(name); got != value {
+				t.Errorf("%s = %q, want %q", name, got, value)
+			}
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body: io.NopCloser(strings.NewReader(`{"access_token":"keycloak.jwt.value","token_type":"Bearer",` +
+				`"issued_token_type":"urn:ietf:params:oauth:token-type:access_token"}`)),
+		}, nil
+	})}
+
+	tokens, err := exchangeToken(t.Context(), testOIDCOptions(), TokenExchangeOptions{
+		SubjectToken:       "github.jwt.value",
+		SubjectTokenType:   config.ExchangeTokenTypeJWT,
+		RequestedTokenType: config.ExchangeTokenTypeAccessToken,
+		Audience:           "radosgw",
+	}, testTokenEndpointDependencies(client))
+	if err != nil {
+		t.Fatalf("exchangeToken() error = %v", err)
+	}
+	if tokens.AccessToken != "keycloak.jwt.value" {
+		t.Errorf("access token = %q, want keycloak.jwt.value", tokens.AccessToken)
+	}
+}
+
+func TestExchangeTokenErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		subjectToken string
+		status       int
+		body         string
+		wantContain  string
+	}{
+		{
+			name:        "empty subject token",
+			wantContain: "subject token is empty",
+		},
+		{
+			name:         "rejected subject token",
+			subjectToken: "expired.jwt.value",
+			status:       http.StatusBadRequest,
+			body:         `{"error":"invalid_request","error_description":"Invalid token"}`,
+			wantContain:  "Invalid token",
+		},
+		{
+			name:         "server error",
+			subjectToken: "github.jwt.value",
+			status:       http.StatusBadGateway,
+			body:         `upstream unavailable`,
+			wantContain:  "token exchange request failed with status 502: upstream unavailable",
+		},
+		{
+			name:         "unexpected issued token type",
+			subjectToken: "github.jwt.value",
+			status:       http.StatusOK,
+			body:         `{"access_token":"refresh-token","issued_token_type":"urn:ietf:params:oauth:token-type:refresh_token"}`,
+			wantContain:  "token exchange issued urn:ietf:params:oauth:token-type:refresh_token, requested urn:ietf:params:oauth:token-type:access_token",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: test.status,
+					Header:     make(http.Header),
+					Body:       io.NopCloser(strings.NewReader(test.body)),
+				}, nil
+			})}
+
+			_, err := exchangeToken(t.Context(), testOIDCOptions(), TokenExchangeOptions{
+				SubjectToken:       test.subjectToken,
+				SubjectTokenType:   config.ExchangeTokenTypeJWT,
+				RequestedTokenType: config.ExchangeTokenTypeAccessToken,
+			}, testTokenEndpointDependencies(client))
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("exchangeToken() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+	"github.com/fitbeard/radosgw-assume/pkg/duration"
+)
+
+// tokenClockSkew is the difference between the local and the provider's clock
+// tolerated when checking exp and nbf, so a token issued by a provider whose
+// clock runs slightly ahead is not rejected as not yet valid.
+const tokenClockSkew = 60 * time.Second
+
+// ErrTokenVerificationUnavailable indicates that the token could not be
+// checked locally, because the provider's keys could not be fetched or the
+// token is signed with an algorithm or key this verifier does not support.
+// It says nothing about whether STS accepts the token.
+var ErrTokenVerificationUnavailable = errors.New("local token verification unavailable")
+
+// TokenVerificationOptions lists the audiences a web identity token may be
+// issued for. The token is also accepted when its azp claim names the client,
+// matching how RadosGW falls back to azp for access tokens.
+type TokenVerificationOptions struct {
+	Audiences []string
+}
+
+type tokenVerificationDependencies struct {
+	newHTTPClient     func(httpclient.Options) *http.Client
+	discoverEndpoints func(context.Context, *http.Client, string) (oidcEndpoints, error)
+	now               func() time.Time
+}
+
+func newTokenVerificationDependencies() tokenVerificationDependencies {
+	return tokenVerificationDependencies{
+		newHTTPClient:     NewHTTPClient,
+		discoverEndpoints: discoverOIDCEndpoints,
+		now:               time.Now,
+	}
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+// VerifyWebIdentityToken checks a provider-issued JWT against the keys
+// published at the provider's jwks_uri and validates its iss, exp, nbf, aud
+// and azp claims, so problems are reported precisely before STS rejects the
+// token.
+func VerifyWebIdentityToken(ctx context.Context, options OIDCOptions, token string, verification TokenVerificationOptions) error {
+	return verifyWebIdentityToken(ctx, options, token, verification, newTokenVerificationDependencies())
+}
+
+func verifyWebIdentityToken(ctx context.Context, options OIDCOptions, token string, verification TokenVerificationOptions, dependencies tokenVerificationDependencies) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := splitJWT(token); err != nil {
+		return err
+	}
+
+	client := dependencies.newHTTPClient(options.httpClientOptions())
+	endpoints, err := discoverEndpointsWithRetry(ctx, client, options, dependencies.discoverEndpoints)
+	if err != nil {
+		return verificationUnavailable(err)
+	}
+	if endpoints.jwks == "" {
+		return verificationUnavailable(fmt.Errorf("OIDC discovery response is missing jwks_uri required by token verification"))
+	}
+	claims, err := verifyJWT(ctx, client, endpoints.jwks, options.ProviderURL, token)
+	if err != nil {
+		return err
+	}
+
+	return validateJWTClaims(claims, options, verification, dependencies.now())
+}
+
+func verificationUnavailable(err error) error {
+	return fmt.Errorf("%w: %w", ErrTokenVerificationUnavailable, err)
+}
+
+// verifyJWT checks the token's signature against the keys published at
+// jwksURI and returns its claims. Claims are not validated.
+func verifyJWT(ctx context.Context, client *http.Client, jwksURI, providerURL, token string) (jwtClaims, error) {
+	parts, err := splitJWT(token)
+	if err != nil {
+		return jwtClaims{}, err
+	}
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], "header", &header); err != nil {
+		return jwtClaims{}, err
+	}
+	var claims jwtClaims
+	if err := decodeJWTSegment(parts[1], "payload", &claims); err != nil {
+		return jwtClaims{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return jwtClaims{}, fmt.Errorf("decode JWT signature: %w", err)
+	}
+
+	keys, err := fetchJSONWebKeys(ctx, client, jwksURI, providerURL)
+	if err != nil {
+		return jwtClaims{}, verificationUnavailable(err)
+	}
+	if err := verifyJWTSignature(header, parts[0]+"."+parts[1], signature, keys, jwksURI); err != nil {
+		return jwtClaims{}, err
+	}
+	return claims, nil
+}
+
+func fetchJSONWebKeys(ctx context.Context, client *http.Client, jwksURI, providerURL string) ([]jsonWebKey, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("JWKS request failed: %w", err)
+	}
+	body, err := readOIDCResponseAndClose(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS response: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, oidcHTTPStatusError("JWKS request", response.StatusCode, body, providerURL)
+	}
+
+	var keySet jsonWebKeySet
+	if err := json.Unmarshal(body, &keySet); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS response: %w", err)
+	}
+	return keySet.Keys, nil
+}
+
+func verifyJWTSignature(header jwtHeader, signingInput string, signature []byte, keys []jsonWebKey, jwksURI string) error {
+	keyType, hash, err := jwtSigningAlgorithm(header.Algorithm)
+	if err != nil {
+		return verificationUnavailable(err)
+	}
+
+	// A key that cannot be used is skipped rather than failing verification,
+	// so one malformed or unsupported entry does not hide a valid key with
+	// the same key ID.
+	candidates := 0
+	var keyErr error
+	for _, key := range keys {
+		if key.KeyType != keyType || key.Use == "enc" || (header.KeyID != "" && key.KeyID != header.KeyID) {
+			continue
+		}
+		if key.Algorithm != "" && key.Algorithm != header.Algorithm {
+			continue
+		}
+		publicKey, err := key.publicKey()
+		if err != nil {
+			if keyErr == nil {
+				keyErr = fmt.Errorf("JWKS key %q: %w", key.KeyID, err)
+			}
+			continue
+		}
+		candidates++
+		if verifySignature(header.Algorithm, publicKey, hash, signingInput, signature) {
+			return nil
+		}
+	}
+	if candidates == 0 && keyErr != nil {
+		return verificationUnavailable(keyErr)
+	}
+	if candidates == 0 {
+		return fmt.Errorf("no %s key with key ID %q found at %s", header.Algorithm, header.KeyID, jwksURI)
+	}
+	return fmt.Errorf("token signature does not verify with the %s key from %s", header.Algorithm, jwksURI)
+}
+
+func jwtSigningAlgorithm(algorithm string) (string, crypto.Hash, error) {
+	switch algorithm {
+	case "RS256", "PS256":
+		return "RSA", crypto.SHA256, nil
+	case "RS384", "PS384":
+		return "RSA", crypto.SHA384, nil
+	case "RS512", "PS512":
+		return "RSA", crypto.SHA512, nil
+	case "ES256":
+		return "EC", crypto.SHA256, nil
+	case "ES384":
+		return "EC", crypto.SHA384, nil
+	case "ES512":
+		return "EC", crypto.SHA512, nil
+	default:
+		return "", 0, fmt.Errorf("unsupported token signing algorithm %q", algorithm)
+	}
+}
+
+func verifySignature(algorithm string, publicKey crypto.PublicKey, hash crypto.Hash, signingInput string, signature []byte) bool {
+	digest := hash.New()
+	digest.Write([]byte(signingInput))
+	sum := digest.Sum(nil)
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(algorithm, "PS") {
+			return rsa.VerifyPSS(key, hash, sum, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+		return rsa.VerifyPKCS1v15(key, hash, sum, signature) == nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size || key.Curve.Params().BitSize != ecdsaHashBits(hash) {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(key, sum, r, s)
+	default:
+		return false
+	}
+}
+
+// ecdsaHashBits returns the curve size each ES algorithm is defined for.
+func ecdsaHashBits(hash crypto.Hash) int {
+	switch hash {
+	case crypto.SHA256:
+		return 256
+	case crypto.SHA384:
+		return 384
+	default:
+		return 521
+	}
+}
+
+func (key jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch key.KeyType {
+	case "RSA":
+		modulus, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil || len(modulus) == 0 {
+			return nil, fmt.Errorf("invalid RSA modulus")
+		}
+		exponent, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil || len(exponent) == 0 || len(exponent) > 4 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(new(big.Int).SetBytes(exponent).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch key.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", key.Curve)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(key.X)
+		y, errY := base64.RawURLEncoding.DecodeString(key.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("invalid EC coordinates")
+		}
+		publicKey := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, fmt.Errorf("EC point is not on curve %s", key.Curve)
+		}
+		return publicKey, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", key.KeyType)
+	}
+}
+
+func validateJWTClaims(claims jwtClaims, options OIDCOptions, verification TokenVerificationOptions, now time.Time) error {
+	issuer, _, err := normalizeOIDCIssuer(options.ProviderURL)
+	if err != nil {
+		return err
+	}
+	if claims.Issuer != issuer {
+		return fmt.Errorf("token issuer %q does not match radosgw_oidc_provider %q", claims.Issuer, issuer)
+	}
+
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("token has no exp claim")
+	}
+	expiresAt, err := numericDate(claims.ExpiresAt, "exp")
+	if err != nil {
+		return err
+	}
+	if !now.Before(expiresAt.Add(tokenClockSkew)) {
+		return fmt.Errorf("token expired %s ago", duration.Format(now.Sub(expiresAt)))
+	}
+	if claims.NotBefore != nil {
+		notBefore, err := numericDate(claims.NotBefore, "nbf")
+		if err != nil {
+			return err
+		}
+		if now.Add(tokenClockSkew).Before(notBefore) {
+			return fmt.Errorf("token not valid for another %s", duration.Format(notBefore.Sub(now)))
+		}
+	}
+
+	if claims.AuthorizedParty != "" && claims.AuthorizedParty != options.ClientID {
+		return fmt.Errorf("authorized party %q does not match radosgw_oidc_client_id %q", claims.AuthorizedParty, options.ClientID)
+	}
+	if claims.AuthorizedParty != "" || len(verification.Audiences) == 0 {
+		return nil
+	}
+	if len(claims.Audience) == 0 {
+		return fmt.Errorf("token has no aud or azp claim; want one of [%s]", strings.Join(verification.Audiences, ", "))
+	}
+	if !slices.ContainsFunc(claims.Audience, func(audience string) bool {
+		return slices.Contains(verification.Audiences, audience)
+	}) {
+		return fmt.Errorf("audience %s not in [%s]", strings.Join(claims.Audience, ", "), strings.Join(verification.Audiences, ", "))
+	}
+	return nil
+}
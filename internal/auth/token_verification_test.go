@@ -0,0 +1,318 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
+
+const testJWKSURI = "https://oidc.example.com/keys"
+
+func TestVerifyWebIdentityToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	staleKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	validClaims := func() map[string]any {
+		return map[string]any{
+			"iss": "https://oidc.example.com",
+			"aud": []string{"test-client", "account"},
+			"exp": now.Add(5 * time.Minute).Unix(),
+			"nbf": now.Add(-time.Minute).Unix(),
+		}
+	}
+	withClaims := func(changes map[string]any) map[string]any {
+		claims := validClaims()
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+				continue
+			}
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name            string
+		token           string
+		audiences       []string
+		wantContain     string
+		wantUnavailable bool
+	}{
+		{name: "RS256", token: signTestJWT(t, rsaKey, "RS256", "rsa-key", validClaims())},
+		{name: "PS256", token: signTestJWT(t, rsaKey, "PS256", "rsa-key", validClaims())},
+		{name: "ES256", token: signTestJWT(t, ecKey, "ES256", "ec-key", validClaims())},
+		{
+			name:      "configured audience",
+			token:     signTestJWT(t, rsaKey, "RS256", "rsa-key", withClaims(map[string]any{"aud": "storage"})),
+			audiences: []string{"test-client", "storage"},
+		},
+		{
+			name:  "azp names the client",
+			token: signTestJWT(t, rsaKey, "RS256", "rsa-key", withClaims(map[string]any{"aud": "account", "azp": "test-client"})),
+		},
+		{
+			name:        "unknown key ID",
+			token:       signTestJWT(t, rsaKey, "RS256", "rotated-key", validClaims()),
+			wantContain: `no RS256 key with key ID "rotated-key" found at ` + testJWKSURI,
+		},
+		{
+			name:        "wrong signing key",
+			token:       signTestJWT(t, otherKey, "RS256", "rsa-key", validClaims()),
+			wantContain: "token signature does not verify with the RS256 key from " + testJWKSURI,
+		},
+		{
+			name:            "unsupported algorithm",
+			token:           unsignedTestJWT(t, "HS256", validClaims()),
+			wantContain:     `unsupported token signing algorithm "HS256"`,
+			wantUnavailable: true,
+		},
+		{
+			name:            "EdDSA",
+			token:           unsignedTestJWT(t, "EdDSA", validClaims()),
+			wantContain:     `unsupported token signing algorithm "EdDSA"`,
+			wantUnavailable: true,
+		},
+		{
+			name:        "issuer mismatch",
+			token:       signTestJWT(t, rsaKey, "RS256", "rsa-key", withClaims(map[string]any{"iss": "https://other.example.com"})),
+			wantContain: `token issuer "https://other.example.com" does not match radosgw_oidc_provider "https://oidc.example.com"`,
+		},
+		{
+			name:        "expired",
+			token:       signTestJWT(t, rsaKey, "RS256", "rsa-key", withClaims(map[string]any{"exp": now.Add(-3 * time.Minute).Unix()})),
+			wantContain: "token expired 3m ago",
+		},
+		{
+			name:  "expired within clock skew",
+			token: signTestJWT(t, rsaKey, "RS256", "rsa-key", withClaims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()})),
+		},
+		{
+			name:        "missing exp",
+			token:       signTestJWT(t, rsaKey, "RS256", "rsa-key", withClaims(map[string]any{"exp": nil})),
+			wantContain: "token has no exp claim",
+		},
+		{
+			name:        "not yet valid",
+			token:       signTestJWT(t, rsaKey, "RS256", "rsa-key", withClaims(map[string]any{"nbf": now.Add(90 * time.Second).Unix()})),
+			wantContain: "token not valid for another 1m 30s",
+		},
+		{
+			name:  "issued by a provider clock ahead",
+			token: signTestJWT(t, rsaKey, "RS256", "rsa-key", withClaims(map[string]any{"nbf": now.Add(30 * time.Second).Unix()})),
+		},
+		{
+			name:        "audience mismatch",
+			token:       signTestJWT(t, rsaKey, "RS256", "rsa-key", withClaims(map[string]any{"aud": "account"})),
+			wantContain: "audience account not in [test-client]",
+		},
+		{
+			name:        "missing audience",
+			token:       signTestJWT(t, rsaKey, "RS256", "rsa-key", withClaims(map[string]any{"aud": nil})),
+			wantContain: "token has no aud or azp claim; want one of [test-client]",
+		},
+		{
+			name:        "authorized party mismatch",
+			token:       signTestJWT(t, rsaKey, "RS256", "rsa-key", withClaims(map[string]any{"azp": "other-client"})),
+			wantContain: `authorized party "other-client" does not match radosgw_oidc_client_id "test-client"`,
+		},
+		{name: "not a JWT", token: "opaque-token", wantContain: "token is not a JWT"},
+	}
+
+	client := &http.Client{Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		if got := request.URL.String(); got != testJWKSURI {
+			t.Errorf("JWKS URL = %q, want %q", got, testJWKSURI)
+		}
+		body, err := json.Marshal(jsonWebKeySet{Keys: []jsonWebKey{
+			{KeyType: "RSA", KeyID: "rsa-key", Use: "enc", N: "AQAB", E: "AQAB"},
+			// Unusable and non-matching keys with the same key ID are skipped.
+			{KeyType: "RSA", KeyID: "rsa-key", N: "", E: "AQAB"},
+			testRSAJSONWebKey("rsa-key", &rsaKey.PublicKey),
+			{KeyType: "EC", KeyID: "ec-key", Curve: "P-256", X: "AQ", Y: "AQ"},
+			testECJSONWebKey("ec-key", &staleKey.PublicKey),
+			testECJSONWebKey("ec-key", &ecKey.PublicKey),
+		}})
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(string(body)))}, nil
+	})}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			audiences := test.audiences
+			if audiences == nil {
+				audiences = []string{"test-client"}
+			}
+			options := testOIDCOptions()
+			options.ProviderURL = "https://oidc.example.com/"
+			err := verifyWebIdentityToken(t.Context(), options, test.token, TokenVerificationOptions{Audiences: audiences}, testTokenVerificationDependencies(client, testJWKSURI, now))
+			if test.wantContain == "" {
+				if err != nil {
+					t.Fatalf("verifyWebIdentityToken() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Fatalf("verifyWebIdentityToken() error = %v, want containing %q", err, test.wantContain)
+			}
+			if got := errors.Is(err, ErrTokenVerificationUnavailable); got != test.wantUnavailable {
+				t.Errorf("errors.Is(%v, ErrTokenVerificationUnavailable) = %v, want %v", err, got, test.wantUnavailable)
+			}
+		})
+	}
+}
+
+func TestVerifyWebIdentityTokenJWKSErrors(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	token := signTestJWT(t, key, "ES256", "ec-key", map[string]any{"iss": "https://oidc.example.com", "aud": "test-client", "exp": now.Add(time.Minute).Unix()})
+
+	tests := []struct {
+		name        string
+		jwksURI     string
+		status      int
+		body        string
+		wantContain string
+	}{
+		{name: "missing jwks_uri", wantContain: "missing jwks_uri required by token verification"},
+		{name: "HTTP error", jwksURI: testJWKSURI, status: http.StatusNotFound, body: "not found", wantContain: "JWKS request"},
+		{name: "malformed JSON", jwksURI: testJWKSURI, status: http.StatusOK, body: "{", wantContain: "failed to parse JWKS response"},
+		{
+			name:        "point not on curve",
+			jwksURI:     testJWKSURI,
+			status:      http.StatusOK,
+			body:        `{"keys":[{"kty":"EC","kid":"ec-key","crv":"P-256","x":"AQ","y":"AQ"}]}`,
+			wantContain: `JWKS key "ec-key": EC point is not on curve P-256`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: test.status, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(test.body))}, nil
+			})}
+			options := testOIDCOptions()
+			options.ProviderURL = "https://oidc.example.com"
+			err := verifyWebIdentityToken(t.Context(), options, token, TokenVerificationOptions{Audiences: []string{"test-client"}}, testTokenVerificationDependencies(client, test.jwksURI, now))
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Fatalf("verifyWebIdentityToken() error = %v, want containing %q", err, test.wantContain)
+			}
+			if !errors.Is(err, ErrTokenVerificationUnavailable) {
+				t.Errorf("verifyWebIdentityToken() error = %v, want ErrTokenVerificationUnavailable", err)
+			}
+		})
+	}
+}
+
+func testTokenVerificationDependencies(client *http.Client, jwksURI string, now time.Time) tokenVerificationDependencies {
+	return tokenVerificationDependencies{
+		newHTTPClient: func(httpclient.Options) *http.Client { return client },
+		discoverEndpoints: func(context.Context, *http.Client, string) (oidcEndpoints, error) {
+			return oidcEndpoints{token: "https://oidc.example.com/token", jwks: jwksURI}, nil
+		},
+		now: func() time.Time { return now },
+	}
+}
+
+func signTestJWT(t *testing.T, key crypto.Signer, algorithm, keyID string, claims map[string]any) string {
+	t.Helper()
+
+	signingInput := encodeTestJWTSegment(t, map[string]string{"alg": algorithm, "kid": keyID, "typ": "JWT"}) + "." + encodeTestJWTSegment(t, claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	var signature []byte
+	var err error
+	switch algorithm {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	case "PS256":
+		signature, err = rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES256":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		signature = make([]byte, 64)
+		if err == nil {
+			r.FillBytes(signature[:32])
+			s.FillBytes(signature[32:])
+		}
+	default:
+		t.Fatalf("unsupported test algorithm %s", algorithm)
+	}
+	if err != nil {
+		t.Fatalf("sign %s: %v", algorithm, err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func unsignedTestJWT(t *testing.T, algorithm string, claims map[string]any) string {
+	t.Helper()
+
+	return encodeTestJWTSegment(t, map[string]string{"alg": algorithm}) + "." + encodeTestJWTSegment(t, claims) + ".c2lnbmF0dXJl"
+}
+
+func encodeTestJWTSegment(t *testing.T, value any) string {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func testRSAJSONWebKey(keyID string, key *rsa.PublicKey) jsonWebKey {
+	return jsonWebKey{
+		KeyType: "RSA",
+		KeyID:   keyID,
+		Use:     "sig",
+		N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func testECJSONWebKey(keyID string, key *ecdsa.PublicKey) jsonWebKey {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	return jsonWebKey{
+		KeyType:   "EC",
+		KeyID:     keyID,
+		Algorithm: "ES256",
+		Curve:     "P-256",
+		X:         base64.RawURLEncoding.EncodeToString(x),
+		Y:         base64.RawURLEncoding.EncodeToString(y),
+	}
+}
@@ -1,5 +1,11 @@
 package auth
 
+import (
+	"fmt"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
 // DeviceAuthResponse represents the OIDC device authorization response
 type DeviceAuthResponse struct {
 	DeviceCode              string `json:"device_code"`
@@ -16,6 +22,29 @@ type TokenResponse struct {
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int    `json:"expires_in"`
 	RefreshToken string `json:"refresh_token,omitempty"`
-	Error        string `json:"error,omitempty"`
-	ErrorDesc    string `json:"error_description,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	// IssuedTokenType is set by RFC 8693 token exchange responses.
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+	Error           string `json:"error,omitempty"`
+	ErrorDesc       string `json:"error_description,omitempty"`
+}
+
+// WebIdentityToken returns the token selected by radosgw_oidc_token_type
+// exactly as issued by the provider. An unset token type selects the access
+// token.
+func (tokenResponse TokenResponse) WebIdentityToken(tokenType config.TokenType) (string, error) {
+	switch tokenType {
+	case "", config.TokenTypeAccessToken:
+		if tokenResponse.AccessToken == "" {
+			return "", fmt.Errorf("no access token received")
+		}
+		return tokenResponse.AccessToken, nil
+	case config.TokenTypeIDToken:
+		if tokenResponse.IDToken == "" {
+			return "", fmt.Errorf("no ID token received; include 'openid' in radosgw_oidc_scope to use radosgw_oidc_token_type = %s", config.TokenTypeIDToken)
+		}
+		return tokenResponse.IDToken, nil
+	default:
+		return "", tokenType.Validate()
+	}
 }
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
+func TestTokenResponseWebIdentityToken(t *testing.T) {
+	tokens := TokenResponse{AccessToken: "access.jwt.value", IDToken: "id.jwt.value"}
+
+	for _, test := range []struct {
+		name        string
+		tokens      TokenResponse
+		tokenType   config.TokenType
+		want        string
+		wantContain string
+	}{
+		{name: "default", tokens: tokens, want: "access.jwt.value"},
+		{name: "access token", tokens: tokens, tokenType: config.TokenTypeAccessToken, want: "access.jwt.value"},
+		{name: "ID token", tokens: tokens, tokenType: config.TokenTypeIDToken, want: "id.jwt.value"},
+		{name: "missing access token", tokens: TokenResponse{IDToken: "id.jwt.value"}, tokenType: config.TokenTypeAccessToken, wantContain: "no access token"},
+		{name: "missing ID token", tokens: TokenResponse{AccessToken: "access.jwt.value"}, tokenType: config.TokenTypeIDToken, wantContain: "include 'openid'"},
+		{name: "unsupported", tokens: tokens, tokenType: "refresh_token", wantContain: "radosgw_oidc_token_type"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.tokens.WebIdentityToken(test.tokenType)
+			if test.wantContain != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+					t.Fatalf("WebIdentityToken() error = %v, want containing %q", err, test.wantContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WebIdentityToken() error = %v", err)
+			}
+			if got != test.want {
+				t.Errorf("WebIdentityToken() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// OIDC prompt values defined by OpenID Connect Core 1.0 section 3.1.2.1.
+const (
+	// PromptNone asks the provider not to display any interaction.
+	PromptNone = "none"
+	// PromptLogin asks the provider to reauthenticate the user.
+	PromptLogin = "login"
+	// PromptConsent asks the provider to request consent again.
+	PromptConsent = "consent"
+	// PromptSelectAccount asks the provider to let the user pick an account.
+	PromptSelectAccount = "select_account"
+)
+
+// ValidatePrompt reports whether value is empty or a space-separated list of
+// supported prompt values. none cannot be combined with other values.
+func ValidatePrompt(value string) error {
+	prompts := strings.Fields(value)
+	for _, prompt := range prompts {
+		switch prompt {
+		case PromptNone, PromptLogin, PromptConsent, PromptSelectAccount:
+		default:
+			return fmt.Errorf(
+				"invalid radosgw_oidc_prompt %q (supported: %s, %s, %s, %s)",
+				value,
+				PromptNone,
+				PromptLogin,
+				PromptConsent,
+				PromptSelectAccount,
+			)
+		}
+	}
+	if len(prompts) > 1 && slices.Contains(prompts, PromptNone) {
+		return fmt.Errorf("invalid radosgw_oidc_prompt %q: %s cannot be combined with other values", value, PromptNone)
+	}
+	return nil
+}
+
+// ParseResourceIndicators parses the space-separated radosgw_oidc_resource
+// value into RFC 8707 resource indicators, which must be absolute URIs
+// without a fragment. An empty value returns no resources.
+func ParseResourceIndicators(value string) ([]string, error) {
+	resources := strings.Fields(value)
+	for _, resource := range resources {
+		parsed, err := url.Parse(resource)
+		if err != nil || !parsed.IsAbs() || parsed.Fragment != "" || strings.Contains(resource, "#") {
+			return nil, fmt.Errorf("invalid radosgw_oidc_resource %q: %q is not an absolute URI without a fragment", value, resource)
+		}
+	}
+	if len(resources) == 0 {
+		return nil, nil
+	}
+	return resources, nil
+}
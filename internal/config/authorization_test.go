@@ -0,0 +1,57 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidatePrompt(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		prompt  string
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "login", prompt: "login"},
+		{name: "several values", prompt: "login consent"},
+		{name: "none", prompt: "none"},
+		{name: "none combined", prompt: "none login", wantErr: true},
+		{name: "unknown", prompt: "force", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidatePrompt(test.prompt)
+			if (err != nil) != test.wantErr {
+				t.Errorf("ValidatePrompt(%q) error = %v, wantErr %v", test.prompt, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseResourceIndicators(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "single", value: "https://storage.example.com", want: []string{"https://storage.example.com"}},
+		{
+			name:  "several",
+			value: " https://storage.example.com  urn:example:sts ",
+			want:  []string{"https://storage.example.com", "urn:example:sts"},
+		},
+		{name: "relative", value: "storage.example.com", wantErr: true},
+		{name: "fragment", value: "https://storage.example.com#s3", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resources, err := ParseResourceIndicators(test.value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseResourceIndicators(%q) error = %v, wantErr %v", test.value, err, test.wantErr)
+			}
+			if !slices.Equal(resources, test.want) {
+				t.Errorf("ParseResourceIndicators(%q) = %q, want %q", test.value, resources, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// BrowserMode selects how the browser flow receives the authorization
+// response.
+type BrowserMode string
+
+const (
+	// BrowserModeCallback receives the redirect on a local loopback server,
+	// the default.
+	BrowserModeCallback BrowserMode = "callback"
+	// BrowserModeManual prints the authorization URL and reads the redirected
+	// URL or authorization code from standard input. Use it when the browser
+	// runs on another machine, such as over SSH.
+	BrowserModeManual BrowserMode = "manual"
+)
+
+// Validate reports whether the browser mode is empty or supported.
+// Empty values are valid because defaults are applied by the browser flow.
+func (mode BrowserMode) Validate() error {
+	switch mode {
+	case "", BrowserModeCallback, BrowserModeManual:
+		return nil
+	default:
+		return fmt.Errorf(
+			"invalid radosgw_oidc_browser_mode %q (supported: %s, %s)",
+			mode,
+			BrowserModeCallback,
+			BrowserModeManual,
+		)
+	}
+}
+
+// RequirePAR stores whether the browser flow must use pushed authorization
+// requests (RFC 9126). It accepts the same boolean representations as
+// radosgw_ssl_verify. Pushed requests are also used whenever the provider
+// advertises them, so the flag only turns a missing endpoint into an error.
+type RequirePAR string
+
+// Enabled reports whether pushed authorization requests are required.
+func (require RequirePAR) Enabled() bool {
+	return require == "true" || require == "1"
+}
+
+// Validate reports whether the value is empty or a supported boolean.
+func (require RequirePAR) Validate() error {
+	switch require {
+	case "", "true", "false", "1", "0":
+		return nil
+	default:
+		return fmt.Errorf("invalid radosgw_oidc_require_par %q (supported: true, false, 1, 0)", require)
+	}
+}
+
+// CallbackHost is the loopback host name used in the browser flow's redirect
+// URI. It must match the redirect URIs registered for the OIDC client.
+type CallbackHost string
+
+const (
+	// CallbackHostLocalhost uses the localhost name, the default.
+	CallbackHostLocalhost CallbackHost = "localhost"
+	// CallbackHostIPv4 uses the IPv4 loopback address.
+	CallbackHostIPv4 CallbackHost = "127.0.0.1"
+	// CallbackHostIPv6 uses the IPv6 loopback address.
+	CallbackHostIPv6 CallbackHost = "[::1]"
+)
+
+// Validate reports whether the callback host is empty or supported.
+// Empty values are valid because defaults are applied by the browser flow.
+func (host CallbackHost) Validate() error {
+	switch host {
+	case "", CallbackHostLocalhost, CallbackHostIPv4, CallbackHostIPv6:
+		return nil
+	default:
+		return fmt.Errorf(
+			"invalid radosgw_oidc_callback_host %q (supported: %s, %s, %s)",
+			host,
+			CallbackHostLocalhost,
+			CallbackHostIPv4,
+			CallbackHostIPv6,
+		)
+	}
+}
+
+// ParseCallbackPorts parses the comma-separated radosgw_oidc_callback_ports
+// value. Ports are tried in order; 0 selects any free port. An empty value
+// returns no ports so the browser flow can apply its defaults.
+func ParseCallbackPorts(value string) ([]int, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var ports []int
+	for field := range strings.SplitSeq(value, ",") {
+		field = strings.TrimSpace(field)
+		port, err := strconv.Atoi(field)
+		if err != nil || port < 0 || port > 65535 {
+			return nil, fmt.Errorf("invalid radosgw_oidc_callback_ports %q: %q is not a port number between 0 and 65535", value, field)
+		}
+		if slices.Contains(ports, port) {
+			return nil, fmt.Errorf("invalid radosgw_oidc_callback_ports %q: port %d is listed more than once", value, port)
+		}
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// ValidateCallbackPath reports whether path can be used as the redirect URI
+// path. Empty values are valid because defaults are applied by the browser
+// flow.
+func ValidateCallbackPath(path string) error {
+	if path == "" {
+		return nil
+	}
+	parsed, err := url.Parse(path)
+	if err != nil || !strings.HasPrefix(path, "/") || parsed.Host != "" || parsed.Path != path || parsed.EscapedPath() != path || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Errorf("invalid radosgw_oidc_callback_path %q: must be an absolute path without query, fragment or escapes", path)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBrowserModeValidate(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		mode    BrowserMode
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "callback", mode: BrowserModeCallback},
+		{name: "manual", mode: BrowserModeManual},
+		{name: "unknown", mode: "paste", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.mode.Validate()
+			if (err != nil) != test.wantErr {
+				t.Errorf("BrowserMode(%q).Validate() error = %v, wantErr %v", test.mode, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRequirePAR(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		value       RequirePAR
+		wantEnabled bool
+		wantErr     bool
+	}{
+		{name: "unset"},
+		{name: "true", value: "true", wantEnabled: true},
+		{name: "one", value: "1", wantEnabled: true},
+		{name: "false", value: "false"},
+		{name: "zero", value: "0"},
+		{name: "unknown", value: "yes", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.value.Validate()
+			if (err != nil) != test.wantErr {
+				t.Errorf("RequirePAR(%q).Validate() error = %v, wantErr %v", test.value, err, test.wantErr)
+			}
+			if got := test.value.Enabled(); got != test.wantEnabled {
+				t.Errorf("RequirePAR(%q).Enabled() = %v, want %v", test.value, got, test.wantEnabled)
+			}
+		})
+	}
+}
+
+func TestCallbackHostValidate(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		host    CallbackHost
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "localhost", host: CallbackHostLocalhost},
+		{name: "IPv4 loopback", host: CallbackHostIPv4},
+		{name: "IPv6 loopback", host: CallbackHostIPv6},
+		{name: "unbracketed IPv6", host: "::1", wantErr: true},
+		{name: "remote host", host: "example.com", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.host.Validate()
+			if (err != nil) != test.wantErr {
+				t.Errorf("CallbackHost(%q).Validate() error = %v, wantErr %v", test.host, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseCallbackPorts(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		value       string
+		want        []int
+		wantContain string
+	}{
+		{name: "unset"},
+		{name: "single port", value: "8400", want: []int{8400}},
+		{name: "ordered list with spaces", value: " 8400, 8401 ,0", want: []int{8400, 8401, 0}},
+		{name: "not a number", value: "8400,http", wantContain: `"http" is not a port number`},
+		{name: "empty entry", value: "8400,,8401", wantContain: `"" is not a port number`},
+		{name: "out of range", value: "65536", wantContain: `"65536" is not a port number`},
+		{name: "negative", value: "-1", wantContain: `"-1" is not a port number`},
+		{name: "duplicate", value: "8400,8400", wantContain: "port 8400 is listed more than once"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseCallbackPorts(test.value)
+			if test.wantContain != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+					t.Fatalf("ParseCallbackPorts(%q) error = %v, want containing %q", test.value, err, test.wantContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCallbackPorts(%q) error = %v", test.value, err)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("ParseCallbackPorts(%q) = %v, want %v", test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateCallbackPath(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "default", path: "/callback"},
+		{name: "nested", path: "/oauth2/radosgw/callback"},
+		{name: "relative", path: "callback", wantErr: true},
+		{name: "query", path: "/callback?source=cli", wantErr: true},
+		{name: "fragment", path: "/callback#done", wantErr: true},
+		{name: "escaped", path: "/call%20back", wantErr: true},
+		{name: "needs escaping", path: "/call back", wantErr: true},
+		{name: "network path", path: "//example.com/callback", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateCallbackPath(test.path)
+			if (err != nil) != test.wantErr {
+				t.Errorf("ValidateCallbackPath(%q) error = %v, wantErr %v", test.path, err, test.wantErr)
+			}
+		})
+	}
+}
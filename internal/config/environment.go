@@ -8,38 +8,92 @@ import (
 // GetProfileConfigFromEnv creates a ProfileConfig from environment variables
 func GetProfileConfigFromEnv() (*ProfileConfig, error) {
 	profileConfig := &ProfileConfig{
-		EndpointURL:           os.Getenv("AWS_ENDPOINT_URL"),
-		RadosGWOIDCProvider:   os.Getenv("RADOSGW_OIDC_PROVIDER"),
-		RadosGWOIDCClientID:   os.Getenv("RADOSGW_OIDC_CLIENT_ID"),
-		RadosGWOIDCAuthType:   AuthType(os.Getenv("RADOSGW_OIDC_AUTH_TYPE")),
-		RadosGWOIDCScope:      os.Getenv("RADOSGW_OIDC_SCOPE"),
-		RadosGWOIDCPKCEMethod: PKCEMethod(os.Getenv("RADOSGW_OIDC_PKCE_METHOD")),
-		RadosGWSSLVerify:      SSLVerification(os.Getenv("RADOSGW_SSL_VERIFY")),
-		RoleArn:               os.Getenv("RADOSGW_ROLE_ARN"),
-		RoleSessionName:       os.Getenv("RADOSGW_ROLE_SESSION_NAME"),
+		EndpointURL:                   os.Getenv("AWS_ENDPOINT_URL"),
+		STSEndpointURL:                os.Getenv("AWS_ENDPOINT_URL_STS"),
+		STSEndpoints:                  os.Getenv("RADOSGW_STS_ENDPOINTS"),
+		Region:                        RegionFromEnv(os.Getenv),
+		RadosGWOIDCProvider:           os.Getenv("RADOSGW_OIDC_PROVIDER"),
+		RadosGWOIDCClientID:           os.Getenv("RADOSGW_OIDC_CLIENT_ID"),
+		RadosGWOIDCAuthType:           AuthType(os.Getenv("RADOSGW_OIDC_AUTH_TYPE")),
+		RadosGWOIDCScope:              os.Getenv("RADOSGW_OIDC_SCOPE"),
+		RadosGWOIDCPKCEMethod:         PKCEMethod(os.Getenv("RADOSGW_OIDC_PKCE_METHOD")),
+		RadosGWOIDCTokenType:          TokenType(os.Getenv("RADOSGW_OIDC_TOKEN_TYPE")),
+		RadosGWOIDCAudience:           os.Getenv("RADOSGW_OIDC_AUDIENCE"),
+		RadosGWOIDCResource:           os.Getenv("RADOSGW_OIDC_RESOURCE"),
+		RadosGWOIDCPrompt:             os.Getenv("RADOSGW_OIDC_PROMPT"),
+		RadosGWOIDCLoginHint:          os.Getenv("RADOSGW_OIDC_LOGIN_HINT"),
+		RadosGWOIDCACRValues:          os.Getenv("RADOSGW_OIDC_ACR_VALUES"),
+		RadosGWOIDCClientAuthMethod:   ClientAuthMethod(os.Getenv("RADOSGW_OIDC_CLIENT_AUTH_METHOD")),
+		RadosGWOIDCClientSecretFile:   os.Getenv("RADOSGW_OIDC_CLIENT_SECRET_FILE"),
+		RadosGWOIDCClientKeyFile:      os.Getenv("RADOSGW_OIDC_CLIENT_PRIVATE_KEY_FILE"),
+		RadosGWOIDCSubjectAuthType:    AuthType(os.Getenv("RADOSGW_OIDC_SUBJECT_AUTH_TYPE")),
+		RadosGWOIDCSubjectTokenType:   ExchangeTokenType(os.Getenv("RADOSGW_OIDC_SUBJECT_TOKEN_TYPE")),
+		RadosGWOIDCRequestedTokenType: ExchangeTokenType(os.Getenv("RADOSGW_OIDC_REQUESTED_TOKEN_TYPE")),
+		RadosGWOIDCBrowserMode:        BrowserMode(os.Getenv("RADOSGW_OIDC_BROWSER_MODE")),
+		RadosGWOIDCRequirePAR:         RequirePAR(os.Getenv("RADOSGW_OIDC_REQUIRE_PAR")),
+		RadosGWOIDCCallbackPorts:      os.Getenv("RADOSGW_OIDC_CALLBACK_PORTS"),
+		RadosGWOIDCCallbackHost:       CallbackHost(os.Getenv("RADOSGW_OIDC_CALLBACK_HOST")),
+		RadosGWOIDCCallbackPath:       os.Getenv("RADOSGW_OIDC_CALLBACK_PATH"),
+		RadosGWSSLVerify:              SSLVerification(os.Getenv("RADOSGW_SSL_VERIFY")),
+		RadosGWTLSClientCertFile:      os.Getenv("RADOSGW_TLS_CLIENT_CERT_FILE"),
+		RadosGWTLSClientKeyFile:       os.Getenv("RADOSGW_TLS_CLIENT_KEY_FILE"),
+		CABundle:                      os.Getenv("AWS_CA_BUNDLE"),
+		RadosGWOIDCCABundle:           os.Getenv("RADOSGW_OIDC_CA_BUNDLE"),
+		RadosGWTLSPinnedSHA256:        os.Getenv("RADOSGW_TLS_PINNED_SHA256"),
+		RadosGWOIDCTLSPinnedSHA256:    os.Getenv("RADOSGW_OIDC_TLS_PINNED_SHA256"),
+		RadosGWProxyURL:               os.Getenv("RADOSGW_PROXY_URL"),
+		RadosGWOIDCProxyURL:           os.Getenv("RADOSGW_OIDC_PROXY_URL"),
+		RadosGWNoProxy:                os.Getenv("RADOSGW_NO_PROXY"),
+		RadosGWOIDCNoProxy:            os.Getenv("RADOSGW_OIDC_NO_PROXY"),
+		RadosGWMaxAttempts:            os.Getenv("RADOSGW_MAX_ATTEMPTS"),
+		RoleArn:                       os.Getenv("RADOSGW_ROLE_ARN"),
+		RoleSessionName:               os.Getenv("RADOSGW_ROLE_SESSION_NAME"),
+		SessionPolicy:                 os.Getenv("RADOSGW_SESSION_POLICY"),
+		SessionPolicyFile:             os.Getenv("RADOSGW_SESSION_POLICY_FILE"),
+		SessionPolicyARNs:             os.Getenv("RADOSGW_SESSION_POLICY_ARNS"),
+		WebIdentityTokenFile:          webIdentityTokenFileFromEnv(),
 	}
 	normalizedConfig, err := profileConfig.Normalize()
 	if err != nil {
 		return nil, err
 	}
 
-	if normalizedConfig.EndpointURL == "" {
-		return nil, fmt.Errorf("AWS_ENDPOINT_URL environment variable is required")
+	if normalizedConfig.EndpointURL == "" && normalizedConfig.STSEndpoints == "" {
+		return nil, fmt.Errorf("AWS_ENDPOINT_URL or RADOSGW_STS_ENDPOINTS environment variable is required")
 	}
 
-	// For token auth type, only token and endpoint are required. Scope and OIDC
-	// provider settings are ignored because the token has already been issued.
-	if normalizedConfig.RadosGWOIDCAuthType == AuthTypeToken {
+	// For token and github-actions auth types, only token and endpoint are
+	// required. Scope and OIDC provider settings are ignored because the token
+	// is issued outside the configured provider.
+	if !normalizedConfig.RadosGWOIDCAuthType.UsesOIDCProvider() {
 		return normalizedConfig, nil
 	}
 
 	// For other auth types, check for required OIDC variables
 	if normalizedConfig.RadosGWOIDCProvider == "" {
-		return nil, fmt.Errorf("RADOSGW_OIDC_PROVIDER environment variable is required (not needed for auth_type=token)")
+		return nil, fmt.Errorf("RADOSGW_OIDC_PROVIDER environment variable is required (not needed for auth_type=token or github-actions)")
 	}
 	if normalizedConfig.RadosGWOIDCClientID == "" {
-		return nil, fmt.Errorf("RADOSGW_OIDC_CLIENT_ID environment variable is required (not needed for auth_type=token)")
+		return nil, fmt.Errorf("RADOSGW_OIDC_CLIENT_ID environment variable is required (not needed for auth_type=token or github-actions)")
 	}
 
 	return normalizedConfig, nil
 }
+
+// RegionFromEnv returns AWS_REGION, or AWS_DEFAULT_REGION when it is unset,
+// the same precedence the AWS CLI and SDKs use.
+func RegionFromEnv(getenv func(string) string) string {
+	if region := getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return getenv("AWS_DEFAULT_REGION")
+}
+
+// webIdentityTokenFileFromEnv prefers the RadosGW-specific variable so it can
+// override the AWS variable injected by workload identity integrations.
+func webIdentityTokenFileFromEnv() string {
+	if tokenFile := os.Getenv("RADOSGW_OIDC_TOKEN_FILE"); tokenFile != "" {
+		return tokenFile
+	}
+	return os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+}
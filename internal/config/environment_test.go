@@ -7,17 +7,47 @@ import (
 
 func TestGetProfileConfigFromEnv(t *testing.T) {
 	tests := []struct {
-		name            string
-		envVars         map[string]string
-		wantErr         bool
-		wantURL         string
-		wantAuthType    AuthType
-		wantScope       string
-		wantPKCEMethod  PKCEMethod
-		wantSSLVerify   SSLVerification
-		wantRoleARN     string
-		wantSessionName string
-		wantErrContain  string
+		name                   string
+		envVars                map[string]string
+		wantErr                bool
+		wantURL                string
+		wantAuthType           AuthType
+		wantScope              string
+		wantPKCEMethod         PKCEMethod
+		wantTokenType          TokenType
+		wantSSLVerify          SSLVerification
+		wantRoleARN            string
+		wantSessionName        string
+		wantTokenFile          string
+		wantAudience           string
+		wantAuthMethod         ClientAuthMethod
+		wantSecretFile         string
+		wantKeyFile            string
+		wantSubjectAuthType    AuthType
+		wantSubjectTokenType   ExchangeTokenType
+		wantRequestedTokenType ExchangeTokenType
+		wantPrompt             string
+		wantLoginHint          string
+		wantACRValues          string
+		wantResource           string
+		wantBrowserMode        BrowserMode
+		wantRequirePAR         RequirePAR
+		wantCallbackPorts      string
+		wantCallbackHost       CallbackHost
+		wantCallbackPath       string
+		wantTLSClientCertFile  string
+		wantTLSClientKeyFile   string
+		wantCABundle           string
+		wantOIDCCABundle       string
+		wantProxyURL           string
+		wantOIDCNoProxy        string
+		wantMaxAttempts        string
+		wantSessionPolicyFile  string
+		wantSessionPolicyARNs  string
+		wantSTSEndpointURL     string
+		wantSTSEndpoints       string
+		wantRegion             string
+		wantErrContain         string
 	}{
 		{
 			name: "complete OIDC config",
@@ -31,6 +61,7 @@ func TestGetProfileConfigFromEnv(t *testing.T) {
 			wantAuthType:   AuthTypeDevice,
 			wantScope:      DefaultOIDCScope,
 			wantPKCEMethod: PKCEMethodS256,
+			wantTokenType:  TokenTypeAccessToken,
 			wantSSLVerify:  SSLVerificationTrue,
 		},
 		{
@@ -53,6 +84,7 @@ func TestGetProfileConfigFromEnv(t *testing.T) {
 				"RADOSGW_OIDC_AUTH_TYPE":    "browser",
 				"RADOSGW_OIDC_SCOPE":        "openid profile",
 				"RADOSGW_OIDC_PKCE_METHOD":  "plain",
+				"RADOSGW_OIDC_TOKEN_TYPE":   "id_token",
 				"RADOSGW_SSL_VERIFY":        "false",
 				"RADOSGW_ROLE_ARN":          "arn:aws:iam::123456789012:role/TestRole",
 				"RADOSGW_ROLE_SESSION_NAME": "custom-session",
@@ -61,6 +93,7 @@ func TestGetProfileConfigFromEnv(t *testing.T) {
 			wantAuthType:    AuthTypeBrowser,
 			wantScope:       "openid profile",
 			wantPKCEMethod:  PKCEMethodPlain,
+			wantTokenType:   TokenTypeIDToken,
 			wantSSLVerify:   SSLVerificationFalse,
 			wantRoleARN:     "arn:aws:iam::123456789012:role/TestRole",
 			wantSessionName: "custom-session",
@@ -78,6 +111,7 @@ func TestGetProfileConfigFromEnv(t *testing.T) {
 			wantAuthType:    AuthTypeDevice,
 			wantScope:       DefaultOIDCScope,
 			wantPKCEMethod:  PKCEMethodS256,
+			wantTokenType:   TokenTypeAccessToken,
 			wantSSLVerify:   SSLVerificationTrue,
 			wantSessionName: "my-custom-session",
 		},
@@ -97,6 +131,309 @@ func TestGetProfileConfigFromEnv(t *testing.T) {
 			wantRoleARN:     "arn:aws:iam::123456789012:role/TokenRole",
 			wantSessionName: "token-session",
 		},
+		{
+			name: "AWS web identity token file",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":            "https://test.example.com",
+				"AWS_WEB_IDENTITY_TOKEN_FILE": "/var/run/secrets/eks.amazonaws.com/serviceaccount/token",
+			},
+			wantURL:       "https://test.example.com",
+			wantAuthType:  AuthTypeToken,
+			wantSSLVerify: SSLVerificationTrue,
+			wantTokenFile: "/var/run/secrets/eks.amazonaws.com/serviceaccount/token",
+		},
+		{
+			name: "RadosGW token file overrides AWS token file",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":            "https://test.example.com",
+				"AWS_WEB_IDENTITY_TOKEN_FILE": "/var/run/secrets/eks.amazonaws.com/serviceaccount/token",
+				"RADOSGW_OIDC_TOKEN_FILE":     "/var/run/secrets/tokens/radosgw",
+			},
+			wantURL:       "https://test.example.com",
+			wantAuthType:  AuthTypeToken,
+			wantSSLVerify: SSLVerificationTrue,
+			wantTokenFile: "/var/run/secrets/tokens/radosgw",
+		},
+		{
+			name: "GitHub Actions auth with audience",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":       "https://test.example.com",
+				"RADOSGW_OIDC_AUTH_TYPE": "github-actions",
+				"RADOSGW_OIDC_AUDIENCE":  "sts.test.example.com",
+				"RADOSGW_ROLE_ARN":       "arn:aws:iam::123456789012:role/GitHubRole",
+			},
+			wantURL:       "https://test.example.com",
+			wantAuthType:  AuthTypeGitHubActions,
+			wantSSLVerify: SSLVerificationTrue,
+			wantRoleARN:   "arn:aws:iam::123456789012:role/GitHubRole",
+			wantAudience:  "sts.test.example.com",
+		},
+		{
+			name: "client credentials with secret file",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":                "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":           "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID":          "batch-client",
+				"RADOSGW_OIDC_AUTH_TYPE":          "client_credentials",
+				"RADOSGW_OIDC_CLIENT_AUTH_METHOD": "client_secret_post",
+				"RADOSGW_OIDC_CLIENT_SECRET_FILE": "/run/secrets/client-secret",
+			},
+			wantURL:        "https://test.example.com",
+			wantAuthType:   AuthTypeClientCredentials,
+			wantScope:      DefaultOIDCScope,
+			wantPKCEMethod: PKCEMethodS256,
+			wantTokenType:  TokenTypeAccessToken,
+			wantSSLVerify:  SSLVerificationTrue,
+			wantAuthMethod: ClientAuthSecretPost,
+			wantSecretFile: "/run/secrets/client-secret",
+		},
+		{
+			name: "client credentials with private key",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":                     "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":                "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID":               "batch-client",
+				"RADOSGW_OIDC_AUTH_TYPE":               "client_credentials",
+				"RADOSGW_OIDC_CLIENT_AUTH_METHOD":      "private_key_jwt",
+				"RADOSGW_OIDC_CLIENT_PRIVATE_KEY_FILE": "/etc/radosgw/client-key.pem",
+			},
+			wantURL:        "https://test.example.com",
+			wantAuthType:   AuthTypeClientCredentials,
+			wantScope:      DefaultOIDCScope,
+			wantPKCEMethod: PKCEMethodS256,
+			wantTokenType:  TokenTypeAccessToken,
+			wantSSLVerify:  SSLVerificationTrue,
+			wantAuthMethod: ClientAuthPrivateKeyJWT,
+			wantKeyFile:    "/etc/radosgw/client-key.pem",
+		},
+		{
+			name: "mutual TLS client certificate",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":             "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":        "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID":       "test-client",
+				"RADOSGW_TLS_CLIENT_CERT_FILE": "/etc/radosgw/client.crt",
+				"RADOSGW_TLS_CLIENT_KEY_FILE":  "/etc/radosgw/client.key",
+			},
+			wantURL:               "https://test.example.com",
+			wantAuthType:          AuthTypeDevice,
+			wantScope:             DefaultOIDCScope,
+			wantPKCEMethod:        PKCEMethodS256,
+			wantTokenType:         TokenTypeAccessToken,
+			wantSSLVerify:         SSLVerificationTrue,
+			wantTLSClientCertFile: "/etc/radosgw/client.crt",
+			wantTLSClientKeyFile:  "/etc/radosgw/client.key",
+		},
+		{
+			name: "CA bundles",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":       "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":  "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID": "test-client",
+				"AWS_CA_BUNDLE":          "/etc/radosgw/storage-ca.pem",
+				"RADOSGW_OIDC_CA_BUNDLE": "/etc/radosgw/oidc-ca.pem",
+			},
+			wantURL:          "https://test.example.com",
+			wantAuthType:     AuthTypeDevice,
+			wantScope:        DefaultOIDCScope,
+			wantPKCEMethod:   PKCEMethodS256,
+			wantTokenType:    TokenTypeAccessToken,
+			wantSSLVerify:    SSLVerificationTrue,
+			wantCABundle:     "/etc/radosgw/storage-ca.pem",
+			wantOIDCCABundle: "/etc/radosgw/oidc-ca.pem",
+		},
+		{
+			name: "STS endpoint and region",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":       "https://s3.example.com",
+				"AWS_ENDPOINT_URL_STS":   "https://sts.example.com",
+				"AWS_DEFAULT_REGION":     "eu-central",
+				"RADOSGW_OIDC_PROVIDER":  "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID": "test-client",
+			},
+			wantURL:            "https://s3.example.com",
+			wantAuthType:       AuthTypeDevice,
+			wantScope:          DefaultOIDCScope,
+			wantPKCEMethod:     PKCEMethodS256,
+			wantTokenType:      TokenTypeAccessToken,
+			wantSSLVerify:      SSLVerificationTrue,
+			wantSTSEndpointURL: "https://sts.example.com",
+			wantRegion:         "eu-central",
+		},
+		{
+			name: "STS gateways without an endpoint URL",
+			envVars: map[string]string{
+				"RADOSGW_STS_ENDPOINTS":  "https://rgw1.example.com,https://rgw2.example.com",
+				"RADOSGW_OIDC_PROVIDER":  "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID": "test-client",
+			},
+			wantAuthType:     AuthTypeDevice,
+			wantScope:        DefaultOIDCScope,
+			wantPKCEMethod:   PKCEMethodS256,
+			wantTokenType:    TokenTypeAccessToken,
+			wantSSLVerify:    SSLVerificationTrue,
+			wantSTSEndpoints: "https://rgw1.example.com,https://rgw2.example.com",
+		},
+		{
+			name: "STS gateways with an STS endpoint URL",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL_STS":   "https://sts.example.com",
+				"RADOSGW_STS_ENDPOINTS":  "https://rgw1.example.com",
+				"RADOSGW_OIDC_PROVIDER":  "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID": "test-client",
+			},
+			wantErr:        true,
+			wantErrContain: "sts_endpoint_url and sts_endpoints cannot be used together",
+		},
+		{
+			name: "AWS_REGION takes precedence over AWS_DEFAULT_REGION",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":       "https://s3.example.com",
+				"AWS_REGION":             "zonegroup-a",
+				"AWS_DEFAULT_REGION":     "eu-central",
+				"RADOSGW_OIDC_PROVIDER":  "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID": "test-client",
+			},
+			wantURL:        "https://s3.example.com",
+			wantAuthType:   AuthTypeDevice,
+			wantScope:      DefaultOIDCScope,
+			wantPKCEMethod: PKCEMethodS256,
+			wantTokenType:  TokenTypeAccessToken,
+			wantSSLVerify:  SSLVerificationTrue,
+			wantRegion:     "zonegroup-a",
+		},
+		{
+			name: "proxies",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":       "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":  "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID": "test-client",
+				"RADOSGW_PROXY_URL":      "socks5h://127.0.0.1:1080",
+				"RADOSGW_OIDC_NO_PROXY":  "oidc.example.com",
+				"RADOSGW_MAX_ATTEMPTS":   "5",
+			},
+			wantURL:         "https://test.example.com",
+			wantAuthType:    AuthTypeDevice,
+			wantScope:       DefaultOIDCScope,
+			wantPKCEMethod:  PKCEMethodS256,
+			wantTokenType:   TokenTypeAccessToken,
+			wantSSLVerify:   SSLVerificationTrue,
+			wantProxyURL:    "socks5h://127.0.0.1:1080",
+			wantOIDCNoProxy: "oidc.example.com",
+			wantMaxAttempts: "5",
+		},
+		{
+			name: "invalid proxy URL",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":       "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":  "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID": "test-client",
+				"RADOSGW_OIDC_PROXY_URL": "ftp://proxy.example.com",
+			},
+			wantErr: true,
+		},
+		{
+			name: "session policy",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":            "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":       "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID":      "test-client",
+				"RADOSGW_SESSION_POLICY_FILE": "/etc/radosgw/ci-policy.json",
+				"RADOSGW_SESSION_POLICY_ARNS": "arn:aws:iam::aws:policy/ReadOnly",
+			},
+			wantURL:               "https://test.example.com",
+			wantAuthType:          AuthTypeDevice,
+			wantScope:             DefaultOIDCScope,
+			wantPKCEMethod:        PKCEMethodS256,
+			wantTokenType:         TokenTypeAccessToken,
+			wantSSLVerify:         SSLVerificationTrue,
+			wantSessionPolicyFile: "/etc/radosgw/ci-policy.json",
+			wantSessionPolicyARNs: "arn:aws:iam::aws:policy/ReadOnly",
+		},
+		{
+			name: "invalid session policy",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":       "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":  "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID": "test-client",
+				"RADOSGW_SESSION_POLICY": "{\"Version\":",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid certificate pin",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":          "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":     "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID":    "test-client",
+				"RADOSGW_TLS_PINNED_SHA256": "not-a-fingerprint",
+			},
+			wantErr: true,
+		},
+		{
+			name: "token exchange",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":                  "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":             "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID":            "exchange-client",
+				"RADOSGW_OIDC_AUTH_TYPE":            "token-exchange",
+				"RADOSGW_OIDC_SUBJECT_AUTH_TYPE":    "github-actions",
+				"RADOSGW_OIDC_SUBJECT_TOKEN_TYPE":   "urn:ietf:params:oauth:token-type:jwt",
+				"RADOSGW_OIDC_REQUESTED_TOKEN_TYPE": "urn:ietf:params:oauth:token-type:id_token",
+			},
+			wantURL:                "https://test.example.com",
+			wantAuthType:           AuthTypeTokenExchange,
+			wantScope:              DefaultOIDCScope,
+			wantPKCEMethod:         PKCEMethodS256,
+			wantTokenType:          TokenTypeAccessToken,
+			wantSSLVerify:          SSLVerificationTrue,
+			wantSubjectAuthType:    AuthTypeGitHubActions,
+			wantSubjectTokenType:   ExchangeTokenTypeJWT,
+			wantRequestedTokenType: ExchangeTokenTypeIDToken,
+		},
+		{
+			name: "browser callback",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":            "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":       "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID":      "test-client",
+				"RADOSGW_OIDC_AUTH_TYPE":      "browser",
+				"RADOSGW_OIDC_BROWSER_MODE":   "manual",
+				"RADOSGW_OIDC_REQUIRE_PAR":    "1",
+				"RADOSGW_OIDC_PROMPT":         "login",
+				"RADOSGW_OIDC_LOGIN_HINT":     "user@example.com",
+				"RADOSGW_OIDC_ACR_VALUES":     "mfa",
+				"RADOSGW_OIDC_RESOURCE":       "https://storage.example.com",
+				"RADOSGW_OIDC_CALLBACK_PORTS": "8400,8401",
+				"RADOSGW_OIDC_CALLBACK_HOST":  "127.0.0.1",
+				"RADOSGW_OIDC_CALLBACK_PATH":  "/oauth2/callback",
+			},
+			wantURL:           "https://test.example.com",
+			wantAuthType:      AuthTypeBrowser,
+			wantScope:         DefaultOIDCScope,
+			wantPKCEMethod:    PKCEMethodS256,
+			wantTokenType:     TokenTypeAccessToken,
+			wantSSLVerify:     SSLVerificationTrue,
+			wantPrompt:        "login",
+			wantLoginHint:     "user@example.com",
+			wantACRValues:     "mfa",
+			wantResource:      "https://storage.example.com",
+			wantBrowserMode:   BrowserModeManual,
+			wantRequirePAR:    "1",
+			wantCallbackPorts: "8400,8401",
+			wantCallbackHost:  CallbackHostIPv4,
+			wantCallbackPath:  "/oauth2/callback",
+		},
+		{
+			name: "invalid callback ports",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":            "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":       "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID":      "test-client",
+				"RADOSGW_OIDC_CALLBACK_PORTS": "8080,99999",
+			},
+			wantErr: true,
+		},
 		{
 			name: "missing endpoint",
 			envVars: map[string]string{
@@ -152,6 +489,17 @@ func TestGetProfileConfigFromEnv(t *testing.T) {
 			wantErr:        true,
 			wantErrContain: "radosgw_ssl_verify",
 		},
+		{
+			name: "unsupported token type",
+			envVars: map[string]string{
+				"AWS_ENDPOINT_URL":        "https://test.example.com",
+				"RADOSGW_OIDC_PROVIDER":   "https://oidc.example.com",
+				"RADOSGW_OIDC_CLIENT_ID":  "test-client",
+				"RADOSGW_OIDC_TOKEN_TYPE": "jwt",
+			},
+			wantErr:        true,
+			wantErrContain: "radosgw_oidc_token_type",
+		},
 	}
 
 	for _, test := range tests {
@@ -163,9 +511,46 @@ func TestGetProfileConfigFromEnv(t *testing.T) {
 				"RADOSGW_OIDC_AUTH_TYPE",
 				"RADOSGW_OIDC_SCOPE",
 				"RADOSGW_OIDC_PKCE_METHOD",
+				"RADOSGW_OIDC_TOKEN_TYPE",
 				"RADOSGW_SSL_VERIFY",
 				"RADOSGW_ROLE_ARN",
 				"RADOSGW_ROLE_SESSION_NAME",
+				"RADOSGW_OIDC_TOKEN_FILE",
+				"AWS_WEB_IDENTITY_TOKEN_FILE",
+				"RADOSGW_OIDC_AUDIENCE",
+				"RADOSGW_OIDC_CLIENT_AUTH_METHOD",
+				"RADOSGW_OIDC_CLIENT_SECRET_FILE",
+				"RADOSGW_OIDC_CLIENT_PRIVATE_KEY_FILE",
+				"RADOSGW_OIDC_SUBJECT_AUTH_TYPE",
+				"RADOSGW_OIDC_SUBJECT_TOKEN_TYPE",
+				"RADOSGW_OIDC_REQUESTED_TOKEN_TYPE",
+				"RADOSGW_OIDC_BROWSER_MODE",
+				"RADOSGW_OIDC_REQUIRE_PAR",
+				"RADOSGW_OIDC_PROMPT",
+				"RADOSGW_OIDC_LOGIN_HINT",
+				"RADOSGW_OIDC_ACR_VALUES",
+				"RADOSGW_OIDC_RESOURCE",
+				"RADOSGW_OIDC_CALLBACK_PORTS",
+				"RADOSGW_OIDC_CALLBACK_HOST",
+				"RADOSGW_OIDC_CALLBACK_PATH",
+				"RADOSGW_TLS_CLIENT_CERT_FILE",
+				"RADOSGW_TLS_CLIENT_KEY_FILE",
+				"AWS_CA_BUNDLE",
+				"RADOSGW_OIDC_CA_BUNDLE",
+				"RADOSGW_TLS_PINNED_SHA256",
+				"RADOSGW_OIDC_TLS_PINNED_SHA256",
+				"RADOSGW_PROXY_URL",
+				"RADOSGW_OIDC_PROXY_URL",
+				"RADOSGW_NO_PROXY",
+				"RADOSGW_OIDC_NO_PROXY",
+				"RADOSGW_MAX_ATTEMPTS",
+				"RADOSGW_SESSION_POLICY",
+				"RADOSGW_SESSION_POLICY_FILE",
+				"RADOSGW_SESSION_POLICY_ARNS",
+				"AWS_ENDPOINT_URL_STS",
+				"RADOSGW_STS_ENDPOINTS",
+				"AWS_REGION",
+				"AWS_DEFAULT_REGION",
 			} {
 				t.Setenv(key, "")
 			}
@@ -202,6 +587,9 @@ func TestGetProfileConfigFromEnv(t *testing.T) {
 			if profileConfig.RadosGWOIDCPKCEMethod != test.wantPKCEMethod {
 				t.Errorf("GetProfileConfigFromEnv() pkce_method = %v, want %v", profileConfig.RadosGWOIDCPKCEMethod, test.wantPKCEMethod)
 			}
+			if profileConfig.RadosGWOIDCTokenType != test.wantTokenType {
+				t.Errorf("GetProfileConfigFromEnv() token_type = %v, want %v", profileConfig.RadosGWOIDCTokenType, test.wantTokenType)
+			}
 			if profileConfig.RadosGWSSLVerify != test.wantSSLVerify {
 				t.Errorf("GetProfileConfigFromEnv() ssl_verify = %v, want %v", profileConfig.RadosGWSSLVerify, test.wantSSLVerify)
 			}
@@ -211,6 +599,76 @@ func TestGetProfileConfigFromEnv(t *testing.T) {
 			if profileConfig.RoleSessionName != test.wantSessionName {
 				t.Errorf("GetProfileConfigFromEnv() session_name = %v, want %v", profileConfig.RoleSessionName, test.wantSessionName)
 			}
+			if profileConfig.RadosGWOIDCAudience != test.wantAudience {
+				t.Errorf("GetProfileConfigFromEnv() audience = %v, want %v", profileConfig.RadosGWOIDCAudience, test.wantAudience)
+			}
+			if profileConfig.RadosGWOIDCClientAuthMethod != test.wantAuthMethod {
+				t.Errorf("GetProfileConfigFromEnv() client_auth_method = %v, want %v", profileConfig.RadosGWOIDCClientAuthMethod, test.wantAuthMethod)
+			}
+			if profileConfig.RadosGWOIDCClientSecretFile != test.wantSecretFile {
+				t.Errorf("GetProfileConfigFromEnv() client_secret_file = %v, want %v", profileConfig.RadosGWOIDCClientSecretFile, test.wantSecretFile)
+			}
+			if profileConfig.RadosGWOIDCClientKeyFile != test.wantKeyFile {
+				t.Errorf("GetProfileConfigFromEnv() client_private_key_file = %v, want %v", profileConfig.RadosGWOIDCClientKeyFile, test.wantKeyFile)
+			}
+			if profileConfig.RadosGWOIDCSubjectAuthType != test.wantSubjectAuthType {
+				t.Errorf("GetProfileConfigFromEnv() subject_auth_type = %v, want %v", profileConfig.RadosGWOIDCSubjectAuthType, test.wantSubjectAuthType)
+			}
+			if profileConfig.RadosGWOIDCSubjectTokenType != test.wantSubjectTokenType {
+				t.Errorf("GetProfileConfigFromEnv() subject_token_type = %v, want %v", profileConfig.RadosGWOIDCSubjectTokenType, test.wantSubjectTokenType)
+			}
+			if profileConfig.RadosGWOIDCRequestedTokenType != test.wantRequestedTokenType {
+				t.Errorf("GetProfileConfigFromEnv() requested_token_type = %v, want %v", profileConfig.RadosGWOIDCRequestedTokenType, test.wantRequestedTokenType)
+			}
+			if profileConfig.RadosGWOIDCPrompt != test.wantPrompt || profileConfig.RadosGWOIDCLoginHint != test.wantLoginHint || profileConfig.RadosGWOIDCACRValues != test.wantACRValues {
+				t.Errorf("GetProfileConfigFromEnv() prompt, login_hint, acr_values = %q %q %q, want %q %q %q", profileConfig.RadosGWOIDCPrompt, profileConfig.RadosGWOIDCLoginHint, profileConfig.RadosGWOIDCACRValues, test.wantPrompt, test.wantLoginHint, test.wantACRValues)
+			}
+			if profileConfig.RadosGWOIDCResource != test.wantResource {
+				t.Errorf("GetProfileConfigFromEnv() resource = %v, want %v", profileConfig.RadosGWOIDCResource, test.wantResource)
+			}
+			if profileConfig.RadosGWOIDCBrowserMode != test.wantBrowserMode {
+				t.Errorf("GetProfileConfigFromEnv() browser_mode = %v, want %v", profileConfig.RadosGWOIDCBrowserMode, test.wantBrowserMode)
+			}
+			if profileConfig.RadosGWOIDCRequirePAR != test.wantRequirePAR {
+				t.Errorf("GetProfileConfigFromEnv() require_par = %v, want %v", profileConfig.RadosGWOIDCRequirePAR, test.wantRequirePAR)
+			}
+			if profileConfig.RadosGWOIDCCallbackPorts != test.wantCallbackPorts {
+				t.Errorf("GetProfileConfigFromEnv() callback_ports = %v, want %v", profileConfig.RadosGWOIDCCallbackPorts, test.wantCallbackPorts)
+			}
+			if profileConfig.RadosGWOIDCCallbackHost != test.wantCallbackHost {
+				t.Errorf("GetProfileConfigFromEnv() callback_host = %v, want %v", profileConfig.RadosGWOIDCCallbackHost, test.wantCallbackHost)
+			}
+			if profileConfig.RadosGWOIDCCallbackPath != test.wantCallbackPath {
+				t.Errorf("GetProfileConfigFromEnv() callback_path = %v, want %v", profileConfig.RadosGWOIDCCallbackPath, test.wantCallbackPath)
+			}
+			if profileConfig.RadosGWTLSClientCertFile != test.wantTLSClientCertFile || profileConfig.RadosGWTLSClientKeyFile != test.wantTLSClientKeyFile {
+				t.Errorf("GetProfileConfigFromEnv() tls_client_cert_file, tls_client_key_file = %q %q, want %q %q", profileConfig.RadosGWTLSClientCertFile, profileConfig.RadosGWTLSClientKeyFile, test.wantTLSClientCertFile, test.wantTLSClientKeyFile)
+			}
+			if profileConfig.CABundle != test.wantCABundle || profileConfig.RadosGWOIDCCABundle != test.wantOIDCCABundle {
+				t.Errorf("GetProfileConfigFromEnv() ca_bundle, oidc_ca_bundle = %q %q, want %q %q", profileConfig.CABundle, profileConfig.RadosGWOIDCCABundle, test.wantCABundle, test.wantOIDCCABundle)
+			}
+			if profileConfig.RadosGWProxyURL != test.wantProxyURL || profileConfig.RadosGWOIDCNoProxy != test.wantOIDCNoProxy {
+				t.Errorf("GetProfileConfigFromEnv() proxy_url, oidc_no_proxy = %q %q, want %q %q", profileConfig.RadosGWProxyURL, profileConfig.RadosGWOIDCNoProxy, test.wantProxyURL, test.wantOIDCNoProxy)
+			}
+			if profileConfig.RadosGWMaxAttempts != test.wantMaxAttempts {
+				t.Errorf("GetProfileConfigFromEnv() max_attempts = %q, want %q", profileConfig.RadosGWMaxAttempts, test.wantMaxAttempts)
+			}
+			if profileConfig.SessionPolicyFile != test.wantSessionPolicyFile || profileConfig.SessionPolicyARNs != test.wantSessionPolicyARNs {
+				t.Errorf("GetProfileConfigFromEnv() session_policy_file, session_policy_arns = %q %q, want %q %q", profileConfig.SessionPolicyFile, profileConfig.SessionPolicyARNs, test.wantSessionPolicyFile, test.wantSessionPolicyARNs)
+			}
+			wantRegion := test.wantRegion
+			if wantRegion == "" {
+				wantRegion = DefaultRegion
+			}
+			if profileConfig.STSEndpointURL != test.wantSTSEndpointURL || profileConfig.Region != wantRegion {
+				t.Errorf("GetProfileConfigFromEnv() sts_endpoint_url, region = %q %q, want %q %q", profileConfig.STSEndpointURL, profileConfig.Region, test.wantSTSEndpointURL, wantRegion)
+			}
+			if profileConfig.STSEndpoints != test.wantSTSEndpoints {
+				t.Errorf("GetProfileConfigFromEnv() sts_endpoints = %q, want %q", profileConfig.STSEndpoints, test.wantSTSEndpoints)
+			}
+			if profileConfig.WebIdentityTokenFile != test.wantTokenFile {
+				t.Errorf("GetProfileConfigFromEnv() token_file = %v, want %v", profileConfig.WebIdentityTokenFile, test.wantTokenFile)
+			}
 		})
 	}
 }
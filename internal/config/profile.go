@@ -24,7 +24,7 @@ func GetRadosGWProfiles(awsConfig *ini.File) []string {
 		}
 
 		// Direct profiles provide their endpoint locally.
-		if section.HasKey("endpoint_url") && (section.HasKey("radosgw_oidc_provider") || section.HasKey("role_arn")) {
+		if (section.HasKey("endpoint_url") || section.HasKey("sts_endpoints")) && (section.HasKey("radosgw_oidc_provider") || section.HasKey("role_arn")) {
 			profiles = append(profiles, profileName)
 			continue
 		}
@@ -45,7 +45,7 @@ func GetRadosGWProfiles(awsConfig *ini.File) []string {
 		if err != nil {
 			continue
 		}
-		if resolvedConfig.EndpointURL != "" && (resolvedConfig.RadosGWOIDCProvider != "" || resolvedConfig.RoleArn != "") {
+		if (resolvedConfig.EndpointURL != "" || resolvedConfig.STSEndpoints != "") && (resolvedConfig.RadosGWOIDCProvider != "" || resolvedConfig.RoleArn != "") {
 			profiles = append(profiles, profileName)
 		}
 	}
@@ -71,6 +71,9 @@ func GetProfileConfig(profileName string, awsConfig *ini.File) (*ProfileConfig,
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse profile config: %w", err)
 		}
+		if err := rejectInlineSecrets(sec); err != nil {
+			return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+		}
 		if err := profileConfig.ValidateValues(); err != nil {
 			return nil, fmt.Errorf("profile '%s': %w", profileName, err)
 		}
@@ -84,3 +87,12 @@ func GetProfileConfig(profileName string, awsConfig *ini.File) (*ProfileConfig,
 
 	return profileConfig, nil
 }
+
+// rejectInlineSecrets refuses client secrets written directly into the shared
+// AWS config file, which is commonly world-readable and copied between hosts.
+func rejectInlineSecrets(section *ini.Section) error {
+	if section.HasKey("radosgw_oidc_client_secret") {
+		return fmt.Errorf("radosgw_oidc_client_secret must not be stored in ~/.aws/config. Use the RADOSGW_OIDC_CLIENT_SECRET environment variable or radosgw_oidc_client_secret_file")
+	}
+	return nil
+}
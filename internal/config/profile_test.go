@@ -13,6 +13,10 @@ endpoint_url = https://test.example.com
 radosgw_oidc_provider = https://oidc.example.com
 role_arn = arn:aws:iam::123456789012:role/TestRole
 
+[profile gateways]
+sts_endpoints = https://rgw1.example.com https://rgw2.example.com
+role_arn = arn:aws:iam::123456789012:role/TestRole
+
 [profile incomplete-profile]
 endpoint_url = https://test2.example.com
 
@@ -28,7 +32,7 @@ radosgw_oidc_provider = https://oidc2.example.com
 
 	profiles := GetRadosGWProfiles(config)
 
-	expected := []string{"test-profile", "another-test"}
+	expected := []string{"test-profile", "gateways", "another-test"}
 	if len(profiles) != len(expected) {
 		t.Errorf("GetRadosGWProfiles() returned %d profiles, want %d", len(profiles), len(expected))
 	}
@@ -174,3 +178,32 @@ func TestGetProfileConfigRejectsInvalidValues(t *testing.T) {
 		})
 	}
 }
+
+func TestGetProfileConfigRejectsInlineClientSecret(t *testing.T) {
+	awsConfig, err := ini.Load([]byte(`[profile batch]
+endpoint_url = https://storage.example.com
+radosgw_oidc_auth_type = client_credentials
+radosgw_oidc_client_secret = do-not-store-me
+
+[profile derived]
+source_profile = batch
+role_arn = arn:aws:iam::123456789012:role/BatchRole
+`))
+	if err != nil {
+		t.Fatalf("ini.Load() error = %v", err)
+	}
+
+	_, err = GetProfileConfig("batch", awsConfig)
+	if err == nil || !strings.Contains(err.Error(), "radosgw_oidc_client_secret must not be stored") {
+		t.Errorf("GetProfileConfig() error = %v, want inline secret rejection", err)
+	}
+
+	derivedConfig, err := GetProfileConfig("derived", awsConfig)
+	if err != nil {
+		t.Fatalf("GetProfileConfig() error = %v", err)
+	}
+	_, err = ResolveSourceProfile(derivedConfig, awsConfig, false)
+	if err == nil || !strings.Contains(err.Error(), "radosgw_oidc_client_secret must not be stored") {
+		t.Errorf("ResolveSourceProfile() error = %v, want inline secret rejection", err)
+	}
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ParseProxyURL parses a radosgw_proxy_url or radosgw_oidc_proxy_url value
+// named by key. HTTP, HTTPS and SOCKS5 proxies are supported; socks5h
+// resolves host names on the proxy. An empty value returns nil.
+func ParseProxyURL(key, value string) (*url.URL, error) {
+	if value == "" {
+		return nil, nil
+	}
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("invalid %s %q: scheme must be http, https, socks5 or socks5h", key, proxyURL.Redacted())
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid %s %q: missing proxy host", key, proxyURL.Redacted())
+	}
+	return proxyURL, nil
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultMaxAttempts is the number of attempts made for OIDC discovery,
+	// device polling and STS requests when radosgw_max_attempts is unset.
+	DefaultMaxAttempts = 3
+	// MaxAttemptsLimit keeps a misconfigured profile from retrying for
+	// minutes before reporting an outage.
+	MaxAttemptsLimit = 10
+)
+
+// ParseMaxAttempts parses the radosgw_max_attempts value: the total number of
+// attempts, so 1 disables retries. An empty value returns DefaultMaxAttempts.
+func ParseMaxAttempts(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return DefaultMaxAttempts, nil
+	}
+	attempts, err := strconv.Atoi(value)
+	if err != nil || attempts < 1 || attempts > MaxAttemptsLimit {
+		return 0, fmt.Errorf("invalid radosgw_max_attempts %q: must be a number between 1 and %d", value, MaxAttemptsLimit)
+	}
+	return attempts, nil
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMaxAttempts(t *testing.T) {
+	for _, test := range []struct {
+		value       string
+		want        int
+		wantContain string
+	}{
+		{value: "", want: DefaultMaxAttempts},
+		{value: "1", want: 1},
+		{value: " 5 ", want: 5},
+		{value: "10", want: 10},
+		{value: "0", wantContain: `invalid radosgw_max_attempts "0": must be a number between 1 and 10`},
+		{value: "11", wantContain: "between 1 and 10"},
+		{value: "three", wantContain: "between 1 and 10"},
+	} {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := ParseMaxAttempts(test.value)
+			if test.wantContain != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+					t.Fatalf("ParseMaxAttempts(%q) error = %v, want containing %q", test.value, err, test.wantContain)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Errorf("ParseMaxAttempts(%q) = %d, %v, want %d", test.value, got, err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxSessionPolicyARNs is the number of managed session policies STS accepts
+// in a single request.
+const MaxSessionPolicyARNs = 10
+
+// ParseSessionPolicy validates a session policy document taken from source,
+// the profile key or file that supplied it, and returns it without
+// insignificant whitespace because STS limits the packed policy size.
+func ParseSessionPolicy(source, document string) (string, error) {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(document)); err != nil {
+		return "", fmt.Errorf("invalid %s: policy is not valid JSON: %w", source, err)
+	}
+	if compacted.Len() == 0 || compacted.Bytes()[0] != '{' {
+		return "", fmt.Errorf("invalid %s: policy must be a JSON object", source)
+	}
+	return compacted.String(), nil
+}
+
+// ParseSessionPolicyARNs parses a session_policy_arns value: managed policy
+// ARNs separated by commas or whitespace. An empty value returns no ARNs.
+func ParseSessionPolicyARNs(value string) ([]string, error) {
+	arns := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	for _, arn := range arns {
+		if !strings.HasPrefix(arn, "arn:") {
+			return nil, fmt.Errorf("invalid session_policy_arns %q: %q is not an ARN", value, arn)
+		}
+	}
+	if len(arns) > MaxSessionPolicyARNs {
+		return nil, fmt.Errorf("invalid session_policy_arns %q: at most %d managed policies are allowed", value, MaxSessionPolicyARNs)
+	}
+	return arns, nil
+}
+
+func validateSessionPolicy(profileConfig *ProfileConfig) error {
+	if profileConfig.SessionPolicy != "" {
+		if profileConfig.SessionPolicyFile != "" {
+			return fmt.Errorf("session_policy and session_policy_file cannot be used together")
+		}
+		if _, err := ParseSessionPolicy("session_policy", profileConfig.SessionPolicy); err != nil {
+			return err
+		}
+	}
+	_, err := ParseSessionPolicyARNs(profileConfig.SessionPolicyARNs)
+	return err
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSessionPolicy(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		document    string
+		want        string
+		wantContain string
+	}{
+		{
+			name:     "compacts whitespace",
+			document: "{\n  \"Version\": \"2012-10-17\",\n  \"Statement\": [{\"Effect\": \"Allow\", \"Action\": \"s3:GetObject\", \"Resource\": \"arn:aws:s3:::ci/*\"}]\n}\n",
+			want:     `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::ci/*"}]}`,
+		},
+		{name: "invalid JSON", document: `{"Version": "2012-10-17",}`, wantContain: "invalid session_policy: policy is not valid JSON"},
+		{name: "empty", document: "  ", wantContain: "invalid session_policy: policy is not valid JSON"},
+		{name: "not an object", document: `["s3:GetObject"]`, wantContain: "invalid session_policy: policy must be a JSON object"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseSessionPolicy("session_policy", test.document)
+			if test.wantContain != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+					t.Fatalf("ParseSessionPolicy() error = %v, want containing %q", err, test.wantContain)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Errorf("ParseSessionPolicy() = %q, %v, want %q", got, err, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSessionPolicyARNs(t *testing.T) {
+	for _, test := range []struct {
+		value       string
+		want        []string
+		wantContain string
+	}{
+		{value: ""},
+		{value: "arn:aws:iam::aws:policy/ReadOnly", want: []string{"arn:aws:iam::aws:policy/ReadOnly"}},
+		{value: "arn:aws:iam::123:policy/a, arn:aws:iam::123:policy/b", want: []string{"arn:aws:iam::123:policy/a", "arn:aws:iam::123:policy/b"}},
+		{value: "arn:aws:iam::123:policy/a arn:aws:iam::123:policy/b", want: []string{"arn:aws:iam::123:policy/a", "arn:aws:iam::123:policy/b"}},
+		{value: "ReadOnly", wantContain: `invalid session_policy_arns "ReadOnly": "ReadOnly" is not an ARN`},
+		{value: strings.Repeat("arn:aws:iam::123:policy/p,", 11), wantContain: "at most 10 managed policies"},
+	} {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := ParseSessionPolicyARNs(test.value)
+			if test.wantContain != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+					t.Fatalf("ParseSessionPolicyARNs(%q) error = %v, want containing %q", test.value, err, test.wantContain)
+				}
+				return
+			}
+			if err != nil || strings.Join(got, " ") != strings.Join(test.want, " ") {
+				t.Errorf("ParseSessionPolicyARNs(%q) = %q, %v, want %q", test.value, got, err, test.want)
+			}
+		})
+	}
+}
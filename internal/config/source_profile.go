@@ -8,21 +8,41 @@ import (
 	"gopkg.in/ini.v1"
 )
 
+// RoleChainHop is a profile whose role is assumed on the way to another
+// profile's role. Config holds the profile's settings after source_profile
+// inheritance.
+type RoleChainHop struct {
+	ProfileName string
+	Config      *ProfileConfig
+}
+
 // ResolveSourceProfile resolves source_profile inheritance
 func ResolveSourceProfile(profileConfig *ProfileConfig, awsConfig *ini.File, verboseMode bool) (*ProfileConfig, error) {
+	_, resolvedConfig, err := ResolveRoleChain(profileConfig, awsConfig, verboseMode)
+	return resolvedConfig, err
+}
+
+// ResolveRoleChain resolves source_profile inheritance like
+// ResolveSourceProfile and also returns the roles assumed before the
+// profile's own. A source profile that sets role_arn is not merged away: its
+// role is assumed first and the profile's role is then assumed with those
+// temporary credentials. The chain lists these profiles in the order their
+// roles are assumed, starting with the one that uses web identity, and is
+// empty when the profile's own role is assumed with web identity.
+func ResolveRoleChain(profileConfig *ProfileConfig, awsConfig *ini.File, verboseMode bool) ([]RoleChainHop, *ProfileConfig, error) {
 	return resolveSourceProfile(profileConfig, awsConfig, verboseMode, nil)
 }
 
-func resolveSourceProfile(profileConfig *ProfileConfig, awsConfig *ini.File, verboseMode bool, chain []string) (*ProfileConfig, error) {
+func resolveSourceProfile(profileConfig *ProfileConfig, awsConfig *ini.File, verboseMode bool, chain []string) ([]RoleChainHop, *ProfileConfig, error) {
 	if profileConfig.SourceProfile == "" {
-		return profileConfig, nil
+		return nil, profileConfig, nil
 	}
 
 	sourceProfile := profileConfig.SourceProfile
 	for index, profileName := range chain {
 		if profileName == sourceProfile {
 			cycle := append(append([]string{}, chain[index:]...), sourceProfile)
-			return nil, fmt.Errorf("source_profile cycle detected: %s", strings.Join(cycle, " -> "))
+			return nil, nil, fmt.Errorf("source_profile cycle detected: %s", strings.Join(cycle, " -> "))
 		}
 	}
 	chain = append(chain, sourceProfile)
@@ -32,14 +52,18 @@ func resolveSourceProfile(profileConfig *ProfileConfig, awsConfig *ini.File, ver
 	}
 	sourceConfig, err := getProfileConfigForResolution(sourceProfile, awsConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve source_profile chain %s: %w", strings.Join(chain, " -> "), err)
+		return nil, nil, fmt.Errorf("failed to resolve source_profile chain %s: %w", strings.Join(chain, " -> "), err)
 	}
-	resolvedSourceConfig, err := resolveSourceProfile(sourceConfig, awsConfig, verboseMode, chain)
+	roleChain, resolvedSourceConfig, err := resolveSourceProfile(sourceConfig, awsConfig, verboseMode, chain)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return mergeProfileConfigs(resolvedSourceConfig, profileConfig), nil
+	if sourceConfig.RoleArn == "" {
+		return roleChain, mergeProfileConfigs(resolvedSourceConfig, profileConfig), nil
+	}
+	roleChain = append(roleChain, RoleChainHop{ProfileName: sourceProfile, Config: resolvedSourceConfig})
+	return roleChain, chainProfileConfigs(resolvedSourceConfig, profileConfig), nil
 }
 
 func getProfileConfigForResolution(profileName string, awsConfig *ini.File) (*ProfileConfig, error) {
@@ -57,12 +81,28 @@ func getProfileConfigForResolution(profileName string, awsConfig *ini.File) (*Pr
 	if err := section.MapTo(profileConfig); err != nil {
 		return nil, fmt.Errorf("failed to parse profile '%s': %w", profileName, err)
 	}
+	if err := rejectInlineSecrets(section); err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+	}
 	if err := profileConfig.ValidateValues(); err != nil {
 		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
 	}
 	return profileConfig, nil
 }
 
+// chainProfileConfigs inherits the connection and OIDC settings of a source
+// profile that assumes a role itself. The role and the settings that only
+// apply to assuming it stay the profile's own.
+func chainProfileConfigs(sourceConfig, profileConfig *ProfileConfig) *ProfileConfig {
+	chainedConfig := mergeProfileConfigs(sourceConfig, profileConfig)
+	chainedConfig.RoleArn = profileConfig.RoleArn
+	chainedConfig.RoleSessionName = profileConfig.RoleSessionName
+	chainedConfig.SessionPolicy = profileConfig.SessionPolicy
+	chainedConfig.SessionPolicyFile = profileConfig.SessionPolicyFile
+	chainedConfig.SessionPolicyARNs = profileConfig.SessionPolicyARNs
+	return chainedConfig
+}
+
 func mergeProfileConfigs(sourceConfig, profileConfig *ProfileConfig) *ProfileConfig {
 	// Merge configs: source config as base, current profile overrides
 	mergedConfig := *sourceConfig
@@ -71,6 +111,15 @@ func mergeProfileConfigs(sourceConfig, profileConfig *ProfileConfig) *ProfileCon
 	if profileConfig.EndpointURL != "" {
 		mergedConfig.EndpointURL = profileConfig.EndpointURL
 	}
+	// A single STS endpoint and a list of gateways are alternatives, so
+	// either one replaces an inherited value of the other kind.
+	if profileConfig.STSEndpointURL != "" || profileConfig.STSEndpoints != "" {
+		mergedConfig.STSEndpointURL = profileConfig.STSEndpointURL
+		mergedConfig.STSEndpoints = profileConfig.STSEndpoints
+	}
+	if profileConfig.Region != "" {
+		mergedConfig.Region = profileConfig.Region
+	}
 	if profileConfig.RadosGWOIDCProvider != "" {
 		mergedConfig.RadosGWOIDCProvider = profileConfig.RadosGWOIDCProvider
 	}
@@ -86,15 +135,111 @@ func mergeProfileConfigs(sourceConfig, profileConfig *ProfileConfig) *ProfileCon
 	if profileConfig.RadosGWOIDCPKCEMethod != "" {
 		mergedConfig.RadosGWOIDCPKCEMethod = profileConfig.RadosGWOIDCPKCEMethod
 	}
+	if profileConfig.RadosGWOIDCTokenType != "" {
+		mergedConfig.RadosGWOIDCTokenType = profileConfig.RadosGWOIDCTokenType
+	}
+	if profileConfig.RadosGWOIDCAudience != "" {
+		mergedConfig.RadosGWOIDCAudience = profileConfig.RadosGWOIDCAudience
+	}
+	if profileConfig.RadosGWOIDCResource != "" {
+		mergedConfig.RadosGWOIDCResource = profileConfig.RadosGWOIDCResource
+	}
+	if profileConfig.RadosGWOIDCPrompt != "" {
+		mergedConfig.RadosGWOIDCPrompt = profileConfig.RadosGWOIDCPrompt
+	}
+	if profileConfig.RadosGWOIDCLoginHint != "" {
+		mergedConfig.RadosGWOIDCLoginHint = profileConfig.RadosGWOIDCLoginHint
+	}
+	if profileConfig.RadosGWOIDCACRValues != "" {
+		mergedConfig.RadosGWOIDCACRValues = profileConfig.RadosGWOIDCACRValues
+	}
+	if profileConfig.RadosGWOIDCClientAuthMethod != "" {
+		mergedConfig.RadosGWOIDCClientAuthMethod = profileConfig.RadosGWOIDCClientAuthMethod
+	}
+	if profileConfig.RadosGWOIDCClientSecretFile != "" {
+		mergedConfig.RadosGWOIDCClientSecretFile = profileConfig.RadosGWOIDCClientSecretFile
+	}
+	if profileConfig.RadosGWOIDCClientKeyFile != "" {
+		mergedConfig.RadosGWOIDCClientKeyFile = profileConfig.RadosGWOIDCClientKeyFile
+	}
+	if profileConfig.RadosGWOIDCSubjectAuthType != "" {
+		mergedConfig.RadosGWOIDCSubjectAuthType = profileConfig.RadosGWOIDCSubjectAuthType
+	}
+	if profileConfig.RadosGWOIDCSubjectTokenType != "" {
+		mergedConfig.RadosGWOIDCSubjectTokenType = profileConfig.RadosGWOIDCSubjectTokenType
+	}
+	if profileConfig.RadosGWOIDCRequestedTokenType != "" {
+		mergedConfig.RadosGWOIDCRequestedTokenType = profileConfig.RadosGWOIDCRequestedTokenType
+	}
+	if profileConfig.RadosGWOIDCBrowserMode != "" {
+		mergedConfig.RadosGWOIDCBrowserMode = profileConfig.RadosGWOIDCBrowserMode
+	}
+	if profileConfig.RadosGWOIDCRequirePAR != "" {
+		mergedConfig.RadosGWOIDCRequirePAR = profileConfig.RadosGWOIDCRequirePAR
+	}
+	if profileConfig.RadosGWOIDCCallbackPorts != "" {
+		mergedConfig.RadosGWOIDCCallbackPorts = profileConfig.RadosGWOIDCCallbackPorts
+	}
+	if profileConfig.RadosGWOIDCCallbackHost != "" {
+		mergedConfig.RadosGWOIDCCallbackHost = profileConfig.RadosGWOIDCCallbackHost
+	}
+	if profileConfig.RadosGWOIDCCallbackPath != "" {
+		mergedConfig.RadosGWOIDCCallbackPath = profileConfig.RadosGWOIDCCallbackPath
+	}
 	if profileConfig.RadosGWSSLVerify != "" {
 		mergedConfig.RadosGWSSLVerify = profileConfig.RadosGWSSLVerify
 	}
+	if profileConfig.RadosGWTLSClientCertFile != "" {
+		mergedConfig.RadosGWTLSClientCertFile = profileConfig.RadosGWTLSClientCertFile
+	}
+	if profileConfig.RadosGWTLSClientKeyFile != "" {
+		mergedConfig.RadosGWTLSClientKeyFile = profileConfig.RadosGWTLSClientKeyFile
+	}
+	if profileConfig.CABundle != "" {
+		mergedConfig.CABundle = profileConfig.CABundle
+	}
+	if profileConfig.RadosGWOIDCCABundle != "" {
+		mergedConfig.RadosGWOIDCCABundle = profileConfig.RadosGWOIDCCABundle
+	}
+	if profileConfig.RadosGWTLSPinnedSHA256 != "" {
+		mergedConfig.RadosGWTLSPinnedSHA256 = profileConfig.RadosGWTLSPinnedSHA256
+	}
+	if profileConfig.RadosGWOIDCTLSPinnedSHA256 != "" {
+		mergedConfig.RadosGWOIDCTLSPinnedSHA256 = profileConfig.RadosGWOIDCTLSPinnedSHA256
+	}
+	if profileConfig.RadosGWProxyURL != "" {
+		mergedConfig.RadosGWProxyURL = profileConfig.RadosGWProxyURL
+	}
+	if profileConfig.RadosGWOIDCProxyURL != "" {
+		mergedConfig.RadosGWOIDCProxyURL = profileConfig.RadosGWOIDCProxyURL
+	}
+	if profileConfig.RadosGWNoProxy != "" {
+		mergedConfig.RadosGWNoProxy = profileConfig.RadosGWNoProxy
+	}
+	if profileConfig.RadosGWOIDCNoProxy != "" {
+		mergedConfig.RadosGWOIDCNoProxy = profileConfig.RadosGWOIDCNoProxy
+	}
+	if profileConfig.RadosGWMaxAttempts != "" {
+		mergedConfig.RadosGWMaxAttempts = profileConfig.RadosGWMaxAttempts
+	}
+	if profileConfig.WebIdentityTokenFile != "" {
+		mergedConfig.WebIdentityTokenFile = profileConfig.WebIdentityTokenFile
+	}
 	if profileConfig.RoleArn != "" {
 		mergedConfig.RoleArn = profileConfig.RoleArn
 	}
 	if profileConfig.RoleSessionName != "" {
 		mergedConfig.RoleSessionName = profileConfig.RoleSessionName
 	}
+	// An inline policy and a policy file are alternatives, so either one
+	// replaces an inherited policy of the other kind.
+	if profileConfig.SessionPolicy != "" || profileConfig.SessionPolicyFile != "" {
+		mergedConfig.SessionPolicy = profileConfig.SessionPolicy
+		mergedConfig.SessionPolicyFile = profileConfig.SessionPolicyFile
+	}
+	if profileConfig.SessionPolicyARNs != "" {
+		mergedConfig.SessionPolicyARNs = profileConfig.SessionPolicyARNs
+	}
 	mergedConfig.SourceProfile = ""
 
 	return &mergedConfig
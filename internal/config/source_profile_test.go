@@ -10,16 +10,35 @@ import (
 func TestResolveSourceProfile(t *testing.T) {
 	configContent := `[profile base-profile]
 endpoint_url = https://base.example.com
+sts_endpoint_url = https://sts.base.example.com
+region = zonegroup-a
 radosgw_oidc_provider = https://base-oidc.example.com
 radosgw_oidc_client_id = base-client
 radosgw_oidc_scope = openid
 radosgw_oidc_pkce_method = S256
+radosgw_oidc_callback_ports = 8400,8401
+radosgw_oidc_callback_path = /callback
+radosgw_oidc_require_par = true
+radosgw_tls_client_cert_file = /etc/radosgw/base.crt
+radosgw_tls_client_key_file = /etc/radosgw/base.key
+ca_bundle = /etc/radosgw/storage-ca.pem
+radosgw_oidc_tls_pinned_sha256 = 0000000000000000000000000000000000000000000000000000000000000000
+radosgw_proxy_url = http://proxy.example.com:3128
+radosgw_no_proxy = .internal
+radosgw_max_attempts = 5
+session_policy = {"Version":"2012-10-17","Statement":[]}
+session_policy_arns = arn:aws:iam::aws:policy/ReadOnly
 
 [profile derived-profile]
 source_profile = base-profile
 role_arn = arn:aws:iam::123456789012:role/DerivedRole
+region = zonegroup-b
 radosgw_oidc_scope = openid custom
 radosgw_oidc_pkce_method = plain
+radosgw_oidc_callback_path = /derived/callback
+radosgw_tls_client_cert_file = /etc/radosgw/derived.crt
+radosgw_proxy_url = socks5://proxy.example.com:1080
+session_policy_file = /etc/radosgw/derived-policy.json
 `
 
 	config, err := ini.Load([]byte(configContent))
@@ -43,6 +62,9 @@ radosgw_oidc_pkce_method = plain
 	if resolvedConfig.RoleArn != "arn:aws:iam::123456789012:role/DerivedRole" {
 		t.Errorf("ResolveSourceProfile() role_arn = %v, want %v", resolvedConfig.RoleArn, "arn:aws:iam::123456789012:role/DerivedRole")
 	}
+	if resolvedConfig.STSEndpointURL != "https://sts.base.example.com" || resolvedConfig.Region != "zonegroup-b" {
+		t.Errorf("ResolveSourceProfile() sts_endpoint_url, region = %v %v, want inherited endpoint and zonegroup-b", resolvedConfig.STSEndpointURL, resolvedConfig.Region)
+	}
 	if resolvedConfig.RadosGWOIDCProvider != "https://base-oidc.example.com" {
 		t.Errorf("ResolveSourceProfile() oidc_provider = %v, want %v", resolvedConfig.RadosGWOIDCProvider, "https://base-oidc.example.com")
 	}
@@ -52,6 +74,80 @@ radosgw_oidc_pkce_method = plain
 	if resolvedConfig.RadosGWOIDCPKCEMethod != "plain" {
 		t.Errorf("ResolveSourceProfile() oidc_pkce_method = %v, want plain", resolvedConfig.RadosGWOIDCPKCEMethod)
 	}
+	if resolvedConfig.RadosGWOIDCCallbackPorts != "8400,8401" {
+		t.Errorf("ResolveSourceProfile() oidc_callback_ports = %v, want inherited 8400,8401", resolvedConfig.RadosGWOIDCCallbackPorts)
+	}
+	if resolvedConfig.RadosGWOIDCCallbackPath != "/derived/callback" {
+		t.Errorf("ResolveSourceProfile() oidc_callback_path = %v, want /derived/callback", resolvedConfig.RadosGWOIDCCallbackPath)
+	}
+	if resolvedConfig.RadosGWOIDCRequirePAR != "true" {
+		t.Errorf("ResolveSourceProfile() oidc_require_par = %v, want inherited true", resolvedConfig.RadosGWOIDCRequirePAR)
+	}
+	if resolvedConfig.RadosGWTLSClientCertFile != "/etc/radosgw/derived.crt" || resolvedConfig.RadosGWTLSClientKeyFile != "/etc/radosgw/base.key" {
+		t.Errorf("ResolveSourceProfile() tls_client_cert_file, tls_client_key_file = %v %v, want /etc/radosgw/derived.crt /etc/radosgw/base.key", resolvedConfig.RadosGWTLSClientCertFile, resolvedConfig.RadosGWTLSClientKeyFile)
+	}
+	if resolvedConfig.CABundle != "/etc/radosgw/storage-ca.pem" || resolvedConfig.RadosGWOIDCTLSPinnedSHA256 == "" {
+		t.Errorf("ResolveSourceProfile() ca_bundle, oidc_tls_pinned_sha256 = %v %v, want inherited values", resolvedConfig.CABundle, resolvedConfig.RadosGWOIDCTLSPinnedSHA256)
+	}
+	if resolvedConfig.RadosGWMaxAttempts != "5" {
+		t.Errorf("ResolveSourceProfile() max_attempts = %v, want inherited 5", resolvedConfig.RadosGWMaxAttempts)
+	}
+	if resolvedConfig.RadosGWProxyURL != "socks5://proxy.example.com:1080" || resolvedConfig.RadosGWNoProxy != ".internal" {
+		t.Errorf("ResolveSourceProfile() proxy_url, no_proxy = %v %v, want socks5://proxy.example.com:1080 .internal", resolvedConfig.RadosGWProxyURL, resolvedConfig.RadosGWNoProxy)
+	}
+	if resolvedConfig.SessionPolicy != "" || resolvedConfig.SessionPolicyFile != "/etc/radosgw/derived-policy.json" {
+		t.Errorf("ResolveSourceProfile() session_policy, session_policy_file = %v %v, want only the derived policy file", resolvedConfig.SessionPolicy, resolvedConfig.SessionPolicyFile)
+	}
+	if resolvedConfig.SessionPolicyARNs != "arn:aws:iam::aws:policy/ReadOnly" {
+		t.Errorf("ResolveSourceProfile() session_policy_arns = %v, want inherited arn:aws:iam::aws:policy/ReadOnly", resolvedConfig.SessionPolicyARNs)
+	}
+}
+
+func TestResolveSourceProfileSTSEndpointAlternatives(t *testing.T) {
+	for _, test := range []struct {
+		name             string
+		base             string
+		derived          string
+		wantSTSEndpoint  string
+		wantSTSEndpoints string
+	}{
+		{
+			name:            "sts_endpoint_url replaces inherited sts_endpoints",
+			base:            "sts_endpoints = https://rgw1.example.com https://rgw2.example.com",
+			derived:         "sts_endpoint_url = https://sts.example.com",
+			wantSTSEndpoint: "https://sts.example.com",
+		},
+		{
+			name:             "sts_endpoints replaces inherited sts_endpoint_url",
+			base:             "sts_endpoint_url = https://sts.example.com",
+			derived:          "sts_endpoints = https://rgw1.example.com https://rgw2.example.com",
+			wantSTSEndpoints: "https://rgw1.example.com https://rgw2.example.com",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := ini.Load([]byte("[profile base]\nendpoint_url = https://s3.example.com\n" + test.base +
+				"\n\n[profile derived]\nsource_profile = base\nrole_arn = arn:aws:iam::123456789012:role/Derived\n" + test.derived + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			derivedConfig, err := GetProfileConfig("derived", config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resolvedConfig, err := ResolveSourceProfile(derivedConfig, config, false)
+			if err != nil {
+				t.Fatalf("ResolveSourceProfile() error = %v", err)
+			}
+			if resolvedConfig.STSEndpointURL != test.wantSTSEndpoint || resolvedConfig.STSEndpoints != test.wantSTSEndpoints {
+				t.Errorf("ResolveSourceProfile() sts_endpoint_url, sts_endpoints = %q %q, want %q %q",
+					resolvedConfig.STSEndpointURL, resolvedConfig.STSEndpoints, test.wantSTSEndpoint, test.wantSTSEndpoints)
+			}
+			if _, err := resolvedConfig.Normalize(); err != nil {
+				t.Errorf("Normalize() error = %v", err)
+			}
+		})
+	}
 }
 
 func TestResolveNestedSourceProfiles(t *testing.T) {
@@ -69,7 +165,15 @@ role_session_name = base-session
 source_profile = base
 radosgw_oidc_client_id = shared-client
 radosgw_oidc_scope = openid groups
+radosgw_oidc_token_type = id_token
+radosgw_oidc_audience = sts.example.com
+radosgw_oidc_client_auth_method = client_secret_post
+radosgw_oidc_client_secret_file = /run/secrets/client-secret
+radosgw_oidc_client_private_key_file = /etc/radosgw/client-key.pem
+radosgw_oidc_subject_auth_type = github-actions
+radosgw_oidc_subject_token_type = urn:ietf:params:oauth:token-type:jwt
 radosgw_ssl_verify = false
+web_identity_token_file = /var/run/secrets/tokens/radosgw
 
 [profile leaf]
 source_profile = shared
@@ -94,21 +198,97 @@ role_session_name = leaf-session
 	}
 
 	want := &ProfileConfig{
-		EndpointURL:           "https://base.example.com",
-		RadosGWOIDCProvider:   "https://base-oidc.example.com",
-		RadosGWOIDCClientID:   "shared-client",
-		RadosGWOIDCAuthType:   "browser",
-		RadosGWOIDCScope:      "openid groups",
-		RadosGWOIDCPKCEMethod: "plain",
-		RadosGWSSLVerify:      "false",
-		RoleArn:               "arn:aws:iam::123456789012:role/LeafRole",
-		RoleSessionName:       "leaf-session",
+		EndpointURL:                 "https://base.example.com",
+		RadosGWOIDCProvider:         "https://base-oidc.example.com",
+		RadosGWOIDCClientID:         "shared-client",
+		RadosGWOIDCAuthType:         "browser",
+		RadosGWOIDCScope:            "openid groups",
+		RadosGWOIDCPKCEMethod:       "plain",
+		RadosGWOIDCTokenType:        "id_token",
+		RadosGWOIDCAudience:         "sts.example.com",
+		RadosGWOIDCClientAuthMethod: "client_secret_post",
+		RadosGWOIDCClientSecretFile: "/run/secrets/client-secret",
+		RadosGWOIDCClientKeyFile:    "/etc/radosgw/client-key.pem",
+		RadosGWOIDCSubjectAuthType:  "github-actions",
+		RadosGWOIDCSubjectTokenType: "urn:ietf:params:oauth:token-type:jwt",
+		RadosGWSSLVerify:            "false",
+		WebIdentityTokenFile:        "/var/run/secrets/tokens/radosgw",
+		RoleArn:                     "arn:aws:iam::123456789012:role/LeafRole",
+		RoleSessionName:             "leaf-session",
 	}
 	if *resolvedConfig != *want {
 		t.Errorf("ResolveSourceProfile() = %#v, want %#v", resolvedConfig, want)
 	}
 }
 
+func TestResolveRoleChain(t *testing.T) {
+	awsConfig, err := ini.Load([]byte(`[profile base]
+endpoint_url = https://storage.example.com
+radosgw_oidc_provider = https://oidc.example.com
+radosgw_oidc_client_id = radosgw
+role_session_name = base-session
+
+[profile entry]
+source_profile = base
+role_arn = arn:aws:iam::123456789012:role/Entry
+session_policy_arns = arn:aws:iam::aws:policy/EntryBoundary
+
+[profile tenant-settings]
+source_profile = entry
+radosgw_max_attempts = 5
+
+[profile tenant]
+source_profile = tenant-settings
+role_arn = arn:aws:iam::123456789012:role/Tenant
+role_session_name = tenant-session
+
+[profile tenant-admin]
+source_profile = tenant
+role_arn = arn:aws:iam::123456789012:role/TenantAdmin
+endpoint_url = https://admin.storage.example.com
+`))
+	if err != nil {
+		t.Fatalf("ini.Load() error = %v", err)
+	}
+	profileConfig, err := GetProfileConfig("tenant-admin", awsConfig)
+	if err != nil {
+		t.Fatalf("GetProfileConfig() error = %v", err)
+	}
+
+	roleChain, resolvedConfig, err := ResolveRoleChain(profileConfig, awsConfig, false)
+	if err != nil {
+		t.Fatalf("ResolveRoleChain() error = %v", err)
+	}
+	if len(roleChain) != 2 || roleChain[0].ProfileName != "entry" || roleChain[1].ProfileName != "tenant" {
+		t.Fatalf("ResolveRoleChain() chain = %+v, want entry then tenant", roleChain)
+	}
+	entry := roleChain[0].Config
+	if entry.RoleArn != "arn:aws:iam::123456789012:role/Entry" || entry.RoleSessionName != "base-session" || entry.RadosGWOIDCProvider != "https://oidc.example.com" {
+		t.Errorf("entry hop = %+v, want the web identity role with settings merged from base", entry)
+	}
+	tenant := roleChain[1].Config
+	if tenant.RoleArn != "arn:aws:iam::123456789012:role/Tenant" || tenant.RoleSessionName != "tenant-session" || tenant.SessionPolicyARNs != "" {
+		t.Errorf("tenant hop role, session, policy ARNs = %v %v %v, want only its own role settings", tenant.RoleArn, tenant.RoleSessionName, tenant.SessionPolicyARNs)
+	}
+	if tenant.EndpointURL != "https://storage.example.com" || tenant.RadosGWMaxAttempts != "5" || tenant.SourceProfile != "" {
+		t.Errorf("tenant hop endpoint, max attempts, source = %v %v %v, want inherited connection settings", tenant.EndpointURL, tenant.RadosGWMaxAttempts, tenant.SourceProfile)
+	}
+	if resolvedConfig.RoleArn != "arn:aws:iam::123456789012:role/TenantAdmin" || resolvedConfig.RoleSessionName != "" || resolvedConfig.EndpointURL != "https://admin.storage.example.com" {
+		t.Errorf("ResolveRoleChain() config role, session, endpoint = %v %v %v", resolvedConfig.RoleArn, resolvedConfig.RoleSessionName, resolvedConfig.EndpointURL)
+	}
+	if resolvedConfig.RadosGWOIDCClientID != "radosgw" {
+		t.Errorf("ResolveRoleChain() oidc_client_id = %v, want the web identity profile's client", resolvedConfig.RadosGWOIDCClientID)
+	}
+
+	entryConfig, err := GetProfileConfig("entry", awsConfig)
+	if err != nil {
+		t.Fatalf("GetProfileConfig() error = %v", err)
+	}
+	if roleChain, _, err := ResolveRoleChain(entryConfig, awsConfig, false); err != nil || len(roleChain) != 0 {
+		t.Errorf("ResolveRoleChain(entry) = %+v, %v, want no chained roles", roleChain, err)
+	}
+}
+
 func TestResolveSourceProfileErrors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -140,6 +320,19 @@ source_profile = cycle-a
 			profileName: "cycle-a",
 			wantContain: "cycle-b -> cycle-c -> cycle-a -> cycle-b",
 		},
+		{
+			name: "chained role cycle",
+			config: `[profile tenant]
+source_profile = entry
+role_arn = arn:aws:iam::123456789012:role/Tenant
+
+[profile entry]
+source_profile = tenant
+role_arn = arn:aws:iam::123456789012:role/Entry
+`,
+			profileName: "tenant",
+			wantContain: "entry -> tenant -> entry",
+		},
 		{
 			name: "missing nested source",
 			config: `[profile leaf]
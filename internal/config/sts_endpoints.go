@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ParseSTSEndpoints parses an sts_endpoints value: RadosGW gateway URLs
+// separated by commas or whitespace, in the order they are tried. An empty
+// value returns no endpoints.
+func ParseSTSEndpoints(value string) ([]string, error) {
+	endpoints := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	for _, endpoint := range endpoints {
+		parsed, err := url.Parse(endpoint)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid sts_endpoints %q: %q is not an http or https URL", value, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+func validateSTSEndpoints(profileConfig *ProfileConfig) error {
+	if profileConfig.STSEndpoints != "" && profileConfig.STSEndpointURL != "" {
+		return fmt.Errorf("sts_endpoint_url and sts_endpoints cannot be used together")
+	}
+	_, err := ParseSTSEndpoints(profileConfig.STSEndpoints)
+	return err
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSTSEndpoints(t *testing.T) {
+	for _, test := range []struct {
+		value       string
+		want        []string
+		wantContain string
+	}{
+		{value: ""},
+		{value: "https://rgw1.example.com", want: []string{"https://rgw1.example.com"}},
+		{value: "https://rgw1.example.com, http://rgw2.example.com:8080", want: []string{"https://rgw1.example.com", "http://rgw2.example.com:8080"}},
+		{value: "https://rgw1.example.com\thttps://rgw2.example.com", want: []string{"https://rgw1.example.com", "https://rgw2.example.com"}},
+		{value: "rgw1.example.com", wantContain: `invalid sts_endpoints "rgw1.example.com": "rgw1.example.com" is not an http or https URL`},
+		{value: "https://rgw1.example.com,ftp://rgw2.example.com", wantContain: `"ftp://rgw2.example.com" is not an http or https URL`},
+	} {
+		t.Run(test.value, func(t *testing.T) {
+			got, err := ParseSTSEndpoints(test.value)
+			if test.wantContain != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+					t.Fatalf("ParseSTSEndpoints(%q) error = %v, want containing %q", test.value, err, test.wantContain)
+				}
+				return
+			}
+			if err != nil || strings.Join(got, " ") != strings.Join(test.want, " ") {
+				t.Errorf("ParseSTSEndpoints(%q) = %v, %v, want %v", test.value, got, err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ParseCertificatePins parses a radosgw_tls_pinned_sha256 or
+// radosgw_oidc_tls_pinned_sha256 value named by key. The value lists SHA-256
+// certificate fingerprints separated by commas or spaces, in hex with or
+// without colons, as printed by "openssl x509 -noout -fingerprint -sha256".
+// An empty value returns no pins.
+func ParseCertificatePins(key, value string) ([][sha256.Size]byte, error) {
+	fields := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	var pins [][sha256.Size]byte
+	for _, field := range fields {
+		decoded, err := hex.DecodeString(strings.ReplaceAll(field, ":", ""))
+		if err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("invalid %s %q: %q is not a SHA-256 fingerprint", key, value, field)
+		}
+		pins = append(pins, [sha256.Size]byte(decoded))
+	}
+	return pins, nil
+}
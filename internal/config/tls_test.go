@@ -0,0 +1,53 @@
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseCertificatePins(t *testing.T) {
+	first := sha256.Sum256([]byte("first certificate"))
+	second := sha256.Sum256([]byte("second certificate"))
+	colonSeparated := strings.ToUpper(strings.Join(hexPairs(first), ":"))
+
+	for _, test := range []struct {
+		name        string
+		value       string
+		want        [][sha256.Size]byte
+		wantContain string
+	}{
+		{name: "unset"},
+		{name: "plain hex", value: strings.Join(hexPairs(first), ""), want: [][sha256.Size]byte{first}},
+		{name: "openssl fingerprint", value: colonSeparated, want: [][sha256.Size]byte{first}},
+		{name: "list", value: colonSeparated + ", " + strings.Join(hexPairs(second), ""), want: [][sha256.Size]byte{first, second}},
+		{name: "not hex", value: "fingerprint", wantContain: `invalid radosgw_tls_pinned_sha256 "fingerprint": "fingerprint" is not a SHA-256 fingerprint`},
+		{name: "SHA-1 length", value: strings.Repeat("ab", 20), wantContain: "is not a SHA-256 fingerprint"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseCertificatePins("radosgw_tls_pinned_sha256", test.value)
+			if test.wantContain != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+					t.Fatalf("ParseCertificatePins(%q) error = %v, want containing %q", test.value, err, test.wantContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCertificatePins(%q) error = %v", test.value, err)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("ParseCertificatePins(%q) = %x, want %x", test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func hexPairs(sum [sha256.Size]byte) []string {
+	pairs := make([]string, len(sum))
+	for index, value := range sum {
+		pairs[index] = fmt.Sprintf("%02x", value)
+	}
+	return pairs
+}
@@ -2,16 +2,51 @@ package config
 
 // ProfileConfig represents the configuration for a RadosGW profile
 type ProfileConfig struct {
-	EndpointURL           string          `ini:"endpoint_url"`
-	RadosGWOIDCProvider   string          `ini:"radosgw_oidc_provider"`
-	RadosGWOIDCClientID   string          `ini:"radosgw_oidc_client_id"`
-	RadosGWOIDCAuthType   AuthType        `ini:"radosgw_oidc_auth_type"`
-	RadosGWOIDCScope      string          `ini:"radosgw_oidc_scope"`
-	RadosGWOIDCPKCEMethod PKCEMethod      `ini:"radosgw_oidc_pkce_method"`
-	RadosGWSSLVerify      SSLVerification `ini:"radosgw_ssl_verify"`
-	RoleArn               string          `ini:"role_arn"`
-	RoleSessionName       string          `ini:"role_session_name"`
-	SourceProfile         string          `ini:"source_profile"`
+	EndpointURL                   string            `ini:"endpoint_url"`
+	STSEndpointURL                string            `ini:"sts_endpoint_url"`
+	STSEndpoints                  string            `ini:"sts_endpoints"`
+	Region                        string            `ini:"region"`
+	RadosGWOIDCProvider           string            `ini:"radosgw_oidc_provider"`
+	RadosGWOIDCClientID           string            `ini:"radosgw_oidc_client_id"`
+	RadosGWOIDCAuthType           AuthType          `ini:"radosgw_oidc_auth_type"`
+	RadosGWOIDCScope              string            `ini:"radosgw_oidc_scope"`
+	RadosGWOIDCPKCEMethod         PKCEMethod        `ini:"radosgw_oidc_pkce_method"`
+	RadosGWOIDCTokenType          TokenType         `ini:"radosgw_oidc_token_type"`
+	RadosGWOIDCAudience           string            `ini:"radosgw_oidc_audience"`
+	RadosGWOIDCResource           string            `ini:"radosgw_oidc_resource"`
+	RadosGWOIDCPrompt             string            `ini:"radosgw_oidc_prompt"`
+	RadosGWOIDCLoginHint          string            `ini:"radosgw_oidc_login_hint"`
+	RadosGWOIDCACRValues          string            `ini:"radosgw_oidc_acr_values"`
+	RadosGWOIDCClientAuthMethod   ClientAuthMethod  `ini:"radosgw_oidc_client_auth_method"`
+	RadosGWOIDCClientSecretFile   string            `ini:"radosgw_oidc_client_secret_file"`
+	RadosGWOIDCClientKeyFile      string            `ini:"radosgw_oidc_client_private_key_file"`
+	RadosGWOIDCSubjectAuthType    AuthType          `ini:"radosgw_oidc_subject_auth_type"`
+	RadosGWOIDCSubjectTokenType   ExchangeTokenType `ini:"radosgw_oidc_subject_token_type"`
+	RadosGWOIDCRequestedTokenType ExchangeTokenType `ini:"radosgw_oidc_requested_token_type"`
+	RadosGWOIDCBrowserMode        BrowserMode       `ini:"radosgw_oidc_browser_mode"`
+	RadosGWOIDCRequirePAR         RequirePAR        `ini:"radosgw_oidc_require_par"`
+	RadosGWOIDCCallbackPorts      string            `ini:"radosgw_oidc_callback_ports"`
+	RadosGWOIDCCallbackHost       CallbackHost      `ini:"radosgw_oidc_callback_host"`
+	RadosGWOIDCCallbackPath       string            `ini:"radosgw_oidc_callback_path"`
+	RadosGWSSLVerify              SSLVerification   `ini:"radosgw_ssl_verify"`
+	RadosGWTLSClientCertFile      string            `ini:"radosgw_tls_client_cert_file"`
+	RadosGWTLSClientKeyFile       string            `ini:"radosgw_tls_client_key_file"`
+	CABundle                      string            `ini:"ca_bundle"`
+	RadosGWOIDCCABundle           string            `ini:"radosgw_oidc_ca_bundle"`
+	RadosGWTLSPinnedSHA256        string            `ini:"radosgw_tls_pinned_sha256"`
+	RadosGWOIDCTLSPinnedSHA256    string            `ini:"radosgw_oidc_tls_pinned_sha256"`
+	RadosGWProxyURL               string            `ini:"radosgw_proxy_url"`
+	RadosGWOIDCProxyURL           string            `ini:"radosgw_oidc_proxy_url"`
+	RadosGWNoProxy                string            `ini:"radosgw_no_proxy"`
+	RadosGWOIDCNoProxy            string            `ini:"radosgw_oidc_no_proxy"`
+	RadosGWMaxAttempts            string            `ini:"radosgw_max_attempts"`
+	WebIdentityTokenFile          string            `ini:"web_identity_token_file"`
+	RoleArn                       string            `ini:"role_arn"`
+	RoleSessionName               string            `ini:"role_session_name"`
+	SessionPolicy                 string            `ini:"session_policy"`
+	SessionPolicyFile             string            `ini:"session_policy_file"`
+	SessionPolicyARNs             string            `ini:"session_policy_arns"`
+	SourceProfile                 string            `ini:"source_profile"`
 }
 
 // AssumeRoleResult contains the result of an STS AssumeRoleWithWebIdentity operation
@@ -23,4 +58,14 @@ type AssumeRoleResult struct {
 	ProfileName     string
 	EndpointURL     string
 	AssumedRoleArn  string
+	AssumedRoleID   string
+	// SubjectFromWebIdentityToken, Audience and Provider describe the web
+	// identity token STS accepted. A chained role keeps the values of the
+	// role assumed with web identity.
+	SubjectFromWebIdentityToken string
+	Audience                    string
+	Provider                    string
+	// PackedPolicySize is the percentage of the STS limit used by the session
+	// policies and tags, or nil when STS did not report it.
+	PackedPolicySize *int32
 }
@@ -5,6 +5,10 @@ import "fmt"
 const (
 	// DefaultOIDCScope is used when an authentication profile omits its scope.
 	DefaultOIDCScope = "openid"
+	// DefaultRegion signs STS requests when neither the profile nor the
+	// environment names a region. RadosGW accepts it unless the zonegroup
+	// name is enforced.
+	DefaultRegion = "us-east-1"
 )
 
 // AuthType identifies an OIDC authentication flow.
@@ -17,25 +21,67 @@ const (
 	AuthTypeBrowser AuthType = "browser"
 	// AuthTypeToken uses an existing token from the environment.
 	AuthTypeToken AuthType = "token"
+	// AuthTypeClientCredentials uses the OAuth 2.0 client credentials grant
+	// for confidential clients without user interaction.
+	AuthTypeClientCredentials AuthType = "client_credentials"
+	// AuthTypeGitHubActions requests an ID token from the GitHub Actions
+	// runtime.
+	AuthTypeGitHubActions AuthType = "github-actions"
+	// AuthTypeTokenExchange exchanges a subject token from another source at
+	// the configured provider (RFC 8693).
+	AuthTypeTokenExchange AuthType = "token-exchange"
 )
 
 // Validate reports whether the authentication type is empty or supported.
 // Empty values are valid because defaults are applied after profile inheritance.
 func (authType AuthType) Validate() error {
 	switch authType {
-	case "", AuthTypeDevice, AuthTypeBrowser, AuthTypeToken:
+	case "", AuthTypeDevice, AuthTypeBrowser, AuthTypeClientCredentials, AuthTypeToken, AuthTypeGitHubActions, AuthTypeTokenExchange:
 		return nil
 	default:
 		return fmt.Errorf(
-			"invalid radosgw_oidc_auth_type %q (supported: %s, %s, %s)",
+			"invalid radosgw_oidc_auth_type %q (supported: %s, %s, %s, %s, %s, %s)",
 			authType,
 			AuthTypeDevice,
 			AuthTypeBrowser,
+			AuthTypeClientCredentials,
 			AuthTypeToken,
+			AuthTypeGitHubActions,
+			AuthTypeTokenExchange,
 		)
 	}
 }
 
+// ValidateSubject reports whether the authentication type is empty or can
+// supply the subject token for token exchange.
+func (authType AuthType) ValidateSubject() error {
+	switch authType {
+	case "", AuthTypeToken, AuthTypeGitHubActions, AuthTypeDevice, AuthTypeBrowser:
+		return nil
+	default:
+		return fmt.Errorf(
+			"invalid radosgw_oidc_subject_auth_type %q (supported: %s, %s, %s, %s)",
+			authType,
+			AuthTypeToken,
+			AuthTypeGitHubActions,
+			AuthTypeDevice,
+			AuthTypeBrowser,
+		)
+	}
+}
+
+// UsesOIDCProvider reports whether the authentication type obtains tokens from
+// the configured OIDC provider and client. Token and GitHub Actions
+// authentication receive tokens that were issued elsewhere.
+func (authType AuthType) UsesOIDCProvider() bool {
+	switch authType {
+	case AuthTypeToken, AuthTypeGitHubActions:
+		return false
+	default:
+		return true
+	}
+}
+
 // PKCEMethod identifies the proof-key transformation used by an OIDC flow.
 type PKCEMethod string
 
@@ -62,6 +108,95 @@ func (method PKCEMethod) Validate() error {
 	}
 }
 
+// ClientAuthMethod identifies how a confidential client authenticates to the
+// token endpoint.
+type ClientAuthMethod string
+
+const (
+	// ClientAuthSecretBasic sends the client secret with HTTP Basic
+	// authentication.
+	ClientAuthSecretBasic ClientAuthMethod = "client_secret_basic"
+	// ClientAuthSecretPost sends the client secret in the request body.
+	ClientAuthSecretPost ClientAuthMethod = "client_secret_post"
+	// ClientAuthPrivateKeyJWT signs an RFC 7523 client assertion with the
+	// client's private key.
+	ClientAuthPrivateKeyJWT ClientAuthMethod = "private_key_jwt"
+)
+
+// Validate reports whether the client authentication method is empty or
+// supported. Empty values are valid because defaults are applied after
+// profile inheritance.
+func (method ClientAuthMethod) Validate() error {
+	switch method {
+	case "", ClientAuthSecretBasic, ClientAuthSecretPost, ClientAuthPrivateKeyJWT:
+		return nil
+	default:
+		return fmt.Errorf(
+			"invalid radosgw_oidc_client_auth_method %q (supported: %s, %s, %s)",
+			method,
+			ClientAuthSecretBasic,
+			ClientAuthSecretPost,
+			ClientAuthPrivateKeyJWT,
+		)
+	}
+}
+
+// TokenType identifies which OIDC token is presented to STS as the web
+// identity token.
+type TokenType string
+
+const (
+	// TokenTypeAccessToken presents the OAuth access token.
+	TokenTypeAccessToken TokenType = "access_token"
+	// TokenTypeIDToken presents the OpenID Connect ID token.
+	TokenTypeIDToken TokenType = "id_token"
+)
+
+// Validate reports whether the token type is empty or supported.
+// Empty values are valid because defaults are applied after profile inheritance.
+func (tokenType TokenType) Validate() error {
+	switch tokenType {
+	case "", TokenTypeAccessToken, TokenTypeIDToken:
+		return nil
+	default:
+		return fmt.Errorf(
+			"invalid radosgw_oidc_token_type %q (supported: %s, %s)",
+			tokenType,
+			TokenTypeAccessToken,
+			TokenTypeIDToken,
+		)
+	}
+}
+
+// ExchangeTokenType is an RFC 8693 token type identifier used for the subject
+// and requested tokens of a token exchange.
+type ExchangeTokenType string
+
+const (
+	// ExchangeTokenTypeAccessToken identifies an OAuth 2.0 access token.
+	ExchangeTokenTypeAccessToken ExchangeTokenType = "urn:ietf:params:oauth:token-type:access_token"
+	// ExchangeTokenTypeIDToken identifies an OpenID Connect ID token.
+	ExchangeTokenTypeIDToken ExchangeTokenType = "urn:ietf:params:oauth:token-type:id_token"
+	// ExchangeTokenTypeJWT identifies a JWT without further qualification.
+	ExchangeTokenTypeJWT ExchangeTokenType = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+func validateExchangeTokenType(name string, tokenType ExchangeTokenType) error {
+	switch tokenType {
+	case "", ExchangeTokenTypeAccessToken, ExchangeTokenTypeIDToken, ExchangeTokenTypeJWT:
+		return nil
+	default:
+		return fmt.Errorf(
+			"invalid %s %q (supported: %s, %s, %s)",
+			name,
+			tokenType,
+			ExchangeTokenTypeAccessToken,
+			ExchangeTokenTypeIDToken,
+			ExchangeTokenTypeJWT,
+		)
+	}
+}
+
 // SSLVerification stores the AWS configuration representation of TLS
 // certificate verification behavior.
 type SSLVerification string
@@ -112,9 +247,81 @@ func (profileConfig *ProfileConfig) ValidateValues() error {
 	if err := profileConfig.RadosGWOIDCPKCEMethod.Validate(); err != nil {
 		return err
 	}
+	if err := profileConfig.RadosGWOIDCTokenType.Validate(); err != nil {
+		return err
+	}
+	if err := profileConfig.RadosGWOIDCClientAuthMethod.Validate(); err != nil {
+		return err
+	}
+	if err := profileConfig.RadosGWOIDCSubjectAuthType.ValidateSubject(); err != nil {
+		return err
+	}
+	if err := validateExchangeTokenType("radosgw_oidc_subject_token_type", profileConfig.RadosGWOIDCSubjectTokenType); err != nil {
+		return err
+	}
+	if err := validateExchangeTokenType("radosgw_oidc_requested_token_type", profileConfig.RadosGWOIDCRequestedTokenType); err != nil {
+		return err
+	}
+	if err := ValidatePrompt(profileConfig.RadosGWOIDCPrompt); err != nil {
+		return err
+	}
+	if _, err := ParseResourceIndicators(profileConfig.RadosGWOIDCResource); err != nil {
+		return err
+	}
+	if err := profileConfig.RadosGWOIDCBrowserMode.Validate(); err != nil {
+		return err
+	}
+	if err := profileConfig.RadosGWOIDCRequirePAR.Validate(); err != nil {
+		return err
+	}
+	if _, err := ParseCallbackPorts(profileConfig.RadosGWOIDCCallbackPorts); err != nil {
+		return err
+	}
+	if err := profileConfig.RadosGWOIDCCallbackHost.Validate(); err != nil {
+		return err
+	}
+	if err := ValidateCallbackPath(profileConfig.RadosGWOIDCCallbackPath); err != nil {
+		return err
+	}
+	if _, err := ParseCertificatePins("radosgw_tls_pinned_sha256", profileConfig.RadosGWTLSPinnedSHA256); err != nil {
+		return err
+	}
+	if _, err := ParseCertificatePins("radosgw_oidc_tls_pinned_sha256", profileConfig.RadosGWOIDCTLSPinnedSHA256); err != nil {
+		return err
+	}
+	if _, err := ParseProxyURL("radosgw_proxy_url", profileConfig.RadosGWProxyURL); err != nil {
+		return err
+	}
+	if _, err := ParseProxyURL("radosgw_oidc_proxy_url", profileConfig.RadosGWOIDCProxyURL); err != nil {
+		return err
+	}
+	if _, err := ParseMaxAttempts(profileConfig.RadosGWMaxAttempts); err != nil {
+		return err
+	}
+	if err := validateSessionPolicy(profileConfig); err != nil {
+		return err
+	}
+	if err := validateSTSEndpoints(profileConfig); err != nil {
+		return err
+	}
 	return profileConfig.RadosGWSSLVerify.Validate()
 }
 
+// defaultSubjectTokenType describes the token the subject auth type yields:
+// GitHub Actions issues an ID token, and a device or browser login presents
+// the token selected by radosgw_oidc_token_type.
+func defaultSubjectTokenType(normalized *ProfileConfig) ExchangeTokenType {
+	switch normalized.RadosGWOIDCSubjectAuthType {
+	case AuthTypeGitHubActions:
+		return ExchangeTokenTypeIDToken
+	case AuthTypeDevice, AuthTypeBrowser:
+		if normalized.RadosGWOIDCTokenType == TokenTypeIDToken {
+			return ExchangeTokenTypeIDToken
+		}
+	}
+	return ExchangeTokenTypeAccessToken
+}
+
 // Normalize returns a validated copy with defaults applied. Call it only after
 // source_profile inheritance has been resolved so inherited values are retained.
 func (profileConfig *ProfileConfig) Normalize() (*ProfileConfig, error) {
@@ -125,17 +332,40 @@ func (profileConfig *ProfileConfig) Normalize() (*ProfileConfig, error) {
 	normalized := *profileConfig
 	if normalized.RadosGWOIDCAuthType == "" {
 		normalized.RadosGWOIDCAuthType = AuthTypeDevice
+		if normalized.WebIdentityTokenFile != "" {
+			normalized.RadosGWOIDCAuthType = AuthTypeToken
+		}
 	}
-	if normalized.RadosGWOIDCAuthType != AuthTypeToken {
+	if normalized.RadosGWOIDCAuthType.UsesOIDCProvider() {
 		if normalized.RadosGWOIDCScope == "" {
 			normalized.RadosGWOIDCScope = DefaultOIDCScope
 		}
 		if normalized.RadosGWOIDCPKCEMethod == "" {
 			normalized.RadosGWOIDCPKCEMethod = PKCEMethodS256
 		}
+		if normalized.RadosGWOIDCTokenType == "" {
+			normalized.RadosGWOIDCTokenType = TokenTypeAccessToken
+		}
+	}
+	if normalized.RadosGWOIDCAuthType == AuthTypeClientCredentials && normalized.RadosGWOIDCClientAuthMethod == "" {
+		normalized.RadosGWOIDCClientAuthMethod = ClientAuthSecretBasic
+	}
+	if normalized.RadosGWOIDCAuthType == AuthTypeTokenExchange {
+		if normalized.RadosGWOIDCSubjectAuthType == "" {
+			normalized.RadosGWOIDCSubjectAuthType = AuthTypeToken
+		}
+		if normalized.RadosGWOIDCSubjectTokenType == "" {
+			normalized.RadosGWOIDCSubjectTokenType = defaultSubjectTokenType(&normalized)
+		}
+		if normalized.RadosGWOIDCRequestedTokenType == "" {
+			normalized.RadosGWOIDCRequestedTokenType = ExchangeTokenTypeAccessToken
+		}
 	}
 	if normalized.RadosGWSSLVerify == "" {
 		normalized.RadosGWSSLVerify = SSLVerificationTrue
 	}
+	if normalized.Region == "" {
+		normalized.Region = DefaultRegion
+	}
 	return &normalized, nil
 }
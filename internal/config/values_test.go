@@ -16,7 +16,10 @@ func TestAuthTypeValidate(t *testing.T) {
 		{name: "unset"},
 		{name: "device", authType: AuthTypeDevice},
 		{name: "browser", authType: AuthTypeBrowser},
+		{name: "client credentials", authType: AuthTypeClientCredentials},
 		{name: "token", authType: AuthTypeToken},
+		{name: "GitHub Actions", authType: AuthTypeGitHubActions},
+		{name: "token exchange", authType: AuthTypeTokenExchange},
 		{name: "unsupported", authType: "password", wantErr: true},
 	} {
 		t.Run(test.name, func(t *testing.T) {
@@ -28,6 +31,47 @@ func TestAuthTypeValidate(t *testing.T) {
 	}
 }
 
+func TestAuthTypeUsesOIDCProvider(t *testing.T) {
+	for _, test := range []struct {
+		authType AuthType
+		want     bool
+	}{
+		{authType: AuthTypeDevice, want: true},
+		{authType: AuthTypeBrowser, want: true},
+		{authType: AuthTypeClientCredentials, want: true},
+		{authType: AuthTypeToken},
+		{authType: AuthTypeGitHubActions},
+		{authType: AuthTypeTokenExchange, want: true},
+	} {
+		if got := test.authType.UsesOIDCProvider(); got != test.want {
+			t.Errorf("AuthType(%q).UsesOIDCProvider() = %v, want %v", test.authType, got, test.want)
+		}
+	}
+}
+
+func TestAuthTypeValidateSubject(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		authType AuthType
+		wantErr  bool
+	}{
+		{name: "unset"},
+		{name: "token", authType: AuthTypeToken},
+		{name: "GitHub Actions", authType: AuthTypeGitHubActions},
+		{name: "device", authType: AuthTypeDevice},
+		{name: "browser", authType: AuthTypeBrowser},
+		{name: "client credentials", authType: AuthTypeClientCredentials, wantErr: true},
+		{name: "nested token exchange", authType: AuthTypeTokenExchange, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.authType.ValidateSubject()
+			if (err != nil) != test.wantErr {
+				t.Errorf("AuthType(%q).ValidateSubject() error = %v, wantErr %v", test.authType, err, test.wantErr)
+			}
+		})
+	}
+}
+
 func TestPKCEMethodValidate(t *testing.T) {
 	for _, test := range []struct {
 		name    string
@@ -48,6 +92,47 @@ func TestPKCEMethodValidate(t *testing.T) {
 	}
 }
 
+func TestClientAuthMethodValidate(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		method  ClientAuthMethod
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "basic", method: ClientAuthSecretBasic},
+		{name: "post", method: ClientAuthSecretPost},
+		{name: "private key JWT", method: ClientAuthPrivateKeyJWT},
+		{name: "unsupported", method: "client_secret_jwt", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.method.Validate()
+			if (err != nil) != test.wantErr {
+				t.Errorf("ClientAuthMethod(%q).Validate() error = %v, wantErr %v", test.method, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestTokenTypeValidate(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		tokenType TokenType
+		wantErr   bool
+	}{
+		{name: "unset"},
+		{name: "access token", tokenType: TokenTypeAccessToken},
+		{name: "ID token", tokenType: TokenTypeIDToken},
+		{name: "unsupported", tokenType: "refresh_token", wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.tokenType.Validate()
+			if (err != nil) != test.wantErr {
+				t.Errorf("TokenType(%q).Validate() error = %v, wantErr %v", test.tokenType, err, test.wantErr)
+			}
+		})
+	}
+}
+
 func TestSSLVerificationEnabled(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -103,7 +188,8 @@ func TestProfileConfigNormalize(t *testing.T) {
 		t.Fatal("Normalize() returned the original profile pointer")
 	}
 	if original.RadosGWOIDCAuthType != "" || original.RadosGWOIDCScope != "" ||
-		original.RadosGWOIDCPKCEMethod != "" || original.RadosGWSSLVerify != "" {
+		original.RadosGWOIDCPKCEMethod != "" || original.RadosGWOIDCTokenType != "" ||
+		original.RadosGWSSLVerify != "" {
 		t.Fatalf("Normalize() mutated original profile: %#v", original)
 	}
 	if normalized.RadosGWOIDCAuthType != AuthTypeDevice {
@@ -115,21 +201,129 @@ func TestProfileConfigNormalize(t *testing.T) {
 	if normalized.RadosGWOIDCPKCEMethod != PKCEMethodS256 {
 		t.Errorf("PKCE method = %q, want %q", normalized.RadosGWOIDCPKCEMethod, PKCEMethodS256)
 	}
+	if normalized.RadosGWOIDCTokenType != TokenTypeAccessToken {
+		t.Errorf("token type = %q, want %q", normalized.RadosGWOIDCTokenType, TokenTypeAccessToken)
+	}
 	if normalized.RadosGWSSLVerify != SSLVerificationTrue {
 		t.Errorf("SSL verification = %q, want %q", normalized.RadosGWSSLVerify, SSLVerificationTrue)
 	}
 }
 
 func TestProfileConfigNormalizeTokenDefaults(t *testing.T) {
-	normalized, err := (&ProfileConfig{RadosGWOIDCAuthType: AuthTypeToken}).Normalize()
+	for _, authType := range []AuthType{AuthTypeToken, AuthTypeGitHubActions} {
+		t.Run(string(authType), func(t *testing.T) {
+			normalized, err := (&ProfileConfig{RadosGWOIDCAuthType: authType}).Normalize()
+			if err != nil {
+				t.Fatalf("Normalize() error = %v", err)
+			}
+			if normalized.RadosGWOIDCScope != "" || normalized.RadosGWOIDCPKCEMethod != "" || normalized.RadosGWOIDCTokenType != "" {
+				t.Errorf("token defaults include unused OIDC values: %#v", normalized)
+			}
+			if normalized.RadosGWSSLVerify != SSLVerificationTrue {
+				t.Errorf("SSL verification = %q, want %q", normalized.RadosGWSSLVerify, SSLVerificationTrue)
+			}
+		})
+	}
+}
+
+func TestProfileConfigNormalizeTokenFileDefaultsToTokenAuth(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		authType AuthType
+		want     AuthType
+	}{
+		{name: "implicit", want: AuthTypeToken},
+		{name: "explicit", authType: AuthTypeBrowser, want: AuthTypeBrowser},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			normalized, err := (&ProfileConfig{
+				RadosGWOIDCAuthType:  test.authType,
+				WebIdentityTokenFile: "/var/run/secrets/tokens/radosgw",
+			}).Normalize()
+			if err != nil {
+				t.Fatalf("Normalize() error = %v", err)
+			}
+			if normalized.RadosGWOIDCAuthType != test.want {
+				t.Errorf("auth type = %q, want %q", normalized.RadosGWOIDCAuthType, test.want)
+			}
+		})
+	}
+}
+
+func TestProfileConfigNormalizeClientAuthMethod(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		profile ProfileConfig
+		want    ClientAuthMethod
+	}{
+		{name: "client credentials default", profile: ProfileConfig{RadosGWOIDCAuthType: AuthTypeClientCredentials}, want: ClientAuthSecretBasic},
+		{name: "client credentials explicit", profile: ProfileConfig{RadosGWOIDCAuthType: AuthTypeClientCredentials, RadosGWOIDCClientAuthMethod: ClientAuthSecretPost}, want: ClientAuthSecretPost},
+		{name: "public client", profile: ProfileConfig{RadosGWOIDCAuthType: AuthTypeDevice}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			normalized, err := test.profile.Normalize()
+			if err != nil {
+				t.Fatalf("Normalize() error = %v", err)
+			}
+			if normalized.RadosGWOIDCClientAuthMethod != test.want {
+				t.Errorf("client auth method = %q, want %q", normalized.RadosGWOIDCClientAuthMethod, test.want)
+			}
+		})
+	}
+}
+
+func TestProfileConfigNormalizeTokenExchangeDefaults(t *testing.T) {
+	normalized, err := (&ProfileConfig{RadosGWOIDCAuthType: AuthTypeTokenExchange}).Normalize()
 	if err != nil {
 		t.Fatalf("Normalize() error = %v", err)
 	}
-	if normalized.RadosGWOIDCScope != "" || normalized.RadosGWOIDCPKCEMethod != "" {
-		t.Errorf("token defaults include unused OIDC values: %#v", normalized)
+	if normalized.RadosGWOIDCSubjectAuthType != AuthTypeToken {
+		t.Errorf("subject auth type = %q, want %q", normalized.RadosGWOIDCSubjectAuthType, AuthTypeToken)
 	}
-	if normalized.RadosGWSSLVerify != SSLVerificationTrue {
-		t.Errorf("SSL verification = %q, want %q", normalized.RadosGWSSLVerify, SSLVerificationTrue)
+	if normalized.RadosGWOIDCSubjectTokenType != ExchangeTokenTypeAccessToken {
+		t.Errorf("subject token type = %q, want %q", normalized.RadosGWOIDCSubjectTokenType, ExchangeTokenTypeAccessToken)
+	}
+	if normalized.RadosGWOIDCRequestedTokenType != ExchangeTokenTypeAccessToken {
+		t.Errorf("requested token type = %q, want %q", normalized.RadosGWOIDCRequestedTokenType, ExchangeTokenTypeAccessToken)
+	}
+
+	for _, test := range []struct {
+		name    string
+		profile ProfileConfig
+		want    ExchangeTokenType
+	}{
+		{name: "token file", profile: ProfileConfig{RadosGWOIDCSubjectAuthType: AuthTypeToken}, want: ExchangeTokenTypeAccessToken},
+		{name: "GitHub Actions", profile: ProfileConfig{RadosGWOIDCSubjectAuthType: AuthTypeGitHubActions}, want: ExchangeTokenTypeIDToken},
+		{name: "device access token", profile: ProfileConfig{RadosGWOIDCSubjectAuthType: AuthTypeDevice}, want: ExchangeTokenTypeAccessToken},
+		{
+			name:    "browser ID token",
+			profile: ProfileConfig{RadosGWOIDCSubjectAuthType: AuthTypeBrowser, RadosGWOIDCTokenType: TokenTypeIDToken},
+			want:    ExchangeTokenTypeIDToken,
+		},
+		{
+			name:    "explicit type",
+			profile: ProfileConfig{RadosGWOIDCSubjectAuthType: AuthTypeGitHubActions, RadosGWOIDCSubjectTokenType: ExchangeTokenTypeJWT},
+			want:    ExchangeTokenTypeJWT,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			test.profile.RadosGWOIDCAuthType = AuthTypeTokenExchange
+			normalized, err := test.profile.Normalize()
+			if err != nil {
+				t.Fatalf("Normalize() error = %v", err)
+			}
+			if normalized.RadosGWOIDCSubjectTokenType != test.want {
+				t.Errorf("subject token type = %q, want %q", normalized.RadosGWOIDCSubjectTokenType, test.want)
+			}
+		})
+	}
+
+	device, err := (&ProfileConfig{RadosGWOIDCSubjectTokenType: ExchangeTokenTypeJWT}).Normalize()
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if device.RadosGWOIDCSubjectAuthType != "" || device.RadosGWOIDCRequestedTokenType != "" {
+		t.Errorf("device profile received token exchange defaults: %#v", device)
 	}
 }
 
@@ -142,6 +336,26 @@ func TestProfileConfigNormalizeErrors(t *testing.T) {
 		{name: "missing profile", wantContain: "profile configuration is missing"},
 		{name: "auth type", profile: &ProfileConfig{RadosGWOIDCAuthType: "password"}, wantContain: "radosgw_oidc_auth_type"},
 		{name: "PKCE method", profile: &ProfileConfig{RadosGWOIDCPKCEMethod: "s256"}, wantContain: "radosgw_oidc_pkce_method"},
+		{name: "token type", profile: &ProfileConfig{RadosGWOIDCTokenType: "jwt"}, wantContain: "radosgw_oidc_token_type"},
+		{name: "client auth method", profile: &ProfileConfig{RadosGWOIDCClientAuthMethod: "none"}, wantContain: "radosgw_oidc_client_auth_method"},
+		{name: "subject auth type", profile: &ProfileConfig{RadosGWOIDCSubjectAuthType: AuthTypeClientCredentials}, wantContain: "radosgw_oidc_subject_auth_type"},
+		{name: "subject token type", profile: &ProfileConfig{RadosGWOIDCSubjectTokenType: "access_token"}, wantContain: "radosgw_oidc_subject_token_type"},
+		{name: "requested token type", profile: &ProfileConfig{RadosGWOIDCRequestedTokenType: "urn:ietf:params:oauth:token-type:saml2"}, wantContain: "radosgw_oidc_requested_token_type"},
+		{name: "prompt", profile: &ProfileConfig{RadosGWOIDCPrompt: "force"}, wantContain: "radosgw_oidc_prompt"},
+		{name: "resource", profile: &ProfileConfig{RadosGWOIDCResource: "storage"}, wantContain: "radosgw_oidc_resource"},
+		{name: "browser mode", profile: &ProfileConfig{RadosGWOIDCBrowserMode: "paste"}, wantContain: "radosgw_oidc_browser_mode"},
+		{name: "require PAR", profile: &ProfileConfig{RadosGWOIDCRequirePAR: "yes"}, wantContain: "radosgw_oidc_require_par"},
+		{name: "callback ports", profile: &ProfileConfig{RadosGWOIDCCallbackPorts: "8080,http"}, wantContain: "radosgw_oidc_callback_ports"},
+		{name: "callback host", profile: &ProfileConfig{RadosGWOIDCCallbackHost: "0.0.0.0"}, wantContain: "radosgw_oidc_callback_host"},
+		{name: "callback path", profile: &ProfileConfig{RadosGWOIDCCallbackPath: "callback"}, wantContain: "radosgw_oidc_callback_path"},
+		{name: "certificate pins", profile: &ProfileConfig{RadosGWTLSPinnedSHA256: "abcd"}, wantContain: "radosgw_tls_pinned_sha256"},
+		{name: "OIDC certificate pins", profile: &ProfileConfig{RadosGWOIDCTLSPinnedSHA256: "abcd"}, wantContain: "radosgw_oidc_tls_pinned_sha256"},
+		{name: "proxy URL", profile: &ProfileConfig{RadosGWProxyURL: "socks4://proxy.example.com"}, wantContain: "radosgw_proxy_url"},
+		{name: "OIDC proxy URL", profile: &ProfileConfig{RadosGWOIDCProxyURL: "http://"}, wantContain: "radosgw_oidc_proxy_url"},
+		{name: "max attempts", profile: &ProfileConfig{RadosGWMaxAttempts: "0"}, wantContain: "radosgw_max_attempts"},
+		{name: "session policy", profile: &ProfileConfig{SessionPolicy: "Allow s3:*"}, wantContain: "invalid session_policy"},
+		{name: "session policy and file", profile: &ProfileConfig{SessionPolicy: "{}", SessionPolicyFile: "/etc/radosgw/policy.json"}, wantContain: "session_policy and session_policy_file cannot be used together"},
+		{name: "session policy ARNs", profile: &ProfileConfig{SessionPolicyARNs: "ReadOnly"}, wantContain: "session_policy_arns"},
 		{name: "SSL verification", profile: &ProfileConfig{RadosGWSSLVerify: "yes"}, wantContain: "radosgw_ssl_verify"},
 	} {
 		t.Run(test.name, func(t *testing.T) {
@@ -163,6 +377,16 @@ radosgw_oidc_provider = https://oidc.example.com
 radosgw_oidc_client_id = test-client
 radosgw_oidc_auth_type = browser
 radosgw_oidc_pkce_method = plain
+radosgw_oidc_token_type = id_token
+radosgw_oidc_prompt = login
+radosgw_oidc_login_hint = user@example.com
+radosgw_oidc_acr_values = mfa
+radosgw_oidc_resource = https://storage.example.com
+radosgw_oidc_browser_mode = manual
+radosgw_oidc_require_par = true
+radosgw_oidc_callback_ports = 8400, 8401
+radosgw_oidc_callback_host = [::1]
+radosgw_oidc_callback_path = /oauth2/callback
 radosgw_ssl_verify = false
 role_arn = arn:aws:iam::123456789012:role/TestRole
 `))
@@ -180,6 +404,30 @@ role_arn = arn:aws:iam::123456789012:role/TestRole
 	if profile.RadosGWOIDCPKCEMethod != PKCEMethodPlain {
 		t.Errorf("PKCE method = %q, want %q", profile.RadosGWOIDCPKCEMethod, PKCEMethodPlain)
 	}
+	if profile.RadosGWOIDCTokenType != TokenTypeIDToken {
+		t.Errorf("token type = %q, want %q", profile.RadosGWOIDCTokenType, TokenTypeIDToken)
+	}
+	if profile.RadosGWOIDCPrompt != "login" || profile.RadosGWOIDCLoginHint != "user@example.com" || profile.RadosGWOIDCACRValues != "mfa" {
+		t.Errorf("authorization parameters = %q %q %q, want login user@example.com mfa", profile.RadosGWOIDCPrompt, profile.RadosGWOIDCLoginHint, profile.RadosGWOIDCACRValues)
+	}
+	if profile.RadosGWOIDCResource != "https://storage.example.com" {
+		t.Errorf("resource = %q, want https://storage.example.com", profile.RadosGWOIDCResource)
+	}
+	if profile.RadosGWOIDCBrowserMode != BrowserModeManual {
+		t.Errorf("browser mode = %q, want %q", profile.RadosGWOIDCBrowserMode, BrowserModeManual)
+	}
+	if !profile.RadosGWOIDCRequirePAR.Enabled() {
+		t.Errorf("require PAR = %q, want enabled", profile.RadosGWOIDCRequirePAR)
+	}
+	if profile.RadosGWOIDCCallbackPorts != "8400, 8401" {
+		t.Errorf("callback ports = %q, want 8400, 8401", profile.RadosGWOIDCCallbackPorts)
+	}
+	if profile.RadosGWOIDCCallbackHost != CallbackHostIPv6 {
+		t.Errorf("callback host = %q, want %q", profile.RadosGWOIDCCallbackHost, CallbackHostIPv6)
+	}
+	if profile.RadosGWOIDCCallbackPath != "/oauth2/callback" {
+		t.Errorf("callback path = %q, want /oauth2/callback", profile.RadosGWOIDCCallbackPath)
+	}
 	if profile.RadosGWSSLVerify != SSLVerification("false") {
 		t.Errorf("SSL verification = %q, want false", profile.RadosGWSSLVerify)
 	}
@@ -3,6 +3,7 @@ package credentialcache
 import (
 	"errors"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
@@ -33,7 +34,7 @@ func TestStoreGetOrRetrieve(t *testing.T) {
 	if err != nil {
 		t.Fatalf("second GetOrRetrieve() error = %v", err)
 	}
-	if !hit || result == want || result.AccessKeyID != want.AccessKeyID {
+	if !hit || result == want || !reflect.DeepEqual(result, want) {
 		t.Errorf("second GetOrRetrieve() = (%#v, %v), want decoded cache hit", result, hit)
 	}
 	if retrievals != 1 {
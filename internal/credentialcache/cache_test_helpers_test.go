@@ -36,13 +36,18 @@ func testKey(t *testing.T) string {
 
 func testResult(expiration time.Time) *config.AssumeRoleResult {
 	return &config.AssumeRoleResult{
-		AccessKeyID:     "access-key",
-		SecretAccessKey: "secret-key",
-		SessionToken:    "session-token",
-		Expiration:      expiration.Format(time.RFC3339),
-		ProfileName:     "profile",
-		EndpointURL:     "https://storage.example.com",
-		AssumedRoleArn:  "arn:assumed-role",
+		AccessKeyID:                 "access-key",
+		SecretAccessKey:             "secret-key",
+		SessionToken:                "session-token",
+		Expiration:                  expiration.Format(time.RFC3339),
+		ProfileName:                 "profile",
+		EndpointURL:                 "https://storage.example.com",
+		AssumedRoleArn:              "arn:assumed-role",
+		AssumedRoleID:               "AROA:session",
+		SubjectFromWebIdentityToken: "subject",
+		Audience:                    "radosgw",
+		Provider:                    "https://oidc.example.com",
+		PackedPolicySize:            new(int32(6)),
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/fitbeard/radosgw-assume/internal/config"
@@ -13,48 +14,119 @@ import (
 const cacheKeyVersion = 1
 
 type cacheKeyInput struct {
-	Version           int                    `json:"version"`
-	ProfileName       string                 `json:"profile_name"`
+	Version           int                      `json:"version"`
+	ProfileName       string                   `json:"profile_name"`
+	EndpointURL       string                   `json:"endpoint_url"`
+	STSEndpointURL    string                   `json:"sts_endpoint_url,omitempty"`
+	STSEndpoints      []string                 `json:"sts_endpoints,omitempty"`
+	Region            string                   `json:"region"`
+	OIDCProvider      string                   `json:"oidc_provider"`
+	OIDCClientID      string                   `json:"oidc_client_id"`
+	OIDCAuthType      config.AuthType          `json:"oidc_auth_type"`
+	OIDCScope         string                   `json:"oidc_scope"`
+	OIDCPKCEMethod    config.PKCEMethod        `json:"oidc_pkce_method"`
+	OIDCTokenType     config.TokenType         `json:"oidc_token_type"`
+	OIDCAudience      string                   `json:"oidc_audience,omitempty"`
+	OIDCResource      string                   `json:"oidc_resource,omitempty"`
+	OIDCACRValues     string                   `json:"oidc_acr_values,omitempty"`
+	OIDCLoginHint     string                   `json:"oidc_login_hint,omitempty"`
+	OIDCSubjectAuth   config.AuthType          `json:"oidc_subject_auth_type,omitempty"`
+	OIDCSubjectType   config.ExchangeTokenType `json:"oidc_subject_token_type,omitempty"`
+	OIDCRequestedType config.ExchangeTokenType `json:"oidc_requested_token_type,omitempty"`
+	SSLVerify         config.SSLVerification   `json:"ssl_verify"`
+	TLSClientCert     string                   `json:"tls_client_cert_file,omitempty"`
+	WebIdentityFile   string                   `json:"web_identity_token_file,omitempty"`
+	RoleARN           string                   `json:"role_arn"`
+	RoleSessionName   string                   `json:"role_session_name"`
+	SessionDuration   int64                    `json:"session_duration_nanoseconds"`
+	OIDCTokenIdentity string                   `json:"oidc_token_identity,omitempty"`
+	SessionPolicy     string                   `json:"session_policy,omitempty"`
+	SessionPolicyARNs []string                 `json:"session_policy_arns,omitempty"`
+	RoleChain         []cacheKeyRole           `json:"role_chain,omitempty"`
+}
+
+// cacheKeyRole is a role assumed with the credentials of the role before it.
+type cacheKeyRole struct {
 	EndpointURL       string                 `json:"endpoint_url"`
-	OIDCProvider      string                 `json:"oidc_provider"`
-	OIDCClientID      string                 `json:"oidc_client_id"`
-	OIDCAuthType      config.AuthType        `json:"oidc_auth_type"`
-	OIDCScope         string                 `json:"oidc_scope"`
-	OIDCPKCEMethod    config.PKCEMethod      `json:"oidc_pkce_method"`
+	STSEndpointURL    string                 `json:"sts_endpoint_url,omitempty"`
+	STSEndpoints      []string               `json:"sts_endpoints,omitempty"`
+	Region            string                 `json:"region"`
 	SSLVerify         config.SSLVerification `json:"ssl_verify"`
+	TLSClientCert     string                 `json:"tls_client_cert_file,omitempty"`
 	RoleARN           string                 `json:"role_arn"`
 	RoleSessionName   string                 `json:"role_session_name"`
-	SessionDuration   int64                  `json:"session_duration_nanoseconds"`
-	OIDCTokenIdentity string                 `json:"oidc_token_identity,omitempty"`
+	SessionPolicy     string                 `json:"session_policy,omitempty"`
+	SessionPolicyARNs []string               `json:"session_policy_arns,omitempty"`
 }
 
-// Key returns a stable, non-secret cache key for an effective profile.
-func Key(profileName string, profileConfig *config.ProfileConfig, sessionDuration time.Duration, oidcToken string) (string, error) {
+// Key returns a stable, non-secret cache key for an effective profile. For
+// token authentication oidcToken is the token currently presented to STS, so
+// rotating an environment variable or token file selects a new cache entry.
+// For GitHub Actions it is the job-scoped ID token request token. Token
+// exchange keys on the subject token obtained the same way. A session policy
+// file is not read here; callers replace it with the document in
+// SessionPolicy so that editing the file selects a new cache entry. For a role
+// chain profileConfig is the profile that assumes its role with web identity
+// and chainedRoles are the profiles whose roles are assumed after it, in
+// order.
+func Key(profileName string, profileConfig *config.ProfileConfig, sessionDuration time.Duration, oidcToken string, chainedRoles ...*config.ProfileConfig) (string, error) {
 	normalizedConfig, err := profileConfig.Normalize()
 	if err != nil {
 		return "", fmt.Errorf("create credential cache key: %w", err)
 	}
 
 	tokenIdentity := ""
-	if normalizedConfig.RadosGWOIDCAuthType == config.AuthTypeToken {
+	if !normalizedConfig.RadosGWOIDCAuthType.UsesOIDCProvider() || normalizedConfig.RadosGWOIDCAuthType == config.AuthTypeTokenExchange {
 		tokenHash := sha256.Sum256([]byte(oidcToken))
 		tokenIdentity = hex.EncodeToString(tokenHash[:])
 	}
+	sessionPolicy, policyARNs, err := keySessionPolicy(normalizedConfig)
+	if err != nil {
+		return "", fmt.Errorf("create credential cache key: %w", err)
+	}
+	stsEndpoints, err := config.ParseSTSEndpoints(normalizedConfig.STSEndpoints)
+	if err != nil {
+		return "", fmt.Errorf("create credential cache key: %w", err)
+	}
+	var roleChain []cacheKeyRole
+	for _, roleConfig := range chainedRoles {
+		role, err := keyRole(roleConfig)
+		if err != nil {
+			return "", fmt.Errorf("create credential cache key: %w", err)
+		}
+		roleChain = append(roleChain, role)
+	}
 
 	input := cacheKeyInput{
 		Version:           cacheKeyVersion,
 		ProfileName:       profileName,
 		EndpointURL:       normalizedConfig.EndpointURL,
+		STSEndpointURL:    normalizedConfig.STSEndpointURL,
+		STSEndpoints:      stsEndpoints,
+		Region:            normalizedConfig.Region,
 		OIDCProvider:      normalizedConfig.RadosGWOIDCProvider,
 		OIDCClientID:      normalizedConfig.RadosGWOIDCClientID,
 		OIDCAuthType:      normalizedConfig.RadosGWOIDCAuthType,
 		OIDCScope:         normalizedConfig.RadosGWOIDCScope,
 		OIDCPKCEMethod:    normalizedConfig.RadosGWOIDCPKCEMethod,
+		OIDCTokenType:     normalizedConfig.RadosGWOIDCTokenType,
+		OIDCAudience:      normalizedConfig.RadosGWOIDCAudience,
+		OIDCResource:      strings.Join(strings.Fields(normalizedConfig.RadosGWOIDCResource), " "),
+		OIDCACRValues:     strings.Join(strings.Fields(normalizedConfig.RadosGWOIDCACRValues), " "),
+		OIDCLoginHint:     normalizedConfig.RadosGWOIDCLoginHint,
+		OIDCSubjectAuth:   normalizedConfig.RadosGWOIDCSubjectAuthType,
+		OIDCSubjectType:   normalizedConfig.RadosGWOIDCSubjectTokenType,
+		OIDCRequestedType: normalizedConfig.RadosGWOIDCRequestedTokenType,
 		SSLVerify:         normalizedConfig.RadosGWSSLVerify,
+		TLSClientCert:     normalizedConfig.RadosGWTLSClientCertFile,
+		WebIdentityFile:   normalizedConfig.WebIdentityTokenFile,
 		RoleARN:           normalizedConfig.RoleArn,
 		RoleSessionName:   normalizedConfig.RoleSessionName,
 		SessionDuration:   int64(sessionDuration),
 		OIDCTokenIdentity: tokenIdentity,
+		SessionPolicy:     sessionPolicy,
+		SessionPolicyARNs: policyARNs,
+		RoleChain:         roleChain,
 	}
 	encoded, err := json.Marshal(input)
 	if err != nil {
@@ -65,6 +137,48 @@ func Key(profileName string, profileConfig *config.ProfileConfig, sessionDuratio
 	return hex.EncodeToString(keyHash[:]), nil
 }
 
+func keyRole(roleConfig *config.ProfileConfig) (cacheKeyRole, error) {
+	normalizedConfig, err := roleConfig.Normalize()
+	if err != nil {
+		return cacheKeyRole{}, err
+	}
+	sessionPolicy, policyARNs, err := keySessionPolicy(normalizedConfig)
+	if err != nil {
+		return cacheKeyRole{}, err
+	}
+	stsEndpoints, err := config.ParseSTSEndpoints(normalizedConfig.STSEndpoints)
+	if err != nil {
+		return cacheKeyRole{}, err
+	}
+	return cacheKeyRole{
+		EndpointURL:       normalizedConfig.EndpointURL,
+		STSEndpointURL:    normalizedConfig.STSEndpointURL,
+		STSEndpoints:      stsEndpoints,
+		Region:            normalizedConfig.Region,
+		SSLVerify:         normalizedConfig.RadosGWSSLVerify,
+		TLSClientCert:     normalizedConfig.RadosGWTLSClientCertFile,
+		RoleARN:           normalizedConfig.RoleArn,
+		RoleSessionName:   normalizedConfig.RoleSessionName,
+		SessionPolicy:     sessionPolicy,
+		SessionPolicyARNs: policyARNs,
+	}, nil
+}
+
+func keySessionPolicy(normalizedConfig *config.ProfileConfig) (string, []string, error) {
+	sessionPolicy := ""
+	if normalizedConfig.SessionPolicy != "" {
+		var err error
+		if sessionPolicy, err = config.ParseSessionPolicy("session_policy", normalizedConfig.SessionPolicy); err != nil {
+			return "", nil, err
+		}
+	}
+	policyARNs, err := config.ParseSessionPolicyARNs(normalizedConfig.SessionPolicyARNs)
+	if err != nil {
+		return "", nil, err
+	}
+	return sessionPolicy, policyARNs, nil
+}
+
 func validateKey(key string) error {
 	if len(key) != sha256.Size*2 {
 		return fmt.Errorf("invalid credential cache key")
@@ -26,14 +26,28 @@ func TestKey(t *testing.T) {
 	}{
 		{name: "profile name", profile: "other", duration: time.Hour},
 		{name: "endpoint", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.EndpointURL = "https://other.example.com" }, duration: time.Hour},
+		{name: "STS endpoint", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.STSEndpointURL = "https://sts.example.com" }, duration: time.Hour},
+		{name: "STS gateways", profile: "profile", configure: func(profile *config.ProfileConfig) {
+			profile.STSEndpoints = "https://rgw1.example.com https://rgw2.example.com"
+		}, duration: time.Hour},
+		{name: "region", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.Region = "zonegroup-a" }, duration: time.Hour},
 		{name: "provider", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RadosGWOIDCProvider = "https://other-idp.example.com" }, duration: time.Hour},
 		{name: "client ID", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RadosGWOIDCClientID = "other-client" }, duration: time.Hour},
 		{name: "auth type", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RadosGWOIDCAuthType = "browser" }, duration: time.Hour},
 		{name: "scope", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RadosGWOIDCScope = "openid email" }, duration: time.Hour},
 		{name: "PKCE", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RadosGWOIDCPKCEMethod = "plain" }, duration: time.Hour},
+		{name: "token type", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RadosGWOIDCTokenType = "id_token" }, duration: time.Hour},
 		{name: "TLS", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RadosGWSSLVerify = "false" }, duration: time.Hour},
+		{name: "client certificate", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RadosGWTLSClientCertFile = "/etc/radosgw/client.pem" }, duration: time.Hour},
+		{name: "audience", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RadosGWOIDCAudience = "sts.example.com" }, duration: time.Hour},
+		{name: "resource", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RadosGWOIDCResource = "https://storage.example.com" }, duration: time.Hour},
+		{name: "ACR values", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RadosGWOIDCACRValues = "mfa" }, duration: time.Hour},
+		{name: "login hint", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RadosGWOIDCLoginHint = "user@example.com" }, duration: time.Hour},
+		{name: "token file", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.WebIdentityTokenFile = "/var/run/secrets/tokens/radosgw" }, duration: time.Hour},
 		{name: "role", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RoleArn = "arn:other" }, duration: time.Hour},
 		{name: "session", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.RoleSessionName = "other-session" }, duration: time.Hour},
+		{name: "session policy", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.SessionPolicy = `{"Version":"2012-10-17","Statement":[]}` }, duration: time.Hour},
+		{name: "session policy ARNs", profile: "profile", configure: func(profile *config.ProfileConfig) { profile.SessionPolicyARNs = "arn:aws:iam::aws:policy/ReadOnly" }, duration: time.Hour},
 		{name: "duration", profile: "profile", duration: 2 * time.Hour},
 	}
 
@@ -68,17 +82,19 @@ func TestKeyUsesTokenIdentityOnlyForTokenAuthentication(t *testing.T) {
 		t.Error("device cache key unexpectedly includes an environment token")
 	}
 
-	profile.RadosGWOIDCAuthType = "token"
-	tokenKey, err := Key("profile", profile, time.Hour, "first-token")
-	if err != nil {
-		t.Fatalf("Key() error = %v", err)
-	}
-	otherTokenKey, err := Key("profile", profile, time.Hour, "second-token")
-	if err != nil {
-		t.Fatalf("Key() error = %v", err)
-	}
-	if tokenKey == otherTokenKey {
-		t.Error("token cache key must distinguish source token identities")
+	for _, authType := range []config.AuthType{config.AuthTypeToken, config.AuthTypeGitHubActions, config.AuthTypeTokenExchange} {
+		profile.RadosGWOIDCAuthType = authType
+		tokenKey, err := Key("profile", profile, time.Hour, "first-token")
+		if err != nil {
+			t.Fatalf("Key() error = %v", err)
+		}
+		otherTokenKey, err := Key("profile", profile, time.Hour, "second-token")
+		if err != nil {
+			t.Fatalf("Key() error = %v", err)
+		}
+		if tokenKey == otherTokenKey {
+			t.Errorf("%s cache key must distinguish source token identities", authType)
+		}
 	}
 }
 
@@ -87,9 +103,11 @@ func TestKeyNormalizesEquivalentDefaults(t *testing.T) {
 	implicit.RadosGWOIDCAuthType = ""
 	implicit.RadosGWOIDCScope = ""
 	implicit.RadosGWOIDCPKCEMethod = ""
+	implicit.RadosGWOIDCTokenType = ""
 	implicit.RadosGWSSLVerify = ""
 
 	explicit := testProfileConfig()
+	explicit.Region = config.DefaultRegion
 	implicitKey, err := Key("profile", implicit, time.Hour, "")
 	if err != nil {
 		t.Fatalf("Key() implicit defaults error = %v", err)
@@ -103,6 +121,62 @@ func TestKeyNormalizesEquivalentDefaults(t *testing.T) {
 	}
 }
 
+func TestKeyIgnoresSessionPolicyFormatting(t *testing.T) {
+	compact := testProfileConfig()
+	compact.SessionPolicy = `{"Version":"2012-10-17","Statement":[]}`
+	compact.SessionPolicyARNs = "arn:aws:iam::aws:policy/ReadOnly,arn:aws:iam::123456789012:policy/CI"
+
+	indented := testProfileConfig()
+	indented.SessionPolicy = "{\n  \"Version\": \"2012-10-17\",\n  \"Statement\": []\n}\n"
+	indented.SessionPolicyARNs = "arn:aws:iam::aws:policy/ReadOnly arn:aws:iam::123456789012:policy/CI"
+
+	compactKey, err := Key("profile", compact, time.Hour, "")
+	if err != nil {
+		t.Fatalf("Key() compact policy error = %v", err)
+	}
+	indentedKey, err := Key("profile", indented, time.Hour, "")
+	if err != nil {
+		t.Fatalf("Key() indented policy error = %v", err)
+	}
+	if compactKey != indentedKey {
+		t.Errorf("equivalent session policy keys differ: %q != %q", compactKey, indentedKey)
+	}
+}
+
+func TestKeyIncludesRoleChain(t *testing.T) {
+	tenant := testProfileConfig()
+	tenant.RoleArn = "arn:aws:iam::123456789012:role/Tenant"
+	otherTenant := testProfileConfig()
+	otherTenant.RoleArn = "arn:aws:iam::123456789012:role/OtherTenant"
+	restricted := testProfileConfig()
+	restricted.RoleArn = tenant.RoleArn
+	restricted.SessionPolicyARNs = "arn:aws:iam::aws:policy/ReadOnly"
+
+	keys := make(map[string]string)
+	for name, chainedRoles := range map[string][]*config.ProfileConfig{
+		"direct":         nil,
+		"tenant":         {tenant},
+		"other tenant":   {otherTenant},
+		"session policy": {restricted},
+		"two hops":       {tenant, otherTenant},
+	} {
+		key, err := Key("profile", testProfileConfig(), time.Hour, "", chainedRoles...)
+		if err != nil {
+			t.Fatalf("Key(%s) error = %v", name, err)
+		}
+		if other, found := keys[key]; found {
+			t.Errorf("Key(%s) = Key(%s)", name, other)
+		}
+		keys[key] = name
+	}
+
+	invalid := testProfileConfig()
+	invalid.SessionPolicyARNs = "ReadOnly"
+	if _, err := Key("profile", testProfileConfig(), time.Hour, "", invalid); err == nil {
+		t.Error("Key() accepted an invalid chained session policy ARN")
+	}
+}
+
 func TestKeyRejectsMissingConfiguration(t *testing.T) {
 	if _, err := Key("profile", nil, time.Hour, ""); err == nil {
 		t.Fatal("Key() expected an error")
@@ -50,6 +50,17 @@ func Clear() (ClearResult, error) {
 	return newStore(directory, time.Now, 0).clear()
 }
 
+// ClearProfile removes the cached temporary credentials issued for
+// profileName. Entries that cannot be read are left for Clear or pruning
+// because their profile is unknown.
+func ClearProfile(profileName string) (ClearResult, error) {
+	directory, err := defaultDirectory()
+	if err != nil {
+		return ClearResult{}, err
+	}
+	return newStore(directory, time.Now, 0).clearProfile(profileName)
+}
+
 func (store *Store) inspect() (Summary, error) {
 	summary := Summary{Directory: store.directory}
 	exists, err := store.directoryExists()
@@ -84,6 +95,17 @@ func (store *Store) inspect() (Summary, error) {
 }
 
 func (store *Store) clear() (ClearResult, error) {
+	return store.clearMatching(func(os.DirEntry) bool { return true })
+}
+
+func (store *Store) clearProfile(profileName string) (ClearResult, error) {
+	return store.clearMatching(func(entry os.DirEntry) bool {
+		record, ok := store.readEntry(entry)
+		return ok && record.Credentials.ProfileName == profileName
+	})
+}
+
+func (store *Store) clearMatching(match func(os.DirEntry) bool) (ClearResult, error) {
 	result := ClearResult{Directory: store.directory}
 	exists, err := store.directoryExists()
 	if err != nil || !exists {
@@ -101,7 +123,7 @@ func (store *Store) clear() (ClearResult, error) {
 		return ClearResult{}, fmt.Errorf("read credential cache directory: %w", err)
 	}
 	for _, entry := range entries {
-		if entry.IsDir() || !isCredentialDataFile(entry.Name()) {
+		if entry.IsDir() || !isCredentialDataFile(entry.Name()) || !match(entry) {
 			continue
 		}
 		if err := os.Remove(filepath.Join(store.directory, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
@@ -166,34 +188,44 @@ const (
 )
 
 func (store *Store) entryState(entry os.DirEntry) cacheEntryState {
-	if entry.Type()&os.ModeSymlink != 0 || isTemporaryCacheFile(entry.Name()) {
+	record, ok := store.readEntry(entry)
+	if !ok {
 		return entryInvalid
 	}
+	expiration, valid := credentialExpiration(&record.Credentials)
+	if !valid {
+		return entryInvalid
+	}
+	if !expiration.After(store.now()) {
+		return entryExpired
+	}
+	return entryValid
+}
+
+// readEntry decodes a directory entry that is a regular, current-version
+// cache record.
+func (store *Store) readEntry(entry os.DirEntry) (cacheRecord, bool) {
+	if entry.Type()&os.ModeSymlink != 0 || isTemporaryCacheFile(entry.Name()) {
+		return cacheRecord{}, false
+	}
 	key, found := strings.CutSuffix(entry.Name(), ".json")
 	if !found || validateKey(key) != nil {
-		return entryInvalid
+		return cacheRecord{}, false
 	}
 	info, err := entry.Info()
 	if err != nil || !info.Mode().IsRegular() {
-		return entryInvalid
+		return cacheRecord{}, false
 	}
 
 	encoded, err := os.ReadFile(filepath.Join(store.directory, entry.Name()))
 	if err != nil {
-		return entryInvalid
+		return cacheRecord{}, false
 	}
 	var record cacheRecord
 	if err := json.Unmarshal(encoded, &record); err != nil || record.Version != cacheVersion {
-		return entryInvalid
-	}
-	expiration, valid := credentialExpiration(&record.Credentials)
-	if !valid {
-		return entryInvalid
+		return cacheRecord{}, false
 	}
-	if !expiration.After(store.now()) {
-		return entryExpired
-	}
-	return entryValid
+	return record, true
 }
 
 func isCredentialDataFile(name string) bool {
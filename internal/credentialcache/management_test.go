@@ -82,6 +82,36 @@ func TestStoreInspectAndClear(t *testing.T) {
 	}
 }
 
+func TestStoreClearProfile(t *testing.T) {
+	now := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	directory := filepath.Join(t.TempDir(), "credentials-v1")
+	store := newStore(directory, func() time.Time { return now }, 0)
+	if err := os.MkdirAll(directory, 0o700); err != nil {
+		t.Fatalf("create cache directory: %v", err)
+	}
+	otherProfile := testResult(now.Add(time.Hour))
+	otherProfile.ProfileName = "other"
+	writeRecord(t, directory, numberedKey(1), cacheRecord{Version: cacheVersion, Credentials: *testResult(now.Add(time.Hour))})
+	writeRecord(t, directory, numberedKey(2), cacheRecord{Version: cacheVersion, Credentials: *testResult(now.Add(-time.Second))})
+	writeRecord(t, directory, numberedKey(3), cacheRecord{Version: cacheVersion, Credentials: *otherProfile})
+	if err := os.WriteFile(filepath.Join(directory, numberedKey(4)+".json"), []byte("not JSON"), 0o600); err != nil {
+		t.Fatalf("write invalid cache record: %v", err)
+	}
+
+	cleared, err := store.clearProfile("profile")
+	if err != nil {
+		t.Fatalf("clearProfile() error = %v", err)
+	}
+	if cleared.Directory != directory || cleared.Removed != 2 {
+		t.Errorf("clearProfile() = %+v, want 2 removals from %s", cleared, directory)
+	}
+	for _, key := range []string{numberedKey(3), numberedKey(4)} {
+		if _, err := os.Stat(filepath.Join(directory, key+".json")); err != nil {
+			t.Errorf("entry %s for another or unknown profile: %v", key, err)
+		}
+	}
+}
+
 func TestStoreAutomaticallyPrunesStaleEntries(t *testing.T) {
 	now := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
 	directory := t.TempDir()
@@ -11,38 +11,195 @@ import (
 func authenticate(ctx context.Context, resolvedConfig *resolvedCredentialConfig, verboseMode bool, dependencies credentialDependencies) (string, error) {
 	switch resolvedConfig.authType {
 	case config.AuthTypeToken:
-		accessToken := dependencies.getenv("RADOSGW_OIDC_TOKEN")
-		if accessToken == "" {
-			return "", fmt.Errorf("RADOSGW_OIDC_TOKEN environment variable is required for token auth type")
+		return presentedToken(resolvedConfig, verboseMode, dependencies)
+	case config.AuthTypeGitHubActions:
+		return gitHubActionsToken(ctx, resolvedConfig.sourceConfig.RadosGWOIDCAudience, verboseMode, dependencies)
+	case config.AuthTypeTokenExchange:
+		return exchangeSubjectToken(ctx, resolvedConfig, verboseMode, dependencies)
+	case config.AuthTypeClientCredentials:
+		tokens, err := authenticateClientCredentials(ctx, resolvedConfig, verboseMode, dependencies)
+		if err != nil {
+			return "", err
 		}
+		return tokens.WebIdentityToken(resolvedConfig.tokenType)
+	case config.AuthTypeDevice, config.AuthTypeBrowser:
+		return authenticateOIDC(ctx, resolvedConfig, verboseMode, dependencies)
+	default:
+		return "", fmt.Errorf("unsupported auth type: %s (supported: device, browser, client_credentials, token, github-actions, token-exchange)", resolvedConfig.authType)
+	}
+}
+
+func presentedToken(resolvedConfig *resolvedCredentialConfig, verboseMode bool, dependencies credentialDependencies) (string, error) {
+	token, err := webIdentityToken(resolvedConfig.sourceConfig, dependencies.getenv, dependencies.readFile)
+	if err != nil {
+		return "", err
+	}
+	if resolvedConfig.sourceConfig.WebIdentityTokenFile != "" {
+		verbosef(dependencies.stderr, verboseMode, "# Using web identity token file: %s\n", resolvedConfig.sourceConfig.WebIdentityTokenFile)
+	} else {
 		verbosef(dependencies.stderr, verboseMode, "# Using pre-existing OIDC token\n")
-		return accessToken, nil
-	case config.AuthTypeDevice:
-		verbosef(dependencies.stderr, verboseMode, "# Starting device authentication flow\n")
-		accessToken, err := dependencies.authenticateDevice(ctx, oidcOptions(resolvedConfig, verboseMode))
+	}
+	return token, nil
+}
+
+func gitHubActionsToken(ctx context.Context, audience string, verboseMode bool, dependencies credentialDependencies) (string, error) {
+	verbosef(dependencies.stderr, verboseMode, "# Requesting GitHub Actions OIDC token\n")
+	token, err := dependencies.fetchGitHubToken(ctx, auth.GitHubActionsOptions{
+		RequestURL:   dependencies.getenv("ACTIONS_ID_TOKEN_REQUEST_URL"),
+		RequestToken: dependencies.getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN"),
+		Audience:     audience,
+	})
+	if err != nil {
+		return "", fmt.Errorf("GitHub Actions authentication failed: %w", err)
+	}
+	return token, nil
+}
+
+// exchangeSubjectToken obtains the subject token with the configured subject
+// auth type and exchanges it at the provider. radosgw_oidc_audience is the
+// audience requested from the exchange, so GitHub Actions subject tokens use
+// GitHub's default audience.
+func exchangeSubjectToken(ctx context.Context, resolvedConfig *resolvedCredentialConfig, verboseMode bool, dependencies credentialDependencies) (string, error) {
+	sourceConfig := resolvedConfig.sourceConfig
+	var subjectToken string
+	var err error
+	switch sourceConfig.RadosGWOIDCSubjectAuthType {
+	case config.AuthTypeToken:
+		subjectToken, err = presentedToken(resolvedConfig, verboseMode, dependencies)
+	case config.AuthTypeGitHubActions:
+		subjectToken, err = gitHubActionsToken(ctx, "", verboseMode, dependencies)
+	case config.AuthTypeDevice, config.AuthTypeBrowser:
+		subjectConfig := *resolvedConfig
+		subjectConfig.authType = sourceConfig.RadosGWOIDCSubjectAuthType
+		subjectToken, err = authenticateOIDC(ctx, &subjectConfig, verboseMode, dependencies)
+	default:
+		return "", sourceConfig.RadosGWOIDCSubjectAuthType.ValidateSubject()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	options := oidcOptions(resolvedConfig, verboseMode)
+	if err := applyClientAuthentication(&options, sourceConfig, dependencies, false); err != nil {
+		return "", err
+	}
+	verbosef(dependencies.stderr, verboseMode, "# Exchanging %s for %s\n", sourceConfig.RadosGWOIDCSubjectTokenType, sourceConfig.RadosGWOIDCRequestedTokenType)
+	tokens, err := dependencies.exchangeToken(ctx, options, auth.TokenExchangeOptions{
+		SubjectToken:       subjectToken,
+		SubjectTokenType:   sourceConfig.RadosGWOIDCSubjectTokenType,
+		RequestedTokenType: sourceConfig.RadosGWOIDCRequestedTokenType,
+		Audience:           sourceConfig.RadosGWOIDCAudience,
+	})
+	if err != nil {
+		return "", fmt.Errorf("token exchange failed: %w", err)
+	}
+	return tokens.AccessToken, nil
+}
+
+// authenticateOIDC returns the web identity token for a device or browser
+// login. A still-valid token cached by any profile with the same provider,
+// client, scope, token type and token-shaping authorization parameters is
+// reused first, then a stored refresh token, before the user is prompted.
+// A prompt that requires interaction skips both so the provider can show it.
+func authenticateOIDC(ctx context.Context, resolvedConfig *resolvedCredentialConfig, verboseMode bool, dependencies credentialDependencies) (string, error) {
+	tokenStore := openOIDCTokenStore(verboseMode, dependencies)
+	cacheKey := identityTokenCacheKey(resolvedConfig)
+	interactive := resolvedConfig.authorization.RequiresInteraction()
+	if interactive {
+		verbosef(dependencies.stderr, verboseMode, "# Skipping cached OIDC tokens for prompt=%s\n", resolvedConfig.authorization.Prompt)
+	} else if token, found := loadIdentityToken(tokenStore, cacheKey, verboseMode, dependencies); found {
+		return token, nil
+	}
+
+	options := oidcOptions(resolvedConfig, verboseMode)
+	if options.ClientAuthMethod == config.ClientAuthPrivateKeyJWT {
+		key, err := clientPrivateKey(resolvedConfig.sourceConfig, dependencies.readFile)
 		if err != nil {
-			return "", fmt.Errorf("device authentication failed: %w", err)
+			return "", err
 		}
-		return accessToken, nil
-	case config.AuthTypeBrowser:
-		verbosef(dependencies.stderr, verboseMode, "# Starting browser authentication flow\n")
-		accessToken, err := dependencies.authenticateBrowser(ctx, oidcOptions(resolvedConfig, verboseMode))
+		options.ClientPrivateKey = key
+	}
+
+	var tokens auth.TokenResponse
+	var refreshed bool
+	var err error
+	if !interactive {
+		tokens, refreshed, err = refreshStoredTokens(ctx, tokenStore, options, verboseMode, dependencies)
 		if err != nil {
-			return "", fmt.Errorf("browser authentication failed: %w", err)
+			return "", err
 		}
-		return accessToken, nil
-	default:
-		return "", fmt.Errorf("unsupported auth type: %s (supported: device, browser, token)", resolvedConfig.authType)
 	}
+	if refreshed {
+		if _, err := tokens.WebIdentityToken(resolvedConfig.tokenType); err != nil {
+			verbosef(dependencies.stderr, verboseMode, "# Refreshed tokens do not include %s, authenticating interactively\n", resolvedConfig.tokenType)
+			refreshed = false
+		}
+	}
+
+	if !refreshed {
+		switch resolvedConfig.authType {
+		case config.AuthTypeDevice:
+			verbosef(dependencies.stderr, verboseMode, "# Starting device authentication flow\n")
+			tokens, err = dependencies.authenticateDevice(ctx, options)
+			if err != nil {
+				return "", fmt.Errorf("device authentication failed: %w", err)
+			}
+		default:
+			verbosef(dependencies.stderr, verboseMode, "# Starting browser authentication flow\n")
+			tokens, err = dependencies.authenticateBrowser(ctx, options)
+			if err != nil {
+				return "", fmt.Errorf("browser authentication failed: %w", err)
+			}
+		}
+		saveRefreshToken(tokenStore, options, tokens, verboseMode, dependencies)
+	}
+
+	token, err := tokens.WebIdentityToken(resolvedConfig.tokenType)
+	if err != nil {
+		return "", err
+	}
+	saveIdentityToken(tokenStore, cacheKey, token, tokens, verboseMode, dependencies)
+	return token, nil
+}
+
+// authenticateClientCredentials skips refresh token storage because providers
+// should not issue refresh tokens for the client credentials grant.
+func authenticateClientCredentials(ctx context.Context, resolvedConfig *resolvedCredentialConfig, verboseMode bool, dependencies credentialDependencies) (auth.TokenResponse, error) {
+	options := oidcOptions(resolvedConfig, verboseMode)
+	if err := applyClientAuthentication(&options, resolvedConfig.sourceConfig, dependencies, true); err != nil {
+		return auth.TokenResponse{}, err
+	}
+
+	verbosef(dependencies.stderr, verboseMode, "# Requesting tokens with client credentials grant (%s)\n", options.ClientAuthMethod)
+	tokens, err := dependencies.authenticateClient(ctx, options)
+	if err != nil {
+		return auth.TokenResponse{}, fmt.Errorf("client credentials authentication failed: %w", err)
+	}
+	return tokens, nil
 }
 
 func oidcOptions(resolvedConfig *resolvedCredentialConfig, verboseMode bool) auth.OIDCOptions {
 	return auth.OIDCOptions{
-		ProviderURL: resolvedConfig.sourceConfig.RadosGWOIDCProvider,
-		ClientID:    resolvedConfig.sourceConfig.RadosGWOIDCClientID,
-		Scope:       resolvedConfig.scope,
-		PKCEMethod:  resolvedConfig.sourceConfig.RadosGWOIDCPKCEMethod,
-		SSLVerify:   resolvedConfig.sslVerify,
-		Verbose:     verboseMode,
+		ProviderURL:       resolvedConfig.sourceConfig.RadosGWOIDCProvider,
+		ClientID:          resolvedConfig.sourceConfig.RadosGWOIDCClientID,
+		ClientAuthMethod:  resolvedConfig.sourceConfig.RadosGWOIDCClientAuthMethod,
+		Scope:             resolvedConfig.scope,
+		PKCEMethod:        resolvedConfig.sourceConfig.RadosGWOIDCPKCEMethod,
+		Authorization:     resolvedConfig.authorization,
+		BrowserMode:       resolvedConfig.sourceConfig.RadosGWOIDCBrowserMode,
+		RequirePAR:        resolvedConfig.sourceConfig.RadosGWOIDCRequirePAR.Enabled(),
+		CallbackHost:      resolvedConfig.sourceConfig.RadosGWOIDCCallbackHost,
+		CallbackPorts:     resolvedConfig.callbackPorts,
+		CallbackPath:      resolvedConfig.sourceConfig.RadosGWOIDCCallbackPath,
+		SSLVerify:         resolvedConfig.sslVerify,
+		ClientCertificate: resolvedConfig.clientCertificate,
+		RootCAs:           resolvedConfig.oidcTrust.rootCAs,
+		PinnedSHA256:      resolvedConfig.oidcTrust.pinnedSHA256,
+		ProxyURL:          resolvedConfig.oidcProxy.proxyURL,
+		NoProxy:           resolvedConfig.oidcProxy.noProxy,
+		OnProxy:           resolvedConfig.reportProxy,
+		MaxAttempts:       resolvedConfig.maxAttempts,
+		OnRetry:           resolvedConfig.reportRetry,
+		Verbose:           verboseMode,
 	}
 }
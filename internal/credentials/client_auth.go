@@ -0,0 +1,74 @@
+package credentials
+
+import (
+	"crypto"
+	"fmt"
+	"strings"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
+// applyClientAuthentication loads the secret or private key for the configured
+// client authentication method. When requireSecret is false a client without a
+// configured secret is treated as a public client.
+func applyClientAuthentication(options *auth.OIDCOptions, profileConfig *config.ProfileConfig, dependencies credentialDependencies, requireSecret bool) error {
+	if options.ClientAuthMethod == config.ClientAuthPrivateKeyJWT {
+		key, err := clientPrivateKey(profileConfig, dependencies.readFile)
+		if err != nil {
+			return err
+		}
+		options.ClientPrivateKey = key
+		return nil
+	}
+	if !requireSecret && profileConfig.RadosGWOIDCClientSecretFile == "" && dependencies.getenv("RADOSGW_OIDC_CLIENT_SECRET") == "" {
+		return nil
+	}
+
+	secret, err := clientSecret(profileConfig, dependencies.getenv, dependencies.readFile)
+	if err != nil {
+		return err
+	}
+	options.ClientSecret = secret
+	return nil
+}
+
+// clientSecret loads the confidential client secret from the configured file
+// or the environment. Secrets are never read from the AWS config file itself.
+func clientSecret(profileConfig *config.ProfileConfig, getenv func(string) string, readFile func(string) ([]byte, error)) (string, error) {
+	if profileConfig.RadosGWOIDCClientSecretFile == "" {
+		secret := getenv("RADOSGW_OIDC_CLIENT_SECRET")
+		if secret == "" {
+			return "", fmt.Errorf("RADOSGW_OIDC_CLIENT_SECRET environment variable or radosgw_oidc_client_secret_file is required for client_credentials auth type")
+		}
+		return secret, nil
+	}
+
+	content, err := readFile(profileConfig.RadosGWOIDCClientSecretFile)
+	if err != nil {
+		return "", fmt.Errorf("read client secret file: %w", err)
+	}
+	secret := strings.TrimSpace(string(content))
+	if secret == "" {
+		return "", fmt.Errorf("client secret file %s is empty", profileConfig.RadosGWOIDCClientSecretFile)
+	}
+	return secret, nil
+}
+
+// clientPrivateKey loads the private key used to sign private_key_jwt client
+// assertions. Like client secrets, keys are only referenced by file path.
+func clientPrivateKey(profileConfig *config.ProfileConfig, readFile func(string) ([]byte, error)) (crypto.Signer, error) {
+	if profileConfig.RadosGWOIDCClientKeyFile == "" {
+		return nil, fmt.Errorf("radosgw_oidc_client_auth_method = private_key_jwt requires radosgw_oidc_client_private_key_file")
+	}
+
+	content, err := readFile(profileConfig.RadosGWOIDCClientKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client private key file: %w", err)
+	}
+	key, err := auth.ParseClientPrivateKey(content)
+	if err != nil {
+		return nil, fmt.Errorf("client private key file %s: %w", profileConfig.RadosGWOIDCClientKeyFile, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,49 @@
+package credentials
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
+
+// clientCertificate loads the optional mutual TLS client certificate presented
+// to both the OIDC provider and the STS endpoint. A certificate file without a
+// key file is read as a combined PEM file. The key passphrase is only taken
+// from the environment, never from the AWS config file.
+func clientCertificate(sourceConfig *config.ProfileConfig, dependencies credentialDependencies) (*tls.Certificate, error) {
+	certificateFile := sourceConfig.RadosGWTLSClientCertFile
+	keyFile := sourceConfig.RadosGWTLSClientKeyFile
+	if certificateFile == "" {
+		if keyFile != "" {
+			return nil, fmt.Errorf("radosgw_tls_client_key_file requires radosgw_tls_client_cert_file")
+		}
+		return nil, nil
+	}
+	if keyFile == "" {
+		keyFile = certificateFile
+	}
+
+	certificatePEM, err := dependencies.readFile(certificateFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client certificate file: %w", err)
+	}
+	keyPEM := certificatePEM
+	if keyFile != certificateFile {
+		if keyPEM, err = dependencies.readFile(keyFile); err != nil {
+			return nil, fmt.Errorf("read client key file: %w", err)
+		}
+	}
+
+	passphrase := []byte(dependencies.getenv("RADOSGW_TLS_CLIENT_KEY_PASSPHRASE"))
+	certificate, err := httpclient.LoadClientCertificate(certificatePEM, keyPEM, passphrase, dependencies.now())
+	switch {
+	case errors.Is(err, httpclient.ErrPassphraseRequired):
+		return nil, fmt.Errorf("client key file %s: %w; set RADOSGW_TLS_CLIENT_KEY_PASSPHRASE", keyFile, err)
+	case err != nil:
+		return nil, fmt.Errorf("client certificate file %s: %w", certificateFile, err)
+	}
+	return certificate, nil
+}
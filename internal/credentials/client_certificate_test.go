@@ -0,0 +1,186 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/sts"
+)
+
+const (
+	testClientCertificateFile = "/etc/radosgw/client.crt"
+	testClientKeyFile         = "/etc/radosgw/client.key"
+)
+
+func TestGetCredentialsPresentsClientCertificate(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := refreshTestDependencies(t, stderr, nil)
+	dependencies.openTokenStore = func() (oidcTokenStore, error) { return nil, errors.New("no store") }
+	now := dependencies.now()
+	certificatePEM, keyPEM := createTestClientCertificate(t, now.Add(-time.Hour), now.Add(24*time.Hour))
+	dependencies.getenv = func(string) string { return "" }
+	dependencies.readFile = func(name string) ([]byte, error) {
+		switch name {
+		case testClientCertificateFile:
+			return certificatePEM, nil
+		case testClientKeyFile:
+			return keyPEM, nil
+		}
+		return nil, errors.New("unexpected file")
+	}
+	var presented []*tls.Certificate
+	dependencies.authenticateDevice = func(_ context.Context, options auth.OIDCOptions) (auth.TokenResponse, error) {
+		presented = append(presented, options.ClientCertificate)
+		return auth.TokenResponse{AccessToken: "device.jwt.value"}, nil
+	}
+	dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		presented = append(presented, options.ClientCertificate)
+		return &config.AssumeRoleResult{}, nil
+	}
+	request := refreshTestRequest(stderr)
+	request.ProfileConfig.RadosGWTLSClientCertFile = testClientCertificateFile
+	request.ProfileConfig.RadosGWTLSClientKeyFile = testClientKeyFile
+
+	if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+
+	if len(presented) != 2 || presented[0] == nil || presented[0] != presented[1] {
+		t.Fatalf("client certificates = %v, want the same certificate for OIDC and STS", presented)
+	}
+	if want := "# TLS client certificate: CN=radosgw-assume client (expires 2030-01-03T03:04:05Z)"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("verbose output %q does not contain %q", stderr.String(), want)
+	}
+}
+
+func TestGetCredentialsClientCertificateErrors(t *testing.T) {
+	now := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+	certificatePEM, keyPEM := createTestClientCertificate(t, now.Add(-time.Hour), now.Add(time.Hour))
+	expiredPEM, expiredKeyPEM := createTestClientCertificate(t, now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+	_, otherKeyPEM := createTestClientCertificate(t, now.Add(-time.Hour), now.Add(time.Hour))
+	encryptedKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte("encrypted")})
+
+	for _, test := range []struct {
+		name            string
+		certificateFile string
+		keyFile         string
+		files           map[string][]byte
+		wantContain     string
+	}{
+		{
+			name:        "key without certificate",
+			keyFile:     testClientKeyFile,
+			wantContain: "radosgw_tls_client_key_file requires radosgw_tls_client_cert_file",
+		},
+		{
+			name:            "unreadable certificate",
+			certificateFile: testClientCertificateFile,
+			keyFile:         testClientKeyFile,
+			wantContain:     "read client certificate file: file not found",
+		},
+		{
+			name:            "unreadable key",
+			certificateFile: testClientCertificateFile,
+			keyFile:         testClientKeyFile,
+			files:           map[string][]byte{testClientCertificateFile: certificatePEM},
+			wantContain:     "read client key file: file not found",
+		},
+		{
+			name:            "expired certificate",
+			certificateFile: testClientCertificateFile,
+			keyFile:         testClientKeyFile,
+			files:           map[string][]byte{testClientCertificateFile: expiredPEM, testClientKeyFile: expiredKeyPEM},
+			wantContain:     `client certificate file /etc/radosgw/client.crt: client certificate "CN=radosgw-assume client" expired 24h ago (not after 2030-01-01T03:04:05Z)`,
+		},
+		{
+			name:            "mismatched key",
+			certificateFile: testClientCertificateFile,
+			keyFile:         testClientKeyFile,
+			files:           map[string][]byte{testClientCertificateFile: certificatePEM, testClientKeyFile: otherKeyPEM},
+			wantContain:     "does not match the private key",
+		},
+		{
+			name:            "combined file without key",
+			certificateFile: testClientCertificateFile,
+			files:           map[string][]byte{testClientCertificateFile: certificatePEM},
+			wantContain:     "no PEM private key found",
+		},
+		{
+			name:            "missing passphrase",
+			certificateFile: testClientCertificateFile,
+			keyFile:         testClientKeyFile,
+			files:           map[string][]byte{testClientCertificateFile: certificatePEM, testClientKeyFile: encryptedKeyPEM},
+			wantContain:     "client key file /etc/radosgw/client.key: private key is encrypted but no passphrase was provided; set RADOSGW_TLS_CLIENT_KEY_PASSPHRASE",
+		},
+		{
+			name:            "valid certificate",
+			certificateFile: testClientCertificateFile,
+			keyFile:         testClientKeyFile,
+			files:           map[string][]byte{testClientCertificateFile: certificatePEM, testClientKeyFile: keyPEM},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := newTestCredentialDependencies(t, stderr)
+			dependencies.getenv = func(string) string { return "" }
+			dependencies.readFile = func(name string) ([]byte, error) {
+				if content, ok := test.files[name]; ok {
+					return content, nil
+				}
+				return nil, errors.New("file not found")
+			}
+			sourceConfig := &config.ProfileConfig{RadosGWTLSClientCertFile: test.certificateFile, RadosGWTLSClientKeyFile: test.keyFile}
+
+			certificate, err := clientCertificate(sourceConfig, dependencies)
+
+			if test.wantContain == "" {
+				if err != nil || certificate == nil {
+					t.Fatalf("clientCertificate() = %v, %v", certificate, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("clientCertificate() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
+
+func createTestClientCertificate(t *testing.T, notBefore, notAfter time.Time) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "radosgw-assume client"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDER}), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
@@ -0,0 +1,240 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/sts"
+)
+
+func TestGetCredentialsUsesClientCredentials(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		secretFile string
+		getenv     func(string) string
+		readFile   func(string) ([]byte, error)
+		method     config.ClientAuthMethod
+		wantMethod config.ClientAuthMethod
+	}{
+		{
+			name:       "environment secret",
+			getenv:     func(string) string { return "env-secret" },
+			wantMethod: config.ClientAuthSecretBasic,
+		},
+		{
+			name:       "secret file",
+			secretFile: "/run/secrets/client-secret",
+			readFile: func(name string) ([]byte, error) {
+				if name != "/run/secrets/client-secret" {
+					return nil, errors.New("unexpected file")
+				}
+				return []byte("env-secret\n"), nil
+			},
+			method:     config.ClientAuthSecretPost,
+			wantMethod: config.ClientAuthSecretPost,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := refreshTestDependencies(t, stderr, nil)
+			dependencies.openTokenStore = func() (oidcTokenStore, error) {
+				t.Fatal("unexpected openTokenStore() call")
+				return nil, nil
+			}
+			if test.getenv != nil {
+				dependencies.getenv = test.getenv
+			}
+			if test.readFile != nil {
+				dependencies.readFile = test.readFile
+			}
+			dependencies.authenticateClient = func(_ context.Context, options auth.OIDCOptions) (auth.TokenResponse, error) {
+				if options.ClientID != "test-client" || options.ClientSecret != "env-secret" {
+					t.Errorf("authenticateClient() client = %q/%q", options.ClientID, options.ClientSecret)
+				}
+				if options.ClientAuthMethod != test.wantMethod {
+					t.Errorf("authenticateClient() method = %q, want %q", options.ClientAuthMethod, test.wantMethod)
+				}
+				return auth.TokenResponse{AccessToken: "service.jwt.value"}, nil
+			}
+			var presented string
+			dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+				presented = options.WebIdentityToken
+				return &config.AssumeRoleResult{}, nil
+			}
+			request := refreshTestRequest(stderr)
+			request.ProfileConfig.RadosGWOIDCAuthType = config.AuthTypeClientCredentials
+			request.ProfileConfig.RadosGWOIDCClientAuthMethod = test.method
+			request.ProfileConfig.RadosGWOIDCClientSecretFile = test.secretFile
+
+			if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			if presented != "service.jwt.value" {
+				t.Errorf("assumeRole() web identity token = %q, want service.jwt.value", presented)
+			}
+			if strings.Contains(stderr.String(), "env-secret") {
+				t.Errorf("verbose output %q exposes the client secret", stderr.String())
+			}
+		})
+	}
+}
+
+func TestGetCredentialsClientCredentialsErrors(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		secretFile  string
+		readFile    func(string) ([]byte, error)
+		authErr     error
+		wantContain string
+	}{
+		{
+			name:        "missing secret",
+			wantContain: "RADOSGW_OIDC_CLIENT_SECRET environment variable or radosgw_oidc_client_secret_file is required",
+		},
+		{
+			name:        "unreadable secret file",
+			secretFile:  "/run/secrets/client-secret",
+			readFile:    func(string) ([]byte, error) { return nil, errors.New("permission denied") },
+			wantContain: "read client secret file: permission denied",
+		},
+		{
+			name:        "empty secret file",
+			secretFile:  "/run/secrets/client-secret",
+			readFile:    func(string) ([]byte, error) { return []byte("\n"), nil },
+			wantContain: "client secret file /run/secrets/client-secret is empty",
+		},
+		{
+			name:        "provider rejection",
+			authErr:     errors.New("invalid client"),
+			wantContain: "client credentials authentication failed: invalid client",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := newTestCredentialDependencies(t, stderr)
+			dependencies.getenv = func(string) string {
+				if test.authErr != nil {
+					return "env-secret"
+				}
+				return ""
+			}
+			if test.readFile != nil {
+				dependencies.readFile = test.readFile
+			}
+			dependencies.authenticateClient = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+				return auth.TokenResponse{}, test.authErr
+			}
+			request := refreshTestRequest(stderr)
+			request.ProfileConfig.RadosGWOIDCAuthType = config.AuthTypeClientCredentials
+			request.ProfileConfig.RadosGWOIDCClientSecretFile = test.secretFile
+
+			_, err := getCredentials(t.Context(), request, dependencies)
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("getCredentials() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
+
+func TestGetCredentialsLoadsClientPrivateKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+	for _, authType := range []config.AuthType{config.AuthTypeClientCredentials, config.AuthTypeDevice} {
+		t.Run(string(authType), func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := refreshTestDependencies(t, stderr, nil)
+			dependencies.openTokenStore = func() (oidcTokenStore, error) { return nil, errors.New("no store") }
+			dependencies.readFile = func(name string) ([]byte, error) {
+				if name != "/etc/radosgw/client-key.pem" {
+					return nil, errors.New("unexpected file")
+				}
+				return keyPEM, nil
+			}
+			checkOptions := func(options auth.OIDCOptions) {
+				if options.ClientAuthMethod != config.ClientAuthPrivateKeyJWT || options.ClientSecret != "" {
+					t.Errorf("client authentication = %q with secret %q, want private_key_jwt without secret", options.ClientAuthMethod, options.ClientSecret)
+				}
+				if !key.Equal(options.ClientPrivateKey) {
+					t.Errorf("client private key = %T, want key from file", options.ClientPrivateKey)
+				}
+			}
+			dependencies.authenticateClient = func(_ context.Context, options auth.OIDCOptions) (auth.TokenResponse, error) {
+				checkOptions(options)
+				return auth.TokenResponse{AccessToken: "service.jwt.value"}, nil
+			}
+			dependencies.authenticateDevice = func(_ context.Context, options auth.OIDCOptions) (auth.TokenResponse, error) {
+				checkOptions(options)
+				return auth.TokenResponse{AccessToken: "device.jwt.value"}, nil
+			}
+			request := refreshTestRequest(stderr)
+			request.ProfileConfig.RadosGWOIDCAuthType = authType
+			request.ProfileConfig.RadosGWOIDCClientAuthMethod = config.ClientAuthPrivateKeyJWT
+			request.ProfileConfig.RadosGWOIDCClientKeyFile = "/etc/radosgw/client-key.pem"
+
+			if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestGetCredentialsClientPrivateKeyErrors(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		keyFile     string
+		readFile    func(string) ([]byte, error)
+		wantContain string
+	}{
+		{
+			name:        "missing key file",
+			wantContain: "radosgw_oidc_client_auth_method = private_key_jwt requires radosgw_oidc_client_private_key_file",
+		},
+		{
+			name:        "unreadable key file",
+			keyFile:     "/etc/radosgw/client-key.pem",
+			readFile:    func(string) ([]byte, error) { return nil, errors.New("permission denied") },
+			wantContain: "read client private key file: permission denied",
+		},
+		{
+			name:        "invalid key file",
+			keyFile:     "/etc/radosgw/client-key.pem",
+			readFile:    func(string) ([]byte, error) { return []byte("not a key"), nil },
+			wantContain: "client private key file /etc/radosgw/client-key.pem: no PEM private key found",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := newTestCredentialDependencies(t, stderr)
+			if test.readFile != nil {
+				dependencies.readFile = test.readFile
+			}
+			request := refreshTestRequest(stderr)
+			request.ProfileConfig.RadosGWOIDCAuthType = config.AuthTypeClientCredentials
+			request.ProfileConfig.RadosGWOIDCClientAuthMethod = config.ClientAuthPrivateKeyJWT
+			request.ProfileConfig.RadosGWOIDCClientKeyFile = test.keyFile
+
+			_, err := getCredentials(t.Context(), request, dependencies)
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("getCredentials() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
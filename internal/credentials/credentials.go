@@ -4,14 +4,26 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/fitbeard/radosgw-assume/internal/auth"
 	"github.com/fitbeard/radosgw-assume/internal/config"
 	"github.com/fitbeard/radosgw-assume/internal/sts"
+	"github.com/fitbeard/radosgw-assume/pkg/duration"
 )
 
 // GetCredentials orchestrates the authentication and role assumption process.
 func GetCredentials(ctx context.Context, options RequestOptions) (*config.AssumeRoleResult, error) {
+	return getCredentials(ctx, options, newRequestDependencies(&options))
+}
+
+// GetWebIdentityToken runs the profile's authentication flow and returns the
+// token that would be sent to STS, without assuming the role.
+func GetWebIdentityToken(ctx context.Context, options RequestOptions) (string, error) {
+	return getWebIdentityToken(ctx, options, newRequestDependencies(&options))
+}
+
+func newRequestDependencies(options *RequestOptions) credentialDependencies {
 	output := options.Output
 	if output == nil {
 		output = os.Stderr
@@ -20,13 +32,13 @@ func GetCredentials(ctx context.Context, options RequestOptions) (*config.Assume
 
 	dependencies := newCredentialDependencies()
 	dependencies.stderr = output
-	dependencies.authenticateDevice = func(ctx context.Context, options auth.OIDCOptions) (string, error) {
+	dependencies.authenticateDevice = func(ctx context.Context, options auth.OIDCOptions) (auth.TokenResponse, error) {
 		return auth.AuthenticateDeviceFlowWithOutput(ctx, options, output)
 	}
-	dependencies.authenticateBrowser = func(ctx context.Context, options auth.OIDCOptions) (string, error) {
+	dependencies.authenticateBrowser = func(ctx context.Context, options auth.OIDCOptions) (auth.TokenResponse, error) {
 		return auth.AuthenticateBrowserFlowWithOutput(ctx, options, output)
 	}
-	return getCredentials(ctx, options, dependencies)
+	return dependencies
 }
 
 func getCredentials(ctx context.Context, options RequestOptions, dependencies credentialDependencies) (*config.AssumeRoleResult, error) {
@@ -38,37 +50,124 @@ func getCredentials(ctx context.Context, options RequestOptions, dependencies cr
 		return nil, err
 	}
 
-	printCredentialContext(dependencies.stderr, options.ProfileName, resolvedConfig, options.Verbose, options.SessionDuration)
+	printCredentialContext(dependencies.stderr, options.ProfileName, resolvedConfig, options.Verbose)
+	verbosef(dependencies.stderr, options.Verbose, "# Session duration: %d seconds (%s)\n", int(options.SessionDuration.Seconds()), duration.Format(options.SessionDuration))
 
 	accessToken, err := authenticate(ctx, resolvedConfig, options.Verbose, dependencies)
 	if err != nil {
 		return nil, err
 	}
-
-	roleSessionName := options.ProfileConfig.RoleSessionName
-	if roleSessionName == "" {
-		roleSessionName = fmt.Sprintf("radosgw-assume-%s", dependencies.now().UTC().Format("20060102T150405Z"))
+	if err := verifyWebIdentityToken(ctx, resolvedConfig, accessToken, options.Verbose, dependencies); err != nil {
+		return nil, err
 	}
 
+	roleSessionName := sessionName(resolvedConfig, dependencies)
 	verbosef(dependencies.stderr, options.Verbose, "# Assuming role with web identity: %s\n", resolvedConfig.roleARN)
 	verbosef(dependencies.stderr, options.Verbose, "# Session name: %s\n", roleSessionName)
 
-	result, err := dependencies.assumeRole(ctx, sts.AssumeRoleOptions{
-		EndpointURL:      resolvedConfig.sourceConfig.EndpointURL,
-		RoleARN:          resolvedConfig.roleARN,
-		WebIdentityToken: accessToken,
-		RoleSessionName:  roleSessionName,
-		SSLVerify:        resolvedConfig.sslVerify,
-		SessionDuration:  options.SessionDuration,
-	})
+	assumeRoleOptions := stsOptions(resolvedConfig, roleSessionName, options.SessionDuration)
+	assumeRoleOptions.WebIdentityToken = accessToken
+	result, err := dependencies.assumeRole(ctx, assumeRoleOptions)
 	if err != nil {
 		return nil, err
 	}
+	result.EndpointURL = exportedEndpointURL(resolvedConfig, result, options.Verbose, dependencies)
+
+	printAssumedRole(dependencies.stderr, result, options.Verbose)
+
+	for _, role := range resolvedConfig.chainedRoles {
+		roleSessionName := sessionName(role.config, dependencies)
+		verbosef(dependencies.stderr, options.Verbose, "# Assuming role: %s (profile %s)\n", role.config.roleARN, role.profileName)
+		verbosef(dependencies.stderr, options.Verbose, "# Session name: %s\n", roleSessionName)
 
-	if result.AssumedRoleArn != "" {
-		verbosef(dependencies.stderr, options.Verbose, "# Assumed role ARN: %s\n", result.AssumedRoleArn)
+		chainedResult, err := dependencies.assumeChainedRole(ctx, stsOptions(role.config, roleSessionName, options.SessionDuration), result)
+		if err != nil {
+			return nil, fmt.Errorf("profile '%s': %w", role.profileName, err)
+		}
+		printAssumedRole(dependencies.stderr, chainedResult, options.Verbose)
+		// AssumeRole does not repeat the web identity details, but they still
+		// identify who the chained session acts for.
+		chainedResult.SubjectFromWebIdentityToken = result.SubjectFromWebIdentityToken
+		chainedResult.Audience = result.Audience
+		chainedResult.Provider = result.Provider
+		chainedResult.EndpointURL = exportedEndpointURL(role.config, chainedResult, options.Verbose, dependencies)
+		result = chainedResult
 	}
 
 	result.ProfileName = options.ProfileName
 	return result, nil
 }
+
+// sessionName returns the profile's role_session_name, or a timestamped name
+// when the profile does not set one.
+func sessionName(resolvedConfig *resolvedCredentialConfig, dependencies credentialDependencies) string {
+	if resolvedConfig.roleSessionName != "" {
+		return resolvedConfig.roleSessionName
+	}
+	return fmt.Sprintf("radosgw-assume-%s", dependencies.now().UTC().Format("20060102T150405Z"))
+}
+
+// stsEndpointURL returns sts_endpoint_url, or endpoint_url when RadosGW
+// serves STS and S3 from the same host.
+func stsEndpointURL(sourceConfig *config.ProfileConfig) string {
+	if sourceConfig.STSEndpointURL != "" {
+		return sourceConfig.STSEndpointURL
+	}
+	return sourceConfig.EndpointURL
+}
+
+// exportedEndpointURL returns the endpoint handed to AWS clients with result.
+// endpoint_url, set or inherited, is always the exported S3 endpoint. A
+// profile that lists sts_endpoints without it exports the gateway that issued
+// the credentials, which serves S3 as well. sts_endpoint_url never marks those
+// gateways as STS-only: it is an alternative to sts_endpoints, replacing an
+// inherited list and rejected next to one in the same profile.
+func exportedEndpointURL(resolvedConfig *resolvedCredentialConfig, result *config.AssumeRoleResult, verboseMode bool, dependencies credentialDependencies) string {
+	if len(resolvedConfig.stsEndpoints) > 0 {
+		verbosef(dependencies.stderr, verboseMode, "# Credentials issued by: %s\n", result.EndpointURL)
+	}
+	if resolvedConfig.sourceConfig.EndpointURL != "" || len(resolvedConfig.stsEndpoints) == 0 {
+		return resolvedConfig.sourceConfig.EndpointURL
+	}
+	return result.EndpointURL
+}
+
+func stsOptions(resolvedConfig *resolvedCredentialConfig, roleSessionName string, sessionDuration time.Duration) sts.AssumeRoleOptions {
+	options := sts.AssumeRoleOptions{
+		EndpointURL:       stsEndpointURL(resolvedConfig.sourceConfig),
+		Region:            resolvedConfig.sourceConfig.Region,
+		RoleARN:           resolvedConfig.roleARN,
+		RoleSessionName:   roleSessionName,
+		SSLVerify:         resolvedConfig.sslVerify,
+		ClientCertificate: resolvedConfig.clientCertificate,
+		RootCAs:           resolvedConfig.stsTrust.rootCAs,
+		PinnedSHA256:      resolvedConfig.stsTrust.pinnedSHA256,
+		ProxyURL:          resolvedConfig.stsProxy.proxyURL,
+		NoProxy:           resolvedConfig.stsProxy.noProxy,
+		OnProxy:           resolvedConfig.reportProxy,
+		MaxAttempts:       resolvedConfig.maxAttempts,
+		OnRetry:           resolvedConfig.reportRetry,
+		SessionDuration:   sessionDuration,
+		Policy:            resolvedConfig.sessionPolicy,
+		PolicyARNs:        resolvedConfig.policyARNs,
+	}
+	if len(resolvedConfig.stsEndpoints) > 0 {
+		options.EndpointURL = resolvedConfig.stsEndpoints[0]
+		options.FailoverEndpointURLs = resolvedConfig.stsEndpoints[1:]
+		options.OnFailover = resolvedConfig.reportFailover
+	}
+	return options
+}
+
+func getWebIdentityToken(ctx context.Context, options RequestOptions, dependencies credentialDependencies) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	resolvedConfig, err := resolveCredentialConfig(options.ProfileName, options.ProfileConfig, options.AWSConfig, options.Verbose, dependencies)
+	if err != nil {
+		return "", err
+	}
+
+	printCredentialContext(dependencies.stderr, options.ProfileName, resolvedConfig, options.Verbose)
+	return authenticate(ctx, resolvedConfig, options.Verbose, dependencies)
+}
@@ -9,29 +9,59 @@ import (
 	"github.com/fitbeard/radosgw-assume/internal/auth"
 	"github.com/fitbeard/radosgw-assume/internal/config"
 	"github.com/fitbeard/radosgw-assume/internal/sts"
+	"github.com/fitbeard/radosgw-assume/internal/tokencache"
 
 	"gopkg.in/ini.v1"
 )
 
+type oidcTokenStore interface {
+	LoadRefreshToken(string, string) (tokencache.RefreshToken, bool, error)
+	SaveRefreshToken(string, string, tokencache.RefreshToken) error
+	DeleteRefreshToken(string, string) error
+	LoadIdentityToken(tokencache.IdentityTokenKey, time.Time) (string, bool, error)
+	SaveIdentityToken(tokencache.IdentityTokenKey, string, time.Time) error
+	TakeIdentityToken(tokencache.IdentityTokenKey) (string, time.Time, bool, error)
+}
+
 type credentialDependencies struct {
-	stderr io.Writer
-	getenv func(string) string
-	now    func() time.Time
+	stderr   io.Writer
+	getenv   func(string) string
+	readFile func(string) ([]byte, error)
+	now      func() time.Time
 
-	resolveSourceProfile func(*config.ProfileConfig, *ini.File, bool) (*config.ProfileConfig, error)
-	authenticateDevice   func(context.Context, auth.OIDCOptions) (string, error)
-	authenticateBrowser  func(context.Context, auth.OIDCOptions) (string, error)
-	assumeRole           func(context.Context, sts.AssumeRoleOptions) (*config.AssumeRoleResult, error)
+	resolveRoleChain    func(*config.ProfileConfig, *ini.File, bool) ([]config.RoleChainHop, *config.ProfileConfig, error)
+	authenticateDevice  func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error)
+	authenticateBrowser func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error)
+	authenticateClient  func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error)
+	refreshTokens       func(context.Context, auth.OIDCOptions, string) (auth.TokenResponse, error)
+	fetchGitHubToken    func(context.Context, auth.GitHubActionsOptions) (string, error)
+	exchangeToken       func(context.Context, auth.OIDCOptions, auth.TokenExchangeOptions) (auth.TokenResponse, error)
+	verifyToken         func(context.Context, auth.OIDCOptions, string, auth.TokenVerificationOptions) error
+	revokeToken         func(context.Context, auth.OIDCOptions, string, string) error
+	endSessionURL       func(context.Context, auth.OIDCOptions, string) (string, error)
+	openTokenStore      func() (oidcTokenStore, error)
+	assumeRole          func(context.Context, sts.AssumeRoleOptions) (*config.AssumeRoleResult, error)
+	assumeChainedRole   func(context.Context, sts.AssumeRoleOptions, *config.AssumeRoleResult) (*config.AssumeRoleResult, error)
 }
 
 func newCredentialDependencies() credentialDependencies {
 	return credentialDependencies{
-		stderr:               os.Stderr,
-		getenv:               os.Getenv,
-		now:                  time.Now,
-		resolveSourceProfile: config.ResolveSourceProfile,
-		authenticateDevice:   auth.AuthenticateDeviceFlow,
-		authenticateBrowser:  auth.AuthenticateBrowserFlow,
-		assumeRole:           sts.AssumeRoleWithWebIdentity,
+		stderr:              os.Stderr,
+		getenv:              os.Getenv,
+		readFile:            os.ReadFile,
+		now:                 time.Now,
+		resolveRoleChain:    config.ResolveRoleChain,
+		authenticateDevice:  auth.AuthenticateDeviceFlow,
+		authenticateBrowser: auth.AuthenticateBrowserFlow,
+		authenticateClient:  auth.AuthenticateClientCredentials,
+		refreshTokens:       auth.RefreshTokens,
+		fetchGitHubToken:    auth.FetchGitHubActionsToken,
+		exchangeToken:       auth.ExchangeToken,
+		verifyToken:         auth.VerifyWebIdentityToken,
+		revokeToken:         auth.RevokeToken,
+		endSessionURL:       auth.EndSessionURL,
+		openTokenStore:      func() (oidcTokenStore, error) { return tokencache.New() },
+		assumeRole:          sts.AssumeRoleWithWebIdentity,
+		assumeChainedRole:   sts.AssumeRole,
 	}
 }
@@ -0,0 +1,85 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/sts"
+)
+
+func TestGetCredentialsUsesGitHubActionsToken(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := newTestCredentialDependencies(t, stderr)
+	dependencies.getenv = func(name string) string {
+		switch name {
+		case "ACTIONS_ID_TOKEN_REQUEST_URL":
+			return "https://pipelines.actions.githubusercontent.com/token?api-version=2.0"
+		case "ACTIONS_ID_TOKEN_REQUEST_TOKEN":
+			return "request-token"
+		default:
+			t.Errorf("unexpected getenv(%q) call", name)
+			return ""
+		}
+	}
+	dependencies.fetchGitHubToken = func(_ context.Context, options auth.GitHubActionsOptions) (string, error) {
+		want := auth.GitHubActionsOptions{
+			RequestURL:   "https://pipelines.actions.githubusercontent.com/token?api-version=2.0",
+			RequestToken: "request-token",
+			Audience:     "sts.storage.example.com",
+		}
+		if options != want {
+			t.Errorf("fetchGitHubToken() options = %+v, want %+v", options, want)
+		}
+		return "github.jwt.value", nil
+	}
+	var presented string
+	dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		presented = options.WebIdentityToken
+		return &config.AssumeRoleResult{}, nil
+	}
+
+	if _, err := getCredentials(t.Context(), githubActionsTestRequest(stderr), dependencies); err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+	if presented != "github.jwt.value" {
+		t.Errorf("assumeRole() web identity token = %q, want github.jwt.value", presented)
+	}
+	for _, want := range []string{"# Auth type: github-actions", "# OIDC audience: sts.storage.example.com", "# Requesting GitHub Actions OIDC token"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("verbose output %q does not contain %q", stderr.String(), want)
+		}
+	}
+	if strings.Contains(stderr.String(), "# OIDC provider:") {
+		t.Errorf("verbose output %q reports an unused OIDC provider", stderr.String())
+	}
+}
+
+func TestGetCredentialsReportsGitHubActionsFailure(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := newTestCredentialDependencies(t, stderr)
+	dependencies.getenv = func(string) string { return "" }
+	dependencies.fetchGitHubToken = func(context.Context, auth.GitHubActionsOptions) (string, error) {
+		return "", errors.New("request token missing")
+	}
+
+	_, err := getCredentials(t.Context(), githubActionsTestRequest(stderr), dependencies)
+	if err == nil || !strings.Contains(err.Error(), "GitHub Actions authentication failed: request token missing") {
+		t.Fatalf("getCredentials() error = %v, want wrapped GitHub Actions failure", err)
+	}
+}
+
+func githubActionsTestRequest(stderr *bytes.Buffer) RequestOptions {
+	request := refreshTestRequest(stderr)
+	request.ProfileConfig = &config.ProfileConfig{
+		EndpointURL:         "https://storage.example.com",
+		RoleArn:             "arn:aws:iam::123456789012:role/GitHubRole",
+		RadosGWOIDCAuthType: config.AuthTypeGitHubActions,
+		RadosGWOIDCAudience: "sts.storage.example.com",
+	}
+	return request
+}
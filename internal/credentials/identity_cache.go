@@ -0,0 +1,61 @@
+package credentials
+
+import (
+	"strings"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/tokencache"
+)
+
+// identityTokenCacheKey deliberately omits the role and profile name so every
+// profile that logs in to the same client shares the login.
+func identityTokenCacheKey(resolvedConfig *resolvedCredentialConfig) tokencache.IdentityTokenKey {
+	return tokencache.IdentityTokenKey{
+		ProviderURL: resolvedConfig.sourceConfig.RadosGWOIDCProvider,
+		ClientID:    resolvedConfig.sourceConfig.RadosGWOIDCClientID,
+		Scope:       resolvedConfig.scope,
+		TokenType:   string(resolvedConfig.tokenType),
+		Audience:    resolvedConfig.authorization.Audience,
+		Resource:    strings.Join(resolvedConfig.authorization.Resources, " "),
+		ACRValues:   resolvedConfig.authorization.ACRValues,
+		LoginHint:   resolvedConfig.authorization.LoginHint,
+	}
+}
+
+// loadIdentityToken treats every store failure as a cache miss because the
+// caller can always authenticate again.
+func loadIdentityToken(store oidcTokenStore, key tokencache.IdentityTokenKey, verboseMode bool, dependencies credentialDependencies) (string, bool) {
+	if store == nil {
+		return "", false
+	}
+	token, found, err := store.LoadIdentityToken(key, dependencies.now())
+	if err != nil {
+		verbosef(dependencies.stderr, verboseMode, "# Ignoring cached OIDC token: %v\n", err)
+		return "", false
+	}
+	if found {
+		verbosef(dependencies.stderr, verboseMode, "# Using cached OIDC token for %s\n", key.ClientID)
+	}
+	return token, found
+}
+
+// saveIdentityToken caches a token only when its lifetime is known, from the
+// JWT exp claim or, for access tokens, the token response's expires_in.
+func saveIdentityToken(store oidcTokenStore, key tokencache.IdentityTokenKey, token string, tokens auth.TokenResponse, verboseMode bool, dependencies credentialDependencies) {
+	if store == nil {
+		return
+	}
+	expiresAt, err := auth.TokenExpiry(token)
+	if err != nil {
+		if config.TokenType(key.TokenType) == config.TokenTypeIDToken || tokens.ExpiresIn <= 0 {
+			verbosef(dependencies.stderr, verboseMode, "# Not caching OIDC token with unknown lifetime: %v\n", err)
+			return
+		}
+		expiresAt = dependencies.now().Add(time.Duration(tokens.ExpiresIn) * time.Second)
+	}
+	if err := store.SaveIdentityToken(key, token, expiresAt); err != nil {
+		verbosef(dependencies.stderr, verboseMode, "# Could not cache OIDC token: %v\n", err)
+	}
+}
@@ -0,0 +1,200 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/sts"
+	"github.com/fitbeard/radosgw-assume/internal/tokencache"
+)
+
+func TestGetCredentialsSharesOIDCLoginAcrossRoles(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	store := newTestOIDCTokenStore()
+	dependencies := refreshTestDependencies(t, stderr, store)
+	now := dependencies.now()
+	deviceLogins := 0
+	dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+		deviceLogins++
+		return auth.TokenResponse{AccessToken: testJWT(now.Add(time.Hour))}, nil
+	}
+	var presented []string
+	dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		presented = append(presented, options.RoleARN)
+		return &config.AssumeRoleResult{}, nil
+	}
+
+	for _, role := range []string{"arn:aws:iam::123456789012:role/Reader", "arn:aws:iam::123456789012:role/Writer"} {
+		request := refreshTestRequest(stderr)
+		request.ProfileConfig.RoleArn = role
+		if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+			t.Fatalf("getCredentials(%s) error = %v", role, err)
+		}
+	}
+
+	if deviceLogins != 1 {
+		t.Errorf("device logins = %d, want 1 shared login", deviceLogins)
+	}
+	if len(presented) != 2 {
+		t.Errorf("assumeRole() calls = %q, want one per role", presented)
+	}
+	if !strings.Contains(stderr.String(), "# Using cached OIDC token for test-client") {
+		t.Errorf("verbose output %q does not report the cached token", stderr.String())
+	}
+}
+
+func TestGetCredentialsIdentityTokenCacheMisses(t *testing.T) {
+	now := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+	for _, test := range []struct {
+		name        string
+		accessToken string
+		advance     time.Duration
+		modify      func(*config.ProfileConfig)
+	}{
+		{
+			name:        "different scope",
+			accessToken: testJWT(now.Add(time.Hour)),
+			modify:      func(profile *config.ProfileConfig) { profile.RadosGWOIDCScope = "openid groups" },
+		},
+		{
+			name:        "different token type",
+			accessToken: testJWT(now.Add(time.Hour)),
+			modify:      func(profile *config.ProfileConfig) { profile.RadosGWOIDCTokenType = config.TokenTypeIDToken },
+		},
+		{
+			name:        "different audience",
+			accessToken: testJWT(now.Add(time.Hour)),
+			modify:      func(profile *config.ProfileConfig) { profile.RadosGWOIDCAudience = "radosgw" },
+		},
+		{
+			name:        "different resource",
+			accessToken: testJWT(now.Add(time.Hour)),
+			modify:      func(profile *config.ProfileConfig) { profile.RadosGWOIDCResource = "https://storage.example.com" },
+		},
+		{
+			name:        "different ACR values",
+			accessToken: testJWT(now.Add(time.Hour)),
+			modify:      func(profile *config.ProfileConfig) { profile.RadosGWOIDCACRValues = "mfa" },
+		},
+		{
+			name:        "different login hint",
+			accessToken: testJWT(now.Add(time.Hour)),
+			modify:      func(profile *config.ProfileConfig) { profile.RadosGWOIDCLoginHint = "other@example.com" },
+		},
+		{
+			name:        "nearly expired",
+			accessToken: testJWT(now.Add(90 * time.Second)),
+			advance:     time.Minute,
+		},
+		{
+			name:        "unknown lifetime",
+			accessToken: "opaque-access-token",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := refreshTestDependencies(t, stderr, newTestOIDCTokenStore())
+			dependencies.now = func() time.Time { return now }
+			deviceLogins := 0
+			dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+				deviceLogins++
+				return auth.TokenResponse{AccessToken: test.accessToken, IDToken: testJWT(now.Add(time.Hour))}, nil
+			}
+
+			if _, err := getCredentials(t.Context(), refreshTestRequest(stderr), dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			dependencies.now = func() time.Time { return now.Add(test.advance) }
+			request := refreshTestRequest(stderr)
+			if test.modify != nil {
+				test.modify(request.ProfileConfig)
+			}
+			if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			if deviceLogins != 2 {
+				t.Errorf("device logins = %d, want a new login", deviceLogins)
+			}
+		})
+	}
+}
+
+func TestGetCredentialsCachesAccessTokenWithExpiresIn(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	store := newTestOIDCTokenStore()
+	dependencies := refreshTestDependencies(t, stderr, store)
+	dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+		return auth.TokenResponse{AccessToken: "opaque-access-token", ExpiresIn: 300}, nil
+	}
+
+	if _, err := getCredentials(t.Context(), refreshTestRequest(stderr), dependencies); err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+	for _, cached := range store.identityTokens {
+		if want := dependencies.now().Add(5 * time.Minute); cached.token != "opaque-access-token" || !cached.expiresAt.Equal(want) {
+			t.Errorf("cached token = %+v, want opaque-access-token until %v", cached, want)
+		}
+	}
+	if len(store.identityTokens) != 1 {
+		t.Errorf("cached tokens = %d, want 1", len(store.identityTokens))
+	}
+}
+
+func testJWT(expiresAt time.Time) string {
+	payload := fmt.Sprintf(`{"sub":"user","exp":%d}`, expiresAt.Unix())
+	return "e30." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+}
+
+func TestGetCredentialsInteractivePromptSkipsStoredTokens(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	store := newTestOIDCTokenStore()
+	store.tokens["https://oidc.example.com test-client"] = tokencache.RefreshToken{Token: "stored-refresh-token"}
+	dependencies := refreshTestDependencies(t, stderr, store)
+	now := dependencies.now()
+	deviceLogins := 0
+	dependencies.authenticateDevice = func(_ context.Context, options auth.OIDCOptions) (auth.TokenResponse, error) {
+		deviceLogins++
+		if options.Authorization.Prompt != "" && options.Authorization.Prompt != "select_account" {
+			t.Errorf("authenticateDevice() prompt = %q, want select_account", options.Authorization.Prompt)
+		}
+		return auth.TokenResponse{AccessToken: testJWT(now.Add(time.Hour)), RefreshToken: fmt.Sprintf("refresh-token-%d", deviceLogins)}, nil
+	}
+	dependencies.refreshTokens = func(context.Context, auth.OIDCOptions, string) (auth.TokenResponse, error) {
+		t.Fatal("refreshTokens() called with prompt=select_account")
+		return auth.TokenResponse{}, nil
+	}
+
+	request := refreshTestRequest(stderr)
+	request.ProfileConfig.RadosGWOIDCPrompt = "select_account"
+	for range 2 {
+		if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+			t.Fatalf("getCredentials() error = %v", err)
+		}
+	}
+
+	if deviceLogins != 2 {
+		t.Errorf("device logins = %d, want a login for every request", deviceLogins)
+	}
+	if got := store.tokens["https://oidc.example.com test-client"].Token; got != "refresh-token-2" {
+		t.Errorf("stored refresh token = %q, want the newest login's token", got)
+	}
+	if !strings.Contains(stderr.String(), "# Skipping cached OIDC tokens for prompt=select_account") {
+		t.Errorf("verbose output %q does not report skipped caches", stderr.String())
+	}
+
+	// A profile without the prompt picks up the account selected above.
+	request = refreshTestRequest(stderr)
+	if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+	if deviceLogins != 2 {
+		t.Errorf("device logins = %d, want the cached login reused without prompt", deviceLogins)
+	}
+}
@@ -0,0 +1,142 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
+// LogoutResult describes the stored tokens Logout removed for a profile's
+// OIDC client. Profiles whose authentication never stores tokens return an
+// empty result.
+type LogoutResult struct {
+	ProviderURL string
+	ClientID    string
+	Tokens      []LoggedOutToken
+	// EndSessionURL is the provider's RP-initiated logout URL. It is only set
+	// when LogoutOptions.EndSession is requested and the provider advertises
+	// an end_session_endpoint; the caller decides how to open it.
+	EndSessionURL string
+}
+
+// LoggedOutToken is a stored token that Logout removed from the token cache.
+type LoggedOutToken struct {
+	// Type is refresh_token, access_token or id_token.
+	Type string
+	// Revoked reports whether the provider accepted the revocation request.
+	Revoked bool
+	// Err explains why a revocable token was only removed locally. It wraps
+	// auth.ErrRevocationUnsupported when the provider has no
+	// revocation_endpoint.
+	Err error
+}
+
+// Logout revokes the refresh token and cached web identity token stored for
+// the profile's OIDC client at the provider's revocation_endpoint (RFC 7009)
+// and removes them from the token cache. Tokens are removed even when
+// revocation fails, so a later login always starts a new session.
+func Logout(ctx context.Context, options LogoutOptions) (LogoutResult, error) {
+	output := options.Output
+	if output == nil {
+		output = os.Stderr
+	}
+	dependencies := newCredentialDependencies()
+	dependencies.stderr = output
+	return logout(ctx, options, dependencies)
+}
+
+func logout(ctx context.Context, options LogoutOptions, dependencies credentialDependencies) (LogoutResult, error) {
+	if err := ctx.Err(); err != nil {
+		return LogoutResult{}, err
+	}
+	resolvedConfig, err := resolveCredentialConfig(options.ProfileName, options.ProfileConfig, options.AWSConfig, options.Verbose, dependencies)
+	if err != nil {
+		return LogoutResult{}, err
+	}
+	if !storesOIDCTokens(resolvedConfig) {
+		verbosef(dependencies.stderr, options.Verbose, "# Auth type %s does not store OIDC tokens\n", resolvedConfig.authType)
+		return LogoutResult{}, nil
+	}
+
+	store, err := dependencies.openTokenStore()
+	if err != nil {
+		return LogoutResult{}, fmt.Errorf("open OIDC token storage: %w", err)
+	}
+	oidcOptions := oidcOptions(resolvedConfig, options.Verbose)
+	if err := applyClientAuthentication(&oidcOptions, resolvedConfig.sourceConfig, dependencies, false); err != nil {
+		return LogoutResult{}, err
+	}
+	result := LogoutResult{ProviderURL: oidcOptions.ProviderURL, ClientID: oidcOptions.ClientID}
+
+	refreshToken, found, err := store.LoadRefreshToken(oidcOptions.ProviderURL, oidcOptions.ClientID)
+	if err != nil {
+		return LogoutResult{}, err
+	}
+	if found {
+		token := revokeStoredToken(ctx, oidcOptions, refreshToken.Token, "refresh_token", dependencies)
+		if err := store.DeleteRefreshToken(oidcOptions.ProviderURL, oidcOptions.ClientID); err != nil {
+			return result, err
+		}
+		result.Tokens = append(result.Tokens, token)
+	}
+
+	cacheKey := identityTokenCacheKey(resolvedConfig)
+	// The cached token is taken however little validity remains, since one
+	// too short-lived to reuse can still be used by whoever holds it.
+	identityToken, expiresAt, found, err := store.TakeIdentityToken(cacheKey)
+	if err != nil {
+		return result, err
+	}
+	if found {
+		token := LoggedOutToken{Type: string(resolvedConfig.tokenType)}
+		// RFC 7009 only covers access and refresh tokens; an ID token is
+		// simply discarded, as is an access token that already expired.
+		if resolvedConfig.tokenType == config.TokenTypeAccessToken && expiresAt.After(dependencies.now()) {
+			token = revokeStoredToken(ctx, oidcOptions, identityToken, "access_token", dependencies)
+		}
+		result.Tokens = append(result.Tokens, token)
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	if options.EndSession {
+		idTokenHint := ""
+		if resolvedConfig.tokenType == config.TokenTypeIDToken {
+			idTokenHint = identityToken
+		}
+		endSessionURL, err := dependencies.endSessionURL(ctx, oidcOptions, idTokenHint)
+		switch {
+		case errors.Is(err, auth.ErrEndSessionUnsupported):
+			verbosef(dependencies.stderr, options.Verbose, "# %v\n", err)
+		case err != nil:
+			return result, fmt.Errorf("end session: %w", err)
+		default:
+			result.EndSessionURL = endSessionURL
+		}
+	}
+	return result, nil
+}
+
+// storesOIDCTokens reports whether the profile logs in with a device or
+// browser flow, directly or to obtain a token exchange subject token. Only
+// those flows keep tokens in the token cache.
+func storesOIDCTokens(resolvedConfig *resolvedCredentialConfig) bool {
+	authType := resolvedConfig.authType
+	if authType == config.AuthTypeTokenExchange {
+		authType = resolvedConfig.sourceConfig.RadosGWOIDCSubjectAuthType
+	}
+	return authType == config.AuthTypeDevice || authType == config.AuthTypeBrowser
+}
+
+func revokeStoredToken(ctx context.Context, options auth.OIDCOptions, token, tokenType string, dependencies credentialDependencies) LoggedOutToken {
+	verbosef(dependencies.stderr, options.Verbose, "# Revoking stored %s\n", tokenType)
+	if err := dependencies.revokeToken(ctx, options, token, tokenType); err != nil {
+		return LoggedOutToken{Type: tokenType, Err: err}
+	}
+	return LoggedOutToken{Type: tokenType, Revoked: true}
+}
@@ -0,0 +1,194 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/tokencache"
+)
+
+func logoutTestOptions(stderr *bytes.Buffer) LogoutOptions {
+	request := refreshTestRequest(stderr)
+	return LogoutOptions{
+		ProfileName:   request.ProfileName,
+		ProfileConfig: request.ProfileConfig,
+		AWSConfig:     request.AWSConfig,
+		Verbose:       true,
+		Output:        stderr,
+	}
+}
+
+func TestLogoutRevokesAndRemovesStoredTokens(t *testing.T) {
+	unsupported := fmt.Errorf("provider: %w", auth.ErrRevocationUnsupported)
+	unavailable := errors.New("token revocation failed with status 503")
+
+	for _, test := range []struct {
+		name       string
+		tokenType  config.TokenType
+		expiresIn  time.Duration
+		endSession bool
+		revokeErr  error
+		want       []LoggedOutToken
+		wantHints  []string
+		wantURL    string
+	}{
+		{
+			name:      "access token",
+			want:      []LoggedOutToken{{Type: "refresh_token", Revoked: true}, {Type: "access_token", Revoked: true}},
+			wantHints: []string{"refresh_token", "access_token"},
+		},
+		{
+			name:      "access token about to expire",
+			expiresIn: 30 * time.Second,
+			want:      []LoggedOutToken{{Type: "refresh_token", Revoked: true}, {Type: "access_token", Revoked: true}},
+			wantHints: []string{"refresh_token", "access_token"},
+		},
+		{
+			name:      "expired access token",
+			expiresIn: -time.Minute,
+			want:      []LoggedOutToken{{Type: "refresh_token", Revoked: true}, {Type: "access_token"}},
+			wantHints: []string{"refresh_token"},
+		},
+		{
+			name:       "ID token with end session",
+			tokenType:  config.TokenTypeIDToken,
+			endSession: true,
+			want:       []LoggedOutToken{{Type: "refresh_token", Revoked: true}, {Type: "id_token"}},
+			wantHints:  []string{"refresh_token"},
+			wantURL:    "https://oidc.example.com/logout?id_token_hint=cached-token",
+		},
+		{
+			name:      "revocation unsupported",
+			revokeErr: unsupported,
+			want:      []LoggedOutToken{{Type: "refresh_token", Err: unsupported}, {Type: "access_token", Err: unsupported}},
+			wantHints: []string{"refresh_token", "access_token"},
+		},
+		{
+			name:      "revocation failure still removes tokens",
+			revokeErr: unavailable,
+			want:      []LoggedOutToken{{Type: "refresh_token", Err: unavailable}, {Type: "access_token", Err: unavailable}},
+			wantHints: []string{"refresh_token", "access_token"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			store := newTestOIDCTokenStore()
+			dependencies := refreshTestDependencies(t, stderr, store)
+			dependencies.getenv = func(string) string { return "" }
+			options := logoutTestOptions(stderr)
+			options.ProfileConfig.RadosGWOIDCTokenType = test.tokenType
+			options.EndSession = test.endSession
+			resolvedConfig, err := resolveCredentialConfig(options.ProfileName, options.ProfileConfig, options.AWSConfig, false, dependencies)
+			if err != nil {
+				t.Fatalf("resolveCredentialConfig() error = %v", err)
+			}
+			cacheKey := identityTokenCacheKey(resolvedConfig)
+			store.tokens["https://oidc.example.com test-client"] = tokencache.RefreshToken{Token: "stored-refresh-token"}
+			expiresIn := test.expiresIn
+			if expiresIn == 0 {
+				expiresIn = time.Hour
+			}
+			store.identityTokens[cacheKey] = testIdentityToken{token: "cached-token", expiresAt: dependencies.now().Add(expiresIn)}
+
+			var hints []string
+			dependencies.revokeToken = func(_ context.Context, options auth.OIDCOptions, token, hint string) error {
+				if options.ProviderURL != "https://oidc.example.com" || options.ClientID != "test-client" {
+					t.Errorf("revokeToken() options = %+v", options)
+				}
+				if want := map[string]string{"refresh_token": "stored-refresh-token", "access_token": "cached-token"}[hint]; token != want {
+					t.Errorf("revokeToken(%s) token = %q, want %q", hint, token, want)
+				}
+				hints = append(hints, hint)
+				return test.revokeErr
+			}
+			dependencies.endSessionURL = func(_ context.Context, _ auth.OIDCOptions, idTokenHint string) (string, error) {
+				return "https://oidc.example.com/logout?id_token_hint=" + idTokenHint, nil
+			}
+
+			result, err := logout(t.Context(), options, dependencies)
+			if err != nil {
+				t.Fatalf("logout() error = %v", err)
+			}
+			if result.ProviderURL != "https://oidc.example.com" || result.ClientID != "test-client" {
+				t.Errorf("logout() client = %s %s", result.ProviderURL, result.ClientID)
+			}
+			if fmt.Sprint(result.Tokens) != fmt.Sprint(test.want) {
+				t.Errorf("logout() tokens = %v, want %v", result.Tokens, test.want)
+			}
+			if fmt.Sprint(hints) != fmt.Sprint(test.wantHints) {
+				t.Errorf("revoked token types = %v, want %v", hints, test.wantHints)
+			}
+			if result.EndSessionURL != test.wantURL {
+				t.Errorf("logout() end session URL = %q, want %q", result.EndSessionURL, test.wantURL)
+			}
+			if len(store.tokens) != 0 || len(store.identityTokens) != 0 {
+				t.Errorf("token store still holds %v and %v", store.tokens, store.identityTokens)
+			}
+		})
+	}
+}
+
+func TestLogoutWithoutStoredTokens(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		authType   config.AuthType
+		wantClient string
+	}{
+		{name: "device login", authType: config.AuthTypeDevice, wantClient: "test-client"},
+		{name: "client credentials", authType: config.AuthTypeClientCredentials},
+		{name: "presented token", authType: config.AuthTypeToken},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := refreshTestDependencies(t, stderr, newTestOIDCTokenStore())
+			dependencies.getenv = func(string) string { return "" }
+			options := logoutTestOptions(stderr)
+			options.ProfileConfig.RadosGWOIDCAuthType = test.authType
+
+			result, err := logout(t.Context(), options, dependencies)
+			if err != nil {
+				t.Fatalf("logout() error = %v", err)
+			}
+			if result.ClientID != test.wantClient || len(result.Tokens) != 0 {
+				t.Errorf("logout() = %+v, want no tokens for client %q", result, test.wantClient)
+			}
+		})
+	}
+}
+
+func TestLogoutFailsWithoutTokenStore(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := refreshTestDependencies(t, stderr, nil)
+	dependencies.getenv = func(string) string { return "" }
+	dependencies.openTokenStore = func() (oidcTokenStore, error) { return nil, errors.New("cache directory unavailable") }
+
+	_, err := logout(t.Context(), logoutTestOptions(stderr), dependencies)
+	if err == nil || err.Error() != "open OIDC token storage: cache directory unavailable" {
+		t.Errorf("logout() error = %v", err)
+	}
+}
+
+func TestLogoutEndSessionUnsupported(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := refreshTestDependencies(t, stderr, newTestOIDCTokenStore())
+	dependencies.getenv = func(string) string { return "" }
+	dependencies.endSessionURL = func(context.Context, auth.OIDCOptions, string) (string, error) {
+		return "", auth.ErrEndSessionUnsupported
+	}
+	options := logoutTestOptions(stderr)
+	options.EndSession = true
+
+	result, err := logout(t.Context(), options, dependencies)
+	if err != nil {
+		t.Fatalf("logout() error = %v", err)
+	}
+	if result.EndSessionURL != "" {
+		t.Errorf("logout() end session URL = %q, want none", result.EndSessionURL)
+	}
+}
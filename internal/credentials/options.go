@@ -26,3 +26,15 @@ type ProcessRequestOptions struct {
 	RequestOptions
 	NoCache bool
 }
+
+// LogoutOptions selects the profile whose stored OIDC tokens Logout revokes.
+// EndSession also requests the provider's end_session_endpoint. Output
+// receives verbose diagnostics; when it is nil they go to standard error.
+type LogoutOptions struct {
+	ProfileName   string
+	ProfileConfig *config.ProfileConfig
+	AWSConfig     *ini.File
+	EndSession    bool
+	Verbose       bool
+	Output        io.Writer
+}
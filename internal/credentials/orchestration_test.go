@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -55,18 +57,29 @@ func TestGetCredentials_AuthFlows(t *testing.T) {
 				RadosGWOIDCScope:      "openid groups",
 				RadosGWOIDCPKCEMethod: "plain",
 				RadosGWSSLVerify:      "false",
+
+				RadosGWOIDCAudience:      "radosgw",
+				RadosGWOIDCResource:      "https://storage.example.com",
+				RadosGWOIDCPrompt:        "login",
+				RadosGWOIDCLoginHint:     "user@example.com",
+				RadosGWOIDCACRValues:     "mfa",
+				RadosGWOIDCBrowserMode:   config.BrowserModeManual,
+				RadosGWOIDCRequirePAR:    "true",
+				RadosGWOIDCCallbackPorts: "8400, 0",
+				RadosGWOIDCCallbackHost:  config.CallbackHostIPv6,
+				RadosGWOIDCCallbackPath:  "/oauth2/callback",
 			}
 
 			switch test.resolvedAuthType {
 			case config.AuthTypeDevice:
-				dependencies.authenticateDevice = func(_ context.Context, options auth.OIDCOptions) (string, error) {
+				dependencies.authenticateDevice = func(_ context.Context, options auth.OIDCOptions) (auth.TokenResponse, error) {
 					assertAuthenticationOptions(t, options)
-					return "device-token", nil
+					return auth.TokenResponse{AccessToken: "device-token"}, nil
 				}
 			case config.AuthTypeBrowser:
-				dependencies.authenticateBrowser = func(_ context.Context, options auth.OIDCOptions) (string, error) {
+				dependencies.authenticateBrowser = func(_ context.Context, options auth.OIDCOptions) (auth.TokenResponse, error) {
 					assertAuthenticationOptions(t, options)
-					return "browser-token", nil
+					return auth.TokenResponse{AccessToken: "browser-token"}, nil
 				}
 			case config.AuthTypeToken:
 				expectedAccessToken = "environment-token"
@@ -131,6 +144,21 @@ func TestGetCredentials_AuthFlows(t *testing.T) {
 					t.Errorf("verbose output %q does not contain %q", verboseOutput, expected)
 				}
 			}
+			if test.resolvedAuthType != config.AuthTypeToken {
+				for _, expected := range []string{
+					"# OIDC audience: radosgw",
+					"# OIDC prompt: login",
+					"# OIDC login hint: user@example.com",
+					"# OIDC ACR values: mfa",
+					"# OIDC resource: https://storage.example.com",
+				} {
+					if !strings.Contains(verboseOutput, expected) {
+						t.Errorf("verbose output %q does not contain %q", verboseOutput, expected)
+					}
+				}
+			} else if strings.Contains(verboseOutput, "# OIDC prompt:") {
+				t.Errorf("token verbose output unexpectedly contains authorization parameters: %q", verboseOutput)
+			}
 			if test.resolvedAuthType == config.AuthTypeToken && strings.Contains(verboseOutput, "# OIDC provider:") {
 				t.Errorf("token verbose output unexpectedly contains OIDC provider: %q", verboseOutput)
 			}
@@ -152,11 +180,11 @@ func TestGetCredentials_SourceProfile(t *testing.T) {
 		RadosGWOIDCAuthType: "token",
 	}
 
-	dependencies.resolveSourceProfile = func(gotProfile *config.ProfileConfig, gotConfig *ini.File, verboseMode bool) (*config.ProfileConfig, error) {
+	dependencies.resolveRoleChain = func(gotProfile *config.ProfileConfig, gotConfig *ini.File, verboseMode bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
 		if gotProfile != profileConfig || gotConfig != awsConfig || !verboseMode {
-			t.Error("resolveSourceProfile() received unexpected arguments")
+			t.Error("resolveRoleChain() received unexpected arguments")
 		}
-		return sourceConfig, nil
+		return nil, sourceConfig, nil
 	}
 	dependencies.getenv = func(string) string { return "test-token" }
 	dependencies.now = func() time.Time {
@@ -201,6 +229,210 @@ func TestGetCredentials_SourceProfile(t *testing.T) {
 	}
 }
 
+func TestGetCredentials_SeparateSTSEndpoint(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := newTestCredentialDependencies(t, stderr)
+	dependencies.getenv = func(string) string { return "test-token" }
+	dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		if options.EndpointURL != "https://sts.example.com" || options.Region != "zonegroup-a" {
+			t.Errorf("assumeRole() endpoint, region = %q %q, want the STS endpoint and zonegroup-a", options.EndpointURL, options.Region)
+		}
+		return &config.AssumeRoleResult{EndpointURL: options.EndpointURL}, nil
+	}
+
+	result, err := getCredentials(t.Context(), RequestOptions{
+		ProfileName: "multisite",
+		ProfileConfig: &config.ProfileConfig{
+			EndpointURL:         "https://s3.example.com",
+			STSEndpointURL:      "https://sts.example.com",
+			Region:              "zonegroup-a",
+			RadosGWOIDCAuthType: config.AuthTypeToken,
+			RoleArn:             "arn:aws:iam::123456789012:role/TestRole",
+		},
+		Verbose:         true,
+		SessionDuration: time.Hour,
+		Output:          stderr,
+	}, dependencies)
+	if err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+	if result.EndpointURL != "https://s3.example.com" {
+		t.Errorf("EndpointURL = %q, want the S3 endpoint", result.EndpointURL)
+	}
+	if want := "# RadosGW endpoint: https://s3.example.com\n# STS endpoint: https://sts.example.com\n# Region: zonegroup-a\n"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("verbose output %q does not contain %q", stderr.String(), want)
+	}
+}
+
+func TestGetCredentials_STSGatewayFailover(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := newTestCredentialDependencies(t, stderr)
+	dependencies.getenv = func(string) string { return "test-token" }
+	dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		if options.EndpointURL != "https://rgw1.example.com" || strings.Join(options.FailoverEndpointURLs, " ") != "https://rgw2.example.com https://rgw3.example.com" {
+			t.Errorf("assumeRole() endpoints = %q %v, want the gateways in order", options.EndpointURL, options.FailoverEndpointURLs)
+		}
+		options.OnFailover(options.EndpointURL, errors.New("connection refused"))
+		return &config.AssumeRoleResult{EndpointURL: options.FailoverEndpointURLs[0]}, nil
+	}
+
+	result, err := getCredentials(t.Context(), RequestOptions{
+		ProfileName: "gateways",
+		ProfileConfig: &config.ProfileConfig{
+			STSEndpoints:        "https://rgw1.example.com, https://rgw2.example.com, https://rgw3.example.com",
+			RadosGWOIDCAuthType: config.AuthTypeToken,
+			RoleArn:             "arn:aws:iam::123456789012:role/TestRole",
+		},
+		Verbose:         true,
+		SessionDuration: time.Hour,
+		Output:          stderr,
+	}, dependencies)
+	if err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+	if result.EndpointURL != "https://rgw2.example.com" {
+		t.Errorf("EndpointURL = %q, want the gateway that issued the credentials", result.EndpointURL)
+	}
+	for _, want := range []string{
+		"# STS endpoints: https://rgw1.example.com, https://rgw2.example.com, https://rgw3.example.com\n",
+		"# STS endpoint https://rgw1.example.com failed, trying the next one: connection refused\n",
+		"# Credentials issued by: https://rgw2.example.com\n",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("verbose output %q does not contain %q", stderr.String(), want)
+		}
+	}
+	if strings.Contains(stderr.String(), "# RadosGW endpoint:") {
+		t.Errorf("verbose output %q reports an endpoint_url the profile does not set", stderr.String())
+	}
+}
+
+func TestGetCredentials_RoleChain(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := newTestCredentialDependencies(t, stderr)
+	profileConfig := &config.ProfileConfig{
+		RoleArn:       "arn:aws:iam::123456789012:role/TenantAdmin",
+		SourceProfile: "tenant",
+		SessionPolicy: `{"Version":"2012-10-17","Statement":[]}`,
+	}
+	entryConfig := &config.ProfileConfig{
+		EndpointURL:         "https://storage.example.com",
+		RadosGWOIDCAuthType: "token",
+		RoleArn:             "arn:aws:iam::123456789012:role/Entry",
+		RoleSessionName:     "entry-session",
+	}
+	tenantConfig := &config.ProfileConfig{
+		EndpointURL:         "https://tenant.example.com",
+		RadosGWOIDCAuthType: "token",
+		RoleArn:             "arn:aws:iam::123456789012:role/Tenant",
+	}
+	finalConfig := &config.ProfileConfig{
+		EndpointURL:         "https://tenant.example.com",
+		RadosGWOIDCAuthType: "token",
+		RoleArn:             profileConfig.RoleArn,
+		SessionPolicy:       profileConfig.SessionPolicy,
+	}
+
+	dependencies.resolveRoleChain = func(*config.ProfileConfig, *ini.File, bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+		return []config.RoleChainHop{
+			{ProfileName: "entry", Config: entryConfig},
+			{ProfileName: "tenant", Config: tenantConfig},
+		}, finalConfig, nil
+	}
+	dependencies.getenv = func(string) string { return "test-token" }
+	dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		if options.EndpointURL != entryConfig.EndpointURL || options.RoleARN != entryConfig.RoleArn || options.RoleSessionName != "entry-session" {
+			t.Errorf("assumeRole() options = %+v, want the entry role", options)
+		}
+		if options.Policy != "" {
+			t.Errorf("assumeRole() policy = %q, want none", options.Policy)
+		}
+		return &config.AssumeRoleResult{
+			AccessKeyID:                 "entry-key",
+			AssumedRoleArn:              "arn:aws:sts::123456789012:assumed-role/Entry/entry-session",
+			SubjectFromWebIdentityToken: "user-subject",
+			Provider:                    "https://oidc.example.com",
+		}, nil
+	}
+	var hops []string
+	dependencies.assumeChainedRole = func(_ context.Context, options sts.AssumeRoleOptions, sourceCredentials *config.AssumeRoleResult) (*config.AssumeRoleResult, error) {
+		if options.EndpointURL != tenantConfig.EndpointURL || options.WebIdentityToken != "" || options.SessionDuration != time.Hour {
+			t.Errorf("assumeChainedRole() options = %+v", options)
+		}
+		hops = append(hops, sourceCredentials.AccessKeyID+" -> "+options.RoleARN+" "+options.RoleSessionName+" "+options.Policy)
+		return &config.AssumeRoleResult{
+			AccessKeyID:    options.RoleARN + "-key",
+			AssumedRoleArn: "arn:aws:sts::123456789012:assumed-role/" + options.RoleARN[strings.LastIndex(options.RoleARN, "/")+1:] + "/" + options.RoleSessionName,
+		}, nil
+	}
+
+	result, err := getCredentials(t.Context(), RequestOptions{
+		ProfileName:     "tenant-admin",
+		ProfileConfig:   profileConfig,
+		AWSConfig:       ini.Empty(),
+		Verbose:         true,
+		SessionDuration: time.Hour,
+		Output:          stderr,
+	}, dependencies)
+	if err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+	if result.ProfileName != "tenant-admin" || result.AccessKeyID != profileConfig.RoleArn+"-key" || result.SubjectFromWebIdentityToken != "user-subject" {
+		t.Errorf("getCredentials() = %+v, want the tenant-admin credentials", result)
+	}
+	wantHops := []string{
+		"entry-key -> arn:aws:iam::123456789012:role/Tenant radosgw-assume-20300102T030405Z ",
+		"arn:aws:iam::123456789012:role/Tenant-key -> arn:aws:iam::123456789012:role/TenantAdmin radosgw-assume-20300102T030405Z " + profileConfig.SessionPolicy,
+	}
+	if !slices.Equal(hops, wantHops) {
+		t.Errorf("assumed roles = %q, want %q", hops, wantHops)
+	}
+	for _, expected := range []string{
+		"# Role chain: entry -> tenant -> tenant-admin\n",
+		"# Assuming role with web identity: arn:aws:iam::123456789012:role/Entry\n# Session name: entry-session\n",
+		"# Web identity subject: user-subject\n# Web identity provider: https://oidc.example.com\n",
+		"# Assuming role: arn:aws:iam::123456789012:role/Tenant (profile tenant)\n",
+		"# Assuming role: arn:aws:iam::123456789012:role/TenantAdmin (profile tenant-admin)\n",
+		"# Assumed role ARN: arn:aws:sts::123456789012:assumed-role/TenantAdmin/radosgw-assume-20300102T030405Z\n",
+	} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("verbose output %q does not contain %q", stderr.String(), expected)
+		}
+	}
+}
+
+func TestGetCredentials_RoleChainError(t *testing.T) {
+	dependencies := newTestCredentialDependencies(t, &bytes.Buffer{})
+	chainedConfig := &config.ProfileConfig{
+		EndpointURL:         "https://storage.example.com",
+		RadosGWOIDCAuthType: "token",
+		RoleArn:             "arn:aws:iam::123456789012:role/Entry",
+	}
+	dependencies.resolveRoleChain = func(profile *config.ProfileConfig, _ *ini.File, _ bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+		finalConfig := *chainedConfig
+		finalConfig.RoleArn = profile.RoleArn
+		return []config.RoleChainHop{{ProfileName: "entry", Config: chainedConfig}}, &finalConfig, nil
+	}
+	dependencies.getenv = func(string) string { return "test-token" }
+	dependencies.assumeRole = func(context.Context, sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		return &config.AssumeRoleResult{AccessKeyID: "entry-key"}, nil
+	}
+	denied := errors.New("access denied")
+	dependencies.assumeChainedRole = func(context.Context, sts.AssumeRoleOptions, *config.AssumeRoleResult) (*config.AssumeRoleResult, error) {
+		return nil, denied
+	}
+
+	_, err := getCredentials(t.Context(), RequestOptions{
+		ProfileName:     "tenant",
+		ProfileConfig:   &config.ProfileConfig{RoleArn: "arn:aws:iam::123456789012:role/Tenant", SourceProfile: "entry"},
+		AWSConfig:       ini.Empty(),
+		SessionDuration: time.Hour,
+	}, dependencies)
+	if !errors.Is(err, denied) || err.Error() != "profile 'tenant': access denied" {
+		t.Errorf("getCredentials() error = %v, want the tenant role failure", err)
+	}
+}
+
 func TestGetCredentials_DependencyErrors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -220,8 +452,8 @@ func TestGetCredentials_DependencyErrors(t *testing.T) {
 			name:     "device authentication",
 			authType: config.AuthTypeDevice,
 			configure: func(dependencies *credentialDependencies) {
-				dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (string, error) {
-					return "", errors.New("device failure")
+				dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+					return auth.TokenResponse{}, errors.New("device failure")
 				}
 			},
 			wantMessage: "device authentication failed: device failure",
@@ -230,8 +462,8 @@ func TestGetCredentials_DependencyErrors(t *testing.T) {
 			name:     "browser authentication",
 			authType: config.AuthTypeBrowser,
 			configure: func(dependencies *credentialDependencies) {
-				dependencies.authenticateBrowser = func(context.Context, auth.OIDCOptions) (string, error) {
-					return "", errors.New("browser failure")
+				dependencies.authenticateBrowser = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+					return auth.TokenResponse{}, errors.New("browser failure")
 				}
 			},
 			wantMessage: "browser authentication failed: browser failure",
@@ -279,8 +511,8 @@ func TestGetCredentials_DependencyErrors(t *testing.T) {
 
 func TestGetCredentials_SourceProfileError(t *testing.T) {
 	dependencies := newTestCredentialDependencies(t, &bytes.Buffer{})
-	dependencies.resolveSourceProfile = func(*config.ProfileConfig, *ini.File, bool) (*config.ProfileConfig, error) {
-		return nil, errors.New("source profile failure")
+	dependencies.resolveRoleChain = func(*config.ProfileConfig, *ini.File, bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+		return nil, nil, errors.New("source profile failure")
 	}
 	profileConfig := &config.ProfileConfig{
 		RoleArn:       "arn:aws:iam::123456789012:role/TestRole",
@@ -298,6 +530,78 @@ func TestGetCredentials_SourceProfileError(t *testing.T) {
 	}
 }
 
+func TestGetCredentials_STSGatewayExportedEndpoint(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		sourceConfig config.ProfileConfig
+		wantEndpoint string
+		wantErr      string
+	}{
+		{
+			name:         "issuing gateway",
+			sourceConfig: config.ProfileConfig{STSEndpoints: "https://rgw1.example.com https://rgw2.example.com"},
+			wantEndpoint: "https://rgw2.example.com",
+		},
+		{
+			name: "inherited endpoint_url",
+			sourceConfig: config.ProfileConfig{
+				EndpointURL:  "https://s3.example.com",
+				STSEndpoints: "https://rgw1.example.com https://rgw2.example.com",
+			},
+			wantEndpoint: "https://s3.example.com",
+		},
+		{
+			name: "sts_endpoint_url in the same profile",
+			sourceConfig: config.ProfileConfig{
+				STSEndpointURL: "https://sts.example.com",
+				STSEndpoints:   "https://rgw1.example.com https://rgw2.example.com",
+			},
+			wantErr: "sts_endpoint_url and sts_endpoints cannot be used together",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := newTestCredentialDependencies(t, stderr)
+			sourceConfig := test.sourceConfig
+			sourceConfig.RadosGWOIDCAuthType = config.AuthTypeToken
+			dependencies.resolveRoleChain = func(*config.ProfileConfig, *ini.File, bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+				return nil, &sourceConfig, nil
+			}
+			dependencies.getenv = func(string) string { return "test-token" }
+			dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+				return &config.AssumeRoleResult{EndpointURL: options.FailoverEndpointURLs[0]}, nil
+			}
+
+			result, err := getCredentials(t.Context(), RequestOptions{
+				ProfileName: "derived",
+				ProfileConfig: &config.ProfileConfig{
+					RoleArn:       "arn:aws:iam::123456789012:role/TestRole",
+					SourceProfile: "base",
+				},
+				AWSConfig:       ini.Empty(),
+				Verbose:         true,
+				SessionDuration: time.Hour,
+				Output:          stderr,
+			}, dependencies)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("getCredentials() error = %v, want containing %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			if result.EndpointURL != test.wantEndpoint {
+				t.Errorf("EndpointURL = %q, want %q", result.EndpointURL, test.wantEndpoint)
+			}
+			if !strings.Contains(stderr.String(), "# Credentials issued by: https://rgw2.example.com\n") {
+				t.Errorf("verbose output %q does not report the issuing gateway", stderr.String())
+			}
+		})
+	}
+}
+
 func newTestCredentialDependencies(t *testing.T, stderr *bytes.Buffer) credentialDependencies {
 	t.Helper()
 	return credentialDependencies{
@@ -306,25 +610,63 @@ func newTestCredentialDependencies(t *testing.T, stderr *bytes.Buffer) credentia
 			t.Fatal("unexpected getenv() call")
 			return ""
 		},
+		readFile: func(string) ([]byte, error) {
+			t.Fatal("unexpected readFile() call")
+			return nil, nil
+		},
 		now: func() time.Time {
 			return time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
 		},
-		resolveSourceProfile: func(*config.ProfileConfig, *ini.File, bool) (*config.ProfileConfig, error) {
-			t.Fatal("unexpected resolveSourceProfile() call")
-			return nil, nil
+		resolveRoleChain: func(*config.ProfileConfig, *ini.File, bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+			t.Fatal("unexpected resolveRoleChain() call")
+			return nil, nil, nil
 		},
-		authenticateDevice: func(context.Context, auth.OIDCOptions) (string, error) {
+		authenticateDevice: func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
 			t.Fatal("unexpected authenticateDevice() call")
-			return "", nil
+			return auth.TokenResponse{}, nil
 		},
-		authenticateBrowser: func(context.Context, auth.OIDCOptions) (string, error) {
+		authenticateBrowser: func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
 			t.Fatal("unexpected authenticateBrowser() call")
+			return auth.TokenResponse{}, nil
+		},
+		authenticateClient: func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+			t.Fatal("unexpected authenticateClient() call")
+			return auth.TokenResponse{}, nil
+		},
+		refreshTokens: func(context.Context, auth.OIDCOptions, string) (auth.TokenResponse, error) {
+			t.Fatal("unexpected refreshTokens() call")
+			return auth.TokenResponse{}, nil
+		},
+		fetchGitHubToken: func(context.Context, auth.GitHubActionsOptions) (string, error) {
+			t.Fatal("unexpected fetchGitHubToken() call")
 			return "", nil
 		},
+		exchangeToken: func(context.Context, auth.OIDCOptions, auth.TokenExchangeOptions) (auth.TokenResponse, error) {
+			t.Fatal("unexpected exchangeToken() call")
+			return auth.TokenResponse{}, nil
+		},
+		verifyToken: func(context.Context, auth.OIDCOptions, string, auth.TokenVerificationOptions) error {
+			return nil
+		},
+		revokeToken: func(context.Context, auth.OIDCOptions, string, string) error {
+			t.Fatal("unexpected revokeToken() call")
+			return nil
+		},
+		endSessionURL: func(context.Context, auth.OIDCOptions, string) (string, error) {
+			t.Fatal("unexpected endSessionURL() call")
+			return "", nil
+		},
+		openTokenStore: func() (oidcTokenStore, error) {
+			return newTestOIDCTokenStore(), nil
+		},
 		assumeRole: func(context.Context, sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
 			t.Fatal("unexpected assumeRole() call")
 			return nil, nil
 		},
+		assumeChainedRole: func(context.Context, sts.AssumeRoleOptions, *config.AssumeRoleResult) (*config.AssumeRoleResult, error) {
+			t.Fatal("unexpected assumeChainedRole() call")
+			return nil, nil
+		},
 	}
 }
 
@@ -345,6 +687,25 @@ func assertAuthenticationOptions(t *testing.T, options auth.OIDCOptions) {
 	if options.SSLVerify {
 		t.Error("authenticate() SSL verification = true, want false")
 	}
+	wantAuthorization := auth.AuthorizationParameters{
+		Prompt:    "login",
+		LoginHint: "user@example.com",
+		ACRValues: "mfa",
+		Audience:  "radosgw",
+		Resources: []string{"https://storage.example.com"},
+	}
+	if !reflect.DeepEqual(options.Authorization, wantAuthorization) {
+		t.Errorf("authenticate() authorization = %+v, want %+v", options.Authorization, wantAuthorization)
+	}
+	if options.BrowserMode != config.BrowserModeManual {
+		t.Errorf("authenticate() browser mode = %q, want manual", options.BrowserMode)
+	}
+	if !options.RequirePAR {
+		t.Error("authenticate() require PAR = false, want true")
+	}
+	if options.CallbackHost != config.CallbackHostIPv6 || !slices.Equal(options.CallbackPorts, []int{8400, 0}) || options.CallbackPath != "/oauth2/callback" {
+		t.Errorf("authenticate() callback = %s %v %s, want [::1] [8400 0] /oauth2/callback", options.CallbackHost, options.CallbackPorts, options.CallbackPath)
+	}
 	if !options.Verbose {
 		t.Error("authenticate() verbose mode = false, want true")
 	}
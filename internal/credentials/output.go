@@ -3,20 +3,113 @@ package credentials
 import (
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
+	"github.com/fitbeard/radosgw-assume/internal/auth"
 	"github.com/fitbeard/radosgw-assume/internal/config"
-	"github.com/fitbeard/radosgw-assume/pkg/duration"
 )
 
-func printCredentialContext(stderr io.Writer, profileName string, resolvedConfig *resolvedCredentialConfig, verboseMode bool, sessionDuration time.Duration) {
+func printCredentialContext(stderr io.Writer, profileName string, resolvedConfig *resolvedCredentialConfig, verboseMode bool) {
 	verbosef(stderr, verboseMode, "# Using profile: %s\n", profileName)
-	verbosef(stderr, verboseMode, "# RadosGW endpoint: %s\n", resolvedConfig.sourceConfig.EndpointURL)
-	if resolvedConfig.authType != config.AuthTypeToken {
+	if resolvedConfig.sourceConfig.EndpointURL != "" {
+		verbosef(stderr, verboseMode, "# RadosGW endpoint: %s\n", resolvedConfig.sourceConfig.EndpointURL)
+	}
+	if len(resolvedConfig.stsEndpoints) > 0 {
+		verbosef(stderr, verboseMode, "# STS endpoints: %s\n", strings.Join(resolvedConfig.stsEndpoints, ", "))
+	}
+	if resolvedConfig.sourceConfig.STSEndpointURL != "" {
+		verbosef(stderr, verboseMode, "# STS endpoint: %s\n", resolvedConfig.sourceConfig.STSEndpointURL)
+	}
+	verbosef(stderr, verboseMode, "# Region: %s\n", resolvedConfig.sourceConfig.Region)
+	if resolvedConfig.authType.UsesOIDCProvider() {
 		verbosef(stderr, verboseMode, "# OIDC provider: %s\n", resolvedConfig.sourceConfig.RadosGWOIDCProvider)
 	}
 	verbosef(stderr, verboseMode, "# Auth type: %s\n", resolvedConfig.authType)
-	verbosef(stderr, verboseMode, "# Session duration: %d seconds (%s)\n", int(sessionDuration.Seconds()), duration.Format(sessionDuration))
+	sourceConfig := resolvedConfig.sourceConfig
+	if sourceConfig.CABundle != "" {
+		verbosef(stderr, verboseMode, "# CA bundle: %s\n", sourceConfig.CABundle)
+	}
+	if sourceConfig.RadosGWOIDCCABundle != "" {
+		verbosef(stderr, verboseMode, "# OIDC CA bundle: %s\n", sourceConfig.RadosGWOIDCCABundle)
+	}
+	if pins := len(resolvedConfig.stsTrust.pinnedSHA256); pins > 0 {
+		verbosef(stderr, verboseMode, "# Pinned RadosGW certificates: %d\n", pins)
+	}
+	if pins := len(resolvedConfig.oidcTrust.pinnedSHA256); pins > 0 {
+		verbosef(stderr, verboseMode, "# Pinned OIDC certificates: %d\n", pins)
+	}
+	if certificate := resolvedConfig.clientCertificate; certificate != nil {
+		verbosef(stderr, verboseMode, "# TLS client certificate: %s (expires %s)\n", certificate.Leaf.Subject, certificate.Leaf.NotAfter.UTC().Format(time.RFC3339))
+	}
+	if resolvedConfig.sourceConfig.RadosGWOIDCAudience != "" && (resolvedConfig.authType == config.AuthTypeGitHubActions || resolvedConfig.authType == config.AuthTypeTokenExchange || resolvedConfig.authorization.Audience != "") {
+		verbosef(stderr, verboseMode, "# OIDC audience: %s\n", resolvedConfig.sourceConfig.RadosGWOIDCAudience)
+	}
+	if usesAuthorizationRequest(resolvedConfig) {
+		printAuthorizationParameters(stderr, resolvedConfig.authorization, verboseMode)
+	}
+	if resolvedConfig.authType == config.AuthTypeTokenExchange {
+		verbosef(stderr, verboseMode, "# Subject auth type: %s\n", resolvedConfig.sourceConfig.RadosGWOIDCSubjectAuthType)
+	} else if resolvedConfig.authType.UsesOIDCProvider() {
+		verbosef(stderr, verboseMode, "# Web identity token: %s\n", resolvedConfig.tokenType)
+	}
+	if resolvedConfig.sessionPolicy != "" {
+		policySource := "inline"
+		if sourceConfig.SessionPolicyFile != "" {
+			policySource = sourceConfig.SessionPolicyFile
+		}
+		verbosef(stderr, verboseMode, "# Session policy: %s (%d bytes)\n", policySource, len(resolvedConfig.sessionPolicy))
+	}
+	for _, policyARN := range resolvedConfig.policyARNs {
+		verbosef(stderr, verboseMode, "# Session policy ARN: %s\n", policyARN)
+	}
+}
+
+// printAssumedRole prints the details STS returned about an assumed role.
+func printAssumedRole(stderr io.Writer, result *config.AssumeRoleResult, verboseMode bool) {
+	if result.AssumedRoleArn != "" {
+		verbosef(stderr, verboseMode, "# Assumed role ARN: %s\n", result.AssumedRoleArn)
+	}
+	if result.AssumedRoleID != "" {
+		verbosef(stderr, verboseMode, "# Assumed role ID: %s\n", result.AssumedRoleID)
+	}
+	if result.SubjectFromWebIdentityToken != "" {
+		verbosef(stderr, verboseMode, "# Web identity subject: %s\n", result.SubjectFromWebIdentityToken)
+	}
+	if result.Audience != "" {
+		verbosef(stderr, verboseMode, "# Web identity audience: %s\n", result.Audience)
+	}
+	if result.Provider != "" {
+		verbosef(stderr, verboseMode, "# Web identity provider: %s\n", result.Provider)
+	}
+	if result.PackedPolicySize != nil {
+		verbosef(stderr, verboseMode, "# Packed policy size: %d%% of the limit\n", *result.PackedPolicySize)
+	}
+}
+
+// usesAuthorizationRequest reports whether the profile logs in with a device or
+// browser flow, directly or to obtain a token exchange subject token.
+func usesAuthorizationRequest(resolvedConfig *resolvedCredentialConfig) bool {
+	authType := resolvedConfig.authType
+	if authType == config.AuthTypeTokenExchange {
+		authType = resolvedConfig.sourceConfig.RadosGWOIDCSubjectAuthType
+	}
+	return authType == config.AuthTypeDevice || authType == config.AuthTypeBrowser
+}
+
+func printAuthorizationParameters(stderr io.Writer, parameters auth.AuthorizationParameters, verboseMode bool) {
+	if parameters.Prompt != "" {
+		verbosef(stderr, verboseMode, "# OIDC prompt: %s\n", parameters.Prompt)
+	}
+	if parameters.LoginHint != "" {
+		verbosef(stderr, verboseMode, "# OIDC login hint: %s\n", parameters.LoginHint)
+	}
+	if parameters.ACRValues != "" {
+		verbosef(stderr, verboseMode, "# OIDC ACR values: %s\n", parameters.ACRValues)
+	}
+	if len(parameters.Resources) > 0 {
+		verbosef(stderr, verboseMode, "# OIDC resource: %s\n", strings.Join(parameters.Resources, " "))
+	}
 }
 
 func verbosef(w io.Writer, enabled bool, format string, args ...any) {
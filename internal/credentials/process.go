@@ -18,16 +18,18 @@ type processCredentialCache interface {
 }
 
 type processCredentialDependencies struct {
-	resolveSourceProfile func(*config.ProfileConfig, *ini.File, bool) (*config.ProfileConfig, error)
-	getenv               func(string) string
-	newCache             func(time.Duration) (processCredentialCache, error)
-	getCredentials       func(context.Context, RequestOptions) (*config.AssumeRoleResult, error)
+	resolveRoleChain func(*config.ProfileConfig, *ini.File, bool) ([]config.RoleChainHop, *config.ProfileConfig, error)
+	getenv           func(string) string
+	readFile         func(string) ([]byte, error)
+	newCache         func(time.Duration) (processCredentialCache, error)
+	getCredentials   func(context.Context, RequestOptions) (*config.AssumeRoleResult, error)
 }
 
 func newProcessCredentialDependencies() processCredentialDependencies {
 	return processCredentialDependencies{
-		resolveSourceProfile: config.ResolveSourceProfile,
-		getenv:               os.Getenv,
+		resolveRoleChain: config.ResolveRoleChain,
+		getenv:           os.Getenv,
+		readFile:         os.ReadFile,
 		newCache: func(sessionDuration time.Duration) (processCredentialCache, error) {
 			return credentialcache.New(sessionDuration)
 		},
@@ -63,11 +65,27 @@ func getProcessCredentials(ctx context.Context, options ProcessRequestOptions, d
 		return result, nil
 	}
 
-	effectiveConfig, err := dependencies.resolveSourceProfile(options.ProfileConfig, options.AWSConfig, false)
+	roleChain, effectiveConfig, err := dependencies.resolveRoleChain(options.ProfileConfig, options.AWSConfig, false)
 	if err != nil {
 		return nil, err
 	}
-	cacheKey, err := credentialcache.Key(options.ProfileName, effectiveConfig, options.SessionDuration, dependencies.getenv("RADOSGW_OIDC_TOKEN"))
+	// A chained profile authenticates with the first profile of the chain,
+	// so that profile supplies the token identity and the roles assumed
+	// after it are keyed separately.
+	roleChain = append(roleChain, config.RoleChainHop{ProfileName: options.ProfileName, Config: effectiveConfig})
+	keyConfigs := make([]*config.ProfileConfig, 0, len(roleChain))
+	for _, hop := range roleChain {
+		keyConfig, err := cacheKeyConfig(hop.ProfileName, hop.Config, dependencies)
+		if err != nil {
+			return nil, err
+		}
+		keyConfigs = append(keyConfigs, keyConfig)
+	}
+	oidcToken, err := processCacheToken(keyConfigs[0], dependencies)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey, err := credentialcache.Key(options.ProfileName, keyConfigs[0], options.SessionDuration, oidcToken, keyConfigs[1:]...)
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +106,46 @@ func getProcessCredentials(ctx context.Context, options ProcessRequestOptions, d
 	return result, nil
 }
 
+// cacheKeyConfig replaces a session policy file with the policy document so
+// that editing the file never reuses credentials issued under the previous
+// policy.
+func cacheKeyConfig(profileName string, profileConfig *config.ProfileConfig, dependencies processCredentialDependencies) (*config.ProfileConfig, error) {
+	if profileConfig.SessionPolicyFile == "" {
+		return profileConfig, nil
+	}
+	policy, _, err := sessionPolicy(profileConfig, dependencies.readFile)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+	}
+	keyConfig := *profileConfig
+	keyConfig.SessionPolicy = policy
+	keyConfig.SessionPolicyFile = ""
+	return &keyConfig, nil
+}
+
+// processCacheToken returns the token whose identity keys cached credentials
+// for token and GitHub Actions authentication, including when either supplies
+// the subject token for token exchange. Other flows obtain tokens
+// interactively and do not contribute a token identity.
+func processCacheToken(effectiveConfig *config.ProfileConfig, dependencies processCredentialDependencies) (string, error) {
+	normalizedConfig, err := effectiveConfig.Normalize()
+	if err != nil {
+		return "", err
+	}
+	authType := normalizedConfig.RadosGWOIDCAuthType
+	if authType == config.AuthTypeTokenExchange {
+		authType = normalizedConfig.RadosGWOIDCSubjectAuthType
+	}
+	switch authType {
+	case config.AuthTypeToken:
+		return webIdentityToken(normalizedConfig, dependencies.getenv, dependencies.readFile)
+	case config.AuthTypeGitHubActions:
+		return dependencies.getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN"), nil
+	default:
+		return "", nil
+	}
+}
+
 func reportProcessEndpoint(output io.Writer, verboseMode bool, result *config.AssumeRoleResult) {
 	if result == nil || result.EndpointURL == "" {
 		return
@@ -90,11 +90,11 @@ func TestGetProcessCredentialsCachesResolvedProfile(t *testing.T) {
 	want := processTestResult()
 	cache := &testProcessCredentialCache{retrieve: true}
 	dependencies := processTestDependencies(t)
-	dependencies.resolveSourceProfile = func(gotProfile *config.ProfileConfig, gotConfig *ini.File, verbose bool) (*config.ProfileConfig, error) {
+	dependencies.resolveRoleChain = func(gotProfile *config.ProfileConfig, gotConfig *ini.File, verbose bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
 		if gotProfile != profileConfig || gotConfig != nil || verbose {
-			t.Error("resolveSourceProfile() received unexpected arguments")
+			t.Error("resolveRoleChain() received unexpected arguments")
 		}
-		return effectiveConfig, nil
+		return nil, effectiveConfig, nil
 	}
 	dependencies.getenv = func(name string) string {
 		if name != "RADOSGW_OIDC_TOKEN" {
@@ -130,8 +130,8 @@ func TestGetProcessCredentialsReportsCacheHit(t *testing.T) {
 	want := processTestResult()
 	cache := &testProcessCredentialCache{result: want, hit: true}
 	dependencies := processTestDependencies(t)
-	dependencies.resolveSourceProfile = func(profile *config.ProfileConfig, _ *ini.File, _ bool) (*config.ProfileConfig, error) {
-		return profile, nil
+	dependencies.resolveRoleChain = func(profile *config.ProfileConfig, _ *ini.File, _ bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+		return nil, profile, nil
 	}
 	dependencies.getenv = func(string) string { return "" }
 	dependencies.newCache = func(time.Duration) (processCredentialCache, error) { return cache, nil }
@@ -162,8 +162,8 @@ func TestGetProcessCredentialsHidesEndpointReminderWithoutVerbose(t *testing.T)
 	want := processTestResult()
 	cache := &testProcessCredentialCache{result: want, hit: true}
 	dependencies := processTestDependencies(t)
-	dependencies.resolveSourceProfile = func(profile *config.ProfileConfig, _ *ini.File, _ bool) (*config.ProfileConfig, error) {
-		return profile, nil
+	dependencies.resolveRoleChain = func(profile *config.ProfileConfig, _ *ini.File, _ bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+		return nil, profile, nil
 	}
 	dependencies.getenv = func(string) string { return "" }
 	dependencies.newCache = func(time.Duration) (processCredentialCache, error) { return cache, nil }
@@ -220,6 +220,150 @@ func TestGetProcessCredentialsUsesDefaultCache(t *testing.T) {
 	}
 }
 
+func TestGetProcessCredentialsKeysTokenFileContent(t *testing.T) {
+	for _, authType := range []config.AuthType{"", config.AuthTypeTokenExchange} {
+		t.Run(string(authType), func(t *testing.T) {
+			testGetProcessCredentialsKeysTokenFileContent(t, authType)
+		})
+	}
+}
+
+// testGetProcessCredentialsKeysTokenFileContent covers token authentication and
+// token exchange, which keys on the same file as its default subject token.
+func testGetProcessCredentialsKeysTokenFileContent(t *testing.T, authType config.AuthType) {
+	profile := processTestProfile()
+	profile.RadosGWOIDCAuthType = authType
+	profile.WebIdentityTokenFile = "/var/run/secrets/tokens/radosgw"
+	tokenContent := "first-projected-token\n"
+	dependencies := processTestDependencies(t)
+	dependencies.resolveRoleChain = func(profile *config.ProfileConfig, _ *ini.File, _ bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+		return nil, profile, nil
+	}
+	dependencies.readFile = func(name string) ([]byte, error) {
+		if name != profile.WebIdentityTokenFile {
+			t.Errorf("readFile() name = %q, want %q", name, profile.WebIdentityTokenFile)
+		}
+		return []byte(tokenContent), nil
+	}
+	cache := &testProcessCredentialCache{result: processTestResult(), hit: true}
+	dependencies.newCache = func(time.Duration) (processCredentialCache, error) { return cache, nil }
+	options := ProcessRequestOptions{RequestOptions: RequestOptions{
+		ProfileName:     "profile",
+		ProfileConfig:   profile,
+		SessionDuration: time.Hour,
+		Output:          &bytes.Buffer{},
+	}}
+
+	if _, err := getProcessCredentials(t.Context(), options, dependencies); err != nil {
+		t.Fatalf("getProcessCredentials() error = %v", err)
+	}
+	firstKey := cache.key
+	if _, err := getProcessCredentials(t.Context(), options, dependencies); err != nil {
+		t.Fatalf("getProcessCredentials() error = %v", err)
+	}
+	if cache.key != firstKey {
+		t.Error("cache key changed although the token file was not rotated")
+	}
+
+	tokenContent = "rotated-projected-token\n"
+	if _, err := getProcessCredentials(t.Context(), options, dependencies); err != nil {
+		t.Fatalf("getProcessCredentials() error = %v", err)
+	}
+	if cache.key == firstKey {
+		t.Error("cache key did not follow token file rotation")
+	}
+}
+
+func TestGetProcessCredentialsKeysSessionPolicyFileContent(t *testing.T) {
+	profile := processTestProfile()
+	profile.SessionPolicyFile = "/etc/radosgw/ci-policy.json"
+	policyContent := `{"Version": "2012-10-17", "Statement": []}`
+	dependencies := processTestDependencies(t)
+	dependencies.resolveRoleChain = func(profile *config.ProfileConfig, _ *ini.File, _ bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+		return nil, profile, nil
+	}
+	dependencies.readFile = func(name string) ([]byte, error) {
+		if name != profile.SessionPolicyFile {
+			t.Errorf("readFile() name = %q, want %q", name, profile.SessionPolicyFile)
+		}
+		return []byte(policyContent), nil
+	}
+	cache := &testProcessCredentialCache{result: processTestResult(), hit: true}
+	dependencies.newCache = func(time.Duration) (processCredentialCache, error) { return cache, nil }
+	options := ProcessRequestOptions{RequestOptions: RequestOptions{
+		ProfileName:     "profile",
+		ProfileConfig:   profile,
+		SessionDuration: time.Hour,
+		Output:          &bytes.Buffer{},
+	}}
+
+	if _, err := getProcessCredentials(t.Context(), options, dependencies); err != nil {
+		t.Fatalf("getProcessCredentials() error = %v", err)
+	}
+	firstKey := cache.key
+	policyContent = "{\n  \"Version\": \"2012-10-17\",\n  \"Statement\": []\n}\n"
+	if _, err := getProcessCredentials(t.Context(), options, dependencies); err != nil {
+		t.Fatalf("getProcessCredentials() error = %v", err)
+	}
+	if cache.key != firstKey {
+		t.Error("cache key changed although only the policy formatting changed")
+	}
+
+	policyContent = `{"Version": "2012-10-17", "Statement": [{"Effect": "Deny", "Action": "s3:*", "Resource": "*"}]}`
+	if _, err := getProcessCredentials(t.Context(), options, dependencies); err != nil {
+		t.Fatalf("getProcessCredentials() error = %v", err)
+	}
+	if cache.key == firstKey {
+		t.Error("cache key did not follow the session policy file content")
+	}
+	if profile.SessionPolicy != "" {
+		t.Error("getProcessCredentials() modified the profile configuration")
+	}
+}
+
+func TestGetProcessCredentialsKeysRoleChain(t *testing.T) {
+	profile := processTestProfile()
+	entryRole := "arn:entry"
+	var roleChain []config.RoleChainHop
+	dependencies := processTestDependencies(t)
+	dependencies.resolveRoleChain = func(profile *config.ProfileConfig, _ *ini.File, _ bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+		return roleChain, profile, nil
+	}
+	cache := &testProcessCredentialCache{result: processTestResult(), hit: true}
+	dependencies.newCache = func(time.Duration) (processCredentialCache, error) { return cache, nil }
+	options := ProcessRequestOptions{RequestOptions: RequestOptions{
+		ProfileName:     "profile",
+		ProfileConfig:   profile,
+		SessionDuration: time.Hour,
+		Output:          &bytes.Buffer{},
+	}}
+	keyFor := func(chain []config.RoleChainHop) string {
+		t.Helper()
+		roleChain = chain
+		if _, err := getProcessCredentials(t.Context(), options, dependencies); err != nil {
+			t.Fatalf("getProcessCredentials() error = %v", err)
+		}
+		return cache.key
+	}
+	chainFor := func(roleARN string) []config.RoleChainHop {
+		entry := processTestProfile()
+		entry.RoleArn = roleARN
+		return []config.RoleChainHop{{ProfileName: "entry", Config: entry}}
+	}
+
+	directKey := keyFor(nil)
+	chainedKey := keyFor(chainFor(entryRole))
+	if chainedKey == directKey {
+		t.Error("cache key of a chained role matches the directly assumed role")
+	}
+	if keyFor(chainFor(entryRole)) != chainedKey {
+		t.Error("cache key changed although the role chain did not")
+	}
+	if keyFor(chainFor("arn:other-entry")) == chainedKey {
+		t.Error("cache key did not follow the entry role")
+	}
+}
+
 func TestGetProcessCredentialsErrors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -229,8 +373,8 @@ func TestGetProcessCredentialsErrors(t *testing.T) {
 		{
 			name: "source profile",
 			configure: func(dependencies *processCredentialDependencies) {
-				dependencies.resolveSourceProfile = func(*config.ProfileConfig, *ini.File, bool) (*config.ProfileConfig, error) {
-					return nil, errors.New("source failure")
+				dependencies.resolveRoleChain = func(*config.ProfileConfig, *ini.File, bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+					return nil, nil, errors.New("source failure")
 				}
 			},
 			wantMessage: "source failure",
@@ -238,8 +382,8 @@ func TestGetProcessCredentialsErrors(t *testing.T) {
 		{
 			name: "cache initialization",
 			configure: func(dependencies *processCredentialDependencies) {
-				dependencies.resolveSourceProfile = func(profile *config.ProfileConfig, _ *ini.File, _ bool) (*config.ProfileConfig, error) {
-					return profile, nil
+				dependencies.resolveRoleChain = func(profile *config.ProfileConfig, _ *ini.File, _ bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+					return nil, profile, nil
 				}
 				dependencies.getenv = func(string) string { return "" }
 				dependencies.newCache = func(time.Duration) (processCredentialCache, error) { return nil, errors.New("cache failure") }
@@ -249,8 +393,8 @@ func TestGetProcessCredentialsErrors(t *testing.T) {
 		{
 			name: "cache operation",
 			configure: func(dependencies *processCredentialDependencies) {
-				dependencies.resolveSourceProfile = func(profile *config.ProfileConfig, _ *ini.File, _ bool) (*config.ProfileConfig, error) {
-					return profile, nil
+				dependencies.resolveRoleChain = func(profile *config.ProfileConfig, _ *ini.File, _ bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+					return nil, profile, nil
 				}
 				dependencies.getenv = func(string) string { return "" }
 				dependencies.newCache = func(time.Duration) (processCredentialCache, error) {
@@ -259,6 +403,31 @@ func TestGetProcessCredentialsErrors(t *testing.T) {
 			},
 			wantMessage: "operation failure",
 		},
+		{
+			name: "token file",
+			configure: func(dependencies *processCredentialDependencies) {
+				dependencies.resolveRoleChain = func(profile *config.ProfileConfig, _ *ini.File, _ bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+					tokenProfile := *profile
+					tokenProfile.RadosGWOIDCAuthType = config.AuthTypeToken
+					tokenProfile.WebIdentityTokenFile = "/missing/token"
+					return nil, &tokenProfile, nil
+				}
+				dependencies.readFile = func(string) ([]byte, error) { return nil, errors.New("no such file") }
+			},
+			wantMessage: "read web identity token file: no such file",
+		},
+		{
+			name: "session policy file",
+			configure: func(dependencies *processCredentialDependencies) {
+				dependencies.resolveRoleChain = func(profile *config.ProfileConfig, _ *ini.File, _ bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+					policyProfile := *profile
+					policyProfile.SessionPolicyFile = "/etc/radosgw/ci-policy.json"
+					return nil, &policyProfile, nil
+				}
+				dependencies.readFile = func(string) ([]byte, error) { return []byte("Allow everything"), nil }
+			},
+			wantMessage: "profile 'profile': invalid session policy file /etc/radosgw/ci-policy.json: policy is not valid JSON",
+		},
 	}
 
 	for _, test := range tests {
@@ -281,14 +450,18 @@ func TestGetProcessCredentialsErrors(t *testing.T) {
 func processTestDependencies(t *testing.T) processCredentialDependencies {
 	t.Helper()
 	return processCredentialDependencies{
-		resolveSourceProfile: func(*config.ProfileConfig, *ini.File, bool) (*config.ProfileConfig, error) {
-			t.Fatal("unexpected resolveSourceProfile() call")
-			return nil, nil
+		resolveRoleChain: func(*config.ProfileConfig, *ini.File, bool) ([]config.RoleChainHop, *config.ProfileConfig, error) {
+			t.Fatal("unexpected resolveRoleChain() call")
+			return nil, nil, nil
 		},
 		getenv: func(string) string {
 			t.Fatal("unexpected getenv() call")
 			return ""
 		},
+		readFile: func(string) ([]byte, error) {
+			t.Fatal("unexpected readFile() call")
+			return nil, nil
+		},
 		newCache: func(time.Duration) (processCredentialCache, error) {
 			t.Fatal("unexpected newCache() call")
 			return nil, nil
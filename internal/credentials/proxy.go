@@ -0,0 +1,49 @@
+package credentials
+
+import (
+	"io"
+	"net/url"
+	"sync"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
+// endpointProxy holds the proxy settings for one kind of server. Like
+// serverTrust, the RadosGW STS endpoint and the OIDC provider are configured
+// separately because an identity provider on the internet and a storage
+// cluster on an internal network rarely share a proxy.
+type endpointProxy struct {
+	proxyURL *url.URL
+	noProxy  string
+}
+
+func resolveEndpointProxy(proxyKey, proxyURL, noProxy string) (endpointProxy, error) {
+	parsedURL, err := config.ParseProxyURL(proxyKey, proxyURL)
+	if err != nil {
+		return endpointProxy{}, err
+	}
+	return endpointProxy{proxyURL: parsedURL, noProxy: noProxy}, nil
+}
+
+// proxyReporter returns an httpclient OnProxy callback that prints the proxy
+// used for each host once in verbose mode. It returns nil otherwise so
+// non-verbose runs keep the default transport.
+func proxyReporter(stderr io.Writer, verboseMode bool) func(target, proxy *url.URL) {
+	if !verboseMode {
+		return nil
+	}
+	var mutex sync.Mutex
+	reported := map[string]bool{}
+	return func(target, proxy *url.URL) {
+		via := "direct"
+		if proxy != nil {
+			via = proxy.Redacted()
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		if key := target.Host + " " + via; !reported[key] {
+			reported[key] = true
+			verbosef(stderr, true, "# Proxy for %s: %s\n", target.Host, via)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/tokencache"
+)
+
+// openOIDCTokenStore returns nil when the store is unavailable. Stored tokens
+// only avoid repeated interaction, so authentication continues without them.
+func openOIDCTokenStore(verboseMode bool, dependencies credentialDependencies) oidcTokenStore {
+	store, err := dependencies.openTokenStore()
+	if err != nil {
+		verbosef(dependencies.stderr, verboseMode, "# OIDC token storage unavailable: %v\n", err)
+		return nil
+	}
+	return store
+}
+
+// refreshStoredTokens attempts a silent refresh_token grant. A token stored by
+// a login with other authorization parameters is left for that login, since
+// the provider would issue tokens for its user and authentication level. Only
+// context cancellation is returned as an error; every other failure falls
+// back to interactive authentication.
+func refreshStoredTokens(ctx context.Context, store oidcTokenStore, options auth.OIDCOptions, verboseMode bool, dependencies credentialDependencies) (auth.TokenResponse, bool, error) {
+	if store == nil {
+		return auth.TokenResponse{}, false, nil
+	}
+	refreshToken, found, err := store.LoadRefreshToken(options.ProviderURL, options.ClientID)
+	if err != nil {
+		verbosef(dependencies.stderr, verboseMode, "# Ignoring stored refresh token: %v\n", err)
+		return auth.TokenResponse{}, false, nil
+	}
+	if !found {
+		return auth.TokenResponse{}, false, nil
+	}
+	if refreshToken != storedRefreshToken(options, refreshToken.Token) {
+		verbosef(dependencies.stderr, verboseMode, "# Stored refresh token is from a login with other authorization parameters, authenticating interactively\n")
+		return auth.TokenResponse{}, false, nil
+	}
+
+	verbosef(dependencies.stderr, verboseMode, "# Refreshing OIDC tokens with stored refresh token\n")
+	tokens, err := dependencies.refreshTokens(ctx, options, refreshToken.Token)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return auth.TokenResponse{}, false, ctxErr
+		}
+		if errors.Is(err, auth.ErrRefreshTokenRejected) {
+			if deleteErr := store.DeleteRefreshToken(options.ProviderURL, options.ClientID); deleteErr != nil {
+				verbosef(dependencies.stderr, verboseMode, "# Could not remove rejected refresh token: %v\n", deleteErr)
+			}
+		}
+		verbosef(dependencies.stderr, verboseMode, "# Token refresh failed, authenticating interactively: %v\n", err)
+		return auth.TokenResponse{}, false, nil
+	}
+
+	verbosef(dependencies.stderr, verboseMode, "# ✓ Refreshed OIDC tokens without interaction\n")
+	saveRefreshToken(store, options, tokens, verboseMode, dependencies)
+	return tokens, true, nil
+}
+
+// saveRefreshToken persists a newly issued refresh token. Providers that do
+// not rotate refresh tokens omit the field, which keeps the stored token.
+func saveRefreshToken(store oidcTokenStore, options auth.OIDCOptions, tokens auth.TokenResponse, verboseMode bool, dependencies credentialDependencies) {
+	if store == nil || tokens.RefreshToken == "" {
+		return
+	}
+	if err := store.SaveRefreshToken(options.ProviderURL, options.ClientID, storedRefreshToken(options, tokens.RefreshToken)); err != nil {
+		verbosef(dependencies.stderr, verboseMode, "# Could not store refresh token: %v\n", err)
+		return
+	}
+	verbosef(dependencies.stderr, verboseMode, "# Stored refresh token for silent re-authentication\n")
+}
+
+// storedRefreshToken records token with the authorization parameters that
+// identify the login's user and authentication level, which the refresh grant
+// cannot convey.
+func storedRefreshToken(options auth.OIDCOptions, token string) tokencache.RefreshToken {
+	return tokencache.RefreshToken{
+		Token:     token,
+		Audience:  options.Authorization.Audience,
+		Resource:  strings.Join(options.Authorization.Resources, " "),
+		ACRValues: options.Authorization.ACRValues,
+		LoginHint: options.Authorization.LoginHint,
+	}
+}
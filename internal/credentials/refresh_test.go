@@ -0,0 +1,285 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/sts"
+	"github.com/fitbeard/radosgw-assume/internal/tokencache"
+
+	"gopkg.in/ini.v1"
+)
+
+type testOIDCTokenStore struct {
+	tokens         map[string]tokencache.RefreshToken
+	identityTokens map[tokencache.IdentityTokenKey]testIdentityToken
+	loadErr        error
+	saveErr        error
+}
+
+type testIdentityToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+func newTestOIDCTokenStore() *testOIDCTokenStore {
+	return &testOIDCTokenStore{
+		tokens:         make(map[string]tokencache.RefreshToken),
+		identityTokens: make(map[tokencache.IdentityTokenKey]testIdentityToken),
+	}
+}
+
+func (store *testOIDCTokenStore) LoadIdentityToken(key tokencache.IdentityTokenKey, now time.Time) (string, bool, error) {
+	cached, found := store.identityTokens[key]
+	if !found || !cached.expiresAt.After(now.Add(time.Minute)) {
+		return "", false, nil
+	}
+	return cached.token, true, nil
+}
+
+func (store *testOIDCTokenStore) SaveIdentityToken(key tokencache.IdentityTokenKey, token string, expiresAt time.Time) error {
+	if store.saveErr != nil {
+		return store.saveErr
+	}
+	store.identityTokens[key] = testIdentityToken{token: token, expiresAt: expiresAt}
+	return nil
+}
+
+func (store *testOIDCTokenStore) TakeIdentityToken(key tokencache.IdentityTokenKey) (string, time.Time, bool, error) {
+	cached, found := store.identityTokens[key]
+	delete(store.identityTokens, key)
+	return cached.token, cached.expiresAt, found, nil
+}
+
+func (store *testOIDCTokenStore) LoadRefreshToken(providerURL, clientID string) (tokencache.RefreshToken, bool, error) {
+	if store.loadErr != nil {
+		return tokencache.RefreshToken{}, false, store.loadErr
+	}
+	token, found := store.tokens[providerURL+" "+clientID]
+	return token, found, nil
+}
+
+func (store *testOIDCTokenStore) SaveRefreshToken(providerURL, clientID string, refreshToken tokencache.RefreshToken) error {
+	if store.saveErr != nil {
+		return store.saveErr
+	}
+	store.tokens[providerURL+" "+clientID] = refreshToken
+	return nil
+}
+
+func (store *testOIDCTokenStore) DeleteRefreshToken(providerURL, clientID string) error {
+	delete(store.tokens, providerURL+" "+clientID)
+	return nil
+}
+
+func TestGetCredentialsStoresRefreshTokenAfterInteractiveFlow(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	store := newTestOIDCTokenStore()
+	dependencies := refreshTestDependencies(t, stderr, store)
+	dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+		return auth.TokenResponse{AccessToken: "device-token", RefreshToken: "issued-refresh-token"}, nil
+	}
+
+	if _, err := getCredentials(t.Context(), refreshTestRequest(stderr), dependencies); err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+	if got := store.tokens["https://oidc.example.com test-client"].Token; got != "issued-refresh-token" {
+		t.Errorf("stored refresh token = %q, want issued-refresh-token", got)
+	}
+	if !strings.Contains(stderr.String(), "# Stored refresh token for silent re-authentication") {
+		t.Errorf("verbose output %q does not report refresh token storage", stderr.String())
+	}
+}
+
+func TestGetCredentialsUsesStoredRefreshToken(t *testing.T) {
+	for _, test := range []struct {
+		name            string
+		rotatedToken    string
+		wantStoredToken string
+	}{
+		{name: "rotating provider", rotatedToken: "rotated-refresh-token", wantStoredToken: "rotated-refresh-token"},
+		{name: "non-rotating provider", wantStoredToken: "stored-refresh-token"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			store := newTestOIDCTokenStore()
+			store.tokens["https://oidc.example.com test-client"] = tokencache.RefreshToken{Token: "stored-refresh-token"}
+			dependencies := refreshTestDependencies(t, stderr, store)
+			dependencies.refreshTokens = func(_ context.Context, options auth.OIDCOptions, refreshToken string) (auth.TokenResponse, error) {
+				if options.ProviderURL != "https://oidc.example.com" || options.ClientID != "test-client" {
+					t.Errorf("refreshTokens() options = %+v", options)
+				}
+				if refreshToken != "stored-refresh-token" {
+					t.Errorf("refreshTokens() token = %q, want stored-refresh-token", refreshToken)
+				}
+				return auth.TokenResponse{AccessToken: "refreshed-token", RefreshToken: test.rotatedToken}, nil
+			}
+
+			if _, err := getCredentials(t.Context(), refreshTestRequest(stderr), dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			if got := store.tokens["https://oidc.example.com test-client"].Token; got != test.wantStoredToken {
+				t.Errorf("stored refresh token = %q, want %q", got, test.wantStoredToken)
+			}
+			if strings.Contains(stderr.String(), "# Starting device authentication flow") {
+				t.Errorf("verbose output %q reports an interactive flow after a successful refresh", stderr.String())
+			}
+		})
+	}
+}
+
+func TestGetCredentialsFallsBackWhenRefreshFails(t *testing.T) {
+	for _, test := range []struct {
+		name            string
+		refreshErr      error
+		wantStoredToken string
+	}{
+		{
+			name:       "rejected refresh token",
+			refreshErr: fmt.Errorf("%w: invalid grant", auth.ErrRefreshTokenRejected),
+		},
+		{
+			name:            "transient failure",
+			refreshErr:      errors.New("connection refused"),
+			wantStoredToken: "stored-refresh-token",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			store := newTestOIDCTokenStore()
+			store.tokens["https://oidc.example.com test-client"] = tokencache.RefreshToken{Token: "stored-refresh-token"}
+			dependencies := refreshTestDependencies(t, stderr, store)
+			dependencies.refreshTokens = func(context.Context, auth.OIDCOptions, string) (auth.TokenResponse, error) {
+				return auth.TokenResponse{}, test.refreshErr
+			}
+			dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+				return auth.TokenResponse{AccessToken: "device-token"}, nil
+			}
+
+			if _, err := getCredentials(t.Context(), refreshTestRequest(stderr), dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			if got := store.tokens["https://oidc.example.com test-client"].Token; got != test.wantStoredToken {
+				t.Errorf("stored refresh token = %q, want %q", got, test.wantStoredToken)
+			}
+			for _, want := range []string{"# Token refresh failed, authenticating interactively", "# Starting device authentication flow"} {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("verbose output %q does not contain %q", stderr.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetCredentialsRefreshesOnlyForTheSameLogin(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		stored      tokencache.RefreshToken
+		wantRefresh bool
+	}{
+		{
+			name:        "same login",
+			stored:      tokencache.RefreshToken{Token: "stored-refresh-token", Resource: "https://s3.example.com", ACRValues: "mfa", LoginHint: "alice"},
+			wantRefresh: true,
+		},
+		{
+			name:   "no step-up",
+			stored: tokencache.RefreshToken{Token: "stored-refresh-token", Resource: "https://s3.example.com", LoginHint: "alice"},
+		},
+		{
+			name:   "other user",
+			stored: tokencache.RefreshToken{Token: "stored-refresh-token", Resource: "https://s3.example.com", ACRValues: "mfa", LoginHint: "bob"},
+		},
+		{
+			name:   "other resource",
+			stored: tokencache.RefreshToken{Token: "stored-refresh-token", ACRValues: "mfa", LoginHint: "alice"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			store := newTestOIDCTokenStore()
+			store.tokens["https://oidc.example.com test-client"] = test.stored
+			dependencies := refreshTestDependencies(t, stderr, store)
+			refreshed := false
+			dependencies.refreshTokens = func(context.Context, auth.OIDCOptions, string) (auth.TokenResponse, error) {
+				refreshed = true
+				return auth.TokenResponse{AccessToken: "refreshed-token"}, nil
+			}
+			dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+				return auth.TokenResponse{AccessToken: "device-token", RefreshToken: "issued-refresh-token"}, nil
+			}
+			request := refreshTestRequest(stderr)
+			request.ProfileConfig.RadosGWOIDCResource = "https://s3.example.com"
+			request.ProfileConfig.RadosGWOIDCACRValues = "mfa"
+			request.ProfileConfig.RadosGWOIDCLoginHint = "alice"
+
+			if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			if refreshed != test.wantRefresh {
+				t.Errorf("refreshTokens() called = %v, want %v", refreshed, test.wantRefresh)
+			}
+			if test.wantRefresh {
+				return
+			}
+			want := tokencache.RefreshToken{Token: "issued-refresh-token", Resource: "https://s3.example.com", ACRValues: "mfa", LoginHint: "alice"}
+			if got := store.tokens["https://oidc.example.com test-client"]; got != want {
+				t.Errorf("stored refresh token = %+v, want %+v", got, want)
+			}
+			if !strings.Contains(stderr.String(), "# Stored refresh token is from a login with other authorization parameters") {
+				t.Errorf("verbose output %q does not explain the skipped refresh", stderr.String())
+			}
+		})
+	}
+}
+
+func TestGetCredentialsContinuesWithoutRefreshTokenStore(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := refreshTestDependencies(t, stderr, nil)
+	dependencies.openTokenStore = func() (oidcTokenStore, error) {
+		return nil, errors.New("cache directory unavailable")
+	}
+	dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+		return auth.TokenResponse{AccessToken: "device-token", RefreshToken: "issued-refresh-token"}, nil
+	}
+
+	if _, err := getCredentials(t.Context(), refreshTestRequest(stderr), dependencies); err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+	if !strings.Contains(stderr.String(), "# OIDC token storage unavailable: cache directory unavailable") {
+		t.Errorf("verbose output %q does not report unavailable storage", stderr.String())
+	}
+}
+
+func refreshTestDependencies(t *testing.T, stderr *bytes.Buffer, store oidcTokenStore) credentialDependencies {
+	t.Helper()
+	dependencies := newTestCredentialDependencies(t, stderr)
+	dependencies.openTokenStore = func() (oidcTokenStore, error) { return store, nil }
+	dependencies.assumeRole = func(context.Context, sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		return &config.AssumeRoleResult{}, nil
+	}
+	return dependencies
+}
+
+func refreshTestRequest(stderr *bytes.Buffer) RequestOptions {
+	return RequestOptions{
+		ProfileName: "test-profile",
+		ProfileConfig: &config.ProfileConfig{
+			EndpointURL:         "https://storage.example.com",
+			RoleArn:             "arn:aws:iam::123456789012:role/TestRole",
+			RadosGWOIDCProvider: "https://oidc.example.com",
+			RadosGWOIDCClientID: "test-client",
+		},
+		AWSConfig:       ini.Empty(),
+		Verbose:         true,
+		SessionDuration: time.Hour,
+		Output:          stderr,
+	}
+}
@@ -1,19 +1,50 @@
 package credentials
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net/url"
+	"strings"
 
+	"github.com/fitbeard/radosgw-assume/internal/auth"
 	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
 
 	"gopkg.in/ini.v1"
 )
 
 type resolvedCredentialConfig struct {
-	sourceConfig *config.ProfileConfig
-	roleARN      string
-	authType     config.AuthType
-	scope        string
-	sslVerify    bool
+	sourceConfig      *config.ProfileConfig
+	stsEndpoints      []string
+	roleARN           string
+	roleSessionName   string
+	authType          config.AuthType
+	scope             string
+	tokenType         config.TokenType
+	authorization     auth.AuthorizationParameters
+	callbackPorts     []int
+	sslVerify         bool
+	clientCertificate *tls.Certificate
+	stsTrust          serverTrust
+	oidcTrust         serverTrust
+	stsProxy          endpointProxy
+	oidcProxy         endpointProxy
+	reportProxy       func(target, proxy *url.URL)
+	maxAttempts       int
+	reportRetry       func(httpclient.RetryAttempt)
+	reportFailover    func(endpointURL string, err error)
+	sessionPolicy     string
+	policyARNs        []string
+	// chainedRoles are assumed in order with the credentials of the role
+	// before them once roleARN is assumed with web identity.
+	chainedRoles []chainedRole
+}
+
+// chainedRole is a role assumed with sts:AssumeRole. Only the STS connection,
+// session name and session policy settings of config apply.
+type chainedRole struct {
+	profileName string
+	config      *resolvedCredentialConfig
 }
 
 func resolveCredentialConfig(profileName string, profileConfig *config.ProfileConfig, awsConfig *ini.File, verboseMode bool, dependencies credentialDependencies) (*resolvedCredentialConfig, error) {
@@ -21,35 +52,65 @@ func resolveCredentialConfig(profileName string, profileConfig *config.ProfileCo
 		return nil, fmt.Errorf("profile '%s': missing required 'role_arn'. Specify the IAM role ARN to assume", profileName)
 	}
 
-	sourceConfig := profileConfig
-	if profileConfig.SourceProfile != "" {
-		var err error
-		sourceConfig, err = dependencies.resolveSourceProfile(profileConfig, awsConfig, verboseMode)
+	if profileConfig.SourceProfile == "" {
+		verbosef(dependencies.stderr, verboseMode, "# Direct role assumption: %s\n", profileConfig.RoleArn)
+		return resolveRoleConfig(profileName, profileName, profileConfig, profileConfig, verboseMode, dependencies)
+	}
+	roleChain, sourceConfig, err := dependencies.resolveRoleChain(profileConfig, awsConfig, verboseMode)
+	if err != nil {
+		return nil, err
+	}
+	verbosef(dependencies.stderr, verboseMode, "# Role assumption: %s\n", profileConfig.RoleArn)
+	verbosef(dependencies.stderr, verboseMode, "# Source profile: %s\n", profileConfig.SourceProfile)
+	if len(roleChain) == 0 {
+		return resolveRoleConfig(profileName, profileConfig.SourceProfile, profileConfig, sourceConfig, verboseMode, dependencies)
+	}
+
+	// The first profile of the chain authenticates with web identity; every
+	// later role, ending with the profile's own, is assumed with the
+	// credentials of the role before it.
+	roleChain = append(roleChain, config.RoleChainHop{ProfileName: profileName, Config: sourceConfig})
+	profileNames := make([]string, 0, len(roleChain))
+	for _, hop := range roleChain {
+		profileNames = append(profileNames, hop.ProfileName)
+	}
+	verbosef(dependencies.stderr, verboseMode, "# Role chain: %s\n", strings.Join(profileNames, " -> "))
+
+	head := roleChain[0]
+	resolvedConfig, err := resolveRoleConfig(head.ProfileName, head.ProfileName, head.Config, head.Config, verboseMode, dependencies)
+	if err != nil {
+		return nil, err
+	}
+	for _, hop := range roleChain[1:] {
+		hopConfig, err := resolveRoleConfig(hop.ProfileName, hop.ProfileName, hop.Config, hop.Config, verboseMode, dependencies)
 		if err != nil {
 			return nil, err
 		}
-		verbosef(dependencies.stderr, verboseMode, "# Role assumption: %s\n", profileConfig.RoleArn)
-		verbosef(dependencies.stderr, verboseMode, "# Source profile: %s\n", profileConfig.SourceProfile)
-	} else {
-		verbosef(dependencies.stderr, verboseMode, "# Direct role assumption: %s\n", profileConfig.RoleArn)
+		resolvedConfig.chainedRoles = append(resolvedConfig.chainedRoles, chainedRole{profileName: hop.ProfileName, config: hopConfig})
 	}
-	normalizedSourceConfig, err := sourceConfig.Normalize()
+	return resolvedConfig, nil
+}
+
+// resolveRoleConfig resolves the settings used to assume profileConfig's role.
+// sourceConfig is the profile after source_profile inheritance and
+// sourceProfileName names the profile expected to supply the OIDC settings.
+func resolveRoleConfig(profileName, sourceProfileName string, profileConfig, sourceConfig *config.ProfileConfig, verboseMode bool, dependencies credentialDependencies) (*resolvedCredentialConfig, error) {
+	sourceConfig, err := sourceConfig.Normalize()
 	if err != nil {
 		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
 	}
-	sourceConfig = normalizedSourceConfig
 
-	if sourceConfig.EndpointURL == "" {
-		return nil, fmt.Errorf("profile '%s': missing required 'endpoint_url'. Add endpoint_url to your profile or its source profile", profileName)
+	stsEndpoints, err := config.ParseSTSEndpoints(sourceConfig.STSEndpoints)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+	}
+	if sourceConfig.EndpointURL == "" && len(stsEndpoints) == 0 {
+		return nil, fmt.Errorf("profile '%s': missing required 'endpoint_url'. Add endpoint_url or sts_endpoints to your profile or its source profile", profileName)
 	}
 
 	authType := sourceConfig.RadosGWOIDCAuthType
 
-	if authType != config.AuthTypeToken {
-		sourceProfileName := profileName
-		if profileConfig.SourceProfile != "" {
-			sourceProfileName = profileConfig.SourceProfile
-		}
+	if authType.UsesOIDCProvider() {
 		if sourceConfig.RadosGWOIDCProvider == "" {
 			return nil, fmt.Errorf("profile '%s': missing required 'radosgw_oidc_provider' - specify your OIDC provider URL", sourceProfileName)
 		}
@@ -58,11 +119,86 @@ func resolveCredentialConfig(profileName string, profileConfig *config.ProfileCo
 		}
 	}
 
+	callbackPorts, err := config.ParseCallbackPorts(sourceConfig.RadosGWOIDCCallbackPorts)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+	}
+	authorization, err := authorizationParameters(sourceConfig)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+	}
+	certificate, err := clientCertificate(sourceConfig, dependencies)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+	}
+	stsTrust, err := loadServerTrust("ca_bundle", sourceConfig.CABundle, "radosgw_tls_pinned_sha256", sourceConfig.RadosGWTLSPinnedSHA256, dependencies.readFile)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+	}
+	oidcTrust, err := loadServerTrust("radosgw_oidc_ca_bundle", sourceConfig.RadosGWOIDCCABundle, "radosgw_oidc_tls_pinned_sha256", sourceConfig.RadosGWOIDCTLSPinnedSHA256, dependencies.readFile)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+	}
+	maxAttempts, err := config.ParseMaxAttempts(sourceConfig.RadosGWMaxAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+	}
+	stsProxy, err := resolveEndpointProxy("radosgw_proxy_url", sourceConfig.RadosGWProxyURL, sourceConfig.RadosGWNoProxy)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+	}
+	oidcProxy, err := resolveEndpointProxy("radosgw_oidc_proxy_url", sourceConfig.RadosGWOIDCProxyURL, sourceConfig.RadosGWOIDCNoProxy)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+	}
+	policy, policyARNs, err := sessionPolicy(sourceConfig, dependencies.readFile)
+	if err != nil {
+		return nil, fmt.Errorf("profile '%s': %w", profileName, err)
+	}
+
 	return &resolvedCredentialConfig{
-		sourceConfig: sourceConfig,
-		roleARN:      profileConfig.RoleArn,
-		authType:     authType,
-		scope:        sourceConfig.RadosGWOIDCScope,
-		sslVerify:    sourceConfig.RadosGWSSLVerify.Enabled(),
+		sourceConfig:      sourceConfig,
+		stsEndpoints:      stsEndpoints,
+		roleARN:           profileConfig.RoleArn,
+		roleSessionName:   profileConfig.RoleSessionName,
+		authType:          authType,
+		scope:             sourceConfig.RadosGWOIDCScope,
+		tokenType:         sourceConfig.RadosGWOIDCTokenType,
+		authorization:     authorization,
+		callbackPorts:     callbackPorts,
+		sslVerify:         sourceConfig.RadosGWSSLVerify.Enabled(),
+		clientCertificate: certificate,
+		stsTrust:          stsTrust,
+		oidcTrust:         oidcTrust,
+		stsProxy:          stsProxy,
+		oidcProxy:         oidcProxy,
+		reportProxy:       proxyReporter(dependencies.stderr, verboseMode),
+		maxAttempts:       maxAttempts,
+		reportRetry:       retryReporter(dependencies.stderr, verboseMode),
+		reportFailover:    failoverReporter(dependencies.stderr, verboseMode),
+		sessionPolicy:     policy,
+		policyARNs:        policyARNs,
 	}, nil
 }
+
+// authorizationParameters collects the optional device and browser
+// authorization request parameters. radosgw_oidc_audience is only sent when
+// the profile itself logs in interactively; for token exchange it names the
+// audience of the exchanged token instead.
+func authorizationParameters(sourceConfig *config.ProfileConfig) (auth.AuthorizationParameters, error) {
+	resources, err := config.ParseResourceIndicators(sourceConfig.RadosGWOIDCResource)
+	if err != nil {
+		return auth.AuthorizationParameters{}, err
+	}
+	parameters := auth.AuthorizationParameters{
+		Prompt:    strings.Join(strings.Fields(sourceConfig.RadosGWOIDCPrompt), " "),
+		LoginHint: sourceConfig.RadosGWOIDCLoginHint,
+		ACRValues: strings.Join(strings.Fields(sourceConfig.RadosGWOIDCACRValues), " "),
+		Resources: resources,
+	}
+	switch sourceConfig.RadosGWOIDCAuthType {
+	case config.AuthTypeDevice, config.AuthTypeBrowser:
+		parameters.Audience = sourceConfig.RadosGWOIDCAudience
+	}
+	return parameters, nil
+}
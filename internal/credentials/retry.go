@@ -0,0 +1,31 @@
+package credentials
+
+import (
+	"io"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
+
+// retryReporter returns an OnRetry callback that prints each failed attempt
+// of an OIDC or STS request in verbose mode, or nil otherwise.
+func retryReporter(stderr io.Writer, verboseMode bool) func(httpclient.RetryAttempt) {
+	if !verboseMode {
+		return nil
+	}
+	return func(attempt httpclient.RetryAttempt) {
+		verbosef(stderr, true, "# %s failed (attempt %d/%d), retrying in %s: %v\n",
+			attempt.Operation, attempt.Attempt, attempt.MaxAttempts, attempt.Delay.Round(time.Millisecond), attempt.Err)
+	}
+}
+
+// failoverReporter returns an OnFailover callback that prints each STS
+// gateway given up in verbose mode, or nil otherwise.
+func failoverReporter(stderr io.Writer, verboseMode bool) func(string, error) {
+	if !verboseMode {
+		return nil
+	}
+	return func(endpointURL string, err error) {
+		verbosef(stderr, true, "# STS endpoint %s failed, trying the next one: %v\n", endpointURL, err)
+	}
+}
@@ -0,0 +1,68 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+	"github.com/fitbeard/radosgw-assume/internal/sts"
+)
+
+func TestGetCredentialsConfiguresRetries(t *testing.T) {
+	for _, test := range []struct {
+		name            string
+		maxAttempts     string
+		wantMaxAttempts int
+	}{
+		{name: "default", wantMaxAttempts: config.DefaultMaxAttempts},
+		{name: "profile setting", maxAttempts: "5", wantMaxAttempts: 5},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := refreshTestDependencies(t, stderr, nil)
+			dependencies.openTokenStore = func() (oidcTokenStore, error) { return nil, errors.New("no store") }
+			dependencies.getenv = func(string) string { return "" }
+			dependencies.authenticateDevice = func(_ context.Context, options auth.OIDCOptions) (auth.TokenResponse, error) {
+				if options.MaxAttempts != test.wantMaxAttempts {
+					t.Errorf("OIDC max attempts = %d, want %d", options.MaxAttempts, test.wantMaxAttempts)
+				}
+				options.OnRetry(httpclient.RetryAttempt{Operation: "OIDC discovery", Attempt: 1, MaxAttempts: options.MaxAttempts, Delay: 187500 * time.Microsecond, Err: errors.New("status 503")})
+				return auth.TokenResponse{AccessToken: "device.jwt.value"}, nil
+			}
+			dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+				if options.MaxAttempts != test.wantMaxAttempts {
+					t.Errorf("STS max attempts = %d, want %d", options.MaxAttempts, test.wantMaxAttempts)
+				}
+				options.OnRetry(httpclient.RetryAttempt{Operation: "STS AssumeRoleWithWebIdentity", Attempt: 2, MaxAttempts: options.MaxAttempts, Delay: time.Second, Err: errors.New("connection reset")})
+				return &config.AssumeRoleResult{}, nil
+			}
+			request := refreshTestRequest(stderr)
+			request.ProfileConfig.RadosGWMaxAttempts = test.maxAttempts
+
+			if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			for _, want := range []string{
+				fmt.Sprintf("# OIDC discovery failed (attempt 1/%d), retrying in 188ms: status 503\n", test.wantMaxAttempts),
+				fmt.Sprintf("# STS AssumeRoleWithWebIdentity failed (attempt 2/%d), retrying in 1s: connection reset\n", test.wantMaxAttempts),
+			} {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("verbose output %q does not contain %q", stderr.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryReporterDisabledWithoutVerbose(t *testing.T) {
+	if retryReporter(&bytes.Buffer{}, false) != nil {
+		t.Error("retryReporter() returned a callback without verbose mode")
+	}
+}
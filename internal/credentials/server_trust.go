@@ -0,0 +1,41 @@
+package credentials
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
+
+// serverTrust holds the roots and certificate pins used to verify one kind of
+// server. The RadosGW STS endpoint and the OIDC provider are configured
+// separately because they are often issued by different CAs.
+type serverTrust struct {
+	rootCAs      *x509.CertPool
+	pinnedSHA256 [][sha256.Size]byte
+}
+
+// loadServerTrust reads the CA bundle configured under bundleKey and parses the
+// fingerprints configured under pinsKey. Bundle certificates are added to the
+// system roots rather than replacing them.
+func loadServerTrust(bundleKey, bundleFile, pinsKey, pins string, readFile func(string) ([]byte, error)) (serverTrust, error) {
+	pinnedSHA256, err := config.ParseCertificatePins(pinsKey, pins)
+	if err != nil {
+		return serverTrust{}, err
+	}
+	trust := serverTrust{pinnedSHA256: pinnedSHA256}
+	if bundleFile == "" {
+		return trust, nil
+	}
+
+	content, err := readFile(bundleFile)
+	if err != nil {
+		return serverTrust{}, fmt.Errorf("read %s: %w", bundleKey, err)
+	}
+	if trust.rootCAs, err = httpclient.LoadCertPool(content); err != nil {
+		return serverTrust{}, fmt.Errorf("%s %s: %w", bundleKey, bundleFile, err)
+	}
+	return trust, nil
+}
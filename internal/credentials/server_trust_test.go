@@ -0,0 +1,93 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/sts"
+)
+
+const testPin = "00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff:00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff"
+
+func TestGetCredentialsUsesSeparateServerTrust(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := refreshTestDependencies(t, stderr, nil)
+	dependencies.openTokenStore = func() (oidcTokenStore, error) { return nil, errors.New("no store") }
+	dependencies.getenv = func(string) string { return "" }
+	now := dependencies.now()
+	bundlePEM, _ := createTestClientCertificate(t, now.Add(-time.Hour), now.Add(time.Hour))
+	dependencies.readFile = func(name string) ([]byte, error) {
+		if name != "/etc/radosgw/storage-ca.pem" {
+			return nil, errors.New("unexpected file")
+		}
+		return bundlePEM, nil
+	}
+	dependencies.authenticateDevice = func(_ context.Context, options auth.OIDCOptions) (auth.TokenResponse, error) {
+		if options.RootCAs != nil {
+			t.Error("OIDC options use the RadosGW CA bundle")
+		}
+		if len(options.PinnedSHA256) != 1 || options.PinnedSHA256[0][1] != 0x11 {
+			t.Errorf("OIDC pinned certificates = %x, want the radosgw_oidc_tls_pinned_sha256 value", options.PinnedSHA256)
+		}
+		return auth.TokenResponse{AccessToken: "device.jwt.value"}, nil
+	}
+	dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		if options.RootCAs == nil {
+			t.Error("STS options do not use the CA bundle")
+		}
+		if len(options.PinnedSHA256) != 0 {
+			t.Errorf("STS pinned certificates = %x, want none", options.PinnedSHA256)
+		}
+		return &config.AssumeRoleResult{}, nil
+	}
+	request := refreshTestRequest(stderr)
+	request.ProfileConfig.CABundle = "/etc/radosgw/storage-ca.pem"
+	request.ProfileConfig.RadosGWOIDCTLSPinnedSHA256 = testPin
+
+	if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+	for _, want := range []string{"# CA bundle: /etc/radosgw/storage-ca.pem", "# Pinned OIDC certificates: 1"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("verbose output %q does not contain %q", stderr.String(), want)
+		}
+	}
+}
+
+func TestLoadServerTrustErrors(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		pins        string
+		readFile    func(string) ([]byte, error)
+		wantContain string
+	}{
+		{
+			name:        "unreadable bundle",
+			readFile:    func(string) ([]byte, error) { return nil, errors.New("permission denied") },
+			wantContain: "read radosgw_oidc_ca_bundle: permission denied",
+		},
+		{
+			name:        "bundle without certificates",
+			readFile:    func(string) ([]byte, error) { return []byte("not PEM"), nil },
+			wantContain: "radosgw_oidc_ca_bundle /etc/radosgw/oidc-ca.pem: no PEM certificates found",
+		},
+		{
+			name:        "invalid pin",
+			pins:        "abcd",
+			wantContain: `invalid radosgw_oidc_tls_pinned_sha256 "abcd"`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadServerTrust("radosgw_oidc_ca_bundle", "/etc/radosgw/oidc-ca.pem", "radosgw_oidc_tls_pinned_sha256", test.pins, test.readFile)
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("loadServerTrust() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package credentials
+
+import (
+	"fmt"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
+// sessionPolicy returns the compacted inline session policy and the managed
+// session policy ARNs requested by the profile. A policy file is read on
+// every call so edits take effect without touching the AWS config.
+func sessionPolicy(sourceConfig *config.ProfileConfig, readFile func(string) ([]byte, error)) (string, []string, error) {
+	policyARNs, err := config.ParseSessionPolicyARNs(sourceConfig.SessionPolicyARNs)
+	if err != nil {
+		return "", nil, err
+	}
+	if sourceConfig.SessionPolicyFile == "" {
+		if sourceConfig.SessionPolicy == "" {
+			return "", policyARNs, nil
+		}
+		policy, err := config.ParseSessionPolicy("session_policy", sourceConfig.SessionPolicy)
+		return policy, policyARNs, err
+	}
+
+	document, err := readFile(sourceConfig.SessionPolicyFile)
+	if err != nil {
+		return "", nil, fmt.Errorf("read session policy file: %w", err)
+	}
+	policy, err := config.ParseSessionPolicy("session policy file "+sourceConfig.SessionPolicyFile, string(document))
+	if err != nil {
+		return "", nil, err
+	}
+	return policy, policyARNs, nil
+}
@@ -0,0 +1,112 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/sts"
+)
+
+func TestGetCredentialsSendsSessionPolicies(t *testing.T) {
+	const wantPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"arn:aws:s3:::ci/*"}]}`
+	for _, test := range []struct {
+		name       string
+		configure  func(*config.ProfileConfig)
+		wantOutput string
+	}{
+		{
+			name: "inline",
+			configure: func(profileConfig *config.ProfileConfig) {
+				profileConfig.SessionPolicy = wantPolicy
+			},
+			wantOutput: "# Session policy: inline (104 bytes)\n",
+		},
+		{
+			name: "file",
+			configure: func(profileConfig *config.ProfileConfig) {
+				profileConfig.SessionPolicyFile = "/etc/radosgw/ci-policy.json"
+			},
+			wantOutput: "# Session policy: /etc/radosgw/ci-policy.json (104 bytes)\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := refreshTestDependencies(t, stderr, nil)
+			dependencies.openTokenStore = func() (oidcTokenStore, error) { return nil, errors.New("no store") }
+			dependencies.getenv = func(string) string { return "" }
+			dependencies.readFile = func(name string) ([]byte, error) {
+				if name != "/etc/radosgw/ci-policy.json" {
+					t.Errorf("readFile() name = %q", name)
+				}
+				return []byte("{\n  \"Version\": \"2012-10-17\",\n  \"Statement\": [{\"Effect\": \"Allow\", \"Action\": \"s3:*\", \"Resource\": \"arn:aws:s3:::ci/*\"}]\n}\n"), nil
+			}
+			dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+				return auth.TokenResponse{AccessToken: "device.jwt.value"}, nil
+			}
+			dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+				if options.Policy != wantPolicy {
+					t.Errorf("STS policy = %q, want %q", options.Policy, wantPolicy)
+				}
+				if strings.Join(options.PolicyARNs, " ") != "arn:aws:iam::aws:policy/ReadOnly" {
+					t.Errorf("STS policy ARNs = %q", options.PolicyARNs)
+				}
+				return &config.AssumeRoleResult{}, nil
+			}
+			request := refreshTestRequest(stderr)
+			request.ProfileConfig.SessionPolicyARNs = "arn:aws:iam::aws:policy/ReadOnly"
+			test.configure(request.ProfileConfig)
+
+			if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			for _, want := range []string{test.wantOutput, "# Session policy ARN: arn:aws:iam::aws:policy/ReadOnly\n"} {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("verbose output %q does not contain %q", stderr.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestSessionPolicyErrors(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		profile     config.ProfileConfig
+		fileContent string
+		fileErr     error
+		wantErr     string
+	}{
+		{
+			name:    "missing file",
+			profile: config.ProfileConfig{SessionPolicyFile: "/etc/radosgw/missing.json"},
+			fileErr: os.ErrNotExist,
+			wantErr: "read session policy file: file does not exist",
+		},
+		{
+			name:        "empty file",
+			profile:     config.ProfileConfig{SessionPolicyFile: "/etc/radosgw/empty.json"},
+			fileContent: "\n",
+			wantErr:     "invalid session policy file /etc/radosgw/empty.json: policy is not valid JSON",
+		},
+		{
+			name:    "managed policy ARN",
+			profile: config.ProfileConfig{SessionPolicyARNs: "ReadOnly"},
+			wantErr: "invalid session_policy_arns",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := sessionPolicy(&test.profile, func(string) ([]byte, error) {
+				return []byte(test.fileContent), test.fileErr
+			})
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("sessionPolicy() error = %v, want containing %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/sts"
+)
+
+func TestGetCredentialsExchangesSubjectToken(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		subjectAuth config.AuthType
+		configure   func(*credentialDependencies)
+		wantSubject string
+		wantVerbose string
+	}{
+		{
+			name:        "token file",
+			subjectAuth: config.AuthTypeToken,
+			configure: func(dependencies *credentialDependencies) {
+				dependencies.readFile = func(string) ([]byte, error) { return []byte("kubernetes.jwt.value\n"), nil }
+				dependencies.getenv = func(string) string { return "" }
+			},
+			wantSubject: "kubernetes.jwt.value",
+			wantVerbose: "# Using web identity token file: /var/run/secrets/tokens/radosgw",
+		},
+		{
+			name:        "GitHub Actions",
+			subjectAuth: config.AuthTypeGitHubActions,
+			configure: func(dependencies *credentialDependencies) {
+				dependencies.getenv = func(string) string { return "" }
+				dependencies.fetchGitHubToken = func(_ context.Context, options auth.GitHubActionsOptions) (string, error) {
+					if options.Audience != "" {
+						t.Errorf("fetchGitHubToken() audience = %q, want GitHub default", options.Audience)
+					}
+					return "github.jwt.value", nil
+				}
+			},
+			wantSubject: "github.jwt.value",
+			wantVerbose: "# Requesting GitHub Actions OIDC token",
+		},
+		{
+			name:        "device",
+			subjectAuth: config.AuthTypeDevice,
+			configure: func(dependencies *credentialDependencies) {
+				dependencies.getenv = func(string) string { return "" }
+				dependencies.authenticateDevice = func(_ context.Context, options auth.OIDCOptions) (auth.TokenResponse, error) {
+					if options.Authorization.Audience != "" {
+						t.Errorf("authenticateDevice() audience = %q, want the exchange audience kept out of the subject login", options.Authorization.Audience)
+					}
+					return auth.TokenResponse{AccessToken: "device.jwt.value"}, nil
+				}
+			},
+			wantSubject: "device.jwt.value",
+			wantVerbose: "# Starting device authentication flow",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := refreshTestDependencies(t, stderr, newTestOIDCTokenStore())
+			test.configure(&dependencies)
+			dependencies.exchangeToken = func(_ context.Context, options auth.OIDCOptions, exchange auth.TokenExchangeOptions) (auth.TokenResponse, error) {
+				if options.ProviderURL != "https://oidc.example.com" || options.ClientID != "test-client" || options.ClientSecret != "" {
+					t.Errorf("exchangeToken() client = %q/%q/%q", options.ProviderURL, options.ClientID, options.ClientSecret)
+				}
+				want := auth.TokenExchangeOptions{
+					SubjectToken:       test.wantSubject,
+					SubjectTokenType:   config.ExchangeTokenTypeJWT,
+					RequestedTokenType: config.ExchangeTokenTypeAccessToken,
+					Audience:           "radosgw",
+				}
+				if exchange != want {
+					t.Errorf("exchangeToken() exchange = %+v, want %+v", exchange, want)
+				}
+				return auth.TokenResponse{AccessToken: "keycloak.jwt.value", IssuedTokenType: string(config.ExchangeTokenTypeAccessToken)}, nil
+			}
+			var presented string
+			dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+				presented = options.WebIdentityToken
+				return &config.AssumeRoleResult{}, nil
+			}
+			request := tokenExchangeTestRequest(stderr, test.subjectAuth)
+
+			if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			if presented != "keycloak.jwt.value" {
+				t.Errorf("assumeRole() web identity token = %q, want exchanged token", presented)
+			}
+			for _, want := range []string{
+				"# Auth type: token-exchange",
+				"# Subject auth type: " + string(test.subjectAuth),
+				"# OIDC audience: radosgw",
+				test.wantVerbose,
+				"# Exchanging urn:ietf:params:oauth:token-type:jwt for urn:ietf:params:oauth:token-type:access_token",
+			} {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("verbose output %q does not contain %q", stderr.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetCredentialsTokenExchangeClientSecret(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := refreshTestDependencies(t, stderr, nil)
+	dependencies.readFile = func(name string) ([]byte, error) {
+		if name == "/run/secrets/client-secret" {
+			return []byte("exchange-secret\n"), nil
+		}
+		return []byte("kubernetes.jwt.value"), nil
+	}
+	dependencies.getenv = func(string) string { return "" }
+	dependencies.exchangeToken = func(_ context.Context, options auth.OIDCOptions, _ auth.TokenExchangeOptions) (auth.TokenResponse, error) {
+		if options.ClientSecret != "exchange-secret" {
+			t.Errorf("exchangeToken() client secret = %q, want secret from file", options.ClientSecret)
+		}
+		return auth.TokenResponse{AccessToken: "keycloak.jwt.value"}, nil
+	}
+	request := tokenExchangeTestRequest(stderr, config.AuthTypeToken)
+	request.ProfileConfig.RadosGWOIDCClientSecretFile = "/run/secrets/client-secret"
+
+	if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+}
+
+func TestGetCredentialsTokenExchangeErrors(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		readFile    func(string) ([]byte, error)
+		exchangeErr error
+		wantContain string
+	}{
+		{
+			name:        "subject token",
+			readFile:    func(string) ([]byte, error) { return nil, errors.New("permission denied") },
+			wantContain: "read web identity token file: permission denied",
+		},
+		{
+			name:        "provider rejection",
+			readFile:    func(string) ([]byte, error) { return []byte("kubernetes.jwt.value"), nil },
+			exchangeErr: errors.New("invalid subject token"),
+			wantContain: "token exchange failed: invalid subject token",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := newTestCredentialDependencies(t, stderr)
+			dependencies.readFile = test.readFile
+			dependencies.getenv = func(string) string { return "" }
+			dependencies.exchangeToken = func(context.Context, auth.OIDCOptions, auth.TokenExchangeOptions) (auth.TokenResponse, error) {
+				return auth.TokenResponse{}, test.exchangeErr
+			}
+
+			_, err := getCredentials(t.Context(), tokenExchangeTestRequest(stderr, config.AuthTypeToken), dependencies)
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("getCredentials() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
+
+func tokenExchangeTestRequest(stderr *bytes.Buffer, subjectAuth config.AuthType) RequestOptions {
+	request := refreshTestRequest(stderr)
+	request.ProfileConfig.RadosGWOIDCAuthType = config.AuthTypeTokenExchange
+	request.ProfileConfig.RadosGWOIDCSubjectAuthType = subjectAuth
+	request.ProfileConfig.RadosGWOIDCSubjectTokenType = config.ExchangeTokenTypeJWT
+	request.ProfileConfig.RadosGWOIDCAudience = "radosgw"
+	if subjectAuth == config.AuthTypeToken {
+		request.ProfileConfig.WebIdentityTokenFile = "/var/run/secrets/tokens/radosgw"
+	}
+	return request
+}
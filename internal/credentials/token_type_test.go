@@ -0,0 +1,125 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/sts"
+	"github.com/fitbeard/radosgw-assume/internal/tokencache"
+)
+
+func TestGetCredentialsPresentsConfiguredTokenType(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		tokenType config.TokenType
+		want      string
+	}{
+		{name: "default", want: "access.jwt.value"},
+		{name: "access token", tokenType: config.TokenTypeAccessToken, want: "access.jwt.value"},
+		{name: "ID token", tokenType: config.TokenTypeIDToken, want: "id.jwt.value"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := refreshTestDependencies(t, stderr, nil)
+			dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+				return auth.TokenResponse{AccessToken: "access.jwt.value", IDToken: "id.jwt.value"}, nil
+			}
+			var presented string
+			dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+				presented = options.WebIdentityToken
+				return &config.AssumeRoleResult{}, nil
+			}
+			request := refreshTestRequest(stderr)
+			request.ProfileConfig.RadosGWOIDCTokenType = test.tokenType
+
+			if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			if presented != test.want {
+				t.Errorf("assumeRole() web identity token = %q, want %q", presented, test.want)
+			}
+		})
+	}
+}
+
+func TestGetCredentialsRequiresIDToken(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := refreshTestDependencies(t, stderr, nil)
+	dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+		return auth.TokenResponse{AccessToken: "access.jwt.value"}, nil
+	}
+	dependencies.assumeRole = func(context.Context, sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		t.Fatal("unexpected assumeRole() call")
+		return nil, nil
+	}
+	request := refreshTestRequest(stderr)
+	request.ProfileConfig.RadosGWOIDCTokenType = config.TokenTypeIDToken
+
+	_, err := getCredentials(t.Context(), request, dependencies)
+	if err == nil || !strings.Contains(err.Error(), "no ID token received") {
+		t.Fatalf("getCredentials() error = %v, want missing ID token", err)
+	}
+}
+
+func TestGetCredentialsAuthenticatesWhenRefreshOmitsIDToken(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	store := newTestOIDCTokenStore()
+	store.tokens["https://oidc.example.com test-client"] = tokencache.RefreshToken{Token: "stored-refresh-token"}
+	dependencies := refreshTestDependencies(t, stderr, store)
+	dependencies.refreshTokens = func(context.Context, auth.OIDCOptions, string) (auth.TokenResponse, error) {
+		return auth.TokenResponse{AccessToken: "refreshed-access-token"}, nil
+	}
+	dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+		return auth.TokenResponse{AccessToken: "access.jwt.value", IDToken: "id.jwt.value"}, nil
+	}
+	var presented string
+	dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		presented = options.WebIdentityToken
+		return &config.AssumeRoleResult{}, nil
+	}
+	request := refreshTestRequest(stderr)
+	request.ProfileConfig.RadosGWOIDCTokenType = config.TokenTypeIDToken
+
+	if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+	if presented != "id.jwt.value" {
+		t.Errorf("assumeRole() web identity token = %q, want id.jwt.value", presented)
+	}
+	if !strings.Contains(stderr.String(), "# Refreshed tokens do not include id_token, authenticating interactively") {
+		t.Errorf("verbose output %q does not report the interactive fallback", stderr.String())
+	}
+}
+
+func TestGetWebIdentityTokenSkipsAssumeRole(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := refreshTestDependencies(t, stderr, nil)
+	dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+		return auth.TokenResponse{AccessToken: "access.jwt.value", IDToken: "id.jwt.value"}, nil
+	}
+	dependencies.verifyToken = func(context.Context, auth.OIDCOptions, string, auth.TokenVerificationOptions) error {
+		t.Fatal("unexpected verifyToken() call")
+		return nil
+	}
+	dependencies.assumeRole = func(context.Context, sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		t.Fatal("unexpected assumeRole() call")
+		return nil, nil
+	}
+	request := refreshTestRequest(stderr)
+	request.ProfileConfig.RadosGWOIDCTokenType = config.TokenTypeIDToken
+
+	token, err := getWebIdentityToken(t.Context(), request, dependencies)
+	if err != nil {
+		t.Fatalf("getWebIdentityToken() error = %v", err)
+	}
+	if token != "id.jwt.value" {
+		t.Errorf("getWebIdentityToken() = %q, want configured ID token", token)
+	}
+	if strings.Contains(stderr.String(), "# Session duration") {
+		t.Errorf("stderr = %q, want no session details", stderr.String())
+	}
+}
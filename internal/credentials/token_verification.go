@@ -0,0 +1,42 @@
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+)
+
+// verifyWebIdentityToken checks tokens issued by the configured provider
+// before they are sent to STS, whose rejections do not say which claim failed.
+// Presented tokens come from other issuers and opaque access tokens carry no
+// claims, so both are passed through unchanged. When the token cannot be
+// checked locally, for example because the JWKS is unreachable or uses an
+// unsupported algorithm, a warning is printed and STS decides alone.
+func verifyWebIdentityToken(ctx context.Context, resolvedConfig *resolvedCredentialConfig, token string, verboseMode bool, dependencies credentialDependencies) error {
+	if !resolvedConfig.authType.UsesOIDCProvider() {
+		return nil
+	}
+	if !auth.IsJWT(token) {
+		verbosef(dependencies.stderr, verboseMode, "# Skipping local token verification: token is not a JWT\n")
+		return nil
+	}
+
+	audiences := []string{resolvedConfig.sourceConfig.RadosGWOIDCClientID}
+	if audience := resolvedConfig.sourceConfig.RadosGWOIDCAudience; audience != "" && audience != audiences[0] {
+		audiences = append(audiences, audience)
+	}
+
+	verbosef(dependencies.stderr, verboseMode, "# Verifying web identity token\n")
+	err := dependencies.verifyToken(ctx, oidcOptions(resolvedConfig, verboseMode), token, auth.TokenVerificationOptions{Audiences: audiences})
+	if errors.Is(err, auth.ErrTokenVerificationUnavailable) && ctx.Err() == nil {
+		_, _ = fmt.Fprintf(dependencies.stderr, "# ⚠️  Warning: %v; sending the token to STS unverified\n", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("web identity token rejected before STS: %w", err)
+	}
+	verbosef(dependencies.stderr, verboseMode, "# ✓ Web identity token verified\n")
+	return nil
+}
@@ -0,0 +1,148 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/sts"
+)
+
+func TestGetCredentialsVerifiesWebIdentityToken(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		audience      string
+		wantAudiences []string
+	}{
+		{name: "client audience", wantAudiences: []string{"test-client"}},
+		{name: "configured audience", audience: "storage", wantAudiences: []string{"test-client", "storage"}},
+		{name: "configured audience matches client", audience: "test-client", wantAudiences: []string{"test-client"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := refreshTestDependencies(t, stderr, nil)
+			dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+				return auth.TokenResponse{AccessToken: "access.jwt.value"}, nil
+			}
+			var verified bool
+			dependencies.verifyToken = func(_ context.Context, options auth.OIDCOptions, token string, verification auth.TokenVerificationOptions) error {
+				verified = true
+				if options.ProviderURL != "https://oidc.example.com" || options.ClientID != "test-client" {
+					t.Errorf("verifyToken() options = %+v", options)
+				}
+				if token != "access.jwt.value" {
+					t.Errorf("verifyToken() token = %q, want access.jwt.value", token)
+				}
+				if !slices.Equal(verification.Audiences, test.wantAudiences) {
+					t.Errorf("verifyToken() audiences = %q, want %q", verification.Audiences, test.wantAudiences)
+				}
+				return nil
+			}
+			request := refreshTestRequest(stderr)
+			request.ProfileConfig.RadosGWOIDCAudience = test.audience
+
+			if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			if !verified {
+				t.Fatal("verifyToken() was not called")
+			}
+			if !strings.Contains(stderr.String(), "# ✓ Web identity token verified") {
+				t.Errorf("stderr = %q, want verification message", stderr.String())
+			}
+		})
+	}
+}
+
+func TestGetCredentialsRejectsUnverifiedWebIdentityToken(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := refreshTestDependencies(t, stderr, nil)
+	dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+		return auth.TokenResponse{AccessToken: "access.jwt.value"}, nil
+	}
+	verificationErr := errors.New("token expired 3m ago")
+	dependencies.verifyToken = func(context.Context, auth.OIDCOptions, string, auth.TokenVerificationOptions) error {
+		return verificationErr
+	}
+	dependencies.assumeRole = func(context.Context, sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		t.Fatal("unexpected assumeRole() call")
+		return nil, nil
+	}
+
+	_, err := getCredentials(t.Context(), refreshTestRequest(stderr), dependencies)
+	if !errors.Is(err, verificationErr) || !strings.Contains(err.Error(), "web identity token rejected before STS: token expired 3m ago") {
+		t.Fatalf("getCredentials() error = %v, want wrapped verification error", err)
+	}
+}
+
+func TestGetCredentialsContinuesWhenTokenVerificationUnavailable(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	dependencies := refreshTestDependencies(t, stderr, nil)
+	dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+		return auth.TokenResponse{AccessToken: "access.jwt.value"}, nil
+	}
+	dependencies.verifyToken = func(context.Context, auth.OIDCOptions, string, auth.TokenVerificationOptions) error {
+		return fmt.Errorf("%w: unsupported token signing algorithm %q", auth.ErrTokenVerificationUnavailable, "EdDSA")
+	}
+	assumed := false
+	dependencies.assumeRole = func(context.Context, sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		assumed = true
+		return &config.AssumeRoleResult{}, nil
+	}
+
+	if _, err := getCredentials(t.Context(), refreshTestRequest(stderr), dependencies); err != nil {
+		t.Fatalf("getCredentials() error = %v", err)
+	}
+	if !assumed {
+		t.Error("assumeRole() was not called")
+	}
+	want := `# ⚠️  Warning: local token verification unavailable: unsupported token signing algorithm "EdDSA"; sending the token to STS unverified`
+	if !strings.Contains(stderr.String(), want) {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+}
+
+func TestGetCredentialsSkipsTokenVerification(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		authType   config.AuthType
+		token      string
+		wantStderr string
+	}{
+		{name: "opaque access token", authType: config.AuthTypeDevice, token: "opaque-access-token", wantStderr: "# Skipping local token verification: token is not a JWT"},
+		{name: "presented token", authType: config.AuthTypeToken, token: "external.jwt.value"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := refreshTestDependencies(t, stderr, nil)
+			dependencies.getenv = func(name string) string {
+				if name == "RADOSGW_OIDC_TOKEN" {
+					return test.token
+				}
+				return ""
+			}
+			dependencies.authenticateDevice = func(context.Context, auth.OIDCOptions) (auth.TokenResponse, error) {
+				return auth.TokenResponse{AccessToken: test.token}, nil
+			}
+			dependencies.verifyToken = func(context.Context, auth.OIDCOptions, string, auth.TokenVerificationOptions) error {
+				t.Fatal("unexpected verifyToken() call")
+				return nil
+			}
+			request := refreshTestRequest(stderr)
+			request.ProfileConfig.RadosGWOIDCAuthType = test.authType
+
+			if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+				t.Fatalf("getCredentials() error = %v", err)
+			}
+			if test.wantStderr != "" && !strings.Contains(stderr.String(), test.wantStderr) {
+				t.Errorf("stderr = %q, want %q", stderr.String(), test.wantStderr)
+			}
+		})
+	}
+}
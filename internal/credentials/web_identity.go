@@ -0,0 +1,31 @@
+package credentials
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+)
+
+// webIdentityToken returns the pre-issued token for token authentication.
+// Token files are read on every call because projected service account tokens
+// are rotated in place by the kubelet.
+func webIdentityToken(profileConfig *config.ProfileConfig, getenv func(string) string, readFile func(string) ([]byte, error)) (string, error) {
+	if profileConfig.WebIdentityTokenFile == "" {
+		token := getenv("RADOSGW_OIDC_TOKEN")
+		if token == "" {
+			return "", fmt.Errorf("RADOSGW_OIDC_TOKEN environment variable or web_identity_token_file is required for token auth type")
+		}
+		return token, nil
+	}
+
+	content, err := readFile(profileConfig.WebIdentityTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("read web identity token file: %w", err)
+	}
+	token := strings.TrimSpace(string(content))
+	if token == "" {
+		return "", fmt.Errorf("web identity token file %s is empty", profileConfig.WebIdentityTokenFile)
+	}
+	return token, nil
+}
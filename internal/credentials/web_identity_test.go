@@ -0,0 +1,83 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/sts"
+)
+
+func TestGetCredentialsRereadsWebIdentityTokenFile(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	stderr := &bytes.Buffer{}
+	dependencies := newTestCredentialDependencies(t, stderr)
+	dependencies.readFile = os.ReadFile
+	var presented []string
+	dependencies.assumeRole = func(_ context.Context, options sts.AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		presented = append(presented, options.WebIdentityToken)
+		return &config.AssumeRoleResult{}, nil
+	}
+	request := webIdentityTestRequest(stderr, tokenFile)
+
+	for _, token := range []string{"first-projected-token", "rotated-projected-token"} {
+		if err := os.WriteFile(tokenFile, []byte(token+"\n"), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		if _, err := getCredentials(t.Context(), request, dependencies); err != nil {
+			t.Fatalf("getCredentials() error = %v", err)
+		}
+	}
+
+	if strings.Join(presented, ",") != "first-projected-token,rotated-projected-token" {
+		t.Errorf("assumeRole() tokens = %q, want each rotated file value", presented)
+	}
+	if !strings.Contains(stderr.String(), "# Using web identity token file: "+tokenFile) {
+		t.Errorf("verbose output %q does not report the token file", stderr.String())
+	}
+}
+
+func TestGetCredentialsWebIdentityTokenFileErrors(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		readFile    func(string) ([]byte, error)
+		wantContain string
+	}{
+		{
+			name:        "unreadable",
+			readFile:    func(string) ([]byte, error) { return nil, errors.New("permission denied") },
+			wantContain: "read web identity token file: permission denied",
+		},
+		{
+			name:        "empty",
+			readFile:    func(string) ([]byte, error) { return []byte(" \n"), nil },
+			wantContain: "web identity token file /var/run/secrets/tokens/radosgw is empty",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stderr := &bytes.Buffer{}
+			dependencies := newTestCredentialDependencies(t, stderr)
+			dependencies.readFile = test.readFile
+
+			_, err := getCredentials(t.Context(), webIdentityTestRequest(stderr, "/var/run/secrets/tokens/radosgw"), dependencies)
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("getCredentials() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
+
+func webIdentityTestRequest(stderr *bytes.Buffer, tokenFile string) RequestOptions {
+	request := refreshTestRequest(stderr)
+	request.ProfileConfig = &config.ProfileConfig{
+		EndpointURL:          "https://storage.example.com",
+		RoleArn:              "arn:aws:iam::123456789012:role/TestRole",
+		WebIdentityTokenFile: tokenFile,
+	}
+	return request
+}
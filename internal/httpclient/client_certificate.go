@@ -0,0 +1,119 @@
+package httpclient
+
+import (
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/pkg/duration"
+)
+
+// ErrPassphraseRequired is returned when the client key is encrypted but no
+// passphrase was supplied.
+var ErrPassphraseRequired = errors.New("private key is encrypted but no passphrase was provided")
+
+// LoadClientCertificate parses a PEM client certificate chain and its private
+// key for mutual TLS. The key may be unencrypted (PKCS #1, SEC 1 or PKCS #8) or
+// an encrypted PKCS #8 key, which is decrypted with passphrase. The leaf
+// certificate must be valid at now and match the key, so misconfigurations are
+// reported before any TLS handshake.
+func LoadClientCertificate(certificatePEM, keyPEM, passphrase []byte, now time.Time) (*tls.Certificate, error) {
+	leafBlock := findPEMBlock(certificatePEM, func(blockType string) bool { return blockType == "CERTIFICATE" })
+	if leafBlock == nil {
+		return nil, fmt.Errorf("no PEM certificate found")
+	}
+	leaf, err := x509.ParseCertificate(leafBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse client certificate: %w", err)
+	}
+	subject := leaf.Subject.String()
+	if now.After(leaf.NotAfter) {
+		return nil, fmt.Errorf("client certificate %q expired %s ago (not after %s)", subject, duration.Format(now.Sub(leaf.NotAfter)), leaf.NotAfter.UTC().Format(time.RFC3339))
+	}
+	if now.Before(leaf.NotBefore) {
+		return nil, fmt.Errorf("client certificate %q is not valid for another %s (not before %s)", subject, duration.Format(leaf.NotBefore.Sub(now)), leaf.NotBefore.UTC().Format(time.RFC3339))
+	}
+
+	keyBlock := findPEMBlock(keyPEM, func(blockType string) bool { return strings.HasSuffix(blockType, "PRIVATE KEY") })
+	if keyBlock == nil {
+		return nil, fmt.Errorf("no PEM private key found")
+	}
+	key, err := parseClientKey(keyBlock, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if !publicKeysEqual(leaf.PublicKey, key.Public()) {
+		return nil, fmt.Errorf("client certificate %q does not match the private key", subject)
+	}
+
+	certificate := &tls.Certificate{PrivateKey: key, Leaf: leaf}
+	for rest := certificatePEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			certificate.Certificate = append(certificate.Certificate, block.Bytes)
+		}
+	}
+	return certificate, nil
+}
+
+func parseClientKey(block *pem.Block, passphrase []byte) (crypto.Signer, error) {
+	if _, encrypted := block.Headers["Proc-Type"]; encrypted {
+		return nil, fmt.Errorf("legacy PEM encryption is not supported; convert the key with openssl pkcs8 -topk8 -v2 aes-256-cbc")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "ENCRYPTED PRIVATE KEY":
+		if len(passphrase) == 0 {
+			return nil, ErrPassphraseRequired
+		}
+		der, decryptErr := decryptPKCS8(block.Bytes, passphrase)
+		if decryptErr != nil {
+			return nil, decryptErr
+		}
+		if parsed, err = x509.ParsePKCS8PrivateKey(der); err != nil {
+			return nil, ErrIncorrectPassphrase
+		}
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", block.Type, err)
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+	return signer, nil
+}
+
+func findPEMBlock(data []byte, match func(string) bool) *pem.Block {
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil || match(block.Type) {
+			return block
+		}
+	}
+}
+
+func publicKeysEqual(first, second crypto.PublicKey) bool {
+	key, ok := first.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(second)
+}
@@ -0,0 +1,239 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadClientCertificate(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	key := generateTestKey(t)
+	otherKey := generateTestKey(t)
+	certificatePEM := createTestCertificate(t, key, now.Add(-time.Hour), now.Add(24*time.Hour))
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+	otherDER, err := x509.MarshalPKCS8PrivateKey(otherKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	encryptedPEM := pemEncode("ENCRYPTED PRIVATE KEY", encryptTestPKCS8(t, pkcs8DER, "correct horse"))
+
+	tests := []struct {
+		name        string
+		certificate []byte
+		key         []byte
+		passphrase  string
+		wantErr     error
+		wantContain string
+	}{
+		{name: "PKCS #8 key", certificate: certificatePEM, key: pemEncode("PRIVATE KEY", pkcs8DER)},
+		{name: "SEC 1 key", certificate: certificatePEM, key: pemEncode("EC PRIVATE KEY", ecDER)},
+		{name: "encrypted PKCS #8 key", certificate: certificatePEM, key: encryptedPEM, passphrase: "correct horse"},
+		{name: "combined certificate and key file", certificate: certificatePEM, key: append(append([]byte(nil), certificatePEM...), pemEncode("PRIVATE KEY", pkcs8DER)...)},
+		{name: "missing passphrase", certificate: certificatePEM, key: encryptedPEM, wantErr: ErrPassphraseRequired},
+		{name: "wrong passphrase", certificate: certificatePEM, key: encryptedPEM, passphrase: "battery staple", wantErr: ErrIncorrectPassphrase},
+		{
+			name:        "legacy encryption",
+			certificate: certificatePEM,
+			key:         pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Headers: map[string]string{"Proc-Type": "4,ENCRYPTED"}, Bytes: ecDER}),
+			wantContain: "legacy PEM encryption is not supported",
+		},
+		{
+			name:        "expired",
+			certificate: createTestCertificate(t, key, now.Add(-48*time.Hour), now.Add(-90*time.Minute)),
+			key:         pemEncode("PRIVATE KEY", pkcs8DER),
+			wantContain: `client certificate "CN=radosgw-assume test" expired 1h 30m ago (not after 2026-03-01T10:30:00Z)`,
+		},
+		{
+			name:        "not yet valid",
+			certificate: createTestCertificate(t, key, now.Add(10*time.Minute), now.Add(48*time.Hour)),
+			key:         pemEncode("PRIVATE KEY", pkcs8DER),
+			wantContain: "is not valid for another 10m",
+		},
+		{
+			name:        "mismatched key",
+			certificate: certificatePEM,
+			key:         pemEncode("PRIVATE KEY", otherDER),
+			wantContain: `client certificate "CN=radosgw-assume test" does not match the private key`,
+		},
+		{name: "no certificate", certificate: []byte("not PEM"), key: pemEncode("PRIVATE KEY", pkcs8DER), wantContain: "no PEM certificate found"},
+		{name: "no key", certificate: certificatePEM, key: certificatePEM, wantContain: "no PEM private key found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			certificate, err := LoadClientCertificate(test.certificate, test.key, []byte(test.passphrase), now)
+			switch {
+			case test.wantErr != nil:
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("LoadClientCertificate() error = %v, want %v", err, test.wantErr)
+				}
+				return
+			case test.wantContain != "":
+				if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+					t.Fatalf("LoadClientCertificate() error = %v, want containing %q", err, test.wantContain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadClientCertificate() error = %v", err)
+			}
+			if len(certificate.Certificate) != 1 || certificate.Leaf == nil {
+				t.Errorf("certificate chain = %d entries, leaf = %v", len(certificate.Certificate), certificate.Leaf)
+			}
+			if signer, ok := certificate.PrivateKey.(crypto.Signer); !ok || !publicKeysEqual(&key.PublicKey, signer.Public()) {
+				t.Error("certificate private key does not match the generated key")
+			}
+		})
+	}
+}
+
+func TestNewPresentsClientCertificate(t *testing.T) {
+	key := generateTestKey(t)
+	certificatePEM := createTestCertificate(t, key, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error = %v", err)
+	}
+	certificate, err := LoadClientCertificate(certificatePEM, pemEncode("PRIVATE KEY", keyDER), nil, time.Now())
+	if err != nil {
+		t.Fatalf("LoadClientCertificate() error = %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if len(request.TLS.PeerCertificates) == 0 {
+			t.Error("server received no client certificate")
+		}
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(certificate.Leaf)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	for _, test := range []struct {
+		name    string
+		options Options
+		wantErr bool
+	}{
+		{name: "with certificate", options: Options{ClientCertificate: certificate}},
+		{name: "without certificate", options: Options{}, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := New(test.options, 5*time.Second).Get(server.URL)
+			if response != nil {
+				_ = response.Body.Close()
+			}
+			if (err != nil) != test.wantErr {
+				t.Errorf("Get() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func generateTestKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	return key
+}
+
+func createTestCertificate(t *testing.T, key *ecdsa.PrivateKey, notBefore, notAfter time.Time) []byte {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "radosgw-assume test"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	return pemEncode("CERTIFICATE", der)
+}
+
+// encryptTestPKCS8 produces the same PBES2 structure as
+// "openssl pkcs8 -topk8 -v2 aes-256-cbc -v2prf hmacWithSHA256".
+func encryptTestPKCS8(t *testing.T, der []byte, passphrase string) []byte {
+	t.Helper()
+
+	salt := []byte("0123456789abcdef")
+	iv := []byte("fedcba9876543210")
+	const iterations = 2048
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		t.Fatalf("pbkdf2.Key() error = %v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	plaintext := append(append([]byte(nil), der...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+
+	kdfParameters := marshalTestASN1(t, pbkdf2Parameters{
+		Salt:           salt,
+		IterationCount: iterations,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	schemeParameters := marshalTestASN1(t, iv)
+	pbes2 := marshalTestASN1(t, pbes2Parameters{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParameters}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: schemeParameters}},
+	})
+	return marshalTestASN1(t, encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: pbes2}},
+		EncryptedData: ciphertext,
+	})
+}
+
+func marshalTestASN1(t *testing.T, value any) []byte {
+	t.Helper()
+
+	data, err := asn1.Marshal(value)
+	if err != nil {
+		t.Fatalf("asn1.Marshal() error = %v", err)
+	}
+	return data
+}
+
+func pemEncode(blockType string, data []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data})
+}
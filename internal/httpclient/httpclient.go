@@ -3,23 +3,53 @@
 package httpclient
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
 	"net/http"
+	"net/url"
 	"time"
 )
 
-// New returns an HTTP client with the supplied timeout. When TLS verification
-// is disabled, the default transport is cloned so its proxy, connection pool,
-// and HTTP/2 settings are preserved without mutating global state.
-func New(verifyTLS bool, requestTimeout time.Duration) *http.Client {
+// Options configures the TLS and proxy behavior of clients returned by New.
+type Options struct {
+	// VerifyTLS enables server certificate verification.
+	VerifyTLS bool
+	// ClientCertificate is presented to servers that request one, for mutual
+	// TLS and certificate-bound tokens (RFC 8705).
+	ClientCertificate *tls.Certificate
+	// RootCAs replaces the system roots when verifying servers. Build it with
+	// LoadCertPool to keep the system roots.
+	RootCAs *x509.CertPool
+	// PinnedSHA256 lists SHA-256 fingerprints of accepted server
+	// certificates. When set, a matching certificate is required even if
+	// VerifyTLS is false, and it is accepted without chain verification.
+	PinnedSHA256 [][sha256.Size]byte
+	// ProxyURL replaces the environment proxy. http, https, socks5 and
+	// socks5h URLs are supported.
+	ProxyURL *url.URL
+	// NoProxy lists hosts that bypass ProxyURL or the environment proxy, in
+	// the NO_PROXY format.
+	NoProxy string
+	// OnProxy is called with the proxy chosen for each request, or nil when
+	// the request connects directly.
+	OnProxy func(target, proxy *url.URL)
+}
+
+// New returns an HTTP client with the supplied timeout. When the TLS or proxy
+// settings differ from the defaults, the default transport is cloned so its
+// connection pool and HTTP/2 settings are preserved without mutating global
+// state.
+func New(options Options, requestTimeout time.Duration) *http.Client {
 	client := &http.Client{Timeout: requestTimeout}
-	if !verifyTLS {
-		client.Transport = insecureDefaultTransport()
+	if !options.VerifyTLS || options.ClientCertificate != nil || options.RootCAs != nil || len(options.PinnedSHA256) > 0 ||
+		options.ProxyURL != nil || options.NoProxy != "" || options.OnProxy != nil {
+		client.Transport = defaultTransportWithOptions(options)
 	}
 	return client
 }
 
-func insecureDefaultTransport() *http.Transport {
+func defaultTransportWithOptions(options Options) *http.Transport {
 	defaultTransport, ok := http.DefaultTransport.(*http.Transport)
 	if !ok {
 		defaultTransport = &http.Transport{Proxy: http.ProxyFromEnvironment}
@@ -31,7 +61,20 @@ func insecureDefaultTransport() *http.Transport {
 	} else {
 		transport.TLSClientConfig = transport.TLSClientConfig.Clone()
 	}
-	transport.TLSClientConfig.InsecureSkipVerify = true
+	transport.TLSClientConfig.InsecureSkipVerify = !options.VerifyTLS
+	if options.RootCAs != nil {
+		transport.TLSClientConfig.RootCAs = options.RootCAs
+	}
+	if len(options.PinnedSHA256) > 0 {
+		transport.TLSClientConfig.InsecureSkipVerify = true
+		transport.TLSClientConfig.VerifyConnection = verifyPinnedCertificate(options.PinnedSHA256)
+	}
+	if options.ClientCertificate != nil {
+		transport.TLSClientConfig.Certificates = []tls.Certificate{*options.ClientCertificate}
+	}
+	if options.ProxyURL != nil || options.NoProxy != "" || options.OnProxy != nil {
+		transport.Proxy = proxyFunc(options.ProxyURL, options.NoProxy, transport.Proxy, options.OnProxy)
+	}
 
 	return transport
 }
@@ -2,6 +2,7 @@ package httpclient
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"net/http"
 	"net/url"
@@ -17,26 +18,37 @@ func (fn roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error)
 
 func TestNew(t *testing.T) {
 	const requestTimeout = 15 * time.Second
+	certificate := &tls.Certificate{Certificate: [][]byte{[]byte("test certificate")}}
 	tests := []struct {
 		name          string
-		verifyTLS     bool
+		options       Options
 		wantTransport bool
 	}{
 		{
 			name:          "TLS verification enabled",
-			verifyTLS:     true,
+			options:       Options{VerifyTLS: true},
 			wantTransport: false,
 		},
 		{
 			name:          "TLS verification disabled",
-			verifyTLS:     false,
+			options:       Options{VerifyTLS: false},
+			wantTransport: true,
+		},
+		{
+			name:          "client certificate",
+			options:       Options{VerifyTLS: true, ClientCertificate: certificate},
+			wantTransport: true,
+		},
+		{
+			name:          "custom roots",
+			options:       Options{VerifyTLS: true, RootCAs: x509.NewCertPool()},
 			wantTransport: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			client := New(tt.verifyTLS, requestTimeout)
+			client := New(tt.options, requestTimeout)
 			if client.Timeout != requestTimeout {
 				t.Errorf("client timeout = %v, want %v", client.Timeout, requestTimeout)
 			}
@@ -53,8 +65,18 @@ func TestNew(t *testing.T) {
 			if !ok {
 				t.Fatalf("client transport = %T, want *http.Transport", client.Transport)
 			}
-			if transport.TLSClientConfig == nil || !transport.TLSClientConfig.InsecureSkipVerify {
-				t.Fatal("client transport did not disable TLS verification")
+			if transport.TLSClientConfig == nil || transport.TLSClientConfig.InsecureSkipVerify == tt.options.VerifyTLS {
+				t.Fatalf("client transport InsecureSkipVerify does not match VerifyTLS = %v", tt.options.VerifyTLS)
+			}
+			if tt.options.ClientCertificate != nil {
+				if len(transport.TLSClientConfig.Certificates) != 1 || string(transport.TLSClientConfig.Certificates[0].Certificate[0]) != "test certificate" {
+					t.Errorf("client certificates = %v, want the configured certificate", transport.TLSClientConfig.Certificates)
+				}
+			} else if len(transport.TLSClientConfig.Certificates) != 0 {
+				t.Errorf("client certificates = %v, want none", transport.TLSClientConfig.Certificates)
+			}
+			if transport.TLSClientConfig.RootCAs != tt.options.RootCAs {
+				t.Error("client transport RootCAs does not match the configured pool")
 			}
 			assertClonedDefaultTransport(t, transport)
 		})
@@ -89,8 +111,8 @@ func assertClonedDefaultTransport(t *testing.T, transport *http.Transport) {
 }
 
 func TestNewUsesIndependentTLSConfigurations(t *testing.T) {
-	firstTransport := New(false, time.Second).Transport.(*http.Transport)
-	secondTransport := New(false, time.Second).Transport.(*http.Transport)
+	firstTransport := New(Options{}, time.Second).Transport.(*http.Transport)
+	secondTransport := New(Options{}, time.Second).Transport.(*http.Transport)
 
 	firstTransport.TLSClientConfig.ServerName = "first.example.com"
 	if secondTransport.TLSClientConfig.ServerName != "" {
@@ -110,7 +132,7 @@ func TestNewClonesDefaultTLSConfiguration(t *testing.T) {
 	http.DefaultTransport = customDefault
 	t.Cleanup(func() { http.DefaultTransport = originalDefault })
 
-	transport := New(false, time.Second).Transport.(*http.Transport)
+	transport := New(Options{}, time.Second).Transport.(*http.Transport)
 	if transport.TLSClientConfig == customDefault.TLSClientConfig {
 		t.Fatal("client transport reused the default TLS configuration")
 	}
@@ -129,7 +151,7 @@ func TestNewHandlesCustomDefaultRoundTripper(t *testing.T) {
 	})
 	t.Cleanup(func() { http.DefaultTransport = originalDefault })
 
-	transport := New(false, time.Second).Transport.(*http.Transport)
+	transport := New(Options{}, time.Second).Transport.(*http.Transport)
 	if transport.Proxy == nil {
 		t.Fatal("fallback transport did not configure environment proxy support")
 	}
@@ -140,7 +162,7 @@ func TestNewHandlesCustomDefaultRoundTripper(t *testing.T) {
 
 func TestClientTimeout(t *testing.T) {
 	const requestTimeout = 10 * time.Millisecond
-	client := New(true, requestTimeout)
+	client := New(Options{VerifyTLS: true}, requestTimeout)
 	client.Transport = roundTripFunc(func(request *http.Request) (*http.Response, error) {
 		select {
 		case <-request.Context().Done():
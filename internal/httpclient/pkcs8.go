@@ -0,0 +1,149 @@
+package httpclient
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// ErrIncorrectPassphrase is returned when an encrypted private key cannot be
+// decrypted with the supplied passphrase.
+var ErrIncorrectPassphrase = errors.New("incorrect passphrase or corrupt encrypted key")
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC     = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+// encryptedPrivateKeyInfo is the RFC 5208 section 6 structure found in
+// "ENCRYPTED PRIVATE KEY" PEM blocks.
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Parameters struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Parameters struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// decryptPKCS8 decrypts a PKCS #8 EncryptedPrivateKeyInfo protected with
+// PBES2 and PBKDF2 (RFC 8018) using AES-CBC or the DES-EDE3-CBC that some
+// OpenSSL commands still default to. It returns the DER PrivateKeyInfo.
+func decryptPKCS8(der []byte, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("parse encrypted private key: malformed EncryptedPrivateKeyInfo")
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported private key encryption %s; re-encrypt with openssl pkcs8 -topk8 -v2 aes-256-cbc", info.Algorithm.Algorithm)
+	}
+	var parameters pbes2Parameters
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &parameters); err != nil {
+		return nil, fmt.Errorf("parse PBES2 parameters: %w", err)
+	}
+	if !parameters.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation function %s", parameters.KeyDerivationFunc.Algorithm)
+	}
+	var kdf pbkdf2Parameters
+	if _, err := asn1.Unmarshal(parameters.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("parse PBKDF2 parameters: %w", err)
+	}
+
+	keyLength, newCipher, err := pbes2Cipher(parameters.EncryptionScheme.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if kdf.KeyLength != 0 && kdf.KeyLength != keyLength {
+		return nil, fmt.Errorf("PBKDF2 key length %d does not match the %d-byte cipher key", kdf.KeyLength, keyLength)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(parameters.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("parse cipher parameters: %w", err)
+	}
+	prf, err := pbkdf2PRF(kdf.PRF.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := pbkdf2.Key(prf, string(passphrase), kdf.Salt, kdf.IterationCount, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	blockSize := block.BlockSize()
+	if len(iv) != blockSize {
+		return nil, fmt.Errorf("parse cipher parameters: IV is %d bytes, want %d", len(iv), blockSize)
+	}
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%blockSize != 0 {
+		return nil, ErrIncorrectPassphrase
+	}
+	plaintext := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, info.EncryptedData)
+
+	// A wrong passphrase almost always leaves invalid PKCS #7 padding.
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > blockSize || !bytes.Equal(plaintext[len(plaintext)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, ErrIncorrectPassphrase
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
+
+func pbes2Cipher(algorithm asn1.ObjectIdentifier) (int, func([]byte) (cipher.Block, error), error) {
+	switch {
+	case algorithm.Equal(oidAES128CBC):
+		return 16, aes.NewCipher, nil
+	case algorithm.Equal(oidAES192CBC):
+		return 24, aes.NewCipher, nil
+	case algorithm.Equal(oidAES256CBC):
+		return 32, aes.NewCipher, nil
+	case algorithm.Equal(oidDESEDE3CBC):
+		return 24, des.NewTripleDESCipher, nil
+	default:
+		return 0, nil, fmt.Errorf("unsupported private key cipher %s; re-encrypt with openssl pkcs8 -topk8 -v2 aes-256-cbc", algorithm)
+	}
+}
+
+func pbkdf2PRF(algorithm asn1.ObjectIdentifier) (func() hash.Hash, error) {
+	switch {
+	// RFC 8018 makes HMAC-SHA1 the default when the PRF is omitted.
+	case len(algorithm) == 0, algorithm.Equal(oidHMACWithSHA1):
+		return sha1.New, nil
+	case algorithm.Equal(oidHMACWithSHA256):
+		return sha256.New, nil
+	case algorithm.Equal(oidHMACWithSHA384):
+		return sha512.New384, nil
+	case algorithm.Equal(oidHMACWithSHA512):
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported PBKDF2 pseudorandom function %s", algorithm)
+	}
+}
@@ -0,0 +1,82 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// proxyFunc selects the proxy for each request. Requests to hosts matching
+// noProxy connect directly; otherwise proxyURL is used when set and the
+// environment proxy (HTTPS_PROXY, HTTP_PROXY, NO_PROXY) when not. report, if
+// set, receives every decision, with a nil proxy for direct connections.
+func proxyFunc(proxyURL *url.URL, noProxy string, environment func(*http.Request) (*url.URL, error), report func(*url.URL, *url.URL)) func(*http.Request) (*url.URL, error) {
+	return func(request *http.Request) (*url.URL, error) {
+		var proxy *url.URL
+		if !bypassProxy(noProxy, request.URL) {
+			switch {
+			case proxyURL != nil:
+				proxy = proxyURL
+			case environment != nil:
+				var err error
+				if proxy, err = environment(request); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if report != nil {
+			report(request.URL, proxy)
+		}
+		return proxy, nil
+	}
+}
+
+// bypassProxy reports whether target matches the comma-separated noProxy
+// list. Entries follow the NO_PROXY conventions: "*" matches every host, IP
+// addresses and CIDR ranges match literal addresses, and a domain matches
+// itself and its subdomains, or only subdomains with a leading dot. An entry
+// with a port only matches that port.
+func bypassProxy(noProxy string, target *url.URL) bool {
+	host := strings.ToLower(target.Hostname())
+	port := target.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[target.Scheme]
+	}
+	address, addressErr := netip.ParseAddr(host)
+
+	for entry := range strings.SplitSeq(strings.ToLower(noProxy), ",") {
+		entry = strings.TrimSpace(entry)
+		switch {
+		case entry == "":
+			continue
+		case entry == "*":
+			return true
+		}
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			if addressErr == nil && prefix.Contains(address) {
+				return true
+			}
+			continue
+		}
+		entryHost, entryPort := entry, ""
+		if splitHost, splitPort, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = splitHost, splitPort
+		}
+		entryHost = strings.Trim(entryHost, "[]")
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if strings.HasPrefix(entryHost, ".") {
+			if strings.HasSuffix(host, entryHost) {
+				return true
+			}
+			continue
+		}
+		if host == entryHost || strings.HasSuffix(host, "."+entryHost) {
+			return true
+		}
+	}
+	return false
+}
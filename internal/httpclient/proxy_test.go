@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestBypassProxy(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		noProxy string
+		target  string
+		want    bool
+	}{
+		{name: "empty list", target: "https://storage.example.com"},
+		{name: "wildcard", noProxy: "*", target: "https://storage.example.com", want: true},
+		{name: "exact host", noProxy: "storage.example.com", target: "https://storage.example.com/", want: true},
+		{name: "subdomain", noProxy: "example.com", target: "https://storage.example.com", want: true},
+		{name: "suffix is not a subdomain", noProxy: "example.com", target: "https://storage-example.com"},
+		{name: "leading dot skips the domain itself", noProxy: ".example.com", target: "https://example.com"},
+		{name: "leading dot matches subdomains", noProxy: ".example.com", target: "https://storage.example.com", want: true},
+		{name: "list with spaces and case", noProxy: "oidc.example.com, Storage.Internal", target: "https://storage.internal:8443", want: true},
+		{name: "matching port", noProxy: "storage.internal:8443", target: "https://storage.internal:8443", want: true},
+		{name: "default port", noProxy: "storage.internal:443", target: "https://storage.internal", want: true},
+		{name: "other port", noProxy: "storage.internal:8443", target: "https://storage.internal"},
+		{name: "IP address", noProxy: "10.0.0.5", target: "http://10.0.0.5:7480", want: true},
+		{name: "CIDR range", noProxy: "10.0.0.0/8", target: "http://10.1.2.3:7480", want: true},
+		{name: "CIDR range excludes names", noProxy: "10.0.0.0/8", target: "http://storage.internal"},
+		{name: "IPv6 address", noProxy: "[::1]", target: "http://[::1]:7480", want: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			target, err := url.Parse(test.target)
+			if err != nil {
+				t.Fatalf("url.Parse() error = %v", err)
+			}
+			if got := bypassProxy(test.noProxy, target); got != test.want {
+				t.Errorf("bypassProxy(%q, %q) = %v, want %v", test.noProxy, test.target, got, test.want)
+			}
+		})
+	}
+}
+
+func TestProxyFunc(t *testing.T) {
+	configured := &url.URL{Scheme: "socks5", Host: "proxy.example.com:1080"}
+	environmentProxy := &url.URL{Scheme: "http", Host: "environment.example.com:3128"}
+	environment := func(*http.Request) (*url.URL, error) { return environmentProxy, nil }
+
+	for _, test := range []struct {
+		name        string
+		proxyURL    *url.URL
+		noProxy     string
+		environment func(*http.Request) (*url.URL, error)
+		want        *url.URL
+	}{
+		{name: "configured proxy", proxyURL: configured, environment: environment, want: configured},
+		{name: "environment proxy", environment: environment, want: environmentProxy},
+		{name: "no proxy", proxyURL: configured, noProxy: "storage.internal", environment: environment},
+		{name: "no proxy for environment proxy", noProxy: "*", environment: environment},
+		{name: "no environment proxy"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var reported []*url.URL
+			proxy := proxyFunc(test.proxyURL, test.noProxy, test.environment, func(_ *url.URL, proxy *url.URL) {
+				reported = append(reported, proxy)
+			})
+			request := httptest.NewRequest(http.MethodGet, "https://storage.internal/", nil)
+
+			got, err := proxy(request)
+			if err != nil {
+				t.Fatalf("proxy() error = %v", err)
+			}
+			if got != test.want {
+				t.Errorf("proxy() = %v, want %v", got, test.want)
+			}
+			if len(reported) != 1 || reported[0] != test.want {
+				t.Errorf("reported proxies = %v, want [%v]", reported, test.want)
+			}
+		})
+	}
+}
+
+func TestNewSendsRequestsThroughProxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		proxiedHost = request.URL.Host
+	}))
+	t.Cleanup(proxy.Close)
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+
+	response, err := New(Options{VerifyTLS: true, ProxyURL: proxyURL}, 5*time.Second).Get("http://storage.internal:7480/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = response.Body.Close()
+	if proxiedHost != "storage.internal:7480" {
+		t.Errorf("proxied host = %q, want storage.internal:7480", proxiedHost)
+	}
+}
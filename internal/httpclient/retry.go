@@ -0,0 +1,137 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 250 * time.Millisecond
+	defaultRetryMaxDelay  = 4 * time.Second
+)
+
+// RetryPolicy bounds the retries of an idempotent operation. Delays grow
+// exponentially from BaseDelay up to MaxDelay with random jitter, so clients
+// that failed together do not retry together.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts. Values below 2 disable
+	// retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// OnRetry is called before waiting for the next attempt.
+	OnRetry func(RetryAttempt)
+
+	sleep  func(context.Context, time.Duration) error
+	jitter func(time.Duration) time.Duration
+}
+
+// RetryAttempt describes a failed attempt that is about to be retried.
+type RetryAttempt struct {
+	Operation   string
+	Attempt     int
+	MaxAttempts int
+	Delay       time.Duration
+	Err         error
+}
+
+// Retry calls attempt until it succeeds, returns an error that retryable
+// rejects, or the policy runs out of attempts. It never waits past the
+// context deadline: when the next delay would end after it, the last error is
+// returned immediately.
+func Retry(ctx context.Context, policy RetryPolicy, operation string, attempt func() error, retryable func(error) bool) error {
+	maxAttempts := max(policy.MaxAttempts, 1)
+	for number := 1; ; number++ {
+		err := attempt()
+		if err == nil || number >= maxAttempts || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+
+		delay := policy.delay(number)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			return err
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(RetryAttempt{Operation: operation, Attempt: number, MaxAttempts: maxAttempts, Delay: delay, Err: err})
+		}
+		sleep := policy.sleep
+		if sleep == nil {
+			sleep = sleepWithContext
+		}
+		if sleep(ctx, delay) != nil {
+			return err
+		}
+	}
+}
+
+// delay returns the wait after the given failed attempt: half of the
+// exponential backoff plus a random share of the other half.
+func (policy RetryPolicy) delay(attempt int) time.Duration {
+	baseDelay := policy.BaseDelay
+	if baseDelay <= 0 {
+		baseDelay = defaultRetryBaseDelay
+	}
+	maxDelay := policy.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+	backoff := maxDelay
+	if attempt-1 < 32 && baseDelay<<(attempt-1) < maxDelay {
+		backoff = baseDelay << (attempt - 1)
+	}
+
+	jitter := policy.jitter
+	if jitter == nil {
+		jitter = func(limit time.Duration) time.Duration { return rand.N(limit + 1) }
+	}
+	return backoff/2 + jitter(backoff/2)
+}
+
+// IsTransientNetworkError reports whether err is a connection failure that is
+// likely to succeed when retried: timeouts, refused or reset connections,
+// temporary DNS failures and connections closed mid-response. Certificate and
+// name resolution errors are permanent.
+func IsTransientNetworkError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) {
+		return dnsError.IsTimeout || dnsError.IsTemporary
+	}
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// IsTransientStatus reports whether an HTTP status code indicates a failure
+// that is worth retrying: rate limiting and server errors, which include the
+// 502 and 504 responses of a load balancer whose backend is restarting.
+func IsTransientStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+func sleepWithContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
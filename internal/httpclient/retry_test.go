@@ -0,0 +1,180 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	errTransient := errors.New("transient")
+	errPermanent := errors.New("permanent")
+
+	for _, test := range []struct {
+		name         string
+		maxAttempts  int
+		results      []error
+		wantAttempts int
+		wantErr      error
+		wantDelays   []time.Duration
+	}{
+		{name: "success", maxAttempts: 3, results: []error{nil}, wantAttempts: 1},
+		{name: "retries disabled", maxAttempts: 1, results: []error{errTransient}, wantAttempts: 1, wantErr: errTransient},
+		{name: "zero attempts makes one", results: []error{errTransient}, wantAttempts: 1, wantErr: errTransient},
+		{
+			name:         "recovers",
+			maxAttempts:  3,
+			results:      []error{errTransient, errTransient, nil},
+			wantAttempts: 3,
+			wantDelays:   []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{
+			name:         "exhausted",
+			maxAttempts:  3,
+			results:      []error{errTransient, errTransient, errTransient},
+			wantAttempts: 3,
+			wantErr:      errTransient,
+			wantDelays:   []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{name: "permanent error", maxAttempts: 3, results: []error{errPermanent}, wantAttempts: 1, wantErr: errPermanent},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var delays []time.Duration
+			var reported []RetryAttempt
+			policy := RetryPolicy{
+				MaxAttempts: test.maxAttempts,
+				BaseDelay:   100 * time.Millisecond,
+				MaxDelay:    time.Second,
+				OnRetry:     func(attempt RetryAttempt) { reported = append(reported, attempt) },
+				sleep: func(_ context.Context, delay time.Duration) error {
+					delays = append(delays, delay)
+					return nil
+				},
+				jitter: func(limit time.Duration) time.Duration { return limit },
+			}
+			attempts := 0
+
+			err := Retry(t.Context(), policy, "discovery", func() error {
+				attempts++
+				return test.results[attempts-1]
+			}, func(err error) bool { return errors.Is(err, errTransient) })
+
+			if !errors.Is(err, test.wantErr) || (test.wantErr == nil && err != nil) {
+				t.Errorf("Retry() error = %v, want %v", err, test.wantErr)
+			}
+			if attempts != test.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, test.wantAttempts)
+			}
+			if fmt.Sprint(delays) != fmt.Sprint(test.wantDelays) {
+				t.Errorf("delays = %v, want %v", delays, test.wantDelays)
+			}
+			if len(reported) != len(test.wantDelays) {
+				t.Fatalf("reported %d retries, want %d", len(reported), len(test.wantDelays))
+			}
+			for index, attempt := range reported {
+				if attempt.Operation != "discovery" || attempt.Attempt != index+1 || attempt.MaxAttempts != test.maxAttempts || attempt.Delay != test.wantDelays[index] || attempt.Err == nil {
+					t.Errorf("reported retry %d = %+v", index, attempt)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryStopsBeforeDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	errTransient := errors.New("transient")
+	attempts := 0
+
+	err := Retry(ctx, RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second}, "STS", func() error {
+		attempts++
+		return errTransient
+	}, func(error) bool { return true })
+
+	if !errors.Is(err, errTransient) || attempts != 1 {
+		t.Errorf("Retry() = %v after %d attempts, want the first error without waiting", err, attempts)
+	}
+}
+
+func TestRetryStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	errTransient := errors.New("transient")
+	attempts := 0
+
+	err := Retry(ctx, RetryPolicy{MaxAttempts: 5}, "STS", func() error {
+		attempts++
+		cancel()
+		return errTransient
+	}, func(error) bool { return true })
+
+	if !errors.Is(err, errTransient) || attempts != 1 {
+		t.Errorf("Retry() = %v after %d attempts, want one attempt", err, attempts)
+	}
+}
+
+func TestRetryDelayIsBounded(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second, jitter: func(time.Duration) time.Duration { return 0 }}
+	for attempt, want := range map[int]time.Duration{1: 500 * time.Millisecond, 2: time.Second, 3: 2 * time.Second, 4: 2500 * time.Millisecond, 40: 2500 * time.Millisecond} {
+		if got := policy.delay(attempt); got != want {
+			t.Errorf("delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+	for range 100 {
+		if got := (RetryPolicy{}).delay(1); got < defaultRetryBaseDelay/2 || got > defaultRetryBaseDelay {
+			t.Fatalf("default delay(1) = %v, want between %v and %v", got, defaultRetryBaseDelay/2, defaultRetryBaseDelay)
+		}
+	}
+}
+
+func TestIsTransientNetworkError(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil"},
+		{name: "timeout", err: &net.OpError{Op: "dial", Err: timeoutError{}}, want: true},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "connection reset", err: fmt.Errorf("read: %w", syscall.ECONNRESET), want: true},
+		{name: "closed mid-response", err: fmt.Errorf("read body: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "temporary DNS failure", err: &net.DNSError{Err: "server misbehaving", IsTemporary: true}, want: true},
+		{name: "unknown host", err: &net.DNSError{Err: "no such host", IsNotFound: true}},
+		{name: "canceled", err: fmt.Errorf("request: %w", context.Canceled)},
+		{name: "other error", err: errors.New("x509: certificate signed by unknown authority")},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsTransientNetworkError(test.err); got != test.want {
+				t.Errorf("IsTransientNetworkError(%v) = %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsTransientStatus(t *testing.T) {
+	for statusCode, want := range map[int]bool{
+		http.StatusOK:                  false,
+		http.StatusBadRequest:          false,
+		http.StatusUnauthorized:        false,
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusBadGateway:          true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusGatewayTimeout:      true,
+	} {
+		if got := IsTransientStatus(statusCode); got != want {
+			t.Errorf("IsTransientStatus(%d) = %v, want %v", statusCode, got, want)
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,69 @@
+package httpclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"slices"
+)
+
+// LoadCertPool returns the system roots extended with the PEM certificates in
+// bundle, so a private CA can be trusted without disabling verification.
+func LoadCertPool(bundle []byte) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("no PEM certificates found")
+	}
+	return pool, nil
+}
+
+// verifyPinnedCertificate accepts a connection when the server's own
+// certificate has one of the pinned SHA-256 fingerprints, which lets
+// self-signed servers be trusted individually. A pinned intermediate or root
+// CA in the presented chain is only accepted as the sole trust anchor of a
+// verified chain for the server name; the server chooses which certificates
+// it presents, so finding a pinned one among them proves nothing by itself.
+func verifyPinnedCertificate(pins [][sha256.Size]byte) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return fmt.Errorf("certificate for %s (SHA-256 none) does not match any pinned fingerprint", state.ServerName)
+		}
+		leaf := state.PeerCertificates[0]
+		leafSum := sha256.Sum256(leaf.Raw)
+		if slices.Contains(pins, leafSum) {
+			return nil
+		}
+		for _, certificate := range state.PeerCertificates[1:] {
+			if slices.Contains(pins, sha256.Sum256(certificate.Raw)) && verifyPinnedChain(state, certificate) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("certificate for %s (SHA-256 %s) does not match any pinned fingerprint", state.ServerName, hex.EncodeToString(leafSum[:]))
+	}
+}
+
+// verifyPinnedChain verifies the server certificate with pinned as the only
+// root. Connections to an IP address carry no server name to verify, so they
+// can only be pinned to the server certificate itself.
+func verifyPinnedChain(state tls.ConnectionState, pinned *x509.Certificate) error {
+	if state.ServerName == "" {
+		return fmt.Errorf("no server name to verify against the pinned CA")
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(pinned)
+	intermediates := x509.NewCertPool()
+	for _, certificate := range state.PeerCertificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       state.ServerName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
@@ -0,0 +1,165 @@
+package httpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewVerifiesServerTrust(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	serverCertificate := server.Certificate()
+	rootCAs, err := LoadCertPool(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverCertificate.Raw}))
+	if err != nil {
+		t.Fatalf("LoadCertPool() error = %v", err)
+	}
+	pin := sha256.Sum256(serverCertificate.Raw)
+	otherPin := sha256.Sum256([]byte("other certificate"))
+
+	tests := []struct {
+		name        string
+		options     Options
+		wantContain string
+	}{
+		{name: "system roots", options: Options{VerifyTLS: true}, wantContain: "certificate signed by unknown authority"},
+		{name: "CA bundle", options: Options{VerifyTLS: true, RootCAs: rootCAs}},
+		{name: "pinned certificate", options: Options{VerifyTLS: true, PinnedSHA256: [][sha256.Size]byte{otherPin, pin}}},
+		{name: "pin mismatch", options: Options{VerifyTLS: true, PinnedSHA256: [][sha256.Size]byte{otherPin}}, wantContain: "does not match any pinned fingerprint"},
+		{name: "pin mismatch without verification", options: Options{PinnedSHA256: [][sha256.Size]byte{otherPin}}, wantContain: "does not match any pinned fingerprint"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := New(test.options, 5*time.Second).Get(server.URL)
+			if response != nil {
+				_ = response.Body.Close()
+			}
+			if test.wantContain == "" {
+				if err != nil {
+					t.Errorf("Get() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantContain) {
+				t.Errorf("Get() error = %v, want containing %q", err, test.wantContain)
+			}
+		})
+	}
+}
+
+func TestLoadCertPoolRejectsBundleWithoutCertificates(t *testing.T) {
+	if _, err := LoadCertPool([]byte("not PEM")); err == nil || err.Error() != "no PEM certificates found" {
+		t.Errorf("LoadCertPool() error = %v, want no PEM certificates found", err)
+	}
+}
+
+func TestNewRejectsPinnedCertificateAfterForeignLeaf(t *testing.T) {
+	pinnedDER := createTestServerCertificate(t, generateTestKey(t), "rgw.example.com", nil, nil)
+	foreignKey := generateTestKey(t)
+	foreignDER := createTestServerCertificate(t, foreignKey, "127.0.0.1", nil, nil)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{foreignDER, pinnedDER}, PrivateKey: foreignKey}}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	response, err := New(Options{VerifyTLS: true, PinnedSHA256: [][sha256.Size]byte{sha256.Sum256(pinnedDER)}}, 5*time.Second).Get(server.URL)
+	if response != nil {
+		_ = response.Body.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), "does not match any pinned fingerprint") {
+		t.Errorf("Get() error = %v, want the foreign leaf rejected", err)
+	}
+}
+
+func TestVerifyPinnedCertificate(t *testing.T) {
+	caKey := generateTestKey(t)
+	caDER := createTestServerCertificate(t, caKey, "", nil, nil)
+	ca := parseTestCertificate(t, caDER)
+	leaf := parseTestCertificate(t, createTestServerCertificate(t, generateTestKey(t), "rgw.example.com", ca, caKey))
+	foreign := parseTestCertificate(t, createTestServerCertificate(t, generateTestKey(t), "rgw.example.com", nil, nil))
+	leafPin := sha256.Sum256(leaf.Raw)
+	caPin := sha256.Sum256(ca.Raw)
+
+	for _, test := range []struct {
+		name       string
+		serverName string
+		chain      []*x509.Certificate
+		pin        [sha256.Size]byte
+		wantErr    bool
+	}{
+		{name: "pinned server certificate", serverName: "rgw.example.com", chain: []*x509.Certificate{leaf, ca}, pin: leafPin},
+		{name: "pinned server certificate by IP address", chain: []*x509.Certificate{leaf}, pin: leafPin},
+		{name: "pinned CA", serverName: "rgw.example.com", chain: []*x509.Certificate{leaf, ca}, pin: caPin},
+		{name: "pinned CA for another name", serverName: "other.example.com", chain: []*x509.Certificate{leaf, ca}, pin: caPin, wantErr: true},
+		{name: "pinned CA by IP address", chain: []*x509.Certificate{leaf, ca}, pin: caPin, wantErr: true},
+		{name: "pinned CA after foreign leaf", serverName: "rgw.example.com", chain: []*x509.Certificate{foreign, ca}, pin: caPin, wantErr: true},
+		{name: "pinned certificate after foreign leaf", serverName: "rgw.example.com", chain: []*x509.Certificate{foreign, leaf}, pin: leafPin, wantErr: true},
+		{name: "no certificates", serverName: "rgw.example.com", pin: leafPin, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := verifyPinnedCertificate([][sha256.Size]byte{test.pin})(tls.ConnectionState{ServerName: test.serverName, PeerCertificates: test.chain})
+			if (err != nil) != test.wantErr {
+				t.Errorf("verifyPinnedCertificate() error = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// createTestServerCertificate returns a DER server certificate for dnsName,
+// signed by parent or, without one, a self-signed CA certificate.
+func createTestServerCertificate(t *testing.T, key *ecdsa.PrivateKey, dnsName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) []byte {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "radosgw-assume test " + dnsName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	if ip := net.ParseIP(dnsName); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else if dnsName != "" {
+		template.DNSNames = []string{dnsName}
+	}
+	if parent == nil {
+		template.IsCA = true
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	return der
+}
+
+func parseTestCertificate(t *testing.T, der []byte) *x509.Certificate {
+	t.Helper()
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	return certificate
+}
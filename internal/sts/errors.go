@@ -7,11 +7,14 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"syscall"
 	"time"
 
 	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
 	"github.com/fitbeard/radosgw-assume/pkg/duration"
 )
 
@@ -74,6 +77,62 @@ func formatSTSError(err error, endpointURL, roleArn string, sessionDuration time
 	return fmt.Errorf("failed to assume role '%s' via endpoint '%s': %w", roleArn, endpointURL, err)
 }
 
+// formatChainedRoleError explains AssumeRole failures. The request is signed
+// with the previous role's credentials, so a denial points at the chained
+// role's trust policy rather than at the OIDC token.
+func formatChainedRoleError(err error, endpointURL, roleArn string, sessionDuration time.Duration) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "AccessDenied":
+			return newUserFacingError(err, "access denied: cannot assume role '%s' from the previous role in the chain - "+
+				"the role's trust policy must allow the previous role, and the previous role needs sts:AssumeRole permission", roleArn)
+		case "ExpiredToken", "InvalidClientTokenId", "SignatureDoesNotMatch":
+			return newUserFacingError(err, "cannot assume role '%s': RadosGW rejected the previous role's credentials (%s)", roleArn, apiErr.ErrorCode())
+		}
+	}
+	return formatSTSError(err, endpointURL, roleArn, sessionDuration)
+}
+
+// isTransientSTSError reports whether an AssumeRoleWithWebIdentity or
+// AssumeRole failure may succeed when repeated. Neither request changes
+// state, so server errors, timeouts and RadosGW failing to reach the identity
+// provider are all safe to retry.
+func isTransientSTSError(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode() == "IDPCommunicationError" {
+		return true
+	}
+	var responseError *smithyhttp.ResponseError
+	if errors.As(err, &responseError) {
+		return httpclient.IsTransientStatus(responseError.HTTPStatusCode())
+	}
+	return httpclient.IsTransientNetworkError(err)
+}
+
+// isFailoverError reports whether another gateway may succeed where one
+// failed. Only failures of the gateway itself qualify: no response because of
+// a connection, name resolution, TLS or timeout error, or a 5xx response.
+// Every other error, such as AccessDenied, IDPCommunicationError, a malformed
+// response or a signing failure, would repeat at every gateway of the cluster.
+func isFailoverError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var sendError *smithyhttp.RequestSendError
+	if errors.As(err, &sendError) {
+		return true
+	}
+	var responseError *smithyhttp.ResponseError
+	if errors.As(err, &responseError) {
+		return responseError.HTTPStatusCode() >= http.StatusInternalServerError
+	}
+	return false
+}
+
 func formatSTSNetworkError(err error, endpointURL string) error {
 	if errors.Is(err, context.DeadlineExceeded) {
 		return newUserFacingError(err, "connection timeout: STS endpoint '%s' did not respond in time - check network connectivity", endpointURL)
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"net/url"
 	"strings"
 	"syscall"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 )
 
 func TestFormatSTSError(t *testing.T) {
@@ -154,6 +156,37 @@ func TestCertificateVerificationErrorTypes(t *testing.T) {
 	}
 }
 
+func TestIsFailoverError(t *testing.T) {
+	response := func(status int, err error) error {
+		return &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+			Err:      err,
+		}
+	}
+
+	for _, test := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "connection refused", err: response(0, &smithyhttp.RequestSendError{Err: syscall.ECONNREFUSED}), want: true},
+		{name: "gateway timeout", err: fmt.Errorf("attempt: %w", context.DeadlineExceeded), want: true},
+		{name: "server error", err: response(http.StatusServiceUnavailable, &smithy.GenericAPIError{Code: "ServiceUnavailable"}), want: true},
+		{name: "canceled", err: &smithyhttp.RequestSendError{Err: context.Canceled}},
+		{name: "access denied", err: response(http.StatusForbidden, &smithy.GenericAPIError{Code: "AccessDenied"})},
+		{name: "identity provider unreachable", err: response(http.StatusBadRequest, &smithy.GenericAPIError{Code: "IDPCommunicationError"})},
+		{name: "throttled", err: response(http.StatusTooManyRequests, &smithy.GenericAPIError{Code: "Throttling"})},
+		{name: "malformed response", err: response(http.StatusOK, &smithy.DeserializationError{Err: errors.New("unexpected EOF")})},
+		{name: "signing failure", err: &smithy.OperationError{ServiceID: "STS", OperationName: "AssumeRole", Err: errors.New("failed to sign request")}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isFailoverError(test.err); got != test.want {
+				t.Errorf("isFailoverError(%v) = %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
 type testTimeoutError struct{}
 
 func (testTimeoutError) Error() string {
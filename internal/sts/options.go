@@ -1,15 +1,57 @@
 package sts
 
-import "time"
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"time"
 
-// AssumeRoleOptions contains the inputs for an STS
-// AssumeRoleWithWebIdentity request. WebIdentityToken is sensitive and must not
-// be logged or included in user-facing diagnostics.
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
+)
+
+// AssumeRoleOptions contains the inputs for an STS AssumeRoleWithWebIdentity
+// or AssumeRole request. WebIdentityToken is sensitive and must not be logged
+// or included in user-facing diagnostics.
 type AssumeRoleOptions struct {
-	EndpointURL      string
-	RoleARN          string
-	WebIdentityToken string
-	RoleSessionName  string
-	SSLVerify        bool
-	SessionDuration  time.Duration
+	// EndpointURL is the STS endpoint, which RadosGW may serve from a
+	// different host than S3. Region signs the request and defaults to
+	// config.DefaultRegion.
+	EndpointURL       string
+	Region            string
+	RoleARN           string
+	WebIdentityToken  string
+	RoleSessionName   string
+	SSLVerify         bool
+	ClientCertificate *tls.Certificate
+	RootCAs           *x509.CertPool
+	PinnedSHA256      [][sha256.Size]byte
+	ProxyURL          *url.URL
+	NoProxy           string
+	OnProxy           func(target, proxy *url.URL)
+	MaxAttempts       int
+	OnRetry           func(httpclient.RetryAttempt)
+	SessionDuration   time.Duration
+	// FailoverEndpointURLs are further RadosGW gateways tried in order when
+	// EndpointURL cannot be reached or fails with a server error. OnFailover
+	// is called with each gateway that is given up and its error.
+	FailoverEndpointURLs []string
+	OnFailover           func(endpointURL string, err error)
+	// Policy is an optional inline session policy document and PolicyARNs
+	// optional managed session policies. Both can only narrow the role's
+	// permissions.
+	Policy     string
+	PolicyARNs []string
+}
+
+func (options AssumeRoleOptions) httpClientOptions() httpclient.Options {
+	return httpclient.Options{
+		VerifyTLS:         options.SSLVerify,
+		ClientCertificate: options.ClientCertificate,
+		RootCAs:           options.RootCAs,
+		PinnedSHA256:      options.PinnedSHA256,
+		ProxyURL:          options.ProxyURL,
+		NoProxy:           options.NoProxy,
+		OnProxy:           options.OnProxy,
+	}
 }
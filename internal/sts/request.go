@@ -2,16 +2,19 @@ package sts
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
 
 	"github.com/fitbeard/radosgw-assume/internal/config"
 	"github.com/fitbeard/radosgw-assume/internal/httpclient"
 )
 
-// STSRequestTimeout bounds the complete role-assumption operation, including retries.
+// STSRequestTimeout bounds the role-assumption operation at one gateway,
+// including retries. Each failover gateway gets its own budget.
 const STSRequestTimeout = 30 * time.Second
 
 // AssumeRoleWithWebIdentity performs STS AssumeRoleWithWebIdentity operation
@@ -20,30 +23,136 @@ func AssumeRoleWithWebIdentity(ctx context.Context, options AssumeRoleOptions) (
 }
 
 func assumeRoleWithWebIdentity(ctx context.Context, options AssumeRoleOptions, requestTimeout time.Duration) (*config.AssumeRoleResult, error) {
-	cfg := aws.Config{
-		Credentials: aws.AnonymousCredentials{},
-		HTTPClient:  httpclient.New(options.SSLVerify, requestTimeout),
-		Region:      "us-east-1",
-	}
-
-	stsClient := sts.NewFromConfig(cfg, func(o *sts.Options) {
-		o.BaseEndpoint = aws.String(options.EndpointURL)
-	})
-
 	input := &sts.AssumeRoleWithWebIdentityInput{
 		RoleArn:          aws.String(options.RoleARN),
 		RoleSessionName:  aws.String(options.RoleSessionName),
 		DurationSeconds:  aws.Int32(int32(options.SessionDuration.Seconds())),
 		WebIdentityToken: aws.String(options.WebIdentityToken),
+		Policy:           sessionPolicy(options),
+		PolicyArns:       sessionPolicyARNs(options),
+	}
+
+	return failOver(ctx, options, func(options AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		stsClient, requestContext, cancelRequest := newSTSClient(ctx, options, aws.AnonymousCredentials{}, requestTimeout)
+		defer cancelRequest()
+
+		var result *sts.AssumeRoleWithWebIdentityOutput
+		err := httpclient.Retry(requestContext, retryPolicy(options), "STS AssumeRoleWithWebIdentity", func() error {
+			var err error
+			result, err = stsClient.AssumeRoleWithWebIdentity(requestContext, input)
+			return err
+		}, isTransientSTSError)
+		if err != nil {
+			return nil, formatSTSError(err, options.EndpointURL, options.RoleARN, options.SessionDuration)
+		}
+
+		return buildAssumeRoleResult(result, options.EndpointURL)
+	})
+}
+
+// AssumeRole performs an STS AssumeRole operation signed with the temporary
+// credentials of a previously assumed role, the second and later hops of a
+// role chain. options.WebIdentityToken is not used.
+func AssumeRole(ctx context.Context, options AssumeRoleOptions, sourceCredentials *config.AssumeRoleResult) (*config.AssumeRoleResult, error) {
+	return assumeRole(ctx, options, sourceCredentials, STSRequestTimeout)
+}
+
+func assumeRole(ctx context.Context, options AssumeRoleOptions, sourceCredentials *config.AssumeRoleResult, requestTimeout time.Duration) (*config.AssumeRoleResult, error) {
+	input := &sts.AssumeRoleInput{
+		RoleArn:         aws.String(options.RoleARN),
+		RoleSessionName: aws.String(options.RoleSessionName),
+		DurationSeconds: aws.Int32(int32(options.SessionDuration.Seconds())),
+		Policy:          sessionPolicy(options),
+		PolicyArns:      sessionPolicyARNs(options),
+	}
+	signingCredentials := aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+		return aws.Credentials{
+			AccessKeyID:     sourceCredentials.AccessKeyID,
+			SecretAccessKey: sourceCredentials.SecretAccessKey,
+			SessionToken:    sourceCredentials.SessionToken,
+		}, nil
+	})
+
+	return failOver(ctx, options, func(options AssumeRoleOptions) (*config.AssumeRoleResult, error) {
+		stsClient, requestContext, cancelRequest := newSTSClient(ctx, options, signingCredentials, requestTimeout)
+		defer cancelRequest()
+
+		var result *sts.AssumeRoleOutput
+		err := httpclient.Retry(requestContext, retryPolicy(options), "STS AssumeRole", func() error {
+			var err error
+			result, err = stsClient.AssumeRole(requestContext, input)
+			return err
+		}, isTransientSTSError)
+		if err != nil {
+			return nil, formatChainedRoleError(err, options.EndpointURL, options.RoleARN, options.SessionDuration)
+		}
+
+		return buildChainedRoleResult(result, options.EndpointURL)
+	})
+}
+
+// failOver calls assume with options.EndpointURL and then with each of
+// options.FailoverEndpointURLs until a gateway issues credentials or rejects
+// the request itself. The result's EndpointURL names the gateway that issued
+// the credentials.
+func failOver(ctx context.Context, options AssumeRoleOptions, assume func(AssumeRoleOptions) (*config.AssumeRoleResult, error)) (*config.AssumeRoleResult, error) {
+	result, err := assume(options)
+	for _, endpointURL := range options.FailoverEndpointURLs {
+		if err == nil || ctx.Err() != nil || !isFailoverError(err) {
+			return result, err
+		}
+		if options.OnFailover != nil {
+			options.OnFailover(options.EndpointURL, err)
+		}
+		options.EndpointURL = endpointURL
+		result, err = assume(options)
 	}
+	if err != nil && len(options.FailoverEndpointURLs) > 0 && isFailoverError(err) {
+		return nil, fmt.Errorf("%w (all %d STS endpoints failed)", err, len(options.FailoverEndpointURLs)+1)
+	}
+	return result, err
+}
 
+// newSTSClient returns a client for options.EndpointURL and the context that
+// bounds the complete operation. Retries are made by the caller so they can
+// be bounded per profile and reported in verbose mode; each attempt gets an
+// equal share of requestTimeout so a timed-out attempt leaves time for the
+// next one.
+func newSTSClient(ctx context.Context, options AssumeRoleOptions, credentials aws.CredentialsProvider, requestTimeout time.Duration) (*sts.Client, context.Context, context.CancelFunc) {
+	maxAttempts := max(options.MaxAttempts, 1)
+	region := options.Region
+	if region == "" {
+		region = config.DefaultRegion
+	}
+	cfg := aws.Config{
+		Credentials: credentials,
+		HTTPClient:  httpclient.New(options.httpClientOptions(), requestTimeout/time.Duration(maxAttempts)),
+		Region:      region,
+		Retryer:     func() aws.Retryer { return aws.NopRetryer{} },
+	}
+
+	stsClient := sts.NewFromConfig(cfg, func(o *sts.Options) {
+		o.BaseEndpoint = aws.String(options.EndpointURL)
+	})
 	requestContext, cancelRequest := context.WithTimeout(ctx, requestTimeout)
-	defer cancelRequest()
+	return stsClient, requestContext, cancelRequest
+}
 
-	result, err := stsClient.AssumeRoleWithWebIdentity(requestContext, input)
-	if err != nil {
-		return nil, formatSTSError(err, options.EndpointURL, options.RoleARN, options.SessionDuration)
+func retryPolicy(options AssumeRoleOptions) httpclient.RetryPolicy {
+	return httpclient.RetryPolicy{MaxAttempts: max(options.MaxAttempts, 1), OnRetry: options.OnRetry}
+}
+
+func sessionPolicy(options AssumeRoleOptions) *string {
+	if options.Policy == "" {
+		return nil
 	}
+	return aws.String(options.Policy)
+}
 
-	return buildAssumeRoleResult(result, options.EndpointURL)
+func sessionPolicyARNs(options AssumeRoleOptions) []types.PolicyDescriptorType {
+	var policyARNs []types.PolicyDescriptorType
+	for _, policyARN := range options.PolicyARNs {
+		policyARNs = append(policyARNs, types.PolicyDescriptorType{Arn: aws.String(policyARN)})
+	}
+	return policyARNs
 }
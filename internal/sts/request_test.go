@@ -4,11 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/config"
+	"github.com/fitbeard/radosgw-assume/internal/httpclient"
 )
 
 func TestAssumeRoleWithWebIdentity(t *testing.T) {
@@ -28,6 +33,9 @@ func TestAssumeRoleWithWebIdentity(t *testing.T) {
 		if got := r.Form.Get("DurationSeconds"); got != "3600" {
 			t.Errorf("DurationSeconds = %q, want 3600", got)
 		}
+		if r.Form.Has("Policy") || r.Form.Has("PolicyArns.member.1.arn") {
+			t.Errorf("request has session policies without any configured: %v", r.Form)
+		}
 
 		w.Header().Set("Content-Type", "text/xml")
 		_, _ = fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
@@ -43,6 +51,9 @@ func TestAssumeRoleWithWebIdentity(t *testing.T) {
       <SessionToken>test-session-token</SessionToken>
       <Expiration>2030-01-01T00:00:00Z</Expiration>
     </Credentials>
+    <SubjectFromWebIdentityToken>user-subject</SubjectFromWebIdentityToken>
+    <Audience>radosgw</Audience>
+    <Provider>https://oidc.example.com</Provider>
   </AssumeRoleWithWebIdentityResult>
   <ResponseMetadata><RequestId>test-request-id</RequestId></ResponseMetadata>
 </AssumeRoleWithWebIdentityResponse>`)
@@ -69,6 +80,152 @@ func TestAssumeRoleWithWebIdentity(t *testing.T) {
 	if result.AssumedRoleArn != "arn:aws:sts::123456789012:assumed-role/TestRole/test-session" {
 		t.Errorf("AssumedRoleArn = %q, want assumed role ARN", result.AssumedRoleArn)
 	}
+	if result.AssumedRoleID != "AROATEST:test-session" || result.SubjectFromWebIdentityToken != "user-subject" ||
+		result.Audience != "radosgw" || result.Provider != "https://oidc.example.com" {
+		t.Errorf("AssumeRoleWithWebIdentity() = %+v, want the assumed role ID and web identity details", result)
+	}
+	if result.PackedPolicySize != nil {
+		t.Errorf("PackedPolicySize = %d, want none without a session policy", *result.PackedPolicySize)
+	}
+}
+
+func TestAssumeRoleWithWebIdentitySessionPolicies(t *testing.T) {
+	const policy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"arn:aws:s3:::ci/*"}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+		}
+		if got := r.Form.Get("Policy"); got != policy {
+			t.Errorf("Policy = %q, want %q", got, policy)
+		}
+		for index, want := range []string{"arn:aws:iam::aws:policy/ReadOnly", "arn:aws:iam::123456789012:policy/CI"} {
+			if got := r.Form.Get(fmt.Sprintf("PolicyArns.member.%d.arn", index+1)); got != want {
+				t.Errorf("PolicyArns.member.%d.arn = %q, want %q", index+1, got, want)
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = fmt.Fprint(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>test-access-key</AccessKeyId>
+      <SecretAccessKey>test-secret-key</SecretAccessKey>
+      <SessionToken>test-session-token</SessionToken>
+      <Expiration>2030-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`)
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := AssumeRoleWithWebIdentity(t.Context(), AssumeRoleOptions{
+		EndpointURL:      server.URL,
+		RoleARN:          "arn:aws:iam::123456789012:role/TestRole",
+		WebIdentityToken: "test-token",
+		RoleSessionName:  "test-session",
+		SSLVerify:        true,
+		SessionDuration:  time.Hour,
+		Policy:           policy,
+		PolicyARNs:       []string{"arn:aws:iam::aws:policy/ReadOnly", "arn:aws:iam::123456789012:policy/CI"},
+	})
+	if err != nil {
+		t.Fatalf("AssumeRoleWithWebIdentity() error = %v", err)
+	}
+}
+
+func TestAssumeRole(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=entry-access-key/") {
+			t.Errorf("Authorization = %q, want a signature with the entry role's access key", r.Header.Get("Authorization"))
+		}
+		if !strings.Contains(r.Header.Get("Authorization"), "/zonegroup-a/sts/aws4_request") {
+			t.Errorf("Authorization = %q, want a signature for the zonegroup-a region", r.Header.Get("Authorization"))
+		}
+		if got := r.Header.Get("X-Amz-Security-Token"); got != "entry-session-token" {
+			t.Errorf("X-Amz-Security-Token = %q, want entry-session-token", got)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+		}
+		for key, want := range map[string]string{
+			"Action":                  "AssumeRole",
+			"RoleArn":                 "arn:aws:iam::123456789012:role/TenantRole",
+			"RoleSessionName":         "tenant-session",
+			"DurationSeconds":         "900",
+			"Policy":                  `{"Version":"2012-10-17","Statement":[]}`,
+			"PolicyArns.member.1.arn": "arn:aws:iam::aws:policy/ReadOnly",
+			"WebIdentityToken":        "",
+		} {
+			if got := r.Form.Get(key); got != want {
+				t.Errorf("%s = %q, want %q", key, got, want)
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = fmt.Fprint(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::123456789012:assumed-role/TenantRole/tenant-session</Arn>
+      <AssumedRoleId>AROATENANT:tenant-session</AssumedRoleId>
+    </AssumedRoleUser>
+    <Credentials>
+      <AccessKeyId>tenant-access-key</AccessKeyId>
+      <SecretAccessKey>tenant-secret-key</SecretAccessKey>
+      <SessionToken>tenant-session-token</SessionToken>
+      <Expiration>2030-01-01T00:00:00Z</Expiration>
+    </Credentials>
+    <PackedPolicySize>6</PackedPolicySize>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`)
+	}))
+	t.Cleanup(server.Close)
+
+	result, err := AssumeRole(t.Context(), AssumeRoleOptions{
+		EndpointURL:     server.URL,
+		Region:          "zonegroup-a",
+		RoleARN:         "arn:aws:iam::123456789012:role/TenantRole",
+		RoleSessionName: "tenant-session",
+		SSLVerify:       true,
+		SessionDuration: 15 * time.Minute,
+		Policy:          `{"Version":"2012-10-17","Statement":[]}`,
+		PolicyARNs:      []string{"arn:aws:iam::aws:policy/ReadOnly"},
+	}, &config.AssumeRoleResult{
+		AccessKeyID:     "entry-access-key",
+		SecretAccessKey: "entry-secret-key",
+		SessionToken:    "entry-session-token",
+	})
+	if err != nil {
+		t.Fatalf("AssumeRole() error = %v", err)
+	}
+	if result.AccessKeyID != "tenant-access-key" || result.EndpointURL != server.URL {
+		t.Errorf("AssumeRole() = %+v, want the tenant role credentials", result)
+	}
+	if result.AssumedRoleArn != "arn:aws:sts::123456789012:assumed-role/TenantRole/tenant-session" {
+		t.Errorf("AssumedRoleArn = %q, want tenant role ARN", result.AssumedRoleArn)
+	}
+	if result.AssumedRoleID != "AROATENANT:tenant-session" || result.PackedPolicySize == nil || *result.PackedPolicySize != 6 {
+		t.Errorf("AssumeRole() = %+v, want the assumed role ID and packed policy size", result)
+	}
+}
+
+func TestAssumeRoleReportsChainedRoleDenial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><Error><Type>Sender</Type><Code>AccessDenied</Code><Message>test</Message></Error></ErrorResponse>`)
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := AssumeRole(t.Context(), AssumeRoleOptions{
+		EndpointURL:     server.URL,
+		RoleARN:         "arn:aws:iam::123456789012:role/TenantRole",
+		RoleSessionName: "tenant-session",
+		SSLVerify:       true,
+		SessionDuration: time.Hour,
+	}, &config.AssumeRoleResult{AccessKeyID: "entry-access-key", SecretAccessKey: "entry-secret-key", SessionToken: "entry-session-token"})
+	if err == nil || !strings.Contains(err.Error(), "cannot assume role 'arn:aws:iam::123456789012:role/TenantRole' from the previous role in the chain") {
+		t.Errorf("AssumeRole() error = %v, want chained role denial", err)
+	}
 }
 
 func TestAssumeRoleWithWebIdentityTimeout(t *testing.T) {
@@ -126,3 +283,247 @@ func TestSTSRequestTimeout(t *testing.T) {
 		t.Errorf("STSRequestTimeout should be positive, got %v", STSRequestTimeout)
 	}
 }
+
+func TestAssumeRoleWithWebIdentityRetriesTransientFailures(t *testing.T) {
+	const successResponse = `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>test-access-key</AccessKeyId>
+      <SecretAccessKey>test-secret-key</SecretAccessKey>
+      <SessionToken>test-session-token</SessionToken>
+      <Expiration>2030-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`
+	errorResponse := func(code string) string {
+		return `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><Error><Type>Sender</Type><Code>` + code + `</Code><Message>test</Message></Error></ErrorResponse>`
+	}
+
+	for _, test := range []struct {
+		name         string
+		maxAttempts  int
+		statuses     []int
+		bodies       []string
+		wantRequests int
+		wantErr      string
+	}{
+		{
+			name:         "server error",
+			maxAttempts:  3,
+			statuses:     []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			bodies:       []string{"", "", successResponse},
+			wantRequests: 3,
+		},
+		{
+			name:         "identity provider unreachable",
+			maxAttempts:  2,
+			statuses:     []int{http.StatusBadRequest, http.StatusOK},
+			bodies:       []string{errorResponse("IDPCommunicationError"), successResponse},
+			wantRequests: 2,
+		},
+		{
+			name:         "retries disabled",
+			maxAttempts:  1,
+			statuses:     []int{http.StatusBadGateway},
+			bodies:       []string{""},
+			wantRequests: 1,
+			wantErr:      "UnknownError",
+		},
+		{
+			name:         "access denied",
+			maxAttempts:  3,
+			statuses:     []int{http.StatusForbidden},
+			bodies:       []string{errorResponse("AccessDenied")},
+			wantRequests: 1,
+			wantErr:      "access denied",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				index := min(requests, len(test.statuses)-1)
+				requests++
+				w.Header().Set("Content-Type", "text/xml")
+				w.WriteHeader(test.statuses[index])
+				_, _ = fmt.Fprint(w, test.bodies[index])
+			}))
+			t.Cleanup(server.Close)
+			retries := 0
+
+			result, err := AssumeRoleWithWebIdentity(t.Context(), AssumeRoleOptions{
+				EndpointURL:      server.URL,
+				RoleARN:          "arn:aws:iam::123456789012:role/TestRole",
+				WebIdentityToken: "test-token",
+				RoleSessionName:  "test-session",
+				SSLVerify:        true,
+				MaxAttempts:      test.maxAttempts,
+				OnRetry: func(attempt httpclient.RetryAttempt) {
+					retries++
+					if attempt.Operation != "STS AssumeRoleWithWebIdentity" || attempt.Attempt != retries {
+						t.Errorf("retry = %+v", attempt)
+					}
+				},
+				SessionDuration: time.Hour,
+			})
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("AssumeRoleWithWebIdentity() error = %v, want containing %q", err, test.wantErr)
+				}
+			} else if err != nil || result.AccessKeyID != "test-access-key" {
+				t.Errorf("AssumeRoleWithWebIdentity() = %+v, %v", result, err)
+			}
+			if requests != test.wantRequests || retries != test.wantRequests-1 {
+				t.Errorf("requests, retries = %d %d, want %d %d", requests, retries, test.wantRequests, test.wantRequests-1)
+			}
+		})
+	}
+}
+
+func TestAssumeRoleWithWebIdentityFailsOver(t *testing.T) {
+	const successResponse = `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>test-access-key</AccessKeyId>
+      <SecretAccessKey>test-secret-key</SecretAccessKey>
+      <SessionToken>test-session-token</SessionToken>
+      <Expiration>2030-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`
+	respond := func(status int, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/xml")
+			w.WriteHeader(status)
+			_, _ = fmt.Fprint(w, body)
+		}
+	}
+	accessDenied := `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><Error><Type>Sender</Type><Code>AccessDenied</Code><Message>test</Message></Error></ErrorResponse>`
+
+	for _, test := range []struct {
+		name          string
+		firstGateway  func(t *testing.T) string
+		secondStatus  int
+		wantFailovers int
+		wantErr       string
+	}{
+		{
+			name: "server error",
+			firstGateway: func(t *testing.T) string {
+				server := httptest.NewServer(respond(http.StatusServiceUnavailable, ""))
+				t.Cleanup(server.Close)
+				return server.URL
+			},
+			secondStatus:  http.StatusOK,
+			wantFailovers: 1,
+		},
+		{
+			name: "connection refused",
+			firstGateway: func(*testing.T) string {
+				server := httptest.NewServer(respond(http.StatusOK, successResponse))
+				server.Close()
+				return server.URL
+			},
+			secondStatus:  http.StatusOK,
+			wantFailovers: 1,
+		},
+		{
+			name: "untrusted certificate",
+			firstGateway: func(t *testing.T) string {
+				server := httptest.NewUnstartedServer(respond(http.StatusOK, successResponse))
+				server.Config.ErrorLog = log.New(io.Discard, "", 0)
+				server.StartTLS()
+				t.Cleanup(server.Close)
+				return server.URL
+			},
+			secondStatus:  http.StatusOK,
+			wantFailovers: 1,
+		},
+		{
+			name: "access denied",
+			firstGateway: func(t *testing.T) string {
+				server := httptest.NewServer(respond(http.StatusForbidden, accessDenied))
+				t.Cleanup(server.Close)
+				return server.URL
+			},
+			secondStatus: http.StatusOK,
+			wantErr:      "access denied",
+		},
+		{
+			name: "identity provider unreachable",
+			firstGateway: func(t *testing.T) string {
+				server := httptest.NewServer(respond(http.StatusBadRequest, strings.Replace(accessDenied, "AccessDenied", "IDPCommunicationError", 1)))
+				t.Cleanup(server.Close)
+				return server.URL
+			},
+			secondStatus: http.StatusOK,
+			wantErr:      "IDP communication error",
+		},
+		{
+			name: "malformed response",
+			firstGateway: func(t *testing.T) string {
+				server := httptest.NewServer(respond(http.StatusOK, "<AssumeRoleWithWebIdentityResponse>"))
+				t.Cleanup(server.Close)
+				return server.URL
+			},
+			secondStatus: http.StatusOK,
+			wantErr:      "deserialization failed",
+		},
+		{
+			name: "every gateway fails",
+			firstGateway: func(t *testing.T) string {
+				server := httptest.NewServer(respond(http.StatusBadGateway, ""))
+				t.Cleanup(server.Close)
+				return server.URL
+			},
+			secondStatus:  http.StatusServiceUnavailable,
+			wantFailovers: 1,
+			wantErr:       "all 2 STS endpoints failed",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			firstURL := test.firstGateway(t)
+			secondRequests := 0
+			second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				secondRequests++
+				body := successResponse
+				if test.secondStatus != http.StatusOK {
+					body = ""
+				}
+				respond(test.secondStatus, body)(w, r)
+			}))
+			t.Cleanup(second.Close)
+			var failovers []string
+
+			result, err := AssumeRoleWithWebIdentity(t.Context(), AssumeRoleOptions{
+				EndpointURL:          firstURL,
+				FailoverEndpointURLs: []string{second.URL},
+				OnFailover: func(endpointURL string, err error) {
+					if err == nil {
+						t.Error("OnFailover() called without an error")
+					}
+					failovers = append(failovers, endpointURL)
+				},
+				RoleARN:          "arn:aws:iam::123456789012:role/TestRole",
+				WebIdentityToken: "test-token",
+				RoleSessionName:  "test-session",
+				SSLVerify:        true,
+				SessionDuration:  time.Hour,
+			})
+
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Errorf("AssumeRoleWithWebIdentity() error = %v, want containing %q", err, test.wantErr)
+				}
+			} else if err != nil || result.EndpointURL != second.URL {
+				t.Errorf("AssumeRoleWithWebIdentity() = %+v, %v, want credentials issued by %s", result, err, second.URL)
+			}
+			if len(failovers) != test.wantFailovers || (test.wantFailovers > 0 && failovers[0] != firstURL) {
+				t.Errorf("failovers = %v, want %d from %s", failovers, test.wantFailovers, firstURL)
+			}
+			if wantRequests := min(test.wantFailovers, 1); secondRequests != wantRequests {
+				t.Errorf("second gateway requests = %d, want %d", secondRequests, wantRequests)
+			}
+		})
+	}
+}
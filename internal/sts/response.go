@@ -7,6 +7,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
 
 	"github.com/fitbeard/radosgw-assume/internal/config"
 )
@@ -15,11 +16,28 @@ func buildAssumeRoleResult(result *sts.AssumeRoleWithWebIdentityOutput, endpoint
 	if result == nil {
 		return nil, fmt.Errorf("STS endpoint '%s' returned an invalid response: response is missing", endpointURL)
 	}
-	if result.Credentials == nil {
+	assumeRoleResult, err := buildResult(result.Credentials, result.AssumedRoleUser, result.PackedPolicySize, endpointURL)
+	if err != nil {
+		return nil, err
+	}
+	assumeRoleResult.SubjectFromWebIdentityToken = aws.ToString(result.SubjectFromWebIdentityToken)
+	assumeRoleResult.Audience = aws.ToString(result.Audience)
+	assumeRoleResult.Provider = aws.ToString(result.Provider)
+	return assumeRoleResult, nil
+}
+
+func buildChainedRoleResult(result *sts.AssumeRoleOutput, endpointURL string) (*config.AssumeRoleResult, error) {
+	if result == nil {
+		return nil, fmt.Errorf("STS endpoint '%s' returned an invalid response: response is missing", endpointURL)
+	}
+	return buildResult(result.Credentials, result.AssumedRoleUser, result.PackedPolicySize, endpointURL)
+}
+
+func buildResult(credentials *types.Credentials, assumedRoleUser *types.AssumedRoleUser, packedPolicySize *int32, endpointURL string) (*config.AssumeRoleResult, error) {
+	if credentials == nil {
 		return nil, fmt.Errorf("STS endpoint '%s' returned an invalid response: credentials are missing", endpointURL)
 	}
 
-	credentials := result.Credentials
 	var missingFields []string
 	if aws.ToString(credentials.AccessKeyId) == "" {
 		missingFields = append(missingFields, "AccessKeyId")
@@ -41,17 +59,20 @@ func buildAssumeRoleResult(result *sts.AssumeRoleWithWebIdentityOutput, endpoint
 		)
 	}
 
-	var assumedRoleArn string
-	if result.AssumedRoleUser != nil && result.AssumedRoleUser.Arn != nil {
-		assumedRoleArn = *result.AssumedRoleUser.Arn
+	var assumedRoleArn, assumedRoleID string
+	if assumedRoleUser != nil {
+		assumedRoleArn = aws.ToString(assumedRoleUser.Arn)
+		assumedRoleID = aws.ToString(assumedRoleUser.AssumedRoleId)
 	}
 
 	return &config.AssumeRoleResult{
-		AssumedRoleArn:  assumedRoleArn,
-		AccessKeyID:     aws.ToString(credentials.AccessKeyId),
-		SecretAccessKey: aws.ToString(credentials.SecretAccessKey),
-		SessionToken:    aws.ToString(credentials.SessionToken),
-		Expiration:      credentials.Expiration.Format(time.RFC3339),
-		EndpointURL:     endpointURL,
+		AssumedRoleArn:   assumedRoleArn,
+		AssumedRoleID:    assumedRoleID,
+		AccessKeyID:      aws.ToString(credentials.AccessKeyId),
+		SecretAccessKey:  aws.ToString(credentials.SecretAccessKey),
+		SessionToken:     aws.ToString(credentials.SessionToken),
+		Expiration:       credentials.Expiration.Format(time.RFC3339),
+		EndpointURL:      endpointURL,
+		PackedPolicySize: packedPolicySize,
 	}, nil
 }
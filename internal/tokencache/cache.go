@@ -0,0 +1,66 @@
+// Package tokencache persists OIDC tokens in a user-private cache so that
+// authentication can be renewed without repeating an interactive flow.
+package tokencache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store persists OIDC tokens in a user-private cache directory.
+type Store struct {
+	directory string
+}
+
+// New returns a token store under the operating system's user cache
+// directory.
+func New() (*Store, error) {
+	directory, err := defaultDirectory()
+	if err != nil {
+		return nil, err
+	}
+	return newStore(directory), nil
+}
+
+func defaultDirectory() (string, error) {
+	userCacheDirectory, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("find user cache directory: %w", err)
+	}
+	return filepath.Join(userCacheDirectory, "radosgw-assume", "tokens-v1"), nil
+}
+
+func newStore(directory string) *Store {
+	return &Store{directory: directory}
+}
+
+// Clear removes every stored token, including orphaned temporary files, and
+// returns the number of tokens removed. Unrelated files are left in place.
+func (store *Store) Clear() (int, error) {
+	entries, err := os.ReadDir(store.directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read token cache directory: %w", err)
+	}
+	removed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		temporary := strings.HasPrefix(name, ".tokens-") && strings.HasSuffix(name, ".tmp")
+		key, record := strings.CutSuffix(name, ".json")
+		if entry.IsDir() || !temporary && (!record || validateKey(key) != nil) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(store.directory, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, fmt.Errorf("remove token cache entry: %w", err)
+		}
+		if !temporary {
+			removed++
+		}
+	}
+	return removed, nil
+}
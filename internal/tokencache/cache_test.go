@@ -0,0 +1,46 @@
+package tokencache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClear(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "tokens")
+	store := newStore(directory)
+
+	if removed, err := store.Clear(); err != nil || removed != 0 {
+		t.Fatalf("Clear() on missing directory = (%d, %v), want no removals", removed, err)
+	}
+	if err := store.SaveRefreshToken("https://idp.example.com", "client", RefreshToken{Token: "refresh-token"}); err != nil {
+		t.Fatalf("SaveRefreshToken() error = %v", err)
+	}
+	key := IdentityTokenKey{ProviderURL: "https://idp.example.com", ClientID: "client", TokenType: "id_token"}
+	if err := store.SaveIdentityToken(key, "identity-token", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("SaveIdentityToken() error = %v", err)
+	}
+	for _, name := range []string{".tokens-orphan.tmp", "keep.txt"} {
+		if err := os.WriteFile(filepath.Join(directory, name), nil, 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	removed, err := store.Clear()
+	if err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Clear() removed %d tokens, want 2", removed)
+	}
+	if _, found, _ := store.LoadRefreshToken("https://idp.example.com", "client"); found {
+		t.Error("LoadRefreshToken() found a cleared token")
+	}
+	if _, err := os.Stat(filepath.Join(directory, ".tokens-orphan.tmp")); !os.IsNotExist(err) {
+		t.Errorf("orphaned temporary file was not removed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(directory, "keep.txt")); err != nil {
+		t.Errorf("unrelated file was removed: %v", err)
+	}
+}
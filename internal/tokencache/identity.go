@@ -0,0 +1,123 @@
+package tokencache
+
+import "time"
+
+const identityTokenVersion = 1
+
+// minimumIdentityTokenValidity keeps a cached token from expiring between
+// lookup and its use in an STS request.
+const minimumIdentityTokenValidity = time.Minute
+
+// IdentityTokenKey identifies a cached web identity token. Profiles that share
+// these values reuse one login regardless of the role they assume. The
+// authorization request parameters that change the issued token's audience,
+// subject or authentication level are part of the key.
+type IdentityTokenKey struct {
+	ProviderURL string
+	ClientID    string
+	Scope       string
+	TokenType   string
+	Audience    string
+	Resource    string
+	ACRValues   string
+	LoginHint   string
+}
+
+type identityTokenKeyInput struct {
+	Version      int    `json:"version"`
+	Kind         string `json:"kind"`
+	OIDCProvider string `json:"oidc_provider"`
+	OIDCClientID string `json:"oidc_client_id"`
+	OIDCScope    string `json:"oidc_scope"`
+	TokenType    string `json:"token_type"`
+	Audience     string `json:"audience,omitempty"`
+	Resource     string `json:"resource,omitempty"`
+	ACRValues    string `json:"acr_values,omitempty"`
+	LoginHint    string `json:"login_hint,omitempty"`
+}
+
+type identityTokenRecord struct {
+	Version   int       `json:"version"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func identityTokenKey(key IdentityTokenKey) (string, error) {
+	return hashKey(identityTokenKeyInput{
+		Version:      tokenKeyVersion,
+		Kind:         "web_identity_token",
+		OIDCProvider: normalizeProviderURL(key.ProviderURL),
+		OIDCClientID: key.ClientID,
+		OIDCScope:    key.Scope,
+		TokenType:    key.TokenType,
+		Audience:     key.Audience,
+		Resource:     key.Resource,
+		ACRValues:    key.ACRValues,
+		LoginHint:    key.LoginHint,
+	})
+}
+
+// LoadIdentityToken returns a cached web identity token that remains valid for
+// at least a minute after now. Expired entries are removed.
+func (store *Store) LoadIdentityToken(key IdentityTokenKey, now time.Time) (string, bool, error) {
+	cacheKey, err := identityTokenKey(key)
+	if err != nil {
+		return "", false, err
+	}
+
+	var record identityTokenRecord
+	found, err := store.readRecord(cacheKey, &record)
+	if err != nil || !found {
+		return "", false, err
+	}
+	if record.Version != identityTokenVersion || record.Token == "" || !record.ExpiresAt.After(now.Add(minimumIdentityTokenValidity)) {
+		return "", false, store.removeRecord(cacheKey)
+	}
+	return record.Token, true, nil
+}
+
+// SaveIdentityToken atomically stores a web identity token until expiresAt,
+// replacing any previous token for the same key.
+func (store *Store) SaveIdentityToken(key IdentityTokenKey, token string, expiresAt time.Time) error {
+	cacheKey, err := identityTokenKey(key)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return store.removeRecord(cacheKey)
+	}
+	return store.writeRecord(cacheKey, identityTokenRecord{Version: identityTokenVersion, Token: token, ExpiresAt: expiresAt.UTC()})
+}
+
+// TakeIdentityToken removes the cached web identity token for key and returns
+// it with its expiry, however little validity remains, so a caller can still
+// revoke it. Missing and malformed entries are reported as not found.
+func (store *Store) TakeIdentityToken(key IdentityTokenKey) (string, time.Time, bool, error) {
+	cacheKey, err := identityTokenKey(key)
+	if err != nil {
+		return "", time.Time{}, false, err
+	}
+
+	var record identityTokenRecord
+	found, err := store.readRecord(cacheKey, &record)
+	if err != nil || !found {
+		return "", time.Time{}, false, err
+	}
+	if err := store.removeRecord(cacheKey); err != nil {
+		return "", time.Time{}, false, err
+	}
+	if record.Version != identityTokenVersion || record.Token == "" {
+		return "", time.Time{}, false, nil
+	}
+	return record.Token, record.ExpiresAt, true, nil
+}
+
+// DeleteIdentityToken removes the cached web identity token for key. Missing
+// entries are not an error.
+func (store *Store) DeleteIdentityToken(key IdentityTokenKey) error {
+	cacheKey, err := identityTokenKey(key)
+	if err != nil {
+		return err
+	}
+	return store.removeRecord(cacheKey)
+}
@@ -0,0 +1,101 @@
+package tokencache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIdentityTokenRoundTrip(t *testing.T) {
+	store := newStore(filepath.Join(t.TempDir(), "tokens"))
+	now := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+	key := IdentityTokenKey{ProviderURL: "https://idp.example.com", ClientID: "client", Scope: "openid", TokenType: "access_token"}
+
+	if _, found, err := store.LoadIdentityToken(key, now); err != nil || found {
+		t.Fatalf("LoadIdentityToken() = (%v, %v), want missing entry", found, err)
+	}
+	savedKey := key
+	savedKey.ProviderURL += "/"
+	if err := store.SaveIdentityToken(savedKey, "identity.jwt.value", now.Add(time.Hour)); err != nil {
+		t.Fatalf("SaveIdentityToken() error = %v", err)
+	}
+
+	token, found, err := store.LoadIdentityToken(key, now)
+	if err != nil || !found || token != "identity.jwt.value" {
+		t.Fatalf("LoadIdentityToken() = (%q, %v, %v), want stored token", token, found, err)
+	}
+	for name, other := range map[string]IdentityTokenKey{
+		"client":     {ProviderURL: key.ProviderURL, ClientID: "other", Scope: key.Scope, TokenType: key.TokenType},
+		"scope":      {ProviderURL: key.ProviderURL, ClientID: key.ClientID, Scope: "openid groups", TokenType: key.TokenType},
+		"token type": {ProviderURL: key.ProviderURL, ClientID: key.ClientID, Scope: key.Scope, TokenType: "id_token"},
+	} {
+		if _, found, _ := store.LoadIdentityToken(other, now); found {
+			t.Errorf("LoadIdentityToken() found a token for a different %s", name)
+		}
+	}
+	if _, found, _ := store.LoadRefreshToken(key.ProviderURL, key.ClientID); found {
+		t.Error("identity token entry is visible as a refresh token")
+	}
+
+	if err := store.DeleteIdentityToken(key); err != nil {
+		t.Fatalf("DeleteIdentityToken() error = %v", err)
+	}
+	if _, found, _ := store.LoadIdentityToken(key, now); found {
+		t.Error("LoadIdentityToken() found a deleted token")
+	}
+}
+
+func TestIdentityTokenExpiry(t *testing.T) {
+	now := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+	key := IdentityTokenKey{ProviderURL: "https://idp.example.com", ClientID: "client", Scope: "openid", TokenType: "access_token"}
+
+	for _, test := range []struct {
+		name      string
+		expiresAt time.Time
+		wantFound bool
+	}{
+		{name: "valid", expiresAt: now.Add(2 * time.Minute), wantFound: true},
+		{name: "within minimum validity", expiresAt: now.Add(30 * time.Second)},
+		{name: "expired", expiresAt: now.Add(-time.Minute)},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			directory := t.TempDir()
+			store := newStore(directory)
+			if err := store.SaveIdentityToken(key, "identity.jwt.value", test.expiresAt); err != nil {
+				t.Fatalf("SaveIdentityToken() error = %v", err)
+			}
+
+			_, found, err := store.LoadIdentityToken(key, now)
+			if err != nil || found != test.wantFound {
+				t.Fatalf("LoadIdentityToken() = (%v, %v), want found %v", found, err, test.wantFound)
+			}
+			cacheKey, err := identityTokenKey(key)
+			if err != nil {
+				t.Fatalf("identityTokenKey() error = %v", err)
+			}
+			_, statErr := os.Stat(filepath.Join(directory, cacheKey+".json"))
+			if exists := statErr == nil; exists != test.wantFound {
+				t.Errorf("entry exists = %v, want %v", exists, test.wantFound)
+			}
+		})
+	}
+}
+
+func TestTakeIdentityTokenIgnoresRemainingValidity(t *testing.T) {
+	store := newStore(t.TempDir())
+	now := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+	key := IdentityTokenKey{ProviderURL: "https://idp.example.com", ClientID: "client", Scope: "openid", TokenType: "access_token"}
+	expiresAt := now.Add(30 * time.Second)
+	if err := store.SaveIdentityToken(key, "identity.jwt.value", expiresAt); err != nil {
+		t.Fatalf("SaveIdentityToken() error = %v", err)
+	}
+
+	token, gotExpiresAt, found, err := store.TakeIdentityToken(key)
+	if err != nil || !found || token != "identity.jwt.value" || !gotExpiresAt.Equal(expiresAt) {
+		t.Fatalf("TakeIdentityToken() = (%q, %v, %v, %v), want the stored token", token, gotExpiresAt, found, err)
+	}
+	if _, _, found, err := store.TakeIdentityToken(key); err != nil || found {
+		t.Errorf("TakeIdentityToken() after take = (%v, %v), want missing entry", found, err)
+	}
+}
@@ -0,0 +1,52 @@
+package tokencache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const tokenKeyVersion = 1
+
+type refreshTokenKeyInput struct {
+	Version      int    `json:"version"`
+	Kind         string `json:"kind"`
+	OIDCProvider string `json:"oidc_provider"`
+	OIDCClientID string `json:"oidc_client_id"`
+}
+
+func refreshTokenKey(providerURL, clientID string) (string, error) {
+	return hashKey(refreshTokenKeyInput{
+		Version:      tokenKeyVersion,
+		Kind:         "refresh_token",
+		OIDCProvider: normalizeProviderURL(providerURL),
+		OIDCClientID: clientID,
+	})
+}
+
+func hashKey(input any) (string, error) {
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("create token cache key: %w", err)
+	}
+	keyHash := sha256.Sum256(encoded)
+	return hex.EncodeToString(keyHash[:]), nil
+}
+
+// normalizeProviderURL matches the issuer normalization used by OIDC discovery
+// so equivalent provider URLs share cache entries.
+func normalizeProviderURL(providerURL string) string {
+	return strings.TrimRight(providerURL, "/")
+}
+
+func validateKey(key string) error {
+	if len(key) != sha256.Size*2 {
+		return fmt.Errorf("invalid token cache key")
+	}
+	if _, err := hex.DecodeString(key); err != nil {
+		return fmt.Errorf("invalid token cache key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package tokencache
+
+const refreshTokenVersion = 2
+
+// RefreshToken is a stored refresh token together with the authorization
+// request parameters of the login that issued it. A refresh keeps the user
+// and authentication level of that login, so callers redeem the token only
+// for a login that would send the same parameters.
+type RefreshToken struct {
+	Token     string
+	Audience  string
+	Resource  string
+	ACRValues string
+	LoginHint string
+}
+
+type refreshTokenRecord struct {
+	Version      int    `json:"version"`
+	RefreshToken string `json:"refresh_token"`
+	Audience     string `json:"audience,omitempty"`
+	Resource     string `json:"resource,omitempty"`
+	ACRValues    string `json:"acr_values,omitempty"`
+	LoginHint    string `json:"login_hint,omitempty"`
+}
+
+// LoadRefreshToken returns the refresh token stored for an OIDC provider and
+// client ID. Entries written before the login parameters were recorded are
+// removed, because the login that issued them is unknown.
+func (store *Store) LoadRefreshToken(providerURL, clientID string) (RefreshToken, bool, error) {
+	key, err := refreshTokenKey(providerURL, clientID)
+	if err != nil {
+		return RefreshToken{}, false, err
+	}
+
+	var record refreshTokenRecord
+	found, err := store.readRecord(key, &record)
+	if err != nil || !found {
+		return RefreshToken{}, false, err
+	}
+	if record.Version != refreshTokenVersion || record.RefreshToken == "" {
+		return RefreshToken{}, false, store.removeRecord(key)
+	}
+	return RefreshToken{
+		Token:     record.RefreshToken,
+		Audience:  record.Audience,
+		Resource:  record.Resource,
+		ACRValues: record.ACRValues,
+		LoginHint: record.LoginHint,
+	}, true, nil
+}
+
+// SaveRefreshToken atomically stores a refresh token for an OIDC provider and
+// client ID, replacing any previous token.
+func (store *Store) SaveRefreshToken(providerURL, clientID string, refreshToken RefreshToken) error {
+	key, err := refreshTokenKey(providerURL, clientID)
+	if err != nil {
+		return err
+	}
+	if refreshToken.Token == "" {
+		return store.removeRecord(key)
+	}
+	return store.writeRecord(key, refreshTokenRecord{
+		Version:      refreshTokenVersion,
+		RefreshToken: refreshToken.Token,
+		Audience:     refreshToken.Audience,
+		Resource:     refreshToken.Resource,
+		ACRValues:    refreshToken.ACRValues,
+		LoginHint:    refreshToken.LoginHint,
+	})
+}
+
+// DeleteRefreshToken removes the refresh token stored for an OIDC provider and
+// client ID. Missing entries are not an error.
+func (store *Store) DeleteRefreshToken(providerURL, clientID string) error {
+	key, err := refreshTokenKey(providerURL, clientID)
+	if err != nil {
+		return err
+	}
+	return store.removeRecord(key)
+}
@@ -0,0 +1,91 @@
+package tokencache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRefreshTokenRoundTrip(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "tokens")
+	store := newStore(directory)
+
+	if _, found, err := store.LoadRefreshToken("https://idp.example.com", "client"); err != nil || found {
+		t.Fatalf("LoadRefreshToken() = (%v, %v), want missing entry", found, err)
+	}
+	saved := RefreshToken{Token: "refresh-token", Resource: "https://s3.example.com", ACRValues: "mfa", LoginHint: "alice"}
+	if err := store.SaveRefreshToken("https://idp.example.com/", "client", saved); err != nil {
+		t.Fatalf("SaveRefreshToken() error = %v", err)
+	}
+
+	token, found, err := store.LoadRefreshToken("https://idp.example.com", "client")
+	if err != nil || !found || token != saved {
+		t.Fatalf("LoadRefreshToken() = (%+v, %v, %v), want stored token", token, found, err)
+	}
+	if _, found, _ := store.LoadRefreshToken("https://idp.example.com", "other-client"); found {
+		t.Error("LoadRefreshToken() found a token for a different client ID")
+	}
+
+	assertMode(t, directory, 0o700)
+	key, err := refreshTokenKey("https://idp.example.com", "client")
+	if err != nil {
+		t.Fatalf("refreshTokenKey() error = %v", err)
+	}
+	assertMode(t, filepath.Join(directory, key+".json"), 0o600)
+
+	if err := store.DeleteRefreshToken("https://idp.example.com", "client"); err != nil {
+		t.Fatalf("DeleteRefreshToken() error = %v", err)
+	}
+	if _, found, _ := store.LoadRefreshToken("https://idp.example.com", "client"); found {
+		t.Error("LoadRefreshToken() found a deleted token")
+	}
+	if err := store.DeleteRefreshToken("https://idp.example.com", "client"); err != nil {
+		t.Errorf("DeleteRefreshToken() on missing entry error = %v", err)
+	}
+}
+
+func TestRefreshTokenRemovesMalformedEntries(t *testing.T) {
+	for _, content := range []string{`{`, `{"version":99,"refresh_token":"token"}`, `{"version":1,"refresh_token":"token"}`, `{"version":2}`} {
+		directory := t.TempDir()
+		store := newStore(directory)
+		key, err := refreshTokenKey("https://idp.example.com", "client")
+		if err != nil {
+			t.Fatalf("refreshTokenKey() error = %v", err)
+		}
+		path := filepath.Join(directory, key+".json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write token cache entry: %v", err)
+		}
+
+		if _, found, err := store.LoadRefreshToken("https://idp.example.com", "client"); err != nil || found {
+			t.Errorf("LoadRefreshToken(%s) = (%v, %v), want missing entry", content, found, err)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("malformed entry %s was not removed: %v", content, err)
+		}
+	}
+}
+
+func TestSaveEmptyRefreshTokenRemovesEntry(t *testing.T) {
+	store := newStore(t.TempDir())
+	if err := store.SaveRefreshToken("https://idp.example.com", "client", RefreshToken{Token: "refresh-token"}); err != nil {
+		t.Fatalf("SaveRefreshToken() error = %v", err)
+	}
+	if err := store.SaveRefreshToken("https://idp.example.com", "client", RefreshToken{LoginHint: "alice"}); err != nil {
+		t.Fatalf("SaveRefreshToken() empty error = %v", err)
+	}
+	if _, found, _ := store.LoadRefreshToken("https://idp.example.com", "client"); found {
+		t.Error("LoadRefreshToken() found a token after saving an empty value")
+	}
+}
+
+func assertMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat %s: %v", path, err)
+	}
+	if got := info.Mode().Perm(); got != want {
+		t.Errorf("mode for %s = %o, want %o", path, got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package tokencache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func (store *Store) ensureDirectory() error {
+	if err := os.MkdirAll(store.directory, 0o700); err != nil {
+		return fmt.Errorf("create token cache directory: %w", err)
+	}
+	directoryInfo, err := os.Lstat(store.directory)
+	if err != nil {
+		return fmt.Errorf("inspect token cache directory: %w", err)
+	}
+	if !directoryInfo.IsDir() {
+		return fmt.Errorf("token cache path is not a directory")
+	}
+	if err := os.Chmod(store.directory, 0o700); err != nil {
+		return fmt.Errorf("secure token cache directory: %w", err)
+	}
+	return nil
+}
+
+// readRecord decodes the entry for key into record. Missing entries report
+// false; malformed entries are removed and also report false.
+func (store *Store) readRecord(key string, record any) (bool, error) {
+	if err := validateKey(key); err != nil {
+		return false, err
+	}
+	cachePath := filepath.Join(store.directory, key+".json")
+	encoded, err := os.ReadFile(cachePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read token cache: %w", err)
+	}
+	if err := json.Unmarshal(encoded, record); err != nil {
+		if err := store.removeRecord(key); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+func (store *Store) writeRecord(key string, record any) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	if err := store.ensureDirectory(); err != nil {
+		return err
+	}
+
+	temporaryFile, err := os.CreateTemp(store.directory, ".tokens-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temporary token cache: %w", err)
+	}
+	temporaryPath := temporaryFile.Name()
+	defer func() { _ = os.Remove(temporaryPath) }()
+
+	if err := temporaryFile.Chmod(0o600); err != nil {
+		_ = temporaryFile.Close()
+		return fmt.Errorf("secure temporary token cache: %w", err)
+	}
+	if err := json.NewEncoder(temporaryFile).Encode(record); err != nil {
+		_ = temporaryFile.Close()
+		return fmt.Errorf("write temporary token cache: %w", err)
+	}
+	if err := temporaryFile.Sync(); err != nil {
+		_ = temporaryFile.Close()
+		return fmt.Errorf("sync temporary token cache: %w", err)
+	}
+	if err := temporaryFile.Close(); err != nil {
+		return fmt.Errorf("close temporary token cache: %w", err)
+	}
+
+	cachePath := filepath.Join(store.directory, key+".json")
+	if err := os.Rename(temporaryPath, cachePath); err != nil {
+		return fmt.Errorf("replace token cache: %w", err)
+	}
+	return nil
+}
+
+func (store *Store) removeRecord(key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(store.directory, key+".json"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove token cache entry: %w", err)
+	}
+	return nil
+}
@@ -14,10 +14,18 @@ type credentialProcessOutput struct {
 	SecretAccessKey string `json:"SecretAccessKey"`
 	SessionToken    string `json:"SessionToken"`
 	Expiration      string `json:"Expiration"`
+
+	AssumedRoleArn              string `json:"AssumedRoleArn,omitempty"`
+	AssumedRoleID               string `json:"AssumedRoleId,omitempty"`
+	SubjectFromWebIdentityToken string `json:"SubjectFromWebIdentityToken,omitempty"`
+	Audience                    string `json:"Audience,omitempty"`
+	Provider                    string `json:"Provider,omitempty"`
+	PackedPolicySize            *int32 `json:"PackedPolicySize,omitempty"`
 }
 
 // FprintCredentialProcess writes credentials using the AWS process credential
-// provider JSON format.
+// provider JSON format. The STS response details follow the standard fields
+// for auditing; AWS SDKs ignore them.
 func FprintCredentialProcess(w io.Writer, result *config.AssumeRoleResult) error {
 	output := credentialProcessOutput{
 		Version:         1,
@@ -25,6 +33,13 @@ func FprintCredentialProcess(w io.Writer, result *config.AssumeRoleResult) error
 		SecretAccessKey: result.SecretAccessKey,
 		SessionToken:    result.SessionToken,
 		Expiration:      result.Expiration,
+
+		AssumedRoleArn:              result.AssumedRoleArn,
+		AssumedRoleID:               result.AssumedRoleID,
+		SubjectFromWebIdentityToken: result.SubjectFromWebIdentityToken,
+		Audience:                    result.Audience,
+		Provider:                    result.Provider,
+		PackedPolicySize:            result.PackedPolicySize,
 	}
 	if err := json.NewEncoder(w).Encode(output); err != nil {
 		return fmt.Errorf("write credential process output: %w", err)
@@ -17,7 +17,6 @@ func TestFprintCredentialProcess(t *testing.T) {
 		Expiration:      "2030-01-01T00:00:00Z",
 		ProfileName:     "profile-must-not-be-emitted",
 		EndpointURL:     "https://endpoint-must-not-be-emitted.example.com",
-		AssumedRoleArn:  "arn:must-not-be-emitted",
 	}
 	var output bytes.Buffer
 	if err := FprintCredentialProcess(&output, result); err != nil {
@@ -30,6 +29,32 @@ func TestFprintCredentialProcess(t *testing.T) {
 	}
 }
 
+func TestFprintCredentialProcessIncludesSTSResponseDetails(t *testing.T) {
+	result := &config.AssumeRoleResult{
+		AccessKeyID:                 "access-key",
+		SecretAccessKey:             "secret-key",
+		SessionToken:                "session-token",
+		Expiration:                  "2030-01-01T00:00:00Z",
+		AssumedRoleArn:              "arn:aws:sts::123456789012:assumed-role/TestRole/session",
+		AssumedRoleID:               "AROATEST:session",
+		SubjectFromWebIdentityToken: "user-subject",
+		Audience:                    "radosgw",
+		Provider:                    "https://oidc.example.com",
+		PackedPolicySize:            new(int32(6)),
+	}
+	var output bytes.Buffer
+	if err := FprintCredentialProcess(&output, result); err != nil {
+		t.Fatalf("FprintCredentialProcess() error = %v", err)
+	}
+
+	want := "{\"Version\":1,\"AccessKeyId\":\"access-key\",\"SecretAccessKey\":\"secret-key\",\"SessionToken\":\"session-token\",\"Expiration\":\"2030-01-01T00:00:00Z\"," +
+		"\"AssumedRoleArn\":\"arn:aws:sts::123456789012:assumed-role/TestRole/session\",\"AssumedRoleId\":\"AROATEST:session\"," +
+		"\"SubjectFromWebIdentityToken\":\"user-subject\",\"Audience\":\"radosgw\",\"Provider\":\"https://oidc.example.com\",\"PackedPolicySize\":6}\n"
+	if output.String() != want {
+		t.Errorf("FprintCredentialProcess() output = %q, want %q", output.String(), want)
+	}
+}
+
 func TestFprintCredentialProcessWriteError(t *testing.T) {
 	err := FprintCredentialProcess(credentialProcessErrorWriter{}, &config.AssumeRoleResult{})
 	if err == nil || !strings.Contains(err.Error(), "write credential process output") {
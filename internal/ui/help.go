@@ -18,6 +18,8 @@ func FprintUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "       radosgw-assume shell [OPTIONS]")
 	_, _ = fmt.Fprintln(w, "       radosgw-assume credential-process (-p PROFILE | --env) [OPTIONS]")
 	_, _ = fmt.Fprintln(w, "       radosgw-assume cache <status|clear>")
+	_, _ = fmt.Fprintln(w, "       radosgw-assume token inspect [OPTIONS]")
+	_, _ = fmt.Fprintln(w, "       radosgw-assume logout [-p PROFILE | --all] [--end-session]")
 	_, _ = fmt.Fprintln(w, "       radosgw-assume (interactive profile selection)")
 	_, _ = fmt.Fprintln(w)
 	_, _ = fmt.Fprintln(w, "Options:")
@@ -29,9 +31,17 @@ func FprintUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "                            Formats: '3600' (seconds), '60m' (minutes), '1h' (hours)")
 	_, _ = fmt.Fprintln(w, "  -s, --session NAME        Session name (default: radosgw-assume-TIMESTAMP)")
 	_, _ = fmt.Fprintln(w, "                            Only alphanumeric characters and dashes allowed")
+	_, _ = fmt.Fprintln(w, "      --policy-file FILE    Narrow the session with the JSON session policy in FILE")
+	_, _ = fmt.Fprintln(w, "      --policy JSON         Narrow the session with an inline JSON session policy")
+	_, _ = fmt.Fprintln(w, "      --policy-arn ARN      Narrow the session with a managed policy (repeatable, max: 10)")
 	_, _ = fmt.Fprintln(w, "      --show-credentials    Allow credential exports to be printed to a terminal")
 	_, _ = fmt.Fprintln(w, "      --no-prompt           Keep the original prompt in an authenticated shell")
 	_, _ = fmt.Fprintln(w, "      --no-cache            Bypass the credential-process cache")
+	_, _ = fmt.Fprintln(w, "      --token-file FILE     Inspect the token in FILE instead of authenticating (- for stdin)")
+	_, _ = fmt.Fprintln(w, "      --token-env NAME      Inspect the token in environment variable NAME")
+	_, _ = fmt.Fprintln(w, "      --json                Print token inspect output as JSON")
+	_, _ = fmt.Fprintln(w, "      --all                 Log out of every RadosGW profile")
+	_, _ = fmt.Fprintln(w, "      --end-session         Also open the OIDC provider's end session page on logout")
 	_, _ = fmt.Fprintln(w)
 	_, _ = fmt.Fprintln(w, "Commands:")
 	_, _ = fmt.Fprintln(w, "  exec                      Run a command with temporary credentials")
@@ -39,6 +49,8 @@ func FprintUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "  credential-process        Emit AWS process credential provider JSON")
 	_, _ = fmt.Fprintln(w, "  cache status              Show a non-secret credential cache summary")
 	_, _ = fmt.Fprintln(w, "  cache clear               Remove cached temporary credentials")
+	_, _ = fmt.Fprintln(w, "  token inspect, whoami     Decode the web identity token without printing it")
+	_, _ = fmt.Fprintln(w, "  logout                    Revoke stored OIDC tokens and remove cached credentials")
 	_, _ = fmt.Fprintln(w, "  version                   Show version information")
 	_, _ = fmt.Fprintln(w)
 	_, _ = fmt.Fprintln(w, "Examples:")
@@ -47,6 +59,7 @@ func FprintUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "  eval \"$(radosgw-assume --env)\"                         # Export environment configuration")
 	_, _ = fmt.Fprintln(w, "  eval \"$(radosgw-assume -d 2h -p myprofile)\"            # Export a 2-hour session")
 	_, _ = fmt.Fprintln(w, "  eval \"$(radosgw-assume -s my-session -p myprofile)\"    # Export with a custom session name")
+	_, _ = fmt.Fprintln(w, "  eval \"$(radosgw-assume --policy-file ci.json -p ci)\"   # Export credentials limited by a session policy")
 	_, _ = fmt.Fprintln(w, "  source <(radosgw-assume)                               # Select and export with source")
 	_, _ = fmt.Fprintln(w, "  source <(radosgw-assume -p myprofile)                  # Export a profile with source")
 	_, _ = fmt.Fprintln(w, "  radosgw-assume --show-credentials -p myprofile         # Deliberately display credentials")
@@ -59,6 +72,10 @@ func FprintUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "  radosgw-assume credential-process -d 12h -p myprofile  # Request and cache a 12-hour session")
 	_, _ = fmt.Fprintln(w, "  radosgw-assume cache status                            # Inspect cache without exposing credentials")
 	_, _ = fmt.Fprintln(w, "  radosgw-assume cache clear                             # Remove all cached credentials")
+	_, _ = fmt.Fprintln(w, "  radosgw-assume whoami -p myprofile                     # Show the claims sent to STS for a profile")
+	_, _ = fmt.Fprintln(w, "  radosgw-assume token inspect --token-file - --json     # Decode a token read from stdin as JSON")
+	_, _ = fmt.Fprintln(w, "  radosgw-assume logout -p myprofile                     # Revoke a profile's stored OIDC tokens")
+	_, _ = fmt.Fprintln(w, "  radosgw-assume logout --all --end-session              # Log out everywhere and end provider sessions")
 	_, _ = fmt.Fprintln(w, "  eval \"$(radosgw-assume --verbose)\"                     # Export with detailed diagnostics")
 	_, _ = fmt.Fprintln(w)
 	_, _ = fmt.Fprintln(w, "Security:")
@@ -66,16 +83,54 @@ func FprintUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "  Capture them with eval/source, or avoid exporting with exec/shell.")
 	_, _ = fmt.Fprintln(w)
 	_, _ = fmt.Fprintln(w, "Environment Variables (when using -e/--env):")
-	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_PROVIDER      - OIDC issuer URL (required, except for token auth)")
-	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_CLIENT_ID     - OIDC client ID (required, except for token auth)")
-	_, _ = fmt.Fprintln(w, "  AWS_ENDPOINT_URL           - RadosGW endpoint URL (required)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_PROVIDER      - OIDC issuer URL (required, except for token and github-actions auth)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_CLIENT_ID     - OIDC client ID (required, except for token and github-actions auth)")
+	_, _ = fmt.Fprintln(w, "  AWS_ENDPOINT_URL           - RadosGW endpoint URL (required unless RADOSGW_STS_ENDPOINTS is set)")
+	_, _ = fmt.Fprintln(w, "  AWS_ENDPOINT_URL_STS       - RadosGW STS endpoint URL when STS is served separately (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_STS_ENDPOINTS      - Comma-separated RadosGW gateway URLs tried in order, exporting the one that answers (optional)")
+	_, _ = fmt.Fprintln(w, "  AWS_REGION                 - Region that signs STS requests, or AWS_DEFAULT_REGION (overrides region, default: us-east-1)")
 	_, _ = fmt.Fprintln(w, "  RADOSGW_ROLE_ARN           - Role ARN to assume (required)")
 	_, _ = fmt.Fprintln(w, "  RADOSGW_ROLE_SESSION_NAME  - Role session name (optional, default: radosgw-assume-TIMESTAMP)")
-	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_AUTH_TYPE     - Auth type: device|browser|token (optional, default: device)")
-	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_TOKEN         - Pre-existing OIDC token (required for token auth type)")
-	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_SCOPE         - OIDC scope (optional, default: openid, ignored for token auth)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_SESSION_POLICY     - Inline JSON session policy narrowing the role's permissions (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_SESSION_POLICY_FILE - File containing the JSON session policy, re-read on each request (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_SESSION_POLICY_ARNS - Comma-separated managed session policy ARNs (optional, max: 10)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_AUTH_TYPE     - Auth type: device|browser|client_credentials|token|github-actions|token-exchange (optional, default: device)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_TOKEN         - Pre-existing OIDC token (token auth, or token-exchange subject)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_TOKEN_FILE    - File containing the OIDC token, re-read on each request (token auth)")
+	_, _ = fmt.Fprintln(w, "  AWS_WEB_IDENTITY_TOKEN_FILE - Fallback for RADOSGW_OIDC_TOKEN_FILE")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_SCOPE         - OIDC scope (optional, default: openid, ignored for token and github-actions auth)")
 	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_PKCE_METHOD   - PKCE method: S256|plain (optional, default: S256)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_TOKEN_TYPE    - Token sent to STS: access_token|id_token (optional, default: access_token)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_BROWSER_MODE  - Browser flow mode: callback|manual (optional, default: callback)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_REQUIRE_PAR   - Fail browser logins unless the provider supports pushed authorization requests: true|false (optional, default: false)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_CALLBACK_PORTS - Browser callback ports tried in order, 0 for any free port (optional, default: 8080,18088)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_CALLBACK_HOST - Browser redirect URI host: localhost|127.0.0.1|[::1] (optional, default: localhost)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_CALLBACK_PATH - Browser redirect URI path (optional, default: /callback)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_AUDIENCE      - Audience requested for device, browser, github-actions or token-exchange tokens (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_RESOURCE      - Space-separated RFC 8707 resource indicators for device and browser logins (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_PROMPT        - Authorization prompt: login|consent|select_account|none (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_LOGIN_HINT    - User name pre-filled at the provider login page (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_ACR_VALUES    - Requested authentication context class references, e.g. for MFA (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_CLIENT_SECRET - Client secret for client_credentials or token-exchange auth (never read from ~/.aws/config)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_CLIENT_SECRET_FILE - File containing the client secret (alternative to RADOSGW_OIDC_CLIENT_SECRET)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_CLIENT_AUTH_METHOD - client_secret_basic|client_secret_post|private_key_jwt (optional, default: client_secret_basic)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_CLIENT_PRIVATE_KEY_FILE - PEM private key signing private_key_jwt client assertions")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_SUBJECT_AUTH_TYPE - Subject token source for token-exchange: token|github-actions|device|browser (optional, default: token)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_SUBJECT_TOKEN_TYPE - RFC 8693 subject_token_type (optional, default: urn:ietf:params:oauth:token-type:access_token, or id_token for an ID token subject)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_REQUESTED_TOKEN_TYPE - RFC 8693 requested_token_type (optional, default: urn:ietf:params:oauth:token-type:access_token)")
 	_, _ = fmt.Fprintln(w, "  RADOSGW_SSL_VERIFY         - SSL verification: true|false|1|0 (optional, default: true)")
+	_, _ = fmt.Fprintln(w, "  AWS_CA_BUNDLE              - PEM CA bundle added to the system roots for the RadosGW endpoint (overrides ca_bundle)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_CA_BUNDLE     - PEM CA bundle added to the system roots for the OIDC provider (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_TLS_PINNED_SHA256  - Accepted SHA-256 fingerprints of the RadosGW certificate, replacing CA checks (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_TLS_PINNED_SHA256 - Accepted SHA-256 fingerprints of the OIDC provider certificate (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_PROXY_URL          - Proxy for the RadosGW endpoint: http, https, socks5 or socks5h URL (optional, default: HTTPS_PROXY)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_PROXY_URL     - Proxy for the OIDC provider: http, https, socks5 or socks5h URL (optional, default: HTTPS_PROXY)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_NO_PROXY           - Comma-separated hosts, domains and CIDR ranges reached directly instead of through a RadosGW proxy (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_OIDC_NO_PROXY      - Comma-separated hosts, domains and CIDR ranges reached directly instead of through an OIDC proxy (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_MAX_ATTEMPTS       - Attempts for OIDC discovery, device polling and STS requests, 1 disables retries (optional, default: 3, max: 10)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_TLS_CLIENT_CERT_FILE - PEM client certificate for mutual TLS to the OIDC provider and STS (optional)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_TLS_CLIENT_KEY_FILE - PEM key for the client certificate (optional, default: the certificate file)")
+	_, _ = fmt.Fprintln(w, "  RADOSGW_TLS_CLIENT_KEY_PASSPHRASE - Passphrase of an encrypted client key (never read from ~/.aws/config)")
 	_, _ = fmt.Fprintln(w)
 	_, _ = fmt.Fprintln(w, "Configuration:")
 	_, _ = fmt.Fprintln(w, "  Edit ~/.aws/config with RadosGW and OIDC settings")
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+	"github.com/fitbeard/radosgw-assume/pkg/duration"
+)
+
+// leadingClaims are the claims trust policies usually match on, printed before
+// the remaining claims in alphabetical order.
+var leadingClaims = []string{"iss", "sub", "aud", "azp", "groups", "scope", "exp", "iat", "nbf"}
+
+// timestampClaims are printed as RFC 3339 times relative to now.
+var timestampClaims = []string{"exp", "iat", "nbf", "auth_time"}
+
+type tokenInspectionOutput struct {
+	Header    map[string]any `json:"header"`
+	Claims    map[string]any `json:"claims"`
+	ExpiresAt string         `json:"expires_at,omitempty"`
+	Expired   *bool          `json:"expired,omitempty"`
+}
+
+// FprintTokenInspection writes the decoded header and claims of a web identity
+// token. The encoded token itself is never written.
+func FprintTokenInspection(w io.Writer, token auth.DecodedToken, now time.Time) {
+	_, _ = fmt.Fprintln(w, "Header:")
+	for _, name := range sortedClaimNames(token.Header, nil) {
+		_, _ = fmt.Fprintf(w, "  %s: %s\n", name, formatClaimValue(token.Header[name]))
+	}
+	_, _ = fmt.Fprintln(w, "Claims:")
+	for _, name := range sortedClaimNames(token.Claims, leadingClaims) {
+		_, _ = fmt.Fprintf(w, "  %s: %s\n", name, formatClaim(name, token.Claims[name], now))
+	}
+	if _, ok := token.Expiry(); !ok {
+		_, _ = fmt.Fprintln(w, "Warning: token has no exp claim")
+	}
+}
+
+// FprintTokenInspectionJSON writes the decoded header and claims as JSON,
+// adding the expiration time and whether it has passed.
+func FprintTokenInspectionJSON(w io.Writer, token auth.DecodedToken, now time.Time) error {
+	output := tokenInspectionOutput{Header: token.Header, Claims: token.Claims}
+	if expiresAt, ok := token.Expiry(); ok {
+		expired := !now.Before(expiresAt)
+		output.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+		output.Expired = &expired
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		return fmt.Errorf("write token inspection output: %w", err)
+	}
+	return nil
+}
+
+func sortedClaimNames(values map[string]any, leading []string) []string {
+	names := make([]string, 0, len(values))
+	for _, name := range leading {
+		if _, ok := values[name]; ok {
+			names = append(names, name)
+		}
+	}
+	remaining := make([]string, 0, len(values))
+	for name := range values {
+		if !slices.Contains(leading, name) {
+			remaining = append(remaining, name)
+		}
+	}
+	slices.Sort(remaining)
+	return append(names, remaining...)
+}
+
+func formatClaim(name string, value any, now time.Time) string {
+	number, ok := value.(json.Number)
+	if !ok || !slices.Contains(timestampClaims, name) {
+		return formatClaimValue(value)
+	}
+	seconds, err := number.Int64()
+	if err != nil {
+		return formatClaimValue(value)
+	}
+	at := time.Unix(seconds, 0)
+	return fmt.Sprintf("%s (%s)", at.UTC().Format(time.RFC3339), relativeTime(name, at, now))
+}
+
+func relativeTime(name string, at, now time.Time) string {
+	if at.After(now) {
+		if name == "exp" {
+			return "expires in " + duration.Format(at.Sub(now))
+		}
+		return "in " + duration.Format(at.Sub(now))
+	}
+	if name == "exp" {
+		return "expired " + duration.Format(now.Sub(at)) + " ago"
+	}
+	return duration.Format(now.Sub(at)) + " ago"
+}
+
+func formatClaimValue(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case json.Number:
+		return typed.String()
+	case []any:
+		values := make([]string, 0, len(typed))
+		for _, item := range typed {
+			text, ok := item.(string)
+			if !ok {
+				return marshalClaimValue(value)
+			}
+			values = append(values, text)
+		}
+		return strings.Join(values, ", ")
+	default:
+		return marshalClaimValue(value)
+	}
+}
+
+func marshalClaimValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
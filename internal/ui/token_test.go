@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fitbeard/radosgw-assume/internal/auth"
+)
+
+func testDecodedToken() auth.DecodedToken {
+	return auth.DecodedToken{
+		Header: map[string]any{"typ": "JWT", "alg": "RS256", "kid": "key-1"},
+		Claims: map[string]any{
+			"email":  "user@example.com",
+			"exp":    json.Number("1700000270"),
+			"iat":    json.Number("1699999880"),
+			"iss":    "https://oidc.example.com",
+			"sub":    "user",
+			"aud":    []any{"radosgw", "account"},
+			"groups": []any{"admins", "developers"},
+			"nested": map[string]any{"roles": []any{"viewer"}},
+		},
+	}
+}
+
+func TestFprintTokenInspection(t *testing.T) {
+	var output bytes.Buffer
+	FprintTokenInspection(&output, testDecodedToken(), time.Unix(1700000000, 0))
+
+	want := `Header:
+  alg: RS256
+  kid: key-1
+  typ: JWT
+Claims:
+  iss: https://oidc.example.com
+  sub: user
+  aud: radosgw, account
+  groups: admins, developers
+  exp: 2023-11-14T22:17:50Z (expires in 4m 30s)
+  iat: 2023-11-14T22:11:20Z (2m ago)
+  email: user@example.com
+  nested: {"roles":["viewer"]}
+`
+	if output.String() != want {
+		t.Errorf("FprintTokenInspection() output =\n%s\nwant\n%s", output.String(), want)
+	}
+}
+
+func TestFprintTokenInspectionExpiredAndMissingExp(t *testing.T) {
+	token := testDecodedToken()
+	var output bytes.Buffer
+	FprintTokenInspection(&output, token, time.Unix(1700000450, 0))
+	if !strings.Contains(output.String(), "exp: 2023-11-14T22:17:50Z (expired 3m ago)") {
+		t.Errorf("output = %q, want expired relative time", output.String())
+	}
+
+	delete(token.Claims, "exp")
+	output.Reset()
+	FprintTokenInspection(&output, token, time.Unix(1700000000, 0))
+	if !strings.Contains(output.String(), "Warning: token has no exp claim") {
+		t.Errorf("output = %q, want missing exp warning", output.String())
+	}
+}
+
+func TestFprintTokenInspectionJSON(t *testing.T) {
+	var output bytes.Buffer
+	if err := FprintTokenInspectionJSON(&output, testDecodedToken(), time.Unix(1700000450, 0)); err != nil {
+		t.Fatalf("FprintTokenInspectionJSON() error = %v", err)
+	}
+
+	var got struct {
+		Header    map[string]any `json:"header"`
+		Claims    map[string]any `json:"claims"`
+		ExpiresAt string         `json:"expires_at"`
+		Expired   bool           `json:"expired"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, output.String())
+	}
+	if got.Header["kid"] != "key-1" || got.Claims["sub"] != "user" {
+		t.Errorf("decoded output = %+v", got)
+	}
+	if got.ExpiresAt != "2023-11-14T22:17:50Z" || !got.Expired {
+		t.Errorf("expires_at = %q, expired = %v", got.ExpiresAt, got.Expired)
+	}
+	if !strings.Contains(output.String(), `"exp": 1700000270`) {
+		t.Errorf("output = %s, want exp kept as a number", output.String())
+	}
+}